@@ -0,0 +1,126 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlindex"
+)
+
+// Variables for version output
+var (
+	Program  = "xlindex"
+	Build    = "unknown"
+	Version  = "unknown"
+	Revision = "unknown"
+)
+
+var (
+	//behaviour
+	version = false
+	help    = false
+	//build params
+	output    = ""
+	resources = []string{}
+)
+
+func init() {
+	pflag.BoolVar(&version, "version", version, "Show version.")
+	pflag.BoolVarP(&help, "help", "h", help, "Show this help.")
+	pflag.StringVarP(&output, "output", "o", output, "Output index file.")
+	pflag.StringSliceVar(&resources, "resources", resources, "Resources to index (default all).")
+	pflag.Usage = usage
+	pflag.Parse()
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [options] <command> [files...]\n\n", Program)
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	fmt.Fprintf(os.Stderr, "  build <xlist files...>  creates an index file from xlist files\n")
+	fmt.Fprintf(os.Stderr, "  info <index file>       shows the contents of an index file\n\n")
+	fmt.Fprintf(os.Stderr, "Options:\n")
+	pflag.PrintDefaults()
+}
+
+func main() {
+	if version {
+		fmt.Printf("version: %s\nrevision: %s\nbuild: %s\n", Version, Revision, Build)
+		os.Exit(0)
+	}
+	if help {
+		pflag.Usage()
+		os.Exit(0)
+	}
+	args := pflag.Args()
+	if len(args) < 2 {
+		pflag.Usage()
+		os.Exit(1)
+	}
+	var err error
+	switch args[0] {
+	case "build":
+		err = build(args[1:])
+	case "info":
+		err = info(args[1])
+	default:
+		err = fmt.Errorf("invalid command '%s'", args[0])
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func build(files []string) error {
+	if output == "" {
+		return fmt.Errorf("output file is required")
+	}
+	res := xlist.Resources
+	if len(resources) > 0 {
+		res = make([]xlist.Resource, 0, len(resources))
+		for _, s := range resources {
+			r, err := xlist.ToResource(s)
+			if err != nil {
+				return fmt.Errorf("invalid resource '%s'", s)
+			}
+			res = append(res, r)
+		}
+	}
+	start := time.Now()
+	w := xlindex.NewWriter()
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		err = w.LoadReader(context.Background(), f, res)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("loading '%s': %v", file, err)
+		}
+	}
+	if err := w.WriteFile(output); err != nil {
+		return fmt.Errorf("writing '%s': %v", output, err)
+	}
+	fmt.Printf("index '%s' created in %v\n", output, time.Since(start))
+	return nil
+}
+
+func info(file string) error {
+	index, err := xlindex.Open(file)
+	if err != nil {
+		return fmt.Errorf("opening '%s': %v", file, err)
+	}
+	defer index.Close()
+	for _, k := range xlindex.Kinds {
+		fmt.Printf("%-10s %d\n", k.String(), index.Count(k))
+	}
+	return nil
+}
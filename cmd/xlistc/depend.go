@@ -3,10 +3,17 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+
 	"github.com/luids-io/api/xlist/grpc/check"
 	cconfig "github.com/luids-io/common/config"
 	cfactory "github.com/luids-io/common/factory"
+	"github.com/luids-io/core/grpctls"
 	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/authz"
 )
 
 func createLogger(debug bool) (yalogi.Logger, error) {
@@ -17,7 +24,13 @@ func createLogger(debug bool) (yalogi.Logger, error) {
 func createClient(logger yalogi.Logger) (*check.Client, error) {
 	//create dial
 	cfgDial := cfg.Data("client").(*cconfig.ClientCfg)
-	dial, err := cfactory.ClientConn(cfgDial)
+	var dial *grpc.ClientConn
+	var err error
+	if token == "" {
+		dial, err = cfactory.ClientConn(cfgDial)
+	} else {
+		dial, err = tokenClientConn(cfgDial, token)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -25,3 +38,18 @@ func createClient(logger yalogi.Logger) (*check.Client, error) {
 	client := check.NewClient(dial, check.SetLogger(logger))
 	return client, nil
 }
+
+func tokenClientConn(cfg *cconfig.ClientCfg, token string) (*grpc.ClientConn, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid client config: %v", err)
+	}
+	if !cfg.TLS.UseTLS() {
+		return nil, errors.New("token requires tls")
+	}
+	dial, err := grpctls.Dial(cfg.RemoteURI, cfg.TLS, grpc.WithPerRPCCredentials(authz.TokenCredentials(token)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot dial with %s: %v", cfg.RemoteURI, err)
+	}
+	return dial, nil
+}
@@ -31,6 +31,7 @@ var (
 	version    = false
 	debug      = false
 	help       = false
+	token      = ""
 	//input
 	inStdin = false
 	inFile  = ""
@@ -44,6 +45,7 @@ func init() {
 	pflag.BoolVar(&version, "version", version, "Show version.")
 	pflag.BoolVarP(&help, "help", "h", help, "Show this help.")
 	pflag.BoolVar(&debug, "debug", debug, "Enable debug.")
+	pflag.StringVar(&token, "token", token, "Bearer token for authorization.")
 	//input params
 	pflag.BoolVar(&inStdin, "stdin", inStdin, "From stdin.")
 	pflag.StringVarP(&inFile, "file", "f", inFile, "File for input.")
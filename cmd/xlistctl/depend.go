@@ -0,0 +1,22 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package main
+
+import (
+	cconfig "github.com/luids-io/common/config"
+	cfactory "github.com/luids-io/common/factory"
+	"github.com/luids-io/core/yalogi"
+	iconfig "github.com/luids-io/xlist/internal/config"
+	ifactory "github.com/luids-io/xlist/internal/factory"
+	"github.com/luids-io/xlist/pkg/xlistd/admin"
+)
+
+func createLogger(debug bool) (yalogi.Logger, error) {
+	cfgLog := cfg.Data("log").(*cconfig.LoggerCfg)
+	return cfactory.Logger(cfgLog, debug)
+}
+
+func createClient() (*admin.Client, error) {
+	cfgAdmin := cfg.Data("admin").(*iconfig.AdminClientCfg)
+	return ifactory.AdminClient(cfgAdmin)
+}
@@ -0,0 +1,271 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/cmd/xlistctl/config"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/admin"
+)
+
+//Variables for version output
+var (
+	Program  = "xlistctl"
+	Build    = "unknown"
+	Version  = "unknown"
+	Revision = "unknown"
+)
+
+var (
+	cfg = config.Default(Program)
+	//behaviour
+	configFile = ""
+	version    = false
+	debug      = false
+	help       = false
+	//output
+	doPing  = false
+	outJSON = false
+	//items
+	itemFormat  = "plain"
+	itemAuthor  = os.Getenv("USER")
+	itemComment = ""
+)
+
+func init() {
+	//config mapped params
+	cfg.PFlags()
+	//behaviour params
+	pflag.StringVar(&configFile, "config", configFile, "Use explicit config file.")
+	pflag.BoolVar(&version, "version", version, "Show version.")
+	pflag.BoolVarP(&help, "help", "h", help, "Show this help.")
+	pflag.BoolVar(&debug, "debug", debug, "Enable debug.")
+	//output params
+	pflag.BoolVarP(&doPing, "ping", "p", doPing, "Ping lists when listing.")
+	pflag.BoolVar(&outJSON, "json", outJSON, "Output in json format.")
+	//items params
+	pflag.StringVar(&itemFormat, "format", itemFormat, "Format of the item (plain, cidr or sub).")
+	pflag.StringVar(&itemAuthor, "author", itemAuthor, "Author of the modification, by default the identity of the caller.")
+	pflag.StringVar(&itemComment, "comment", itemComment, "Comment of the modification.")
+	pflag.Usage = usage
+	pflag.Parse()
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [options] <command> [list id] [resource value]\n\n", Program)
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	fmt.Fprintf(os.Stderr, "  lists           shows the tree of lists\n")
+	fmt.Fprintf(os.Stderr, "  info <id>       shows information of the list\n")
+	fmt.Fprintf(os.Stderr, "  ping <id>       pings the list\n")
+	fmt.Fprintf(os.Stderr, "  reload <id>     reloads the list\n")
+	fmt.Fprintf(os.Stderr, "  flush <id>      flushes the caches of the list\n")
+	fmt.Fprintf(os.Stderr, "  enable <id>     enables the list\n")
+	fmt.Fprintf(os.Stderr, "  disable <id>    disables the list\n")
+	fmt.Fprintf(os.Stderr, "  items <id>      shows the items of an editable list\n")
+	fmt.Fprintf(os.Stderr, "  add <id> <resource> <value>\n")
+	fmt.Fprintf(os.Stderr, "                  adds an item to an editable list\n")
+	fmt.Fprintf(os.Stderr, "  remove <id> <resource> <value>\n")
+	fmt.Fprintf(os.Stderr, "                  removes an item from an editable list\n\n")
+	fmt.Fprintf(os.Stderr, "Options:\n")
+	pflag.PrintDefaults()
+}
+
+func main() {
+	if version {
+		fmt.Printf("version: %s\nrevision: %s\nbuild: %s\n", Version, Revision, Build)
+		os.Exit(0)
+	}
+	if help {
+		pflag.Usage()
+		os.Exit(0)
+	}
+	// load configuration
+	err := cfg.LoadIfFile(configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// creates logger
+	logger, err := createLogger(debug)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	args := pflag.Args()
+	if len(args) == 0 {
+		pflag.Usage()
+		os.Exit(1)
+	}
+	command := args[0]
+	switch command {
+	case "lists":
+	case "add", "remove":
+		if len(args) != 4 {
+			logger.Fatalf("command '%s' requires a list id, a resource and a value", command)
+		}
+	default:
+		if len(args) != 2 {
+			logger.Fatalf("command '%s' requires a list id", command)
+		}
+	}
+
+	// create admin client
+	client, err := createClient()
+	if err != nil {
+		logger.Fatalf("couldn't create client: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	switch command {
+	case "lists":
+		infos, err := client.Lists(ctx, doPing)
+		if err != nil {
+			logger.Fatalf("getting lists: %v", err)
+		}
+		if outJSON {
+			printJSON(infos)
+			return
+		}
+		for _, info := range infos {
+			printTree(info, 0)
+		}
+	case "info":
+		info, err := client.Info(ctx, args[1])
+		if err != nil {
+			logger.Fatalf("getting info: %v", err)
+		}
+		if outJSON {
+			printJSON(info)
+			return
+		}
+		printInfo(info)
+	case "ping":
+		result, err := client.Ping(ctx, args[1])
+		if err != nil {
+			logger.Fatalf("ping: %v", err)
+		}
+		if outJSON {
+			printJSON(result)
+			return
+		}
+		fmt.Fprintf(os.Stdout, "%s: %s\n", args[1], pingStatus(&result))
+	case "reload":
+		err = client.Reload(ctx, args[1])
+	case "flush":
+		err = client.Flush(ctx, args[1])
+	case "enable":
+		err = client.Enable(ctx, args[1])
+	case "disable":
+		err = client.Disable(ctx, args[1])
+	case "items":
+		items, err := client.Items(ctx, args[1])
+		if err != nil {
+			logger.Fatalf("getting items: %v", err)
+		}
+		if outJSON {
+			printJSON(items)
+			return
+		}
+		for _, item := range items {
+			fmt.Fprintf(os.Stdout, "%v,%v,%s,\"%s\",\"%s\",%s\n", item.Resource, item.Format, item.Value,
+				item.Author, item.Comment, item.Timestamp.Format(time.RFC3339))
+		}
+	case "add", "remove":
+		item, err := getItem(args[2], args[3])
+		if err != nil {
+			logger.Fatalf("invalid item: %v", err)
+		}
+		if command == "add" {
+			err = client.AddItem(ctx, args[1], item)
+		} else {
+			err = client.RemoveItem(ctx, args[1], item)
+		}
+		if err != nil {
+			logger.Fatalf("%s '%s': %v", command, args[1], err)
+		}
+	default:
+		logger.Fatalf("invalid command '%s'", command)
+	}
+	if err != nil {
+		logger.Fatalf("%s '%s': %v", command, args[1], err)
+	}
+}
+
+func getItem(r, value string) (xlistd.Item, error) {
+	resource, err := xlist.ToResource(r)
+	if err != nil {
+		return xlistd.Item{}, err
+	}
+	format, err := xlistd.ToFormat(itemFormat)
+	if err != nil {
+		return xlistd.Item{}, err
+	}
+	return xlistd.Item{
+		Resource: resource,
+		Format:   format,
+		Value:    value,
+		Author:   itemAuthor,
+		Comment:  itemComment,
+	}, nil
+}
+
+func printTree(info admin.ListInfo, level int) {
+	status := ""
+	if !info.Enabled {
+		status = " [disabled]"
+	}
+	if info.Ping != nil {
+		status = status + " " + pingStatus(info.Ping)
+	}
+	fmt.Fprintf(os.Stdout, "%s%s (%s)%s\n", strings.Repeat("  ", level), info.ID, info.Class, status)
+	for _, child := range info.Childs {
+		printTree(child, level+1)
+	}
+}
+
+func printInfo(info admin.ListInfo) {
+	fmt.Fprintf(os.Stdout, "id: %s\n", info.ID)
+	fmt.Fprintf(os.Stdout, "class: %s\n", info.Class)
+	fmt.Fprintf(os.Stdout, "enabled: %v\n", info.Enabled)
+	fmt.Fprintf(os.Stdout, "resources: %v\n", info.Resources)
+	fmt.Fprintf(os.Stdout, "supports: %s\n", strings.Join(info.Supports, ","))
+	if info.Ping != nil {
+		fmt.Fprintf(os.Stdout, "health: %s\n", pingStatus(info.Ping))
+	}
+	for k, v := range info.Info {
+		fmt.Fprintf(os.Stdout, "%s: %v\n", k, v)
+	}
+	childs := make([]string, 0, len(info.Childs))
+	for _, child := range info.Childs {
+		childs = append(childs, child.ID)
+	}
+	if len(childs) > 0 {
+		fmt.Fprintf(os.Stdout, "childs: %s\n", strings.Join(childs, ","))
+	}
+}
+
+func pingStatus(r *admin.PingResult) string {
+	if r.Healthy {
+		return fmt.Sprintf("ok (%s)", r.Latency)
+	}
+	return fmt.Sprintf("error: %s (%s)", r.Error, r.Latency)
+}
+
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
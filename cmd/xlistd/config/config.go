@@ -39,6 +39,11 @@ func Default(program string) *goconfig.Config {
 				RootListID: "root",
 			},
 		},
+		goconfig.Section{
+			Name:     "service.xlist.replication",
+			Required: false,
+			Data:     &iconfig.ReplicationAPICfg{},
+		},
 		goconfig.Section{
 			Name:     "ids.api",
 			Required: false,
@@ -64,6 +69,31 @@ func Default(program string) *goconfig.Config {
 			Required: false,
 			Data:     &cconfig.HealthCfg{},
 		},
+		goconfig.Section{
+			Name:     "admin",
+			Required: false,
+			Data:     &iconfig.AdminAPICfg{},
+		},
+		goconfig.Section{
+			Name:     "authz",
+			Required: false,
+			Data:     &iconfig.AuthzCfg{},
+		},
+		goconfig.Section{
+			Name:     "ratelimit",
+			Required: false,
+			Data: &iconfig.RateLimitCfg{
+				By: "peer",
+			},
+		},
+		goconfig.Section{
+			Name:     "tracing",
+			Required: false,
+			Data: &iconfig.TracingCfg{
+				Service: "xlistd",
+				Ratio:   1,
+			},
+		},
 	)
 	if err != nil {
 		panic(err)
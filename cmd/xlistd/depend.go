@@ -18,7 +18,11 @@ import (
 	"github.com/luids-io/core/yalogi"
 	iconfig "github.com/luids-io/xlist/internal/config"
 	ifactory "github.com/luids-io/xlist/internal/factory"
+	"github.com/luids-io/xlist/internal/systemd"
+	"github.com/luids-io/xlist/pkg/authz"
+	"github.com/luids-io/xlist/pkg/tracing"
 	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/replication"
 )
 
 func createLogger(debug bool) (yalogi.Logger, error) {
@@ -26,10 +30,39 @@ func createLogger(debug bool) (yalogi.Logger, error) {
 	return cfactory.Logger(cfgLog, debug)
 }
 
+func createNotifier(msrv *serverd.Manager, logger yalogi.Logger) (*systemd.Notifier, error) {
+	listeners, err := systemd.Listeners()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range listeners {
+		logger.Debugf("activated socket '%s' (%v)", l.Name, l.Addr())
+	}
+	ifactory.SetActivated(listeners)
+	notifier := systemd.NewNotifier(logger)
+	// registered first, so it notifies before reloading other services
+	msrv.Register(serverd.Service{
+		Name:   "systemd.reloading",
+		Reload: notifier.Reloading,
+	})
+	return notifier, nil
+}
+
+func registerNotifier(notifier *systemd.Notifier, msrv *serverd.Manager) error {
+	// registered last, so it notifies when all services are started
+	msrv.Register(serverd.Service{
+		Name:     "systemd.ready",
+		Start:    notifier.Ready,
+		Reload:   notifier.Reloaded,
+		Shutdown: notifier.Stopping,
+	})
+	return nil
+}
+
 func createHealthSrv(msrv *serverd.Manager, logger yalogi.Logger) error {
 	cfgHealth := cfg.Data("health").(*cconfig.HealthCfg)
 	if !cfgHealth.Empty() {
-		hlis, health, err := cfactory.Health(cfgHealth, msrv, logger)
+		hlis, health, err := ifactory.Health(cfgHealth, msrv, logger)
 		if err != nil {
 			return err
 		}
@@ -42,6 +75,23 @@ func createHealthSrv(msrv *serverd.Manager, logger yalogi.Logger) error {
 	return nil
 }
 
+func createTracer(msrv *serverd.Manager, logger yalogi.Logger) (*tracing.Tracer, error) {
+	cfgTracing := cfg.Data("tracing").(*iconfig.TracingCfg)
+	if cfgTracing.Empty() {
+		return nil, nil
+	}
+	tracer, err := ifactory.Tracer(cfgTracing, logger)
+	if err != nil {
+		return nil, err
+	}
+	// registered before lists and servers, so it exports their spans on shutdown
+	msrv.Register(serverd.Service{
+		Name:     "tracing",
+		Shutdown: func() { tracer.Close() },
+	})
+	return tracer, nil
+}
+
 func createAPIServices(msrv *serverd.Manager, logger yalogi.Logger) (apiservice.Discover, error) {
 	cfgServices := cfg.Data("ids.api").(*cconfig.APIServicesCfg)
 	registry, err := cfactory.APIAutoloader(cfgServices, logger)
@@ -56,9 +106,11 @@ func createAPIServices(msrv *serverd.Manager, logger yalogi.Logger) (apiservice.
 	return registry, nil
 }
 
-func createLists(apisvc apiservice.Discover, msrv *serverd.Manager, logger yalogi.Logger) (*xlistd.Builder, error) {
+func createLists(apisvc apiservice.Discover, tracer *tracing.Tracer, msrv *serverd.Manager, logger yalogi.Logger) (*xlistd.Builder, error) {
 	cfgList := cfg.Data("xlistd").(*iconfig.XListCfg)
-	builder, err := ifactory.ListBuilder(cfgList, apisvc, logger)
+	cfgAdmin := cfg.Data("admin").(*iconfig.AdminAPICfg)
+	builder, err := ifactory.ListBuilder(cfgList, apisvc, logger,
+		xlistd.RuntimeControl(!cfgAdmin.Empty()), xlistd.Tracing(tracer != nil))
 	if err != nil {
 		return nil, err
 	}
@@ -90,6 +142,36 @@ func createLists(apisvc apiservice.Discover, msrv *serverd.Manager, logger yalog
 	return builder, nil
 }
 
+func createAuthorizer(logger yalogi.Logger) (*authz.Authorizer, error) {
+	cfgAuthz := cfg.Data("authz").(*iconfig.AuthzCfg)
+	if cfgAuthz.Empty() {
+		return nil, nil
+	}
+	return ifactory.Authorizer(cfgAuthz, logger)
+}
+
+func createAdminSrv(builder *xlistd.Builder, authorizer *authz.Authorizer, msrv *serverd.Manager, logger yalogi.Logger) error {
+	cfgAdmin := cfg.Data("admin").(*iconfig.AdminAPICfg)
+	if !cfgAdmin.Empty() {
+		alis, admin, watcher, err := ifactory.AdminAPI(cfgAdmin, builder, authorizer, logger)
+		if err != nil {
+			return err
+		}
+		if watcher != nil {
+			msrv.Register(serverd.Service{
+				Name:     fmt.Sprintf("admin.[%s].tls", cfgAdmin.ListenURI),
+				Shutdown: watcher.Close,
+			})
+		}
+		msrv.Register(serverd.Service{
+			Name:     fmt.Sprintf("admin.[%s]", cfgAdmin.ListenURI),
+			Start:    func() error { go admin.Serve(alis); return nil },
+			Shutdown: func() { admin.Close() },
+		})
+	}
+	return nil
+}
+
 func createCheckAPI(gsrv *grpc.Server, finder *xlistd.Builder, msrv *serverd.Manager, logger yalogi.Logger) error {
 	cfgCheck := cfg.Data("service.xlist.check").(*iconfig.XListCheckAPICfg)
 	gsvc, err := ifactory.XListCheckAPI(cfgCheck, finder, logger)
@@ -109,12 +191,50 @@ func createCheckAPI(gsrv *grpc.Server, finder *xlistd.Builder, msrv *serverd.Man
 	return nil
 }
 
-func createServer(msrv *serverd.Manager) (*grpc.Server, error) {
+func createReplicationAPI(gsrv *grpc.Server, finder *xlistd.Builder, authorizer *authz.Authorizer, logger yalogi.Logger) error {
+	cfgRepl := cfg.Data("service.xlist.replication").(*iconfig.ReplicationAPICfg)
+	if !cfgRepl.Empty() {
+		gsvc, err := ifactory.ReplicationAPI(cfgRepl, finder, authorizer, logger)
+		if err != nil {
+			return err
+		}
+		replication.RegisterServer(gsrv, gsvc)
+	}
+	return nil
+}
+
+func createServer(authorizer *authz.Authorizer, tracer *tracing.Tracer, msrv *serverd.Manager, logger yalogi.Logger) (*grpc.Server, error) {
 	cfgServer := cfg.Data("server").(*cconfig.ServerCfg)
-	glis, gsrv, err := cfactory.Server(cfgServer)
+	var interceptors ifactory.Interceptors
+	if tracer != nil {
+		// first, so rejected requests are also traced
+		interceptors.Add(tracer.UnaryServerInterceptor, tracer.StreamServerInterceptor)
+	}
+	if authorizer != nil {
+		cfgCheck := cfg.Data("service.xlist.check").(*iconfig.XListCheckAPICfg)
+		services := map[string]string{checkapi.ServiceName(): cfgCheck.RootListID}
+		interceptors.Add(
+			authorizer.UnaryServerInterceptor(services),
+			authorizer.StreamServerInterceptor(services))
+	}
+	cfgRateLimit := cfg.Data("ratelimit").(*iconfig.RateLimitCfg)
+	if !cfgRateLimit.Empty() {
+		limiter, err := ifactory.RateLimit(cfgRateLimit, logger)
+		if err != nil {
+			return nil, err
+		}
+		interceptors.Add(limiter.Unary, limiter.Stream)
+	}
+	glis, gsrv, watcher, err := ifactory.Server(cfgServer, interceptors, logger)
 	if err != nil {
 		return nil, err
 	}
+	if watcher != nil {
+		msrv.Register(serverd.Service{
+			Name:     fmt.Sprintf("server.[%s].tls", cfgServer.ListenURI),
+			Shutdown: watcher.Close,
+		})
+	}
 	if cfgServer.Metrics {
 		grpc_prometheus.Register(gsrv)
 	}
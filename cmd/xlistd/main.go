@@ -74,6 +74,18 @@ func main() {
 	// creates main server manager
 	msrv := serverd.New(Program, serverd.SetLogger(logger))
 
+	// creates systemd notifier and gets activated sockets
+	notifier, err := createNotifier(msrv, logger)
+	if err != nil {
+		logger.Fatalf("couldn't setup systemd: %v", err)
+	}
+
+	// create tracer
+	tracer, err := createTracer(msrv, logger)
+	if err != nil {
+		logger.Fatalf("couldn't create tracer: %v", err)
+	}
+
 	// create api services and register
 	apisvc, err := createAPIServices(msrv, logger)
 	if err != nil {
@@ -81,18 +93,24 @@ func main() {
 	}
 
 	// create lists
-	lists, err := createLists(apisvc, msrv, logger)
+	lists, err := createLists(apisvc, tracer, msrv, logger)
 	if err != nil {
 		logger.Fatalf("couldn't create lists: %v", err)
 	}
 
+	// create authorizer
+	authorizer, err := createAuthorizer(logger)
+	if err != nil {
+		logger.Fatalf("couldn't create authorizer: %v", err)
+	}
+
 	if dryRun {
 		fmt.Println("configuration seems ok")
 		os.Exit(0)
 	}
 
 	// create grpc check server
-	gsrv, err := createServer(msrv)
+	gsrv, err := createServer(authorizer, tracer, msrv, logger)
 	if err != nil {
 		logger.Fatalf("couldn't create check server: %v", err)
 	}
@@ -103,12 +121,30 @@ func main() {
 		logger.Fatalf("couldn't create check api: %v", err)
 	}
 
+	// create grpc replication service
+	err = createReplicationAPI(gsrv, lists, authorizer, logger)
+	if err != nil {
+		logger.Fatalf("couldn't create replication api: %v", err)
+	}
+
 	// creates health server
 	err = createHealthSrv(msrv, logger)
 	if err != nil {
 		logger.Fatalf("creating health server: %v", err)
 	}
 
+	// creates admin server
+	err = createAdminSrv(lists, authorizer, msrv, logger)
+	if err != nil {
+		logger.Fatalf("creating admin server: %v", err)
+	}
+
+	// notifies systemd when services are started
+	err = registerNotifier(notifier, msrv)
+	if err != nil {
+		logger.Fatalf("registering systemd notifier: %v", err)
+	}
+
 	//run server
 	err = msrv.Run()
 	if err != nil {
@@ -5,24 +5,42 @@ package main
 import (
 	//components
 	_ "github.com/luids-io/xlist/pkg/xlistd/components/apicheckxl"
+	_ "github.com/luids-io/xlist/pkg/xlistd/components/conditionalxl"
 	_ "github.com/luids-io/xlist/pkg/xlistd/components/dnsxl"
+	_ "github.com/luids-io/xlist/pkg/xlistd/components/dynxl"
+	_ "github.com/luids-io/xlist/pkg/xlistd/components/failoverxl"
 	_ "github.com/luids-io/xlist/pkg/xlistd/components/filexl"
 	_ "github.com/luids-io/xlist/pkg/xlistd/components/geoip2xl"
 	_ "github.com/luids-io/xlist/pkg/xlistd/components/grpcxl"
+	_ "github.com/luids-io/xlist/pkg/xlistd/components/httpxl"
+	_ "github.com/luids-io/xlist/pkg/xlistd/components/indexxl"
 	_ "github.com/luids-io/xlist/pkg/xlistd/components/memxl"
 	_ "github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
 	_ "github.com/luids-io/xlist/pkg/xlistd/components/parallelxl"
+	_ "github.com/luids-io/xlist/pkg/xlistd/components/patternxl"
+	_ "github.com/luids-io/xlist/pkg/xlistd/components/ptrxl"
+	_ "github.com/luids-io/xlist/pkg/xlistd/components/quorumxl"
+	_ "github.com/luids-io/xlist/pkg/xlistd/components/redisxl"
+	_ "github.com/luids-io/xlist/pkg/xlistd/components/resolvexl"
+	_ "github.com/luids-io/xlist/pkg/xlistd/components/routerxl"
+	_ "github.com/luids-io/xlist/pkg/xlistd/components/rpzxl"
 	_ "github.com/luids-io/xlist/pkg/xlistd/components/sblookupxl"
+	_ "github.com/luids-io/xlist/pkg/xlistd/components/scoringxl"
 	_ "github.com/luids-io/xlist/pkg/xlistd/components/selectorxl"
 	_ "github.com/luids-io/xlist/pkg/xlistd/components/sequencexl"
 	_ "github.com/luids-io/xlist/pkg/xlistd/components/wbeforexl"
 
 	//wrappers
+	_ "github.com/luids-io/xlist/pkg/xlistd/wrappers/bloomwr"
 	_ "github.com/luids-io/xlist/pkg/xlistd/wrappers/cachewr"
+	_ "github.com/luids-io/xlist/pkg/xlistd/wrappers/cnamewr"
+	_ "github.com/luids-io/xlist/pkg/xlistd/wrappers/eventwr"
 	_ "github.com/luids-io/xlist/pkg/xlistd/wrappers/loggerwr"
 	_ "github.com/luids-io/xlist/pkg/xlistd/wrappers/metricswr"
 	_ "github.com/luids-io/xlist/pkg/xlistd/wrappers/policywr"
+	_ "github.com/luids-io/xlist/pkg/xlistd/wrappers/querylogwr"
 	_ "github.com/luids-io/xlist/pkg/xlistd/wrappers/responsewr"
 	_ "github.com/luids-io/xlist/pkg/xlistd/wrappers/scorewr"
 	_ "github.com/luids-io/xlist/pkg/xlistd/wrappers/timeoutwr"
+	_ "github.com/luids-io/xlist/pkg/xlistd/wrappers/topwr"
 )
@@ -0,0 +1,34 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package config
+
+import (
+	cconfig "github.com/luids-io/common/config"
+	"github.com/luids-io/core/goconfig"
+	iconfig "github.com/luids-io/xlist/internal/config"
+)
+
+// Default returns the default configuration
+func Default(program string) *goconfig.Config {
+	cfg, err := goconfig.New(program,
+		goconfig.Section{
+			Name:     "querylog",
+			Required: true,
+			Short:    true,
+			Data: &iconfig.QueryLogCfg{
+				Dir: "/var/lib/luids/xlist/querylog",
+			},
+		},
+		goconfig.Section{
+			Name:     "log",
+			Required: true,
+			Data: &cconfig.LoggerCfg{
+				Level: "info",
+			},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
@@ -0,0 +1,23 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package main
+
+import (
+	cconfig "github.com/luids-io/common/config"
+	cfactory "github.com/luids-io/common/factory"
+	"github.com/luids-io/core/yalogi"
+	iconfig "github.com/luids-io/xlist/internal/config"
+)
+
+func createLogger(debug bool) (yalogi.Logger, error) {
+	cfgLog := cfg.Data("log").(*cconfig.LoggerCfg)
+	return cfactory.Logger(cfgLog, debug)
+}
+
+func getQueryLogDir() (string, error) {
+	cfgQueryLog := cfg.Data("querylog").(*iconfig.QueryLogCfg)
+	if err := cfgQueryLog.Validate(); err != nil {
+		return "", err
+	}
+	return cfgQueryLog.Dir, nil
+}
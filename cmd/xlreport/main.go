@@ -0,0 +1,198 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/cmd/xlreport/config"
+	"github.com/luids-io/xlist/pkg/querylog"
+)
+
+// Variables for version output
+var (
+	Program  = "xlreport"
+	Build    = "unknown"
+	Version  = "unknown"
+	Revision = "unknown"
+)
+
+var (
+	cfg = config.Default(Program)
+	//behaviour
+	configFile = ""
+	version    = false
+	debug      = false
+	help       = false
+	//filters
+	fromTime = "24h"
+	toTime   = ""
+	listID   = ""
+	client   = ""
+	resource = ""
+	limit    = 10
+	//output
+	outJSON = false
+)
+
+func init() {
+	//config mapped params
+	cfg.PFlags()
+	//behaviour params
+	pflag.StringVar(&configFile, "config", configFile, "Use explicit config file.")
+	pflag.BoolVar(&version, "version", version, "Show version.")
+	pflag.BoolVarP(&help, "help", "h", help, "Show this help.")
+	pflag.BoolVar(&debug, "debug", debug, "Enable debug.")
+	//filter params
+	pflag.StringVar(&fromTime, "from", fromTime, "Start of the time range (RFC3339 or duration ago).")
+	pflag.StringVar(&toTime, "to", toTime, "End of the time range (RFC3339 or duration ago).")
+	pflag.StringVar(&listID, "list", listID, "Filter by list id.")
+	pflag.StringVar(&client, "client", client, "Filter by client (identity or ip).")
+	pflag.StringVar(&resource, "resource", resource, "Filter by resource.")
+	pflag.IntVarP(&limit, "limit", "n", limit, "Max number of results in tops.")
+	//output params
+	pflag.BoolVar(&outJSON, "json", outJSON, "Output in json format.")
+	pflag.Usage = usage
+	pflag.Parse()
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [options] <command> [name]\n\n", Program)
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	fmt.Fprintf(os.Stderr, "  positives       shows the indicators with more positive responses\n")
+	fmt.Fprintf(os.Stderr, "  clients         shows the clients with more queries\n")
+	fmt.Fprintf(os.Stderr, "  history <name>  shows the queries of the indicator\n\n")
+	fmt.Fprintf(os.Stderr, "Options:\n")
+	pflag.PrintDefaults()
+}
+
+func main() {
+	if version {
+		fmt.Printf("version: %s\nrevision: %s\nbuild: %s\n", Version, Revision, Build)
+		os.Exit(0)
+	}
+	if help {
+		pflag.Usage()
+		os.Exit(0)
+	}
+	// load configuration
+	err := cfg.LoadIfFile(configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// creates logger
+	logger, err := createLogger(debug)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	args := pflag.Args()
+	if len(args) == 0 {
+		pflag.Usage()
+		os.Exit(1)
+	}
+	command := args[0]
+	if command == "history" && len(args) != 2 {
+		logger.Fatalf("command '%s' requires a name", command)
+	}
+
+	dir, err := getQueryLogDir()
+	if err != nil {
+		logger.Fatalf("invalid config: %v", err)
+	}
+	filter, err := getFilter()
+	if err != nil {
+		logger.Fatalf("invalid filter: %v", err)
+	}
+
+	switch command {
+	case "positives", "clients":
+		var top []querylog.Count
+		if command == "positives" {
+			top, err = querylog.TopPositives(dir, filter, limit)
+		} else {
+			top, err = querylog.TopClients(dir, filter, limit)
+		}
+		if err != nil {
+			logger.Fatalf("%s: %v", command, err)
+		}
+		if outJSON {
+			printJSON(top)
+			return
+		}
+		for _, c := range top {
+			fmt.Fprintf(os.Stdout, "%8d  %s  (last %s)\n", c.Count, c.Key, c.Last.Format(time.RFC3339))
+		}
+	case "history":
+		filter.Name = args[1]
+		records, err := querylog.History(dir, filter)
+		if err != nil {
+			logger.Fatalf("%s: %v", command, err)
+		}
+		if outJSON {
+			printJSON(records)
+			return
+		}
+		for _, r := range records {
+			result := "negative"
+			if r.Error != "" {
+				result = "error " + r.Error
+			} else if r.Result {
+				result = "positive"
+			}
+			fmt.Fprintf(os.Stdout, "%s [%s] %s: Check('%s',%v) = %s (%s) %vus\n", r.Time.Format(time.RFC3339),
+				r.Client(), r.List, r.Name, r.Resource, result, r.Reason, r.Latency)
+		}
+	default:
+		logger.Fatalf("invalid command '%s'", command)
+	}
+}
+
+func getFilter() (querylog.Filter, error) {
+	var err error
+	filter := querylog.Filter{List: listID, Client: client}
+	if filter.From, err = parseTime(fromTime); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTime(toTime); err != nil {
+		return filter, err
+	}
+	if resource != "" {
+		r, err := xlist.ToResource(resource)
+		if err != nil {
+			return filter, err
+		}
+		filter.Resource = &r
+	}
+	return filter, nil
+}
+
+// parseTime accepts RFC3339 times or durations before now
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s'", s)
+	}
+	return t, nil
+}
+
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/luids-io/common/util"
+	"github.com/luids-io/core/grpctls"
+)
+
+// AdminAPICfg stores administration api preferences
+type AdminAPICfg struct {
+	ListenURI string
+	Allowed   []string
+	Tokens    []string
+	TLS       grpctls.ServerCfg
+}
+
+// SetPFlags setups posix flags for commandline configuration
+func (cfg *AdminAPICfg) SetPFlags(short bool, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	pflag.StringVar(&cfg.ListenURI, aprefix+"listenuri", cfg.ListenURI, "Admin api socket.")
+	pflag.StringSliceVar(&cfg.Allowed, aprefix+"allowed", cfg.Allowed, "List of allowed IPs or CIDRs.")
+	pflag.StringSliceVar(&cfg.Tokens, aprefix+"tokens", cfg.Tokens, "List of valid bearer tokens.")
+	pflag.StringVar(&cfg.TLS.CertFile, aprefix+"certfile", cfg.TLS.CertFile, "Path to server cert file.")
+	pflag.StringVar(&cfg.TLS.KeyFile, aprefix+"keyfile", cfg.TLS.KeyFile, "Path to server key file.")
+	pflag.StringVar(&cfg.TLS.CACert, aprefix+"cacert", cfg.TLS.CACert, "Path to CA cert file.")
+	pflag.BoolVar(&cfg.TLS.ClientAuth, aprefix+"clientauth", cfg.TLS.ClientAuth, "Require client auth.")
+}
+
+// BindViper setups posix flags for commandline configuration and bind to viper
+func (cfg *AdminAPICfg) BindViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	util.BindViper(v, aprefix+"listenuri")
+	util.BindViper(v, aprefix+"allowed")
+	util.BindViper(v, aprefix+"tokens")
+	util.BindViper(v, aprefix+"certfile")
+	util.BindViper(v, aprefix+"keyfile")
+	util.BindViper(v, aprefix+"cacert")
+	util.BindViper(v, aprefix+"clientauth")
+}
+
+// FromViper fill values from viper
+func (cfg *AdminAPICfg) FromViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	cfg.ListenURI = v.GetString(aprefix + "listenuri")
+	cfg.Allowed = v.GetStringSlice(aprefix + "allowed")
+	cfg.Tokens = v.GetStringSlice(aprefix + "tokens")
+	cfg.TLS.CertFile = v.GetString(aprefix + "certfile")
+	cfg.TLS.KeyFile = v.GetString(aprefix + "keyfile")
+	cfg.TLS.CACert = v.GetString(aprefix + "cacert")
+	cfg.TLS.ClientAuth = v.GetBool(aprefix + "clientauth")
+}
+
+// Empty returns true if configuration is empty
+func (cfg AdminAPICfg) Empty() bool {
+	if cfg.ListenURI != "" {
+		return false
+	}
+	if len(cfg.Allowed) > 0 {
+		return false
+	}
+	if len(cfg.Tokens) > 0 {
+		return false
+	}
+	if cfg.TLS.UseTLS() {
+		return false
+	}
+	return true
+}
+
+// Validate checks that configuration is ok
+func (cfg AdminAPICfg) Validate() error {
+	if cfg.ListenURI == "" {
+		return errors.New("listenuri is required")
+	}
+	_, _, err := util.ParseListenURI(cfg.ListenURI)
+	if err != nil {
+		return err
+	}
+	for _, item := range cfg.Allowed {
+		_, _, err = net.ParseCIDR(item)
+		if err != nil {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return fmt.Errorf("value '%v' is not a valid ip or cidr", item)
+			}
+		}
+	}
+	for _, token := range cfg.Tokens {
+		if token == "" {
+			return errors.New("tokens can't be empty")
+		}
+	}
+	if cfg.TLS.UseTLS() {
+		return cfg.TLS.Validate()
+	}
+	if len(cfg.Tokens) > 0 && cfg.Cleartext() {
+		return errors.New("tokens require tls in non loopback listeners")
+	}
+	return nil
+}
+
+// Cleartext returns true if credentials would be sent in clear text over
+// the network: the listener is not a loopback and tls is not used.
+func (cfg AdminAPICfg) Cleartext() bool {
+	if cfg.TLS.UseTLS() {
+		return false
+	}
+	proto, addr, err := util.ParseListenURI(cfg.ListenURI)
+	return err == nil && proto == "tcp" && !isLoopback(addr)
+}
+
+// Dump configuration
+func (cfg AdminAPICfg) Dump() string {
+	return fmt.Sprintf("%+v", cfg)
+}
+
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
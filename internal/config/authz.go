@@ -0,0 +1,70 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/luids-io/common/util"
+)
+
+// AuthzCfg stores authorization preferences
+type AuthzCfg struct {
+	RulesFile string
+}
+
+// SetPFlags setups posix flags for commandline configuration
+func (cfg *AuthzCfg) SetPFlags(short bool, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	pflag.StringVar(&cfg.RulesFile, aprefix+"rules", cfg.RulesFile, "Authorization rules file.")
+}
+
+// BindViper setups posix flags for commandline configuration and bind to viper
+func (cfg *AuthzCfg) BindViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	util.BindViper(v, aprefix+"rules")
+}
+
+// FromViper fill values from viper
+func (cfg *AuthzCfg) FromViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	cfg.RulesFile = v.GetString(aprefix + "rules")
+}
+
+// Empty returns true if configuration is empty
+func (cfg AuthzCfg) Empty() bool {
+	return cfg.RulesFile == ""
+}
+
+// Validate checks that configuration is ok
+func (cfg AuthzCfg) Validate() error {
+	if cfg.RulesFile == "" {
+		return errors.New("rules file is required")
+	}
+	if !strings.HasSuffix(cfg.RulesFile, ".json") {
+		return fmt.Errorf("rules file '%s' without .json extension", cfg.RulesFile)
+	}
+	if !util.FileExists(cfg.RulesFile) {
+		return fmt.Errorf("rules file '%v' doesn't exists", cfg.RulesFile)
+	}
+	return nil
+}
+
+// Dump configuration
+func (cfg AuthzCfg) Dump() string {
+	return fmt.Sprintf("%+v", cfg)
+}
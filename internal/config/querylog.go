@@ -0,0 +1,66 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/luids-io/common/util"
+)
+
+// QueryLogCfg stores query log preferences
+type QueryLogCfg struct {
+	Dir string
+}
+
+// SetPFlags setups posix flags for commandline configuration
+func (cfg *QueryLogCfg) SetPFlags(short bool, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	pflag.StringVar(&cfg.Dir, aprefix+"dir", cfg.Dir, "Query log directory.")
+}
+
+// BindViper setups posix flags for commandline configuration and bind to viper
+func (cfg *QueryLogCfg) BindViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	util.BindViper(v, aprefix+"dir")
+}
+
+// FromViper fill values from viper
+func (cfg *QueryLogCfg) FromViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	cfg.Dir = v.GetString(aprefix + "dir")
+}
+
+// Empty returns true if configuration is empty
+func (cfg QueryLogCfg) Empty() bool {
+	return cfg.Dir == ""
+}
+
+// Validate checks that configuration is ok
+func (cfg QueryLogCfg) Validate() error {
+	if cfg.Dir == "" {
+		return errors.New("query log dir is required")
+	}
+	if !util.DirExists(cfg.Dir) {
+		return fmt.Errorf("query log dir '%v' doesn't exists", cfg.Dir)
+	}
+	return nil
+}
+
+// Dump configuration
+func (cfg QueryLogCfg) Dump() string {
+	return fmt.Sprintf("%+v", cfg)
+}
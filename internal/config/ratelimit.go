@@ -0,0 +1,88 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/luids-io/common/util"
+)
+
+// RateLimitCfg stores rate limit preferences
+type RateLimitCfg struct {
+	Rate         float64
+	Burst        int
+	By           string
+	Queue        bool
+	MaxWaitMSecs int
+}
+
+// SetPFlags setups posix flags for commandline configuration
+func (cfg *RateLimitCfg) SetPFlags(short bool, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	pflag.Float64Var(&cfg.Rate, aprefix+"rate", cfg.Rate, "Requests per second allowed for each client.")
+	pflag.IntVar(&cfg.Burst, aprefix+"burst", cfg.Burst, "Burst allowed for each client.")
+	pflag.StringVar(&cfg.By, aprefix+"by", cfg.By, "Client key (peer or identity).")
+	pflag.BoolVar(&cfg.Queue, aprefix+"queue", cfg.Queue, "Queue requests instead of rejecting.")
+	pflag.IntVar(&cfg.MaxWaitMSecs, aprefix+"maxwait", cfg.MaxWaitMSecs, "Max wait in milliseconds for queued requests.")
+}
+
+// BindViper setups posix flags for commandline configuration and bind to viper
+func (cfg *RateLimitCfg) BindViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	util.BindViper(v, aprefix+"rate")
+	util.BindViper(v, aprefix+"burst")
+	util.BindViper(v, aprefix+"by")
+	util.BindViper(v, aprefix+"queue")
+	util.BindViper(v, aprefix+"maxwait")
+}
+
+// FromViper fill values from viper
+func (cfg *RateLimitCfg) FromViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	cfg.Rate = v.GetFloat64(aprefix + "rate")
+	cfg.Burst = v.GetInt(aprefix + "burst")
+	cfg.By = v.GetString(aprefix + "by")
+	cfg.Queue = v.GetBool(aprefix + "queue")
+	cfg.MaxWaitMSecs = v.GetInt(aprefix + "maxwait")
+}
+
+// Empty returns true if configuration is empty
+func (cfg RateLimitCfg) Empty() bool {
+	return cfg.Rate == 0
+}
+
+// Validate checks that configuration is ok
+func (cfg RateLimitCfg) Validate() error {
+	if cfg.Rate <= 0 {
+		return errors.New("rate must be greater than zero")
+	}
+	if cfg.Burst < 0 {
+		return errors.New("invalid burst")
+	}
+	if cfg.By != "peer" && cfg.By != "identity" {
+		return fmt.Errorf("invalid by '%s'", cfg.By)
+	}
+	if cfg.Queue && cfg.MaxWaitMSecs <= 0 {
+		return errors.New("maxwait is required for queue")
+	}
+	return nil
+}
+
+// Dump configuration
+func (cfg RateLimitCfg) Dump() string {
+	return fmt.Sprintf("%+v", cfg)
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/luids-io/common/util"
+)
+
+// ReplicationAPICfg stores replication service preferences
+type ReplicationAPICfg struct {
+	Enable    bool
+	BatchSize int
+}
+
+// SetPFlags setups posix flags for commandline configuration
+func (cfg *ReplicationAPICfg) SetPFlags(short bool, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	pflag.BoolVar(&cfg.Enable, aprefix+"enable", cfg.Enable, "Enable replication of dynamic lists to followers.")
+	pflag.IntVar(&cfg.BatchSize, aprefix+"batchsize", cfg.BatchSize, "Max entries sent in each message.")
+}
+
+// BindViper setups posix flags for commandline configuration and bind to viper
+func (cfg *ReplicationAPICfg) BindViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	util.BindViper(v, aprefix+"enable")
+	util.BindViper(v, aprefix+"batchsize")
+}
+
+// FromViper fill values from viper
+func (cfg *ReplicationAPICfg) FromViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	cfg.Enable = v.GetBool(aprefix + "enable")
+	cfg.BatchSize = v.GetInt(aprefix + "batchsize")
+}
+
+// Empty returns true if configuration is empty
+func (cfg ReplicationAPICfg) Empty() bool {
+	return !cfg.Enable
+}
+
+// Validate checks that configuration is ok
+func (cfg ReplicationAPICfg) Validate() error {
+	if cfg.BatchSize < 0 {
+		return errors.New("invalid batch size")
+	}
+	return nil
+}
+
+// Dump configuration
+func (cfg ReplicationAPICfg) Dump() string {
+	return fmt.Sprintf("%+v", cfg)
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/luids-io/common/util"
+)
+
+// TracingCfg stores tracing preferences
+type TracingCfg struct {
+	Endpoint string
+	Service  string
+	Ratio    float64
+}
+
+// SetPFlags setups posix flags for commandline configuration
+func (cfg *TracingCfg) SetPFlags(short bool, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	pflag.StringVar(&cfg.Endpoint, aprefix+"endpoint", cfg.Endpoint, "OTLP/HTTP traces endpoint (ex: http://127.0.0.1:4318/v1/traces).")
+	pflag.StringVar(&cfg.Service, aprefix+"service", cfg.Service, "Service name reported in spans.")
+	pflag.Float64Var(&cfg.Ratio, aprefix+"ratio", cfg.Ratio, "Ratio of new traces sampled (0-1).")
+}
+
+// BindViper setups posix flags for commandline configuration and bind to viper
+func (cfg *TracingCfg) BindViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	util.BindViper(v, aprefix+"endpoint")
+	util.BindViper(v, aprefix+"service")
+	util.BindViper(v, aprefix+"ratio")
+}
+
+// FromViper fill values from viper
+func (cfg *TracingCfg) FromViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	cfg.Endpoint = v.GetString(aprefix + "endpoint")
+	cfg.Service = v.GetString(aprefix + "service")
+	cfg.Ratio = v.GetFloat64(aprefix + "ratio")
+}
+
+// Empty returns true if configuration is empty
+func (cfg TracingCfg) Empty() bool {
+	return cfg.Endpoint == ""
+}
+
+// Validate checks that configuration is ok
+func (cfg TracingCfg) Validate() error {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid endpoint '%s'", cfg.Endpoint)
+	}
+	if cfg.Service == "" {
+		return errors.New("service is required")
+	}
+	if cfg.Ratio < 0 || cfg.Ratio > 1 {
+		return errors.New("ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+// Dump configuration
+func (cfg TracingCfg) Dump() string {
+	return fmt.Sprintf("%+v", cfg)
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/luids-io/common/util"
+	"github.com/luids-io/core/grpctls"
+)
+
+// AdminClientCfg stores administration client preferences
+type AdminClientCfg struct {
+	RemoteURI   string
+	Token       string
+	TimeoutSecs int
+	TLS         grpctls.ClientCfg
+}
+
+// SetPFlags setups posix flags for commandline configuration
+func (cfg *AdminClientCfg) SetPFlags(short bool, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	pflag.StringVar(&cfg.RemoteURI, aprefix+"remoteuri", cfg.RemoteURI, "Admin api uri.")
+	pflag.StringVar(&cfg.Token, aprefix+"token", cfg.Token, "Bearer token.")
+	pflag.IntVar(&cfg.TimeoutSecs, aprefix+"timeout", cfg.TimeoutSecs, "Timeout in seconds.")
+	pflag.StringVar(&cfg.TLS.CertFile, aprefix+"clientcert", cfg.TLS.CertFile, "Path to client cert file.")
+	pflag.StringVar(&cfg.TLS.KeyFile, aprefix+"clientkey", cfg.TLS.KeyFile, "Path to client key file.")
+	pflag.StringVar(&cfg.TLS.ServerCert, aprefix+"servercert", cfg.TLS.ServerCert, "Path to server cert file.")
+	pflag.StringVar(&cfg.TLS.ServerName, aprefix+"servername", cfg.TLS.ServerName, "Server name for TLS check.")
+	pflag.StringVar(&cfg.TLS.CACert, aprefix+"cacert", cfg.TLS.CACert, "Path to CA cert file.")
+	pflag.BoolVar(&cfg.TLS.UseSystemCAs, aprefix+"systemca", cfg.TLS.UseSystemCAs, "Use system CA pool.")
+}
+
+// BindViper setups posix flags for commandline configuration and bind to viper
+func (cfg *AdminClientCfg) BindViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	util.BindViper(v, aprefix+"remoteuri")
+	util.BindViper(v, aprefix+"token")
+	util.BindViper(v, aprefix+"timeout")
+	util.BindViper(v, aprefix+"clientcert")
+	util.BindViper(v, aprefix+"clientkey")
+	util.BindViper(v, aprefix+"servercert")
+	util.BindViper(v, aprefix+"servername")
+	util.BindViper(v, aprefix+"cacert")
+	util.BindViper(v, aprefix+"systemca")
+}
+
+// FromViper fill values from viper
+func (cfg *AdminClientCfg) FromViper(v *viper.Viper, prefix string) {
+	aprefix := ""
+	if prefix != "" {
+		aprefix = prefix + "."
+	}
+	cfg.RemoteURI = v.GetString(aprefix + "remoteuri")
+	cfg.Token = v.GetString(aprefix + "token")
+	cfg.TimeoutSecs = v.GetInt(aprefix + "timeout")
+	cfg.TLS.CertFile = v.GetString(aprefix + "clientcert")
+	cfg.TLS.KeyFile = v.GetString(aprefix + "clientkey")
+	cfg.TLS.ServerCert = v.GetString(aprefix + "servercert")
+	cfg.TLS.ServerName = v.GetString(aprefix + "servername")
+	cfg.TLS.CACert = v.GetString(aprefix + "cacert")
+	cfg.TLS.UseSystemCAs = v.GetBool(aprefix + "systemca")
+}
+
+// Empty returns true if configuration is empty
+func (cfg AdminClientCfg) Empty() bool {
+	if cfg.RemoteURI != "" {
+		return false
+	}
+	if cfg.Token != "" {
+		return false
+	}
+	return true
+}
+
+// Validate checks that configuration is ok
+func (cfg AdminClientCfg) Validate() error {
+	if cfg.RemoteURI == "" {
+		return errors.New("remoteuri is required")
+	}
+	if !strings.HasPrefix(cfg.RemoteURI, "tcp://") && !strings.HasPrefix(cfg.RemoteURI, "unix://") {
+		return fmt.Errorf("invalid remoteuri '%s'", cfg.RemoteURI)
+	}
+	if cfg.TimeoutSecs < 0 {
+		return errors.New("invalid timeout")
+	}
+	if cfg.TLS.UseTLS() {
+		return cfg.TLS.Validate()
+	}
+	return nil
+}
+
+// Dump configuration
+func (cfg AdminClientCfg) Dump() string {
+	return fmt.Sprintf("%+v", cfg)
+}
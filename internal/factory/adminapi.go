@@ -0,0 +1,86 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package factory
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/luids-io/core/grpctls"
+	"github.com/luids-io/core/ipfilter"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/internal/config"
+	"github.com/luids-io/xlist/pkg/authz"
+	"github.com/luids-io/xlist/pkg/tlsreload"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/admin"
+)
+
+// AdminAPI creates the administration http server, authorizer is optional.
+// If TLS is used, it returns the watcher that reloads the certificates.
+func AdminAPI(cfg *config.AdminAPICfg, builder *xlistd.Builder, authorizer *authz.Authorizer, logger yalogi.Logger) (net.Listener, *admin.Server, *tlsreload.Watcher, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("bad config: %v", err)
+	}
+	if len(cfg.Tokens) == 0 && authorizer == nil {
+		return nil, nil, nil, errors.New("bad config: tokens or authorization rules are required")
+	}
+	if authorizer != nil && authorizer.HasTokens() && cfg.Cleartext() {
+		return nil, nil, nil, errors.New("bad config: authorization rules with tokens require tls in non loopback listeners")
+	}
+	opts := []admin.Option{
+		admin.SetLogger(logger),
+		admin.SetIPFilter(ipfilter.Whitelist(cfg.Allowed)),
+		admin.Tokens(cfg.Tokens...),
+		admin.SetAuthorizer(authorizer),
+	}
+	var watcher *tlsreload.Watcher
+	if cfg.TLS.UseTLS() {
+		watcher, err = tlsreload.NewWatcher(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.CACert,
+			tlsreload.SetLogger(logger), tlsreload.Interval(DefaultTLSReload))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("initializing TLS: %v", err)
+		}
+		opts = append(opts, admin.SetTLS(tlsreload.HTTPServerConfig(watcher, cfg.TLS.ClientAuth)))
+	}
+	lis, err := Listener("admin", cfg.ListenURI)
+	if err != nil {
+		if watcher != nil {
+			watcher.Close()
+		}
+		return nil, nil, nil, fmt.Errorf("listening admin: %v", err)
+	}
+	return lis, admin.New(builder, opts...), watcher, nil
+}
+
+// AdminClient creates the administration client
+func AdminClient(cfg *config.AdminClientCfg) (*admin.Client, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("bad config: %v", err)
+	}
+	opts := []admin.ClientOption{admin.SetToken(cfg.Token)}
+	if cfg.TimeoutSecs > 0 {
+		opts = append(opts, admin.SetTimeout(time.Duration(cfg.TimeoutSecs)*time.Second))
+	}
+	if cfg.TLS.UseTLS() {
+		tlsCfg := cfg.TLS
+		if tlsCfg.ServerName == "" {
+			_, addr, err := grpctls.ParseURI(cfg.RemoteURI)
+			if err != nil {
+				return nil, fmt.Errorf("bad config: %v", err)
+			}
+			tlsCfg.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		//short-lived client, certificates are not watched
+		tlsConfig, _, err := tlsreload.ClientConfig(tlsCfg, tlsreload.Interval(0))
+		if err != nil {
+			return nil, fmt.Errorf("initializing TLS: %v", err)
+		}
+		opts = append(opts, admin.SetClientTLS(tlsConfig))
+	}
+	return admin.NewClient(cfg.RemoteURI, opts...)
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package factory
+
+import (
+	"fmt"
+
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/internal/config"
+	"github.com/luids-io/xlist/pkg/authz"
+)
+
+// Authorizer creates an authorizer from configuration
+func Authorizer(cfg *config.AuthzCfg, logger yalogi.Logger) (*authz.Authorizer, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("bad config: %v", err)
+	}
+	rules, err := authz.RulesFromFile(cfg.RulesFile)
+	if err != nil {
+		return nil, err
+	}
+	return authz.New(rules, authz.SetLogger(logger))
+}
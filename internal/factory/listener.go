@@ -0,0 +1,70 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package factory
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/luids-io/common/util"
+	"github.com/luids-io/xlist/internal/systemd"
+)
+
+var (
+	activatedMu sync.Mutex
+	activated   []systemd.Listener
+)
+
+// SetActivated sets the listeners passed by socket activation. Factories
+// use them instead of binding new sockets.
+func SetActivated(listeners []systemd.Listener) {
+	activatedMu.Lock()
+	activated = listeners
+	activatedMu.Unlock()
+}
+
+// Listener returns an activated listener that matches the name (the
+// FileDescriptorName of the socket unit) or the address of the uri. If there
+// is no match, it binds a new listener.
+func Listener(name, uri string) (net.Listener, error) {
+	proto, addr, err := util.ParseListenURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse address '%v': %v", uri, err)
+	}
+	activatedMu.Lock()
+	for i, l := range activated {
+		laddr := l.Addr()
+		if l.Name == name || (laddr.Network() == proto && sameAddr(laddr.String(), addr)) {
+			activated = append(activated[:i], activated[i+1:]...)
+			activatedMu.Unlock()
+			return l.Listener, nil
+		}
+	}
+	activatedMu.Unlock()
+	lis, err := net.Listen(proto, addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen socket '%v': %v", uri, err)
+	}
+	return lis, nil
+}
+
+func sameAddr(laddr, addr string) bool {
+	if laddr == addr {
+		return true
+	}
+	lhost, lport, err := net.SplitHostPort(laddr)
+	if err != nil {
+		return false
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || port != lport {
+		return false
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		lip := net.ParseIP(lhost)
+		return lip != nil && lip.IsUnspecified()
+	}
+	lip, ip := net.ParseIP(lhost), net.ParseIP(host)
+	return lip != nil && ip != nil && lip.Equal(ip)
+}
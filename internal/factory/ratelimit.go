@@ -0,0 +1,26 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package factory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/internal/config"
+	"github.com/luids-io/xlist/pkg/ratelimit"
+)
+
+// RateLimit creates a rate limit interceptor from configuration
+func RateLimit(cfg *config.RateLimitCfg, logger yalogi.Logger) (*ratelimit.Interceptor, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("bad config: %v", err)
+	}
+	opts := []ratelimit.Option{ratelimit.SetLogger(logger), ratelimit.By(cfg.By)}
+	if cfg.Queue {
+		opts = append(opts, ratelimit.Queue(time.Duration(cfg.MaxWaitMSecs)*time.Millisecond))
+	}
+	limiter := ratelimit.New(cfg.Rate, cfg.Burst)
+	return ratelimit.NewInterceptor(limiter, opts...), nil
+}
@@ -0,0 +1,25 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package factory
+
+import (
+	"fmt"
+
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/internal/config"
+	"github.com/luids-io/xlist/pkg/authz"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/replication"
+)
+
+// ReplicationAPI creates replication grpc service, authorizer is optional
+func ReplicationAPI(cfg *config.ReplicationAPICfg, finder *xlistd.Builder, authorizer *authz.Authorizer, logger yalogi.Logger) (*replication.Server, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("bad config: %v", err)
+	}
+	return replication.NewServer(finder,
+		replication.SetLogger(logger),
+		replication.SetAuthorizer(authorizer),
+		replication.BatchSize(cfg.BatchSize)), nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package factory
+
+import (
+	"fmt"
+	"net"
+
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	cconfig "github.com/luids-io/common/config"
+	"github.com/luids-io/core/ipfilter"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/tlsreload"
+)
+
+// Interceptors stores additional interceptors for a grpc server.
+type Interceptors struct {
+	Unary  []grpc.UnaryServerInterceptor
+	Stream []grpc.StreamServerInterceptor
+}
+
+// Add appends the interceptors passed.
+func (i *Interceptors) Add(u grpc.UnaryServerInterceptor, s grpc.StreamServerInterceptor) {
+	i.Unary = append(i.Unary, u)
+	i.Stream = append(i.Stream, s)
+}
+
+// Server is a factory for a grpc server that allows additional interceptors.
+// Interceptors are chained after ip filtering and metrics. If TLS is used,
+// it returns the watcher that reloads the certificates. Activated listeners
+// are used if available.
+func Server(cfg *cconfig.ServerCfg, extra Interceptors, logger yalogi.Logger) (net.Listener, *grpc.Server, *tlsreload.Watcher, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid server config: %v", err)
+	}
+	var creds credentials.TransportCredentials
+	var watcher *tlsreload.Watcher
+	if cfg.TLS.UseTLS() {
+		creds, watcher, err = tlsreload.ServerCreds(cfg.TLS,
+			tlsreload.SetLogger(logger), tlsreload.Interval(DefaultTLSReload))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("initializing TLS: %v", err)
+		}
+	}
+	lis, err := Listener("server", cfg.ListenURI)
+	if err != nil {
+		if watcher != nil {
+			watcher.Close()
+		}
+		return nil, nil, nil, fmt.Errorf("listening server: %v", err)
+	}
+	uinterceptors := make([]grpc.UnaryServerInterceptor, 0)
+	sinterceptors := make([]grpc.StreamServerInterceptor, 0)
+	filter := ipfilter.Whitelist(cfg.Allowed)
+	if !filter.Empty() {
+		uinterceptors = append(uinterceptors, filter.UnaryServerInterceptor)
+		sinterceptors = append(sinterceptors, filter.StreamServerInterceptor)
+	}
+	if cfg.Metrics {
+		uinterceptors = append(uinterceptors, grpc_prometheus.UnaryServerInterceptor)
+		sinterceptors = append(sinterceptors, grpc_prometheus.StreamServerInterceptor)
+	}
+	uinterceptors = append(uinterceptors, extra.Unary...)
+	sinterceptors = append(sinterceptors, extra.Stream...)
+	//create options
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(uinterceptors...),
+		grpc.ChainStreamInterceptor(sinterceptors...),
+	}
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+	}
+	return lis, grpc.NewServer(opts...), watcher, nil
+}
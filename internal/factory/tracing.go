@@ -0,0 +1,25 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package factory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/internal/config"
+	"github.com/luids-io/xlist/pkg/tracing"
+)
+
+// DefaultTracingTimeout is the timeout used by the exporter.
+var DefaultTracingTimeout = 10 * time.Second
+
+// Tracer creates a tracer that exports spans to an OTLP collector
+func Tracer(cfg *config.TracingCfg, logger yalogi.Logger) (*tracing.Tracer, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("bad config: %v", err)
+	}
+	exporter := tracing.NewOTLPExporter(cfg.Endpoint, cfg.Service, nil, DefaultTracingTimeout)
+	return tracing.NewTracer(exporter, tracing.SetLogger(logger), tracing.SampleRatio(cfg.Ratio)), nil
+}
@@ -20,19 +20,22 @@ import (
 var (
 	DefaultTimeouts    = 1 * time.Second
 	DefaultRateLimiter = "naive"
+	DefaultTLSReload   = 30 * time.Second
 )
 
 // ListBuilder is a factory for an xlist builder
-func ListBuilder(cfg *config.XListCfg, apisvc apiservice.Discover, logger yalogi.Logger) (*xlistd.Builder, error) {
+func ListBuilder(cfg *config.XListCfg, apisvc apiservice.Discover, logger yalogi.Logger, opt ...xlistd.BuilderOption) (*xlistd.Builder, error) {
 	err := cfg.Validate()
 	if err != nil {
 		return nil, err
 	}
-	b := xlistd.NewBuilder(apisvc,
+	opts := []xlistd.BuilderOption{
 		xlistd.DataDir(cfg.DataDir),
 		xlistd.CertsDir(cfg.CertsDir),
 		xlistd.SetLogger(logger),
-	)
+	}
+	opts = append(opts, opt...)
+	b := xlistd.NewBuilder(apisvc, opts...)
 	return b, nil
 }
 
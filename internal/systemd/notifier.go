@@ -0,0 +1,88 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package systemd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/luids-io/core/yalogi"
+)
+
+// Notifier sends readiness and watchdog notifications to systemd.
+type Notifier struct {
+	logger   yalogi.Logger
+	interval time.Duration
+	close    chan struct{}
+	started  bool
+	wg       sync.WaitGroup
+}
+
+// NewNotifier returns a new notifier.
+func NewNotifier(logger yalogi.Logger) *Notifier {
+	if logger == nil {
+		logger = yalogi.LogNull
+	}
+	return &Notifier{logger: logger, close: make(chan struct{})}
+}
+
+// Ready notifies readiness and starts watchdog if it is enabled. Watchdog
+// keepalives only report the liveness of the process, the health of the
+// lists is not taken into account, so an outage of a remote source doesn't
+// cause systemd to restart the service.
+func (n *Notifier) Ready() error {
+	n.notify(Ready)
+	interval, ok, err := WatchdogInterval()
+	if err != nil {
+		return err
+	}
+	if ok {
+		n.interval = interval / 2
+		n.logger.Debugf("systemd: starting watchdog every %v", n.interval)
+		n.started = true
+		n.wg.Add(1)
+		go n.watchdog()
+	}
+	return nil
+}
+
+// Reloading notifies that the service is reloading.
+func (n *Notifier) Reloading() error {
+	n.notify(Reloading)
+	return nil
+}
+
+// Reloaded notifies that the service is ready after a reload.
+func (n *Notifier) Reloaded() error {
+	n.notify(Ready)
+	return nil
+}
+
+// Stopping notifies that the service is stopping and stops watchdog.
+func (n *Notifier) Stopping() {
+	n.notify(Stopping)
+	if n.started {
+		close(n.close)
+		n.wg.Wait()
+	}
+}
+
+func (n *Notifier) watchdog() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.close:
+			return
+		case <-ticker.C:
+			n.notify(Watchdog)
+		}
+	}
+}
+
+func (n *Notifier) notify(state string) {
+	if _, err := Notify(state); err != nil {
+		n.logger.Warnf("%v", err)
+	}
+}
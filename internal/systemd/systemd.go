@@ -0,0 +1,112 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+// Package systemd implements socket activation and the notification
+// protocol of systemd.
+package systemd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// States used in notifications.
+const (
+	Ready     = "READY=1"
+	Reloading = "RELOADING=1"
+	Stopping  = "STOPPING=1"
+	Watchdog  = "WATCHDOG=1"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd
+const listenFdsStart = 3
+
+// Listener is a listener passed by socket activation.
+type Listener struct {
+	net.Listener
+	Name string
+}
+
+// Listeners returns the stream listeners passed by socket activation. The
+// environment variables are unset, so they are not inherited by childs.
+func Listeners() ([]Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	nfds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || nfds <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	listeners := make([]Listener, 0, nfds)
+	for fd := listenFdsStart; fd < listenFdsStart+nfds; fd++ {
+		syscall.CloseOnExec(fd)
+		name := ""
+		if idx := fd - listenFdsStart; idx < len(names) {
+			name = names[idx]
+		}
+		file := os.NewFile(uintptr(fd), name)
+		l, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("systemd: fd %v (%s) is not a stream socket: %v", fd, name, err)
+		}
+		listeners = append(listeners, Listener{Listener: l, Name: name})
+	}
+	return listeners, nil
+}
+
+// Notify sends the state to systemd. It returns false if notification is not
+// supported.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("systemd: %v", err)
+	}
+	defer conn.Close()
+	if _, err = conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("systemd: %v", err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the interval configured for the watchdog. It
+// returns false if watchdog is not enabled for the process.
+func WatchdogInterval() (time.Duration, bool, error) {
+	wusec := os.Getenv("WATCHDOG_USEC")
+	if wusec == "" {
+		return 0, false, nil
+	}
+	if wpid := os.Getenv("WATCHDOG_PID"); wpid != "" {
+		pid, err := strconv.Atoi(wpid)
+		if err != nil {
+			return 0, false, fmt.Errorf("systemd: invalid WATCHDOG_PID: %v", err)
+		}
+		if pid != os.Getpid() {
+			return 0, false, nil
+		}
+	}
+	usec, err := strconv.ParseInt(wusec, 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false, errors.New("systemd: invalid WATCHDOG_USEC")
+	}
+	return time.Duration(usec) * time.Microsecond, true, nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package systemd_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/luids-io/xlist/internal/systemd"
+)
+
+func TestNotify(t *testing.T) {
+	os.Unsetenv("NOTIFY_SOCKET")
+	ok, err := systemd.Notify(systemd.Ready)
+	if ok || err != nil {
+		t.Fatalf("Notify() without socket = %v, %v", ok, err)
+	}
+
+	dir, err := ioutil.TempDir("", "systemd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	os.Setenv("NOTIFY_SOCKET", socket)
+	defer os.Unsetenv("NOTIFY_SOCKET")
+
+	var tests = []string{systemd.Ready, systemd.Reloading, systemd.Watchdog, systemd.Stopping}
+	for _, state := range tests {
+		ok, err := systemd.Notify(state)
+		if !ok || err != nil {
+			t.Fatalf("Notify(%s) = %v, %v", state, ok, err)
+		}
+		buf := make([]byte, 64)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("reading notification: %v", err)
+		}
+		if got := string(buf[:n]); got != state {
+			t.Errorf("Notify(%s) got %s", state, got)
+		}
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	defer os.Unsetenv("WATCHDOG_USEC")
+	defer os.Unsetenv("WATCHDOG_PID")
+	pid := strconv.Itoa(os.Getpid())
+	var tests = []struct {
+		usec    string
+		pid     string
+		want    time.Duration
+		wantOk  bool
+		wantErr bool
+	}{
+		{"", "", 0, false, false},
+		{"1000000", "", time.Second, true, false},
+		{"1000000", pid, time.Second, true, false},
+		{"1000000", "1", 0, false, false},
+		{"abc", "", 0, false, true},
+		{"1000000", "abc", 0, false, true},
+	}
+	for idx, test := range tests {
+		os.Setenv("WATCHDOG_USEC", test.usec)
+		os.Setenv("WATCHDOG_PID", test.pid)
+		got, ok, err := systemd.WatchdogInterval()
+		if (err != nil) != test.wantErr {
+			t.Errorf("idx[%v] WatchdogInterval() err=%v", idx, err)
+			continue
+		}
+		if got != test.want || ok != test.wantOk {
+			t.Errorf("idx[%v] WatchdogInterval() = %v, %v; want %v, %v", idx, got, ok, test.want, test.wantOk)
+		}
+	}
+}
+
+func TestListeners(t *testing.T) {
+	os.Setenv("LISTEN_PID", "1")
+	os.Setenv("LISTEN_FDS", "1")
+	listeners, err := systemd.Listeners()
+	if err != nil || len(listeners) > 0 {
+		t.Fatalf("Listeners() other pid = %v, %v", listeners, err)
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Errorf("Listeners() didn't unset environment")
+	}
+	listeners, err = systemd.Listeners()
+	if err != nil || len(listeners) > 0 {
+		t.Fatalf("Listeners() no env = %v, %v", listeners, err)
+	}
+}
+
+func TestNotifier_Watchdog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "systemd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	os.Setenv("NOTIFY_SOCKET", socket)
+	defer os.Unsetenv("NOTIFY_SOCKET")
+	os.Setenv("WATCHDOG_USEC", "100000")
+	defer os.Unsetenv("WATCHDOG_USEC")
+
+	notifier := systemd.NewNotifier(nil)
+	if err := notifier.Ready(); err != nil {
+		t.Fatalf("Ready() err=%v", err)
+	}
+	// readiness and keepalives
+	var tests = []string{systemd.Ready, systemd.Watchdog, systemd.Watchdog}
+	for _, state := range tests {
+		buf := make([]byte, 64)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("reading notification: %v", err)
+		}
+		if got := string(buf[:n]); got != state {
+			t.Errorf("notifier want %s got %s", state, got)
+		}
+	}
+	notifier.Stopping()
+}
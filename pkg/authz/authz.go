@@ -0,0 +1,287 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package authz provides an authorizer that identifies callers by source
+// address, mTLS certificate or bearer token and grants them permissions on
+// root lists and administrative operations.
+//
+// This package is a work in progress and makes no API stability promises.
+package authz
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	cliprom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/luids-io/core/yalogi"
+)
+
+// Any is the wildcard used in permissions.
+const Any = "*"
+
+// Reasons for denied requests.
+const (
+	Unauthenticated = "unauthenticated"
+	Forbidden       = "forbidden"
+)
+
+// Rule defines an identity and the permissions granted to it. A caller
+// matches the identity if it matches all the criteria defined in the rule.
+// A rule without criteria matches any caller.
+type Rule struct {
+	Name     string   `json:"name"`
+	CIDRs    []string `json:"cidrs,omitempty"`
+	Subjects []string `json:"subjects,omitempty"`
+	Tokens   []string `json:"tokens,omitempty"`
+	Lists    []string `json:"lists,omitempty"`
+	Ops      []string `json:"ops,omitempty"`
+}
+
+// Request stores the credentials presented by a caller.
+type Request struct {
+	IP    net.IP
+	Certs []*x509.Certificate
+	Token string
+}
+
+// Option encapsules authorizer options.
+type Option func(*options)
+
+type options struct {
+	logger yalogi.Logger
+}
+
+var defaultOptions = options{logger: yalogi.LogNull}
+
+// SetLogger option sets a logger for the component.
+func SetLogger(l yalogi.Logger) Option {
+	return func(o *options) {
+		if l != nil {
+			o.logger = l
+		}
+	}
+}
+
+// Authorizer identifies callers and checks their permissions.
+type Authorizer struct {
+	logger yalogi.Logger
+	rules  []rule
+}
+
+type rule struct {
+	Rule
+	nets []*net.IPNet
+}
+
+// New creates a new authorizer with the rules passed. Rules are evaluated in
+// order and the first matching rule defines the identity.
+func New(rules []Rule, opt ...Option) (*Authorizer, error) {
+	opts := defaultOptions
+	for _, o := range opt {
+		o(&opts)
+	}
+	a := &Authorizer{logger: opts.logger, rules: make([]rule, 0, len(rules))}
+	names := make(map[string]bool)
+	for idx, r := range rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("rule %v: name is required", idx)
+		}
+		if names[r.Name] {
+			return nil, fmt.Errorf("rule '%s': duplicated name", r.Name)
+		}
+		names[r.Name] = true
+		ar := rule{Rule: r}
+		for _, s := range r.CIDRs {
+			_, ipnet, err := net.ParseCIDR(s)
+			if err != nil {
+				ip := net.ParseIP(s)
+				if ip == nil {
+					return nil, fmt.Errorf("rule '%s': invalid cidr '%s'", r.Name, s)
+				}
+				bits := 8 * net.IPv6len
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 8*net.IPv4len
+				}
+				ipnet = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+			}
+			ar.nets = append(ar.nets, ipnet)
+		}
+		for _, t := range r.Tokens {
+			if t == "" {
+				return nil, fmt.Errorf("rule '%s': empty token", r.Name)
+			}
+		}
+		a.rules = append(a.rules, ar)
+	}
+	return a, nil
+}
+
+// RulesFromFile reads rules from a json file.
+func RulesFromFile(filename string) ([]Rule, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("opening file '%s': %v", filename, err)
+	}
+	var rules []Rule
+	err = json.Unmarshal(data, &rules)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling rules from json file '%s': %v", filename, err)
+	}
+	return rules, nil
+}
+
+// HasTokens returns true if some rule matches by token.
+func (a *Authorizer) HasTokens() bool {
+	for _, ar := range a.rules {
+		if len(ar.Tokens) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Identify returns the identity of the caller.
+func (a *Authorizer) Identify(r Request) (string, bool) {
+	for _, ar := range a.rules {
+		if ar.match(r) {
+			return ar.Name, true
+		}
+	}
+	return "", false
+}
+
+// AllowList returns true if identity can check the root list passed.
+func (a *Authorizer) AllowList(identity, list string) bool {
+	ar, ok := a.get(identity)
+	if !ok {
+		return false
+	}
+	return inArray(list, ar.Lists)
+}
+
+// AllowOp returns true if identity can do the administrative operation passed.
+func (a *Authorizer) AllowOp(identity, op string) bool {
+	ar, ok := a.get(identity)
+	if !ok {
+		return false
+	}
+	return inArray(op, ar.Ops)
+}
+
+// Denied registers a denied request in metrics and logs.
+func (a *Authorizer) Denied(service, identity, reason string, r Request) {
+	stats.denied.WithLabelValues(service, identity, reason).Inc()
+	a.logger.Warnf("authz: [peer=%v] [identity=%s] %s denied: %s", r.IP, identity, service, reason)
+}
+
+func (a *Authorizer) get(identity string) (rule, bool) {
+	for _, ar := range a.rules {
+		if ar.Name == identity {
+			return ar, true
+		}
+	}
+	return rule{}, false
+}
+
+func (r rule) match(req Request) bool {
+	if len(r.nets) > 0 {
+		if req.IP == nil {
+			return false
+		}
+		found := false
+		for _, ipnet := range r.nets {
+			if ipnet.Contains(req.IP) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.Subjects) > 0 {
+		if len(req.Certs) == 0 || !matchSubject(req.Certs[0], r.Subjects) {
+			return false
+		}
+	}
+	if len(r.Tokens) > 0 {
+		if req.Token == "" {
+			return false
+		}
+		found := false
+		for _, t := range r.Tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(req.Token)) == 1 {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSubject checks common name and subject alternative names
+func matchSubject(cert *x509.Certificate, subjects []string) bool {
+	names := []string{cert.Subject.CommonName, cert.Subject.String()}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	for _, s := range subjects {
+		for _, name := range names {
+			if name != "" && strings.EqualFold(s, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func inArray(s string, array []string) bool {
+	for _, v := range array {
+		if v == Any || v == s {
+			return true
+		}
+	}
+	return false
+}
+
+type identityKey struct{}
+
+// NewContext returns a new context with the identity passed.
+func NewContext(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the identity stored in the context.
+func FromContext(ctx context.Context) (string, bool) {
+	identity, ok := ctx.Value(identityKey{}).(string)
+	return identity, ok
+}
+
+// stats is a global structure.
+var stats struct {
+	denied *cliprom.CounterVec
+}
+
+func init() {
+	stats.denied = cliprom.NewCounterVec(
+		cliprom.CounterOpts{
+			Name: "authz_denied_total",
+			Help: "How many requests denied, partitioned by service, identity and reason",
+		},
+		[]string{"service", "identity", "reason"})
+
+	cliprom.MustRegister(stats.denied)
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package authz_test
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/luids-io/xlist/pkg/authz"
+)
+
+var testrules = []authz.Rule{
+	{Name: "admin", Tokens: []string{"secret1"}, Lists: []string{authz.Any}, Ops: []string{authz.Any}},
+	{Name: "soar", CIDRs: []string{"10.0.0.0/8"}, Tokens: []string{"secret2"}, Lists: []string{"root"}, Ops: []string{"read", "edit"}},
+	{Name: "client1", Subjects: []string{"client1.example.com"}, Lists: []string{"root", "other"}},
+	{Name: "lan", CIDRs: []string{"192.168.1.0/24", "172.16.1.1"}, Lists: []string{"root"}},
+}
+
+func TestAuthorizer(t *testing.T) {
+	a, err := authz.New(testrules)
+	if err != nil {
+		t.Fatalf("authz.New(): %v", err)
+	}
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}, DNSNames: []string{"client1.example.com"}}
+	var tests = []struct {
+		req      authz.Request
+		identity string
+		list     string
+		op       string
+		wantList bool
+		wantOp   bool
+	}{
+		{authz.Request{Token: "secret1"}, "admin", "root", "reload", true, true},
+		{authz.Request{IP: net.ParseIP("10.1.1.1"), Token: "secret2"}, "soar", "root", "edit", true, true},
+		{authz.Request{IP: net.ParseIP("10.1.1.1"), Token: "secret2"}, "soar", "other", "reload", false, false},
+		{authz.Request{IP: net.ParseIP("11.1.1.1"), Token: "secret2"}, "", "root", "read", false, false},
+		{authz.Request{IP: net.ParseIP("10.1.1.1"), Certs: []*x509.Certificate{cert}}, "client1", "other", "read", true, false},
+		{authz.Request{IP: net.ParseIP("192.168.1.10")}, "lan", "root", "read", true, false},
+		{authz.Request{IP: net.ParseIP("172.16.1.1")}, "lan", "root", "read", true, false},
+		{authz.Request{IP: net.ParseIP("172.16.1.2")}, "", "root", "read", false, false},
+		{authz.Request{IP: net.ParseIP("192.168.1.10"), Token: "bad"}, "lan", "root", "read", true, false},
+	}
+	for idx, test := range tests {
+		identity, ok := a.Identify(test.req)
+		if identity != test.identity || ok != (test.identity != "") {
+			t.Errorf("idx[%v] authz.Identify(): want=%v got=%v", idx, test.identity, identity)
+			continue
+		}
+		if got := a.AllowList(identity, test.list); got != test.wantList {
+			t.Errorf("idx[%v] authz.AllowList(): want=%v got=%v", idx, test.wantList, got)
+		}
+		if got := a.AllowOp(identity, test.op); got != test.wantOp {
+			t.Errorf("idx[%v] authz.AllowOp(): want=%v got=%v", idx, test.wantOp, got)
+		}
+	}
+	if !a.HasTokens() {
+		t.Error("authz.HasTokens(): want=true")
+	}
+	a, _ = authz.New([]authz.Rule{{Name: "lan", CIDRs: []string{"192.168.0.0/16"}}})
+	if a.HasTokens() {
+		t.Error("authz.HasTokens(): want=false")
+	}
+}
+
+func TestNew(t *testing.T) {
+	var tests = []struct {
+		rules   []authz.Rule
+		wantErr string
+	}{
+		{[]authz.Rule{{Name: "r1"}}, ""},
+		{[]authz.Rule{{}}, "name is required"},
+		{[]authz.Rule{{Name: "r1"}, {Name: "r1"}}, "duplicated"},
+		{[]authz.Rule{{Name: "r1", CIDRs: []string{"10.0.0.0/33"}}}, "invalid cidr"},
+		{[]authz.Rule{{Name: "r1", Tokens: []string{""}}}, "empty token"},
+	}
+	for idx, test := range tests {
+		_, err := authz.New(test.rules)
+		switch {
+		case test.wantErr == "" && err != nil:
+			t.Errorf("idx[%v] authz.New(): unexpected err=%v", idx, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("idx[%v] authz.New(): expected error", idx)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("idx[%v] authz.New(): unexpected err=%v", idx, err)
+		}
+	}
+}
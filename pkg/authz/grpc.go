@@ -0,0 +1,112 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package authz
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns an interceptor for grpc servers. Services
+// maps grpc service names to the root lists they use. Methods of services
+// not mapped only require a valid identity.
+func (a *Authorizer) UnaryServerInterceptor(services map[string]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, info.FullMethod, services)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor for grpc servers. See
+// UnaryServerInterceptor.
+func (a *Authorizer) StreamServerInterceptor(services map[string]string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(ss.Context(), info.FullMethod, services)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (a *Authorizer) authorize(ctx context.Context, method string, services map[string]string) (context.Context, error) {
+	r := grpcRequest(ctx)
+	identity, ok := a.Identify(r)
+	if !ok {
+		a.Denied(method, "", Unauthenticated, r)
+		return ctx, status.Error(codes.Unauthenticated, "identity not allowed")
+	}
+	if list, ok := services[serviceName(method)]; ok && !a.AllowList(identity, list) {
+		a.Denied(method, identity, Forbidden, r)
+		return ctx, status.Error(codes.PermissionDenied, "permission denied")
+	}
+	return NewContext(ctx, identity), nil
+}
+
+func grpcRequest(ctx context.Context) Request {
+	r := Request{}
+	if p, ok := peer.FromContext(ctx); ok {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err == nil {
+			r.IP = net.ParseIP(host)
+		}
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			r.Certs = tlsInfo.State.PeerCertificates
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get("authorization") {
+			if len(v) > 7 && strings.EqualFold(v[:7], "bearer ") {
+				r.Token = strings.TrimSpace(v[7:])
+				break
+			}
+		}
+	}
+	return r
+}
+
+// serviceName returns service from a full method in the form /service/method
+func serviceName(method string) string {
+	method = strings.TrimPrefix(method, "/")
+	if idx := strings.LastIndex(method, "/"); idx >= 0 {
+		return method[:idx]
+	}
+	return method
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// TokenCredentials returns credentials for grpc clients that send the
+// bearer token passed in the metadata of each request. Tokens are only sent
+// over connections with transport security.
+func TokenCredentials(token string) credentials.PerRPCCredentials {
+	return tokenCreds(token)
+}
+
+type tokenCreds string
+
+func (t tokenCreds) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t tokenCreds) RequireTransportSecurity() bool {
+	return true
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package authz_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/luids-io/xlist/pkg/authz"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	a, err := authz.New(testrules)
+	if err != nil {
+		t.Fatalf("authz.New(): %v", err)
+	}
+	interceptor := a.UnaryServerInterceptor(map[string]string{"luids.xlist.v1.Check": "root"})
+	var tests = []struct {
+		addr   string
+		token  string
+		method string
+		want   codes.Code
+		wantID string
+	}{
+		{"192.168.1.1:3000", "", "/luids.xlist.v1.Check/Check", codes.OK, "lan"},
+		{"192.168.2.1:3000", "", "/luids.xlist.v1.Check/Check", codes.Unauthenticated, ""},
+		{"192.168.2.1:3000", "secret1", "/luids.xlist.v1.Check/Check", codes.OK, "admin"},
+		{"10.1.1.1:3000", "secret2", "/luids.xlist.v1.Check/Resources", codes.OK, "soar"},
+		{"10.1.1.1:3000", "secret2", "/grpc.health.v1.Health/Check", codes.OK, "soar"},
+		{"192.168.2.1:3000", "", "/grpc.health.v1.Health/Check", codes.Unauthenticated, ""},
+	}
+	for idx, test := range tests {
+		addr, _ := net.ResolveTCPAddr("tcp", test.addr)
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+		if test.token != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+test.token))
+		}
+		var gotID string
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			gotID, _ = authz.FromContext(ctx)
+			return nil, nil
+		}
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: test.method}, handler)
+		if got := status.Code(err); got != test.want {
+			t.Errorf("idx[%v] interceptor: want=%v got=%v", idx, test.want, got)
+		}
+		if gotID != test.wantID {
+			t.Errorf("idx[%v] interceptor identity: want=%v got=%v", idx, test.wantID, gotID)
+		}
+	}
+
+	// tokens are not sent in clear text
+	if !authz.TokenCredentials("secret1").RequireTransportSecurity() {
+		t.Error("authz.TokenCredentials(): transport security not required")
+	}
+
+	// forbidden list
+	a, _ = authz.New([]authz.Rule{{Name: "any", Lists: []string{"other"}}})
+	interceptor = a.UnaryServerInterceptor(map[string]string{"luids.xlist.v1.Check": "root"})
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/luids.xlist.v1.Check/Check"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("interceptor: expected permission denied, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package dnscache implements a dns client that caches the answers of the
+// queries using the ttls of the records.
+//
+// This package is a work in progress and makes no API stability promises.
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	cacheimpl "github.com/patrickmn/go-cache"
+)
+
+// ErrCanceled is returned when context is canceled.
+var ErrCanceled = errors.New("dnscache: canceled request")
+
+// Servers returns the address of the dns server to use in each query.
+// It is implemented by the resolver pools of dnsxl.
+type Servers interface {
+	Resolver() string
+}
+
+// Config options.
+type Config struct {
+	Timeout time.Duration
+	Retries int
+	// Cache enables the cache of answers, ttls of the records are used
+	// with MaxTTL as limit. NegativeTTL is used with empty answers.
+	Cache       bool
+	MaxTTL      time.Duration
+	NegativeTTL time.Duration
+}
+
+// Answer stores the values of the records of a dns response.
+type Answer struct {
+	// Values are ips for A and AAAA queries and hostnames for the rest.
+	Values []string
+	// TTL is the min ttl of the records or the remaining time in cache.
+	TTL int
+}
+
+// Resolver does dns queries caching the answers.
+type Resolver struct {
+	cfg     Config
+	client  *dns.Client
+	servers Servers
+	cache   *cacheimpl.Cache
+}
+
+// New returns a new resolver that uses the servers passed.
+func New(servers Servers, cfg Config) *Resolver {
+	r := &Resolver{
+		cfg:     cfg,
+		client:  &dns.Client{Timeout: cfg.Timeout},
+		servers: servers,
+	}
+	if r.cfg.Retries <= 0 {
+		r.cfg.Retries = 1
+	}
+	if cfg.Cache {
+		r.cache = cacheimpl.New(cacheimpl.NoExpiration, time.Minute)
+	}
+	return r
+}
+
+// Lookup returns the values of the records of type qtype of the name passed.
+// Supported types are A, AAAA, CNAME, MX, NS and PTR. Non existent domains
+// return an empty answer.
+func (r *Resolver) Lookup(ctx context.Context, name string, qtype uint16) (Answer, error) {
+	name = dns.Fqdn(strings.ToLower(name))
+	key := fmt.Sprintf("%s,%s", dns.TypeToString[qtype], name)
+	if r.cache != nil {
+		if v, exp, ok := r.cache.GetWithExpiration(key); ok {
+			a := v.(Answer)
+			a.TTL = int(time.Until(exp).Seconds())
+			if a.TTL < 1 {
+				a.TTL = 1
+			}
+			return a, nil
+		}
+	}
+	m := &dns.Msg{}
+	m.SetQuestion(name, qtype)
+	m.RecursionDesired = true
+	server := r.servers.Resolver()
+	var resp *dns.Msg
+	var err error
+	for count := 0; count < r.cfg.Retries; count++ {
+		select {
+		case <-ctx.Done():
+			return Answer{}, ErrCanceled
+		default:
+		}
+		resp, _, err = r.client.ExchangeContext(ctx, m, server)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return Answer{}, ErrCanceled
+		}
+		return Answer{}, fmt.Errorf("network problems with %v: %v", server, err)
+	}
+	if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+		return Answer{}, fmt.Errorf("unexpected dns code %v(%v) in %s query",
+			resp.Rcode, dns.RcodeToString[resp.Rcode], dns.TypeToString[qtype])
+	}
+	a := Answer{}
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype != qtype {
+			continue
+		}
+		var value string
+		switch v := rr.(type) {
+		case *dns.A:
+			value = v.A.String()
+		case *dns.AAAA:
+			value = v.AAAA.String()
+		case *dns.CNAME:
+			value = hostname(v.Target)
+		case *dns.MX:
+			value = hostname(v.Mx)
+		case *dns.NS:
+			value = hostname(v.Ns)
+		case *dns.PTR:
+			value = hostname(v.Ptr)
+		}
+		if value == "" {
+			continue
+		}
+		a.Values = append(a.Values, value)
+		if a.TTL == 0 || int(rr.Header().Ttl) < a.TTL {
+			a.TTL = int(rr.Header().Ttl)
+		}
+	}
+	// cache answer
+	expires := time.Duration(a.TTL) * time.Second
+	if len(a.Values) == 0 {
+		expires = r.cfg.NegativeTTL
+		a.TTL = int(r.cfg.NegativeTTL.Seconds())
+	}
+	if r.cfg.MaxTTL > 0 && expires > r.cfg.MaxTTL {
+		expires = r.cfg.MaxTTL
+		a.TTL = int(r.cfg.MaxTTL.Seconds())
+	}
+	if r.cache != nil && expires > 0 {
+		r.cache.Set(key, a, expires)
+	}
+	return a, nil
+}
+
+// LookupAddr returns the hostnames of the PTR records of the ip passed.
+func (r *Resolver) LookupAddr(ctx context.Context, ip string) (Answer, error) {
+	arpa, err := dns.ReverseAddr(ip)
+	if err != nil {
+		return Answer{}, fmt.Errorf("invalid ip '%s'", ip)
+	}
+	return r.Lookup(ctx, arpa, dns.TypePTR)
+}
+
+// Flush removes all answers from cache.
+func (r *Resolver) Flush() {
+	if r.cache != nil {
+		r.cache.Flush()
+	}
+}
+
+// Len returns the number of answers in cache.
+func (r *Resolver) Len() int {
+	if r.cache != nil {
+		return r.cache.ItemCount()
+	}
+	return 0
+}
+
+func hostname(s string) string {
+	return strings.TrimSuffix(strings.ToLower(s), ".")
+}
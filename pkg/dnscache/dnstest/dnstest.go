@@ -0,0 +1,97 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package dnstest provides a local dns server for testing components that
+// do dns queries.
+//
+// This package is a work in progress and makes no API stability promises.
+package dnstest
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// Server is a local dns stand-in that answers with the records of a zone.
+// Queries of names prefixed with "servfail." are answered with a server
+// failure. It must be constructed using NewServer.
+type Server struct {
+	// Addr is the address of the server.
+	Addr string
+
+	srv     *dns.Server
+	records map[string][]dns.RR
+
+	mu      sync.Mutex
+	queries int
+}
+
+// NewServer starts a new server that answers with the records passed in
+// zone file format.
+func NewServer(t *testing.T, zone []string) *Server {
+	t.Helper()
+	s := &Server{records: make(map[string][]dns.RR)}
+	for _, line := range zone {
+		rr, err := dns.NewRR(line)
+		if err != nil {
+			t.Fatalf("parsing record '%s': %v", line, err)
+		}
+		key := strings.ToLower(rr.Header().Name) + dns.TypeToString[rr.Header().Rrtype]
+		s.records[key] = append(s.records[key], rr)
+	}
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	s.Addr = pc.LocalAddr().String()
+	started := make(chan struct{})
+	s.srv = &dns.Server{PacketConn: pc, Handler: s, NotifyStartedFunc: func() { close(started) }}
+	go s.srv.ActivateAndServe()
+	<-started
+	return s
+}
+
+// ServeDNS implements dns.Handler interface.
+func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	s.mu.Lock()
+	s.queries++
+	s.mu.Unlock()
+	m := &dns.Msg{}
+	m.SetReply(r)
+	q := r.Question[0]
+	if strings.HasPrefix(q.Name, "servfail.") {
+		m.Rcode = dns.RcodeServerFailure
+		w.WriteMsg(m)
+		return
+	}
+	name := strings.ToLower(q.Name)
+	m.Answer = s.records[name+dns.TypeToString[q.Qtype]]
+	if len(m.Answer) == 0 {
+		found := false
+		for k := range s.records {
+			if strings.HasPrefix(k, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			m.Rcode = dns.RcodeNameError
+		}
+	}
+	w.WriteMsg(m)
+}
+
+// Count returns the number of queries received.
+func (s *Server) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queries
+}
+
+// Close stops the server.
+func (s *Server) Close() {
+	s.srv.Shutdown()
+}
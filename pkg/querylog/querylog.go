@@ -0,0 +1,347 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package querylog implements a structured log of queries stored in json
+// lines files with rotation and retention, and functions for reporting.
+//
+// This package is a work in progress and makes no API stability promises.
+package querylog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+)
+
+// Record stores a query.
+type Record struct {
+	Time     time.Time      `json:"ts"`
+	Peer     string         `json:"peer,omitempty"`
+	Identity string         `json:"identity,omitempty"`
+	List     string         `json:"list"`
+	Resource xlist.Resource `json:"resource"`
+	Name     string         `json:"name"`
+	Result   bool           `json:"result"`
+	Reason   string         `json:"reason,omitempty"`
+	TTL      int            `json:"ttl,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	// Latency in microseconds
+	Latency int64 `json:"latency"`
+}
+
+// Default values.
+const (
+	DefaultMaxSize  = 100 * 1024 * 1024
+	DefaultMaxAge   = 7 * 24 * time.Hour
+	DefaultMaxFiles = 10
+)
+
+// Extension of the log files.
+const Extension = ".log"
+
+// timeLayout is used in names of rotated files
+const timeLayout = "20060102T150405.000"
+
+// Option is used for writer configuration.
+type Option func(*options)
+
+type options struct {
+	maxSize  int64
+	maxAge   time.Duration
+	maxFiles int
+	flush    time.Duration
+}
+
+var defaultOptions = options{
+	maxSize:  DefaultMaxSize,
+	maxAge:   DefaultMaxAge,
+	maxFiles: DefaultMaxFiles,
+	flush:    time.Second,
+}
+
+// MaxSize option sets the size in bytes for rotating the log.
+func MaxSize(n int64) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxSize = n
+		}
+	}
+}
+
+// MaxAge option sets the max age of the records. The log is rotated when
+// its first record is older and rotated files are removed when they expire.
+// Zero disables rotation and removal by age.
+func MaxAge(d time.Duration) Option {
+	return func(o *options) {
+		o.maxAge = d
+	}
+}
+
+// MaxFiles option sets the max number of rotated files. Zero disables
+// removal by number.
+func MaxFiles(n int) Option {
+	return func(o *options) {
+		o.maxFiles = n
+	}
+}
+
+// Writer writes records to a log file that is rotated when it reaches the
+// max size or the max age.
+type Writer struct {
+	opts     options
+	dir      string
+	name     string
+	mu       sync.Mutex
+	file     *os.File
+	buf      *bufio.Writer
+	size     int64
+	opened   time.Time
+	cleaned  time.Time
+	closed   bool
+	close    chan struct{}
+	wg       sync.WaitGroup
+	lastErr  error
+	filename string
+}
+
+// NewWriter returns a writer that logs to the file name.log in the directory.
+func NewWriter(dir, name string, opt ...Option) (*Writer, error) {
+	opts := defaultOptions
+	for _, o := range opt {
+		o(&opts)
+	}
+	if name == "" || strings.ContainsAny(name, "/\\") {
+		return nil, fmt.Errorf("querylog: invalid name '%s'", name)
+	}
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("querylog: dir '%s' doesn't exists", dir)
+	}
+	w := &Writer{
+		opts:     opts,
+		dir:      dir,
+		name:     name,
+		filename: filepath.Join(dir, name+Extension),
+		close:    make(chan struct{}),
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	w.cleanup()
+	w.wg.Add(1)
+	go w.doFlush()
+	return w, nil
+}
+
+// Write writes the record to the log.
+func (w *Writer) Write(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errors.New("querylog: writer is closed")
+	}
+	if w.size+int64(len(data)) > w.opts.maxSize && w.size > 0 {
+		if err := w.rotate(); err != nil {
+			w.lastErr = err
+			return err
+		}
+	}
+	n, err := w.buf.Write(data)
+	w.size += int64(n)
+	if err != nil {
+		w.lastErr = err
+	}
+	return err
+}
+
+// Rotate forces the rotation of the log.
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errors.New("querylog: writer is closed")
+	}
+	if w.size == 0 {
+		return nil
+	}
+	return w.rotate()
+}
+
+// Flush writes buffered records to disk.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	return w.buf.Flush()
+}
+
+// Ping returns the last error writing the log.
+func (w *Writer) Ping() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastErr
+}
+
+// Close flushes and closes the log.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.close)
+	err := w.buf.Flush()
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	w.mu.Unlock()
+	w.wg.Wait()
+	return err
+}
+
+func (w *Writer) open() error {
+	file, err := os.OpenFile(w.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return fmt.Errorf("querylog: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("querylog: %v", err)
+	}
+	w.file = file
+	w.buf = bufio.NewWriter(file)
+	w.size = info.Size()
+	w.opened = time.Now()
+	if w.size > 0 {
+		w.opened = firstTime(w.filename, info.ModTime())
+	}
+	return nil
+}
+
+// firstTime returns the time of the first record in the file or def if it
+// can't be read
+func firstTime(filename string, def time.Time) time.Time {
+	file, err := os.Open(filename)
+	if err != nil {
+		return def
+	}
+	defer file.Close()
+	line, err := bufio.NewReader(file).ReadBytes('\n')
+	if err != nil {
+		return def
+	}
+	var r Record
+	if err := json.Unmarshal(line, &r); err != nil || r.Time.IsZero() {
+		return def
+	}
+	return r.Time
+}
+
+// rotate log, warning! no lock
+func (w *Writer) rotate() error {
+	if err := w.buf.Flush(); err != nil {
+		return fmt.Errorf("querylog: %v", err)
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("querylog: %v", err)
+	}
+	rotated := filepath.Join(w.dir, fmt.Sprintf("%s-%s%s", w.name, time.Now().UTC().Format(timeLayout), Extension))
+	if err := os.Rename(w.filename, rotated); err != nil {
+		return fmt.Errorf("querylog: %v", err)
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	w.cleanup()
+	return nil
+}
+
+// cleanup removes rotated files, warning! no lock
+func (w *Writer) cleanup() {
+	matches, err := filepath.Glob(filepath.Join(w.dir, w.name+"-*"+Extension))
+	if err != nil {
+		return
+	}
+	files := make([]string, 0, len(matches))
+	for _, f := range matches {
+		// names of other logs may share the prefix
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(f), w.name+"-"), Extension)
+		if _, err := time.Parse(timeLayout, stamp); err == nil {
+			files = append(files, f)
+		}
+	}
+	//sorted from newest to oldest
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	now := time.Now()
+	w.cleaned = now
+	for idx, f := range files {
+		if w.opts.maxFiles > 0 && idx >= w.opts.maxFiles {
+			os.Remove(f)
+			continue
+		}
+		if w.opts.maxAge > 0 {
+			if info, err := os.Stat(f); err == nil && now.Sub(info.ModTime()) > w.opts.maxAge {
+				os.Remove(f)
+			}
+		}
+	}
+}
+
+func (w *Writer) doFlush() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.opts.flush)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.close:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			if !w.closed {
+				if err := w.buf.Flush(); err != nil {
+					w.lastErr = err
+				}
+				w.expire()
+			}
+			w.mu.Unlock()
+		}
+	}
+}
+
+// expire rotates the log if its records are older than max age and removes
+// expired files, warning! no lock
+func (w *Writer) expire() {
+	if w.opts.maxAge <= 0 {
+		return
+	}
+	if w.size > 0 && time.Since(w.opened) >= w.opts.maxAge {
+		if err := w.rotate(); err != nil {
+			w.lastErr = err
+		}
+		return
+	}
+	interval := time.Minute
+	if w.opts.maxAge < interval {
+		interval = w.opts.maxAge
+	}
+	if time.Since(w.cleaned) >= interval {
+		w.cleanup()
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package querylog_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/querylog"
+)
+
+func TestWriter_Rotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "querylog")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	// file of other log sharing prefix
+	other := filepath.Join(dir, "test-other.log")
+	ioutil.WriteFile(other, []byte{}, 0644)
+
+	w, err := querylog.NewWriter(dir, "test", querylog.MaxSize(500), querylog.MaxFiles(2))
+	if err != nil {
+		t.Fatalf("querylog.NewWriter(): err=%v", err)
+	}
+	for i := 0; i < 20; i++ {
+		err := w.Write(querylog.Record{Time: time.Now(), List: "test", Resource: xlist.IPv4,
+			Name: fmt.Sprintf("10.0.0.%v", i)})
+		if err != nil {
+			t.Fatalf("w.Write(): err=%v", err)
+		}
+		// rotated files names have milliseconds
+		time.Sleep(2 * time.Millisecond)
+	}
+	w.Close()
+	rotated, _ := filepath.Glob(filepath.Join(dir, "test-2*.log"))
+	if len(rotated) != 2 {
+		t.Errorf("unexpected rotated files: %v", rotated)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("file of other log removed")
+	}
+	records, err := querylog.History(dir, querylog.Filter{List: "test"})
+	if err != nil {
+		t.Fatalf("querylog.History(): err=%v", err)
+	}
+	if len(records) == 0 || records[len(records)-1].Name != "10.0.0.19" {
+		t.Errorf("unexpected records: %v", records)
+	}
+}
+
+func TestWriter_MaxAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "querylog")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	// log with an old record that is never rotated by size
+	active := filepath.Join(dir, "test.log")
+	old := fmt.Sprintf("{\"ts\":%q,\"list\":\"test\",\"resource\":\"ip4\",\"name\":\"10.0.0.1\"}\n",
+		time.Now().Add(-time.Hour).Format(time.RFC3339Nano))
+	ioutil.WriteFile(active, []byte(old), 0644)
+
+	w, err := querylog.NewWriter(dir, "test", querylog.MaxAge(1500*time.Millisecond))
+	if err != nil {
+		t.Fatalf("querylog.NewWriter(): err=%v", err)
+	}
+	defer w.Close()
+	time.Sleep(1400 * time.Millisecond)
+	rotated, _ := filepath.Glob(filepath.Join(dir, "test-2*.log"))
+	if len(rotated) != 1 {
+		t.Errorf("unexpected rotated files: %v", rotated)
+	}
+	if info, err := os.Stat(active); err != nil || info.Size() != 0 {
+		t.Errorf("log not rotated by age")
+	}
+	time.Sleep(2 * time.Second)
+	rotated, _ = filepath.Glob(filepath.Join(dir, "test-2*.log"))
+	if len(rotated) != 0 {
+		t.Errorf("expired files not removed: %v", rotated)
+	}
+}
+
+func TestReports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "querylog")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	w, err := querylog.NewWriter(dir, "test")
+	if err != nil {
+		t.Fatalf("querylog.NewWriter(): err=%v", err)
+	}
+	t0 := time.Now().Add(-time.Hour)
+	var records = []struct {
+		min    int
+		peer   string
+		name   string
+		result bool
+	}{
+		{0, "10.0.0.1:1000", "www.example.com", true},
+		{1, "10.0.0.1:1001", "www.example.com", true},
+		{2, "10.0.0.2:1000", "www.example.org", true},
+		{3, "10.0.0.2:1000", "www.example.net", false},
+		{4, "10.0.0.2:1000", "www.example.net", false},
+		{5, "10.0.0.1:1000", "www.example.org", true},
+		{6, "10.0.0.3:1000", "www.example.com", true},
+	}
+	for _, r := range records {
+		w.Write(querylog.Record{Time: t0.Add(time.Duration(r.min) * time.Minute), Peer: r.peer,
+			List: "test", Resource: xlist.Domain, Name: r.name, Result: r.result})
+	}
+	w.Close()
+
+	top, err := querylog.TopPositives(dir, querylog.Filter{}, 0)
+	if err != nil {
+		t.Fatalf("querylog.TopPositives(): err=%v", err)
+	}
+	if got := fmt.Sprintf("%v:%v %v:%v", top[0].Key, top[0].Count, top[1].Key, top[1].Count); len(top) != 2 ||
+		got != "domain,www.example.com:3 domain,www.example.org:2" {
+		t.Errorf("querylog.TopPositives(): unexpected %v", top)
+	}
+	top, err = querylog.TopClients(dir, querylog.Filter{To: t0.Add(4 * time.Minute)}, 1)
+	if err != nil {
+		t.Fatalf("querylog.TopClients(): err=%v", err)
+	}
+	if len(top) != 1 || top[0].Key != "10.0.0.2" || top[0].Count != 3 {
+		t.Errorf("querylog.TopClients(): unexpected %v", top)
+	}
+	history, err := querylog.History(dir, querylog.Filter{Name: "www.example.org", From: t0.Add(3 * time.Minute)})
+	if err != nil {
+		t.Fatalf("querylog.History(): err=%v", err)
+	}
+	if len(history) != 1 || history[0].Peer != "10.0.0.1:1000" {
+		t.Errorf("querylog.History(): unexpected %v", history)
+	}
+}
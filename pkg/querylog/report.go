@@ -0,0 +1,186 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package querylog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+)
+
+// Filter is used for selecting records.
+type Filter struct {
+	From      time.Time
+	To        time.Time
+	List      string
+	Client    string
+	Name      string
+	Resource  *xlist.Resource
+	Positives bool
+}
+
+// Match returns true if record matches filter.
+func (f Filter) Match(r Record) bool {
+	if !f.From.IsZero() && r.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && r.Time.After(f.To) {
+		return false
+	}
+	if f.List != "" && r.List != f.List {
+		return false
+	}
+	if f.Client != "" && r.Client() != f.Client {
+		return false
+	}
+	if f.Name != "" && r.Name != f.Name {
+		return false
+	}
+	if f.Resource != nil && r.Resource != *f.Resource {
+		return false
+	}
+	if f.Positives && !r.Result {
+		return false
+	}
+	return true
+}
+
+// Client returns the identity of the client if available or the ip of the
+// peer.
+func (r Record) Client() string {
+	if r.Identity != "" {
+		return r.Identity
+	}
+	if host, _, err := net.SplitHostPort(r.Peer); err == nil {
+		return host
+	}
+	return r.Peer
+}
+
+// Read calls fn for each record in the log files of the directory that
+// matches the filter. Malformed lines are ignored.
+func Read(dir string, filter Filter, fn func(Record) error) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+Extension))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		// all records of the file are older
+		if !filter.From.IsZero() && info.ModTime().Before(filter.From) {
+			continue
+		}
+		if err := readFile(f, filter, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFile(filename string, filter Filter, fn func(Record) error) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		if !filter.Match(r) {
+			continue
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading '%s': %v", filename, err)
+	}
+	return nil
+}
+
+// Count stores the number of records for a key.
+type Count struct {
+	Key   string    `json:"key"`
+	Count int       `json:"count"`
+	Last  time.Time `json:"last"`
+}
+
+// Top returns the n keys with more records. Key function returns the key of
+// the record.
+func Top(dir string, filter Filter, key func(Record) string, n int) ([]Count, error) {
+	counts := make(map[string]*Count)
+	err := Read(dir, filter, func(r Record) error {
+		k := key(r)
+		c, ok := counts[k]
+		if !ok {
+			c = &Count{Key: k}
+			counts[k] = c
+		}
+		c.Count++
+		if r.Time.After(c.Last) {
+			c.Last = r.Time
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	top := make([]Count, 0, len(counts))
+	for _, c := range counts {
+		top = append(top, *c)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count == top[j].Count {
+			return top[i].Key < top[j].Key
+		}
+		return top[i].Count > top[j].Count
+	})
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top, nil
+}
+
+// TopPositives returns the indicators with more positive responses.
+func TopPositives(dir string, filter Filter, n int) ([]Count, error) {
+	filter.Positives = true
+	return Top(dir, filter, func(r Record) string {
+		return fmt.Sprintf("%v,%s", r.Resource, r.Name)
+	}, n)
+}
+
+// TopClients returns the clients with more queries.
+func TopClients(dir string, filter Filter, n int) ([]Count, error) {
+	return Top(dir, filter, Record.Client, n)
+}
+
+// History returns the records that matches filter sorted by time.
+func History(dir string, filter Filter) ([]Record, error) {
+	records := make([]Record, 0)
+	err := Read(dir, filter, func(r Record) error {
+		records = append(records, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records, nil
+}
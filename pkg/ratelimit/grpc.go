@@ -0,0 +1,165 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"time"
+
+	cliprom "github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/authz"
+)
+
+// Available client keys.
+const (
+	ByPeer     = "peer"
+	ByIdentity = "identity"
+)
+
+// Option encapsules interceptor options.
+type Option func(*options)
+
+type options struct {
+	logger  yalogi.Logger
+	by      string
+	queue   bool
+	maxWait time.Duration
+}
+
+var defaultOptions = options{
+	logger: yalogi.LogNull,
+	by:     ByPeer,
+}
+
+// SetLogger option sets a logger for the component.
+func SetLogger(l yalogi.Logger) Option {
+	return func(o *options) {
+		if l != nil {
+			o.logger = l
+		}
+	}
+}
+
+// By option sets the key used for identifying clients. If ByIdentity is
+// used, the identity is taken from authz package and the peer address is used
+// if it doesn't exist.
+func By(s string) Option {
+	return func(o *options) {
+		o.by = s
+	}
+}
+
+// Queue option enables queueing of requests up to the max wait passed
+// instead of rejecting them.
+func Queue(maxWait time.Duration) Option {
+	return func(o *options) {
+		o.queue = true
+		o.maxWait = maxWait
+	}
+}
+
+// Interceptor implements grpc server interceptors.
+type Interceptor struct {
+	opts    options
+	logger  yalogi.Logger
+	limiter *Limiter
+}
+
+// NewInterceptor returns a new interceptor that uses the limiter.
+func NewInterceptor(limiter *Limiter, opt ...Option) *Interceptor {
+	opts := defaultOptions
+	for _, o := range opt {
+		o(&opts)
+	}
+	return &Interceptor{opts: opts, logger: opts.logger, limiter: limiter}
+}
+
+// Unary implements grpc.UnaryServerInterceptor.
+func (i *Interceptor) Unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := i.limit(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// Stream implements grpc.StreamServerInterceptor.
+func (i *Interceptor) Stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := i.limit(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (i *Interceptor) limit(ctx context.Context, method string) error {
+	key := i.key(ctx)
+	if !i.opts.queue {
+		if !i.limiter.Allow(key) {
+			stats.throttled.WithLabelValues(method, "rejected").Inc()
+			i.logger.Debugf("ratelimit: [client=%s] %s rejected", key, method)
+			return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		}
+		return nil
+	}
+	delay, err := i.limiter.Wait(ctx, key, i.opts.maxWait)
+	switch {
+	case err == ErrLimited:
+		stats.throttled.WithLabelValues(method, "rejected").Inc()
+		i.logger.Debugf("ratelimit: [client=%s] %s rejected", key, method)
+		return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	case err != nil:
+		stats.throttled.WithLabelValues(method, "canceled").Inc()
+		return status.FromContextError(err).Err()
+	case delay > 0:
+		stats.throttled.WithLabelValues(method, "delayed").Inc()
+		stats.delays.WithLabelValues(method).Observe(delay.Seconds())
+	}
+	return nil
+}
+
+func (i *Interceptor) key(ctx context.Context) string {
+	if i.opts.by == ByIdentity {
+		if identity, ok := authz.FromContext(ctx); ok {
+			return identity
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// stats is a global structure.
+var stats struct {
+	throttled *cliprom.CounterVec
+	delays    *cliprom.HistogramVec
+}
+
+func init() {
+	stats.throttled = cliprom.NewCounterVec(
+		cliprom.CounterOpts{
+			Name: "ratelimit_throttled_total",
+			Help: "How many requests throttled, partitioned by method and action",
+		},
+		[]string{"method", "action"})
+
+	stats.delays = cliprom.NewHistogramVec(
+		cliprom.HistogramOpts{
+			Name: "ratelimit_delay_seconds",
+			Help: "Delays of queued requests in seconds",
+		},
+		[]string{"method"})
+
+	cliprom.MustRegister(stats.throttled)
+	cliprom.MustRegister(stats.delays)
+}
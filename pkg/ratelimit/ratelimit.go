@@ -0,0 +1,142 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package ratelimit provides a token bucket rate limiter per client and
+// interceptors for grpc servers.
+//
+// This package is a work in progress and makes no API stability promises.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLimited is returned when the request exceeds the limits.
+var ErrLimited = errors.New("ratelimit: limit exceeded")
+
+// Limiter implements a token bucket limiter for each client key. It must be
+// constructed using New.
+type Limiter struct {
+	rate    float64
+	burst   float64
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweep   time.Time
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// sweepInterval is the interval used for removing idle buckets
+const sweepInterval = time.Minute
+
+// New returns a limiter that allows rate requests per second with the
+// burst passed for each client.
+func New(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow returns true if the client has tokens available and consumes one.
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.get(key)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Wait consumes a token for the client and waits until it is available.
+// If the wait is greater than maxWait or it exceeds the deadline of the
+// context, it returns ErrLimited without waiting and the token is not
+// consumed.
+func (l *Limiter) Wait(ctx context.Context, key string, maxWait time.Duration) (time.Duration, error) {
+	l.mu.Lock()
+	b := l.get(key)
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / l.rate * float64(time.Second))
+	}
+	deadline, hasDeadline := ctx.Deadline()
+	if delay > maxWait || (hasDeadline && l.now().Add(delay).After(deadline)) {
+		b.tokens++
+		l.mu.Unlock()
+		return delay, ErrLimited
+	}
+	l.mu.Unlock()
+	if delay == 0 {
+		return 0, nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return delay, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.get(key).tokens++
+		l.mu.Unlock()
+		return delay, ctx.Err()
+	}
+}
+
+// Len returns the number of clients tracked.
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// get returns the bucket refilled, warning! no lock
+func (l *Limiter) get(key string) *bucket {
+	now := l.now()
+	if now.Sub(l.sweep) > sweepInterval {
+		l.doSweep(now)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+		return b
+	}
+	l.refill(b, now)
+	return b
+}
+
+func (l *Limiter) refill(b *bucket, now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens += elapsed * l.rate
+		if b.tokens > l.burst {
+			b.tokens = l.burst
+		}
+		b.last = now
+	}
+}
+
+// doSweep removes buckets that are full, warning! no lock
+func (l *Limiter) doSweep(now time.Time) {
+	for key, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.sweep = now
+}
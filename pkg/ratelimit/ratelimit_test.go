@@ -0,0 +1,87 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package ratelimit_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/luids-io/xlist/pkg/ratelimit"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	l := ratelimit.New(10, 2)
+	if !l.Allow("c1") || !l.Allow("c1") {
+		t.Fatal("expected allow within burst")
+	}
+	if l.Allow("c1") {
+		t.Error("expected deny after burst")
+	}
+	if !l.Allow("c2") {
+		t.Error("expected allow for other client")
+	}
+	time.Sleep(150 * time.Millisecond)
+	if !l.Allow("c1") {
+		t.Error("expected allow after refill")
+	}
+}
+
+func TestLimiter_Wait(t *testing.T) {
+	l := ratelimit.New(20, 1)
+	ctx := context.Background()
+	delay, err := l.Wait(ctx, "c1", 0)
+	if err != nil || delay != 0 {
+		t.Fatalf("unexpected wait: delay=%v err=%v", delay, err)
+	}
+	_, err = l.Wait(ctx, "c1", 10*time.Millisecond)
+	if err != ratelimit.ErrLimited {
+		t.Errorf("expected limited: %v", err)
+	}
+	start := time.Now()
+	delay, err = l.Wait(ctx, "c1", time.Second)
+	if err != nil || delay == 0 || time.Since(start) < delay {
+		t.Errorf("unexpected wait: delay=%v err=%v", delay, err)
+	}
+	// deadline exceeded before token available
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err = l.Wait(ctx, "c1", time.Second)
+	if err != ratelimit.ErrLimited {
+		t.Errorf("expected limited by deadline: %v", err)
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+	info := &grpc.UnaryServerInfo{FullMethod: "/luids.xlist.v1.Check/Check"}
+	peerCtx := func(s string) context.Context {
+		addr, _ := net.ResolveTCPAddr("tcp", s)
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+	}
+
+	i := ratelimit.NewInterceptor(ratelimit.New(1, 1))
+	if _, err := i.Unary(peerCtx("10.0.0.1:1000"), nil, info, handler); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err := i.Unary(peerCtx("10.0.0.1:1001"), nil, info, handler)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected resource exhausted: %v", err)
+	}
+	if _, err := i.Unary(peerCtx("10.0.0.2:1000"), nil, info, handler); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	i = ratelimit.NewInterceptor(ratelimit.New(50, 1), ratelimit.Queue(time.Second))
+	for n := 0; n < 3; n++ {
+		if _, err := i.Unary(peerCtx("10.0.0.1:1000"), nil, info, handler); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
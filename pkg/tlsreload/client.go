@@ -0,0 +1,95 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package tlsreload
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/luids-io/core/grpctls"
+)
+
+// ClientConfig returns a tls configuration for clients that aren't grpc
+// (ex: http). If client certificates or a CA file are used, it returns the
+// watcher that reloads them, in other case it is nil. Server name is
+// required if a CA file or a server certificate is used.
+func ClientConfig(cfg grpctls.ClientCfg, wopts ...Option) (*tls.Config, *Watcher, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, nil, fmt.Errorf("tlsreload: validating client tls config: %v", err)
+	}
+	return clientConfig(cfg, cfg.ServerName, wopts)
+}
+
+func clientConfig(cfg grpctls.ClientCfg, serverName string, wopts []Option) (*tls.Config, *Watcher, error) {
+	tlsConfig := &tls.Config{ServerName: serverName}
+	caFile := cfg.CACert
+	if cfg.ServerCert != "" {
+		caFile = cfg.ServerCert
+	}
+	if cfg.CertFile == "" && caFile == "" {
+		var err error
+		tlsConfig.RootCAs, err = rootCAs(cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("tlsreload: %v", err)
+		}
+		return tlsConfig, nil, nil
+	}
+	if caFile != "" && serverName == "" {
+		return nil, nil, errors.New("tlsreload: server name is required")
+	}
+	w, err := NewWatcher(cfg.CertFile, cfg.KeyFile, caFile, wopts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.CertFile != "" {
+		tlsConfig.GetClientCertificate = w.GetClientCertificate
+	}
+	if caFile == "" {
+		tlsConfig.RootCAs, err = rootCAs(cfg)
+		if err != nil {
+			w.Close()
+			return nil, nil, fmt.Errorf("tlsreload: %v", err)
+		}
+		return tlsConfig, w, nil
+	}
+	// default verification uses a fixed pool, so it is done by the callback
+	tlsConfig.InsecureSkipVerify = true
+	tlsConfig.VerifyPeerCertificate = verifyPeer(w, serverName, cfg.UseSystemCAs && cfg.ServerCert == "")
+	return tlsConfig, w, nil
+}
+
+// verifyPeer returns a function that verifies the server certificates
+// against the current CA pool of the watcher and, if systemCAs, the pool
+// of the system.
+func verifyPeer(w *Watcher, serverName string, systemCAs bool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("tlsreload: server certificate is required")
+		}
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("tlsreload: parsing server certificate: %v", err)
+			}
+			certs = append(certs, cert)
+		}
+		opts := x509.VerifyOptions{
+			DNSName:       serverName,
+			Roots:         w.CertPool(),
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(opts)
+		if err != nil && systemCAs {
+			opts.Roots = nil
+			_, err = certs[0].Verify(opts)
+		}
+		return err
+	}
+}
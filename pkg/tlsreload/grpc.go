@@ -0,0 +1,116 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package tlsreload
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/luids-io/core/grpctls"
+)
+
+// ServerConfig returns a tls configuration for grpc servers that uses the
+// certificate and CA pool of the watcher.
+func ServerConfig(w *Watcher, clientAuth bool) *tls.Config {
+	return serverConfig(w, clientAuth, "h2")
+}
+
+// HTTPServerConfig returns a tls configuration for http servers that uses
+// the certificate and CA pool of the watcher.
+func HTTPServerConfig(w *Watcher, clientAuth bool) *tls.Config {
+	return serverConfig(w, clientAuth, "h2", "http/1.1")
+}
+
+func serverConfig(w *Watcher, clientAuth bool, protos ...string) *tls.Config {
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg := &tls.Config{
+				GetCertificate: w.GetCertificate,
+				NextProtos:     protos,
+			}
+			if clientAuth {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				cfg.ClientCAs = w.CertPool()
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// ServerCreds returns grpc credentials for servers and the watcher used.
+func ServerCreds(cfg grpctls.ServerCfg, opt ...Option) (credentials.TransportCredentials, *Watcher, error) {
+	if !cfg.UseTLS() {
+		return nil, nil, errors.New("tlsreload: server config doesn't use TLS")
+	}
+	err := cfg.Validate()
+	if err != nil {
+		return nil, nil, fmt.Errorf("tlsreload: server TLS config provided is not valid: %v", err)
+	}
+	w, err := NewWatcher(cfg.CertFile, cfg.KeyFile, cfg.CACert, opt...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return credentials.NewTLS(ServerConfig(w, cfg.ClientAuth)), w, nil
+}
+
+// Dial is used for grpc client dialing. If client certificates or a CA file
+// are used, it returns the watcher that reloads them, in other case it is nil
+// and it behaves like grpctls.Dial.
+func Dial(uri string, cfg grpctls.ClientCfg, wopts []Option, grpcOpts ...grpc.DialOption) (*grpc.ClientConn, *Watcher, error) {
+	proto, addr, err := grpctls.ParseURI(uri)
+	if err != nil {
+		return nil, nil, fmt.Errorf("tlsreload: cannot parse URI '%v': %v", uri, err)
+	}
+	if proto == "unix" || !cfg.UseTLS() || (cfg.CertFile == "" && cfg.CACert == "" && cfg.ServerCert == "") {
+		conn, err := grpctls.Dial(uri, cfg, grpcOpts...)
+		return conn, nil, err
+	}
+	err = cfg.Validate()
+	if err != nil {
+		return nil, nil, fmt.Errorf("tlsreload: validating client tls config: %v", err)
+	}
+	serverName := cfg.ServerName
+	if serverName == "" {
+		serverName, _, err = net.SplitHostPort(addr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("tlsreload: could not get servername from '%s': %v", addr, err)
+		}
+	}
+	tlsConfig, w, err := clientConfig(cfg, serverName, wopts)
+	if err != nil {
+		return nil, nil, err
+	}
+	dopts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}
+	dopts = append(dopts, grpcOpts...)
+	conn, err := grpc.DialContext(context.Background(), addr, dopts...)
+	if err != nil {
+		w.Close()
+		return nil, nil, err
+	}
+	return conn, w, nil
+}
+
+func rootCAs(cfg grpctls.ClientCfg) (*x509.CertPool, error) {
+	if cfg.ServerCert != "" {
+		return loadPool(nil, cfg.ServerCert)
+	}
+	var pool *x509.CertPool
+	if cfg.UseSystemCAs {
+		var err error
+		pool, err = x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("can't get system cert pool: %v", err)
+		}
+	}
+	if cfg.CACert != "" {
+		return loadPool(pool, cfg.CACert)
+	}
+	return pool, nil
+}
@@ -0,0 +1,292 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package tlsreload provides a watcher that reloads certificate files when
+// they change and helpers for using it in servers and grpc clients.
+// Reloads only affect new handshakes, so established connections are kept.
+//
+// This package is a work in progress and makes no API stability promises.
+package tlsreload
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	cliprom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/luids-io/core/yalogi"
+)
+
+// DefaultInterval is the default interval used for checking changes.
+const DefaultInterval = 30 * time.Second
+
+// Option encapsules watcher options.
+type Option func(*options)
+
+type options struct {
+	logger   yalogi.Logger
+	interval time.Duration
+}
+
+var defaultOptions = options{
+	logger:   yalogi.LogNull,
+	interval: DefaultInterval,
+}
+
+// SetLogger option sets a logger for the component.
+func SetLogger(l yalogi.Logger) Option {
+	return func(o *options) {
+		if l != nil {
+			o.logger = l
+		}
+	}
+}
+
+// Interval option sets the interval for checking changes in files. If it's
+// zero, files are only reloaded calling Reload.
+func Interval(d time.Duration) Option {
+	return func(o *options) {
+		o.interval = d
+	}
+}
+
+// Watcher watches a certificate, its key and optionally a CA file. A CA
+// file can be watched alone. It must be constructed using NewWatcher.
+type Watcher struct {
+	opts     options
+	logger   yalogi.Logger
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu     sync.RWMutex
+	cert   *tls.Certificate
+	pool   *x509.CertPool
+	stamps map[string]stamp
+
+	close chan struct{}
+	once  sync.Once
+}
+
+type stamp struct {
+	mtime time.Time
+	size  int64
+}
+
+// NewWatcher loads the files and starts watching them. CA file is optional
+// if certificate and key are passed.
+func NewWatcher(certFile, keyFile, caFile string, opt ...Option) (*Watcher, error) {
+	opts := defaultOptions
+	for _, o := range opt {
+		o(&opts)
+	}
+	if (certFile == "") != (keyFile == "") || (certFile == "" && caFile == "") {
+		return nil, errors.New("tlsreload: certfile and keyfile or cafile are required")
+	}
+	w := &Watcher{
+		opts:     opts,
+		logger:   opts.logger,
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		stamps:   make(map[string]stamp),
+		close:    make(chan struct{}),
+	}
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+	if opts.interval > 0 {
+		go w.watch()
+	}
+	return w, nil
+}
+
+// Reload forces a reload of the files.
+func (w *Watcher) Reload() error {
+	stamps := make(map[string]stamp)
+	for _, file := range w.files() {
+		s, err := getStamp(file)
+		if err != nil {
+			stats.reloads.WithLabelValues(w.certFile, "fail").Inc()
+			return fmt.Errorf("tlsreload: %v", err)
+		}
+		stamps[file] = s
+	}
+	var cert *tls.Certificate
+	if w.certFile != "" {
+		pair, err := tls.LoadX509KeyPair(w.certFile, w.keyFile)
+		if err != nil {
+			stats.reloads.WithLabelValues(w.name(), "fail").Inc()
+			return fmt.Errorf("tlsreload: loading key pair: %v", err)
+		}
+		pair.Leaf, err = x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			stats.reloads.WithLabelValues(w.name(), "fail").Inc()
+			return fmt.Errorf("tlsreload: parsing certificate: %v", err)
+		}
+		cert = &pair
+	}
+	var pool *x509.CertPool
+	if w.caFile != "" {
+		var err error
+		pool, err = loadPool(nil, w.caFile)
+		if err != nil {
+			stats.reloads.WithLabelValues(w.name(), "fail").Inc()
+			return fmt.Errorf("tlsreload: %v", err)
+		}
+	}
+	w.mu.Lock()
+	w.cert = cert
+	w.pool = pool
+	w.stamps = stamps
+	w.mu.Unlock()
+	stats.reloads.WithLabelValues(w.name(), "success").Inc()
+	if cert != nil {
+		stats.expiry.WithLabelValues(w.certFile).Set(float64(cert.Leaf.NotAfter.Unix()))
+	}
+	return nil
+}
+
+// Certificate returns current certificate.
+func (w *Watcher) Certificate() *tls.Certificate {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.cert
+}
+
+// CertPool returns current CA pool, it returns nil if no CA file is used.
+func (w *Watcher) CertPool() *x509.CertPool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.pool
+}
+
+// NotAfter returns the expiration of the current certificate, it returns
+// zero time if no certificate is used.
+func (w *Watcher) NotAfter() time.Time {
+	cert := w.Certificate()
+	if cert == nil {
+		return time.Time{}
+	}
+	return cert.Leaf.NotAfter
+}
+
+// GetCertificate can be used in tls.Config.
+func (w *Watcher) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return w.Certificate(), nil
+}
+
+// GetClientCertificate can be used in tls.Config.
+func (w *Watcher) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return w.Certificate(), nil
+}
+
+// Close stops watching.
+func (w *Watcher) Close() {
+	w.once.Do(func() { close(w.close) })
+}
+
+func (w *Watcher) watch() {
+	ticker := time.NewTicker(w.opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.close:
+			return
+		case <-ticker.C:
+			if !w.changed() {
+				continue
+			}
+			w.logger.Infof("tlsreload: certificate '%s' has changed", w.name())
+			if err := w.Reload(); err != nil {
+				w.logger.Warnf("%v", err)
+			}
+		}
+	}
+}
+
+func (w *Watcher) changed() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, file := range w.files() {
+		s, err := getStamp(file)
+		if err != nil {
+			continue
+		}
+		if s != w.stamps[file] {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) files() []string {
+	files := make([]string, 0, 3)
+	if w.certFile != "" {
+		files = append(files, w.certFile, w.keyFile)
+	}
+	if w.caFile != "" {
+		files = append(files, w.caFile)
+	}
+	return files
+}
+
+// name returns the file used to identify the watcher
+func (w *Watcher) name() string {
+	if w.certFile != "" {
+		return w.certFile
+	}
+	return w.caFile
+}
+
+func getStamp(file string) (stamp, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return stamp{}, err
+	}
+	return stamp{mtime: info.ModTime(), size: info.Size()}, nil
+}
+
+func loadPool(pool *x509.CertPool, file string) (*x509.CertPool, error) {
+	if pool == nil {
+		pool = x509.NewCertPool()
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading '%s': %v", file, err)
+	}
+	if ok := pool.AppendCertsFromPEM(data); !ok {
+		return nil, fmt.Errorf("appending certs from '%s'", file)
+	}
+	return pool, nil
+}
+
+// stats is a global structure.
+var stats struct {
+	reloads *cliprom.CounterVec
+	expiry  *cliprom.GaugeVec
+}
+
+func init() {
+	stats.reloads = cliprom.NewCounterVec(
+		cliprom.CounterOpts{
+			Name: "tls_cert_reloads_total",
+			Help: "How many certificate reloads, partitioned by file and status",
+		},
+		[]string{"file", "status"})
+
+	stats.expiry = cliprom.NewGaugeVec(
+		cliprom.GaugeOpts{
+			Name: "tls_cert_expiry_timestamp_seconds",
+			Help: "Expiration of the loaded certificates as unix timestamp",
+		},
+		[]string{"file"})
+
+	cliprom.MustRegister(stats.reloads)
+	cliprom.MustRegister(stats.expiry)
+}
@@ -0,0 +1,217 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package tlsreload_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/luids-io/core/grpctls"
+	"github.com/luids-io/xlist/pkg/tlsreload"
+)
+
+func writeCert(t *testing.T, dir, name string, notAfter time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshalling key: %v", err)
+	}
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	return certFile, keyFile
+}
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsreload")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	expiry1 := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	certFile, keyFile := writeCert(t, dir, "cert1", expiry1)
+	w, err := tlsreload.NewWatcher(certFile, keyFile, "", tlsreload.Interval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("tlsreload.NewWatcher(): %v", err)
+	}
+	defer w.Close()
+	if !w.NotAfter().Equal(expiry1) {
+		t.Errorf("unexpected expiry: %v", w.NotAfter())
+	}
+
+	// serve tls with watcher
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", tlsreload.ServerConfig(w, false))
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	peerCN := func() string {
+		conn, err := tls.Dial("tcp", lis.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			t.Fatalf("dialing: %v", err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+	if got := peerCN(); got != "cert1" {
+		t.Errorf("unexpected peer certificate: %v", got)
+	}
+
+	// rotate certificate
+	time.Sleep(20 * time.Millisecond)
+	expiry2 := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	writeCert(t, dir, "cert2", expiry2)
+	deadline := time.Now().Add(2 * time.Second)
+	for !w.NotAfter().Equal(expiry2) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !w.NotAfter().Equal(expiry2) {
+		t.Fatalf("certificate not reloaded")
+	}
+	if got := peerCN(); got != "cert2" {
+		t.Errorf("unexpected peer certificate after reload: %v", got)
+	}
+
+	// invalid files keep current certificate
+	ioutil.WriteFile(keyFile, []byte("invalid"), 0600)
+	if err := w.Reload(); err == nil {
+		t.Error("expected error reloading invalid key")
+	}
+	if got := peerCN(); got != "cert2" {
+		t.Errorf("unexpected peer certificate after failed reload: %v", got)
+	}
+}
+
+func TestClientConfig_RotateCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsreload")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	copyCA := func(certFile string) {
+		data, err := ioutil.ReadFile(certFile)
+		if err != nil {
+			t.Fatalf("reading certificate: %v", err)
+		}
+		if err := ioutil.WriteFile(caFile, data, 0644); err != nil {
+			t.Fatalf("writing ca: %v", err)
+		}
+	}
+
+	// server certificates are self signed, so they are used as CA
+	expiry1 := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	certFile, keyFile := writeCert(t, dir, "cert1", expiry1)
+	copyCA(certFile)
+	w, err := tlsreload.NewWatcher(certFile, keyFile, "", tlsreload.Interval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("tlsreload.NewWatcher(): %v", err)
+	}
+	defer w.Close()
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", tlsreload.ServerConfig(w, false))
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	tlsConfig, cw, err := tlsreload.ClientConfig(grpctls.ClientCfg{CACert: caFile, ServerName: "localhost"},
+		tlsreload.Interval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("tlsreload.ClientConfig(): %v", err)
+	}
+	if cw == nil {
+		t.Fatal("tlsreload.ClientConfig(): expected watcher")
+	}
+	defer cw.Close()
+	dial := func() error {
+		conn, err := tls.Dial("tcp", lis.Addr().String(), tlsConfig)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	if err := dial(); err != nil {
+		t.Fatalf("dialing: %v", err)
+	}
+
+	// rotate server certificate, it isn't trusted until the CA is rotated
+	time.Sleep(20 * time.Millisecond)
+	expiry2 := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	writeCert(t, dir, "cert2", expiry2)
+	deadline := time.Now().Add(2 * time.Second)
+	for !w.NotAfter().Equal(expiry2) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := dial(); err == nil {
+		t.Error("dialing: expected error with previous CA")
+	}
+	copyCA(certFile)
+	deadline = time.Now().Add(2 * time.Second)
+	for dial() != nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := dial(); err != nil {
+		t.Errorf("dialing after CA rotation: %v", err)
+	}
+
+	// server name is verified
+	tlsConfig, cw2, err := tlsreload.ClientConfig(grpctls.ClientCfg{CACert: caFile, ServerName: "otherhost"})
+	if err != nil {
+		t.Fatalf("tlsreload.ClientConfig(): %v", err)
+	}
+	defer cw2.Close()
+	if err := dial(); err == nil {
+		t.Error("dialing: expected error with invalid server name")
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package tracing
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Collector is a minimal stand-in for an OpenTelemetry collector that
+// receives OTLP/HTTP json requests and stores the spans in memory. It's
+// intended for testing and debugging.
+type Collector struct {
+	mu       sync.Mutex
+	services map[SpanID]string
+	spans    []SpanData
+}
+
+// NewCollector returns a new collector.
+func NewCollector() *Collector {
+	return &Collector{services: make(map[SpanID]string)}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	var req otlpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	spans, err := decodeOTLP(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	for _, rs := range req.ResourceSpans {
+		var service string
+		for _, kv := range rs.Resource.Attributes {
+			if kv.Key == "service.name" && kv.Value.String != nil {
+				service = *kv.Value.String
+			}
+		}
+		for _, ss := range rs.ScopeSpans {
+			for _, o := range ss.Spans {
+				var id SpanID
+				decodeID(id[:], o.SpanID)
+				c.services[id] = service
+			}
+		}
+	}
+	c.spans = append(c.spans, spans...)
+	c.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
+// Spans returns the spans received.
+func (c *Collector) Spans() []SpanData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]SpanData, len(c.spans))
+	copy(ret, c.spans)
+	return ret
+}
+
+// Service returns the name of the service that exported the span.
+func (c *Collector) Service(id SpanID) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.services[id]
+}
+
+// Reset removes the spans received.
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	c.spans = nil
+	c.services = make(map[SpanID]string)
+	c.mu.Unlock()
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package tracing
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// TraceParentHeader is the metadata key used for propagation.
+const TraceParentHeader = "traceparent"
+
+// Inject returns a context with the span context stored in ctx appended to
+// the outgoing grpc metadata.
+func Inject(ctx context.Context) context.Context {
+	sc, ok := SpanContextFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, TraceParentHeader, sc.TraceParent())
+}
+
+// Extract returns a context with the remote span context received in the
+// incoming grpc metadata.
+func Extract(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	values := md.Get(TraceParentHeader)
+	if len(values) == 0 {
+		return ctx
+	}
+	sc, err := ParseTraceParent(values[0])
+	if err != nil {
+		return ctx
+	}
+	return ContextWithRemote(ctx, sc)
+}
+
+// UnaryClientInterceptor returns an interceptor that propagates the trace
+// context to the server.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(Inject(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor returns an interceptor that propagates the trace
+// context to the server.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(Inject(ctx), desc, cc, method, opts...)
+	}
+}
+
+// UnaryServerInterceptor implements grpc.UnaryServerInterceptor, it starts a
+// server span for each request.
+func (t *Tracer) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := t.serverSpan(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	if err != nil {
+		span.SetError(err)
+		span.SetAttribute("rpc.grpc.status_code", int64(status.Code(err)))
+	}
+	span.End()
+	return resp, err
+}
+
+// StreamServerInterceptor implements grpc.StreamServerInterceptor, it
+// starts a server span for each stream.
+func (t *Tracer) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := t.serverSpan(ss.Context(), info.FullMethod)
+	err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	if err != nil {
+		span.SetError(err)
+		span.SetAttribute("rpc.grpc.status_code", int64(status.Code(err)))
+	}
+	span.End()
+	return err
+}
+
+func (t *Tracer) serverSpan(ctx context.Context, method string) (context.Context, *Span) {
+	ctx, span := t.Start(Extract(ctx), method, KindServer)
+	span.SetAttribute("rpc.system", "grpc")
+	span.SetAttribute("rpc.method", method)
+	if p, ok := peer.FromContext(ctx); ok {
+		span.SetAttribute("net.peer.address", p.Addr.String())
+	}
+	return ctx, span
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,231 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultOTLPPath is the path used by OTLP/HTTP collectors for traces.
+const DefaultOTLPPath = "/v1/traces"
+
+// OTLPExporter exports spans to an OpenTelemetry collector using the
+// OTLP/HTTP protocol with json encoding.
+type OTLPExporter struct {
+	url     string
+	service string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewOTLPExporter returns a new exporter. Parameter url is the full url of
+// the collector, usually http://host:4318/v1/traces.
+func NewOTLPExporter(url, service string, headers map[string]string, timeout time.Duration) *OTLPExporter {
+	return &OTLPExporter{
+		url:     url,
+		service: service,
+		headers: headers,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Export implements Exporter interface.
+func (e *OTLPExporter) Export(spans []SpanData) error {
+	data, err := json.Marshal(encodeOTLP(e.service, spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", e.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("otlp: %s", resp.Status)
+	}
+	return nil
+}
+
+// Close implements Exporter interface.
+func (e *OTLPExporter) Close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// otlp json mapping
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID      string         `json:"traceId"`
+	SpanID       string         `json:"spanId"`
+	ParentSpanID string         `json:"parentSpanId,omitempty"`
+	Name         string         `json:"name"`
+	Kind         int            `json:"kind"`
+	Start        string         `json:"startTimeUnixNano"`
+	End          string         `json:"endTimeUnixNano"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	Status       *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	String *string  `json:"stringValue,omitempty"`
+	Bool   *bool    `json:"boolValue,omitempty"`
+	Int    *string  `json:"intValue,omitempty"`
+	Double *float64 `json:"doubleValue,omitempty"`
+}
+
+const scopeName = "github.com/luids-io/xlist/pkg/tracing"
+
+func encodeOTLP(service string, spans []SpanData) otlpRequest {
+	ospans := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		o := otlpSpan{
+			TraceID: s.TraceID.String(),
+			SpanID:  s.SpanID.String(),
+			Name:    s.Name,
+			Kind:    int(s.Kind),
+			Start:   strconv.FormatInt(s.Start.UnixNano(), 10),
+			End:     strconv.FormatInt(s.End.UnixNano(), 10),
+		}
+		if s.Parent.IsValid() {
+			o.ParentSpanID = s.Parent.String()
+		}
+		keys := make([]string, 0, len(s.Attributes))
+		for k := range s.Attributes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			o.Attributes = append(o.Attributes, otlpKeyValue{Key: k, Value: encodeValue(s.Attributes[k])})
+		}
+		if s.Error != "" {
+			o.Status = &otlpStatus{Code: 2, Message: s.Error}
+		}
+		ospans = append(ospans, o)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{
+			{Key: "service.name", Value: encodeValue(service)}}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: ospans}},
+	}}}
+}
+
+func encodeValue(v interface{}) otlpValue {
+	switch value := v.(type) {
+	case bool:
+		return otlpValue{Bool: &value}
+	case int64:
+		s := strconv.FormatInt(value, 10)
+		return otlpValue{Int: &s}
+	case float64:
+		return otlpValue{Double: &value}
+	default:
+		s := fmt.Sprint(value)
+		return otlpValue{String: &s}
+	}
+}
+
+func decodeOTLP(req otlpRequest) ([]SpanData, error) {
+	var spans []SpanData
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, o := range ss.Spans {
+				s := SpanData{Name: o.Name, Kind: SpanKind(o.Kind), Attributes: make(map[string]interface{})}
+				if err := decodeID(s.TraceID[:], o.TraceID); err != nil {
+					return nil, fmt.Errorf("invalid traceId: %v", err)
+				}
+				if err := decodeID(s.SpanID[:], o.SpanID); err != nil {
+					return nil, fmt.Errorf("invalid spanId: %v", err)
+				}
+				if o.ParentSpanID != "" {
+					if err := decodeID(s.Parent[:], o.ParentSpanID); err != nil {
+						return nil, fmt.Errorf("invalid parentSpanId: %v", err)
+					}
+				}
+				start, err := strconv.ParseInt(o.Start, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid startTimeUnixNano: %v", err)
+				}
+				end, err := strconv.ParseInt(o.End, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid endTimeUnixNano: %v", err)
+				}
+				s.Start, s.End = time.Unix(0, start), time.Unix(0, end)
+				for _, kv := range o.Attributes {
+					switch {
+					case kv.Value.String != nil:
+						s.Attributes[kv.Key] = *kv.Value.String
+					case kv.Value.Bool != nil:
+						s.Attributes[kv.Key] = *kv.Value.Bool
+					case kv.Value.Int != nil:
+						i, err := strconv.ParseInt(*kv.Value.Int, 10, 64)
+						if err != nil {
+							return nil, fmt.Errorf("invalid intValue: %v", err)
+						}
+						s.Attributes[kv.Key] = i
+					case kv.Value.Double != nil:
+						s.Attributes[kv.Key] = *kv.Value.Double
+					}
+				}
+				if o.Status != nil && o.Status.Code == 2 {
+					s.Error = o.Status.Message
+				}
+				spans = append(spans, s)
+			}
+		}
+	}
+	return spans, nil
+}
+
+func decodeID(dst []byte, s string) error {
+	if hex.DecodedLen(len(s)) != len(dst) {
+		return fmt.Errorf("bad length '%s'", s)
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err
+}
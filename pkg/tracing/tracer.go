@@ -0,0 +1,275 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cliprom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/luids-io/core/yalogi"
+)
+
+// Exporter is the interface for the destinations of the spans.
+type Exporter interface {
+	Export(spans []SpanData) error
+	Close() error
+}
+
+// Option is used for tracer configuration.
+type Option func(*options)
+
+type options struct {
+	logger    yalogi.Logger
+	ratio     float64
+	queueSize int
+	batch     int
+	interval  time.Duration
+}
+
+var defaultOptions = options{
+	logger:    yalogi.LogNull,
+	ratio:     1,
+	queueSize: 2048,
+	batch:     512,
+	interval:  5 * time.Second,
+}
+
+// SetLogger option sets a logger for the component.
+func SetLogger(l yalogi.Logger) Option {
+	return func(o *options) {
+		if l != nil {
+			o.logger = l
+		}
+	}
+}
+
+// SampleRatio option sets the ratio of new traces that are sampled. Traces
+// started by remote parents respect the decision of the parent.
+func SampleRatio(r float64) Option {
+	return func(o *options) {
+		o.ratio = r
+	}
+}
+
+// QueueSize option sets the max spans queued, new spans are dropped when
+// the queue is full.
+func QueueSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.queueSize = n
+		}
+	}
+}
+
+// Batch option sets the max number of spans exported in each call and the
+// max time that a span waits before being exported.
+func Batch(n int, interval time.Duration) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.batch = n
+		}
+		if interval > 0 {
+			o.interval = interval
+		}
+	}
+}
+
+// Tracer creates spans and exports them in batches.
+type Tracer struct {
+	opts     options
+	logger   yalogi.Logger
+	exporter Exporter
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan SpanData
+	flush  chan chan struct{}
+	close  chan struct{}
+	done   chan struct{}
+}
+
+// NewTracer creates a new tracer that uses the exporter passed.
+func NewTracer(exporter Exporter, opt ...Option) *Tracer {
+	opts := defaultOptions
+	for _, o := range opt {
+		o(&opts)
+	}
+	t := &Tracer{
+		opts:     opts,
+		logger:   opts.logger,
+		exporter: exporter,
+		queue:    make(chan SpanData, opts.queueSize),
+		flush:    make(chan chan struct{}),
+		close:    make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// Start creates a new span. The span is a child of the span or the remote
+// span context stored in ctx, if none exists a new trace is started. It
+// returns a nil span if the trace is not sampled.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent, ok := SpanContextFromContext(ctx)
+	if !ok {
+		parent = SpanContext{TraceID: newTraceID(), Sampled: sample(t.opts.ratio)}
+		if !parent.Sampled {
+			// propagates the decision to the remote services
+			parent.SpanID = newSpanID()
+			return ContextWithRemote(ctx, parent), nil
+		}
+	}
+	if !parent.Sampled {
+		return ctx, nil
+	}
+	return t.start(ctx, name, kind, parent)
+}
+
+func (t *Tracer) start(ctx context.Context, name string, kind SpanKind, parent SpanContext) (context.Context, *Span) {
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			TraceID:    parent.TraceID,
+			SpanID:     newSpanID(),
+			Parent:     parent.SpanID,
+			Name:       name,
+			Kind:       kind,
+			Start:      time.Now(),
+			Attributes: make(map[string]interface{}),
+		},
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t *Tracer) export(data SpanData) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		return
+	}
+	select {
+	case t.queue <- data:
+	default:
+		stats.spans.WithLabelValues("dropped").Inc()
+	}
+}
+
+// Flush exports the queued spans.
+func (t *Tracer) Flush() {
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return
+	}
+	done := make(chan struct{})
+	t.flush <- done
+	t.mu.RUnlock()
+	<-done
+}
+
+// Close exports the queued spans and closes the exporter.
+func (t *Tracer) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	close(t.close)
+	t.mu.Unlock()
+	<-t.done
+	return t.exporter.Close()
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+	batch := make([]SpanData, 0, t.opts.batch)
+	ticker := time.NewTicker(t.opts.interval)
+	defer ticker.Stop()
+	send := func() {
+		if len(batch) > 0 {
+			t.send(batch)
+			batch = make([]SpanData, 0, t.opts.batch)
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case data := <-t.queue:
+				batch = append(batch, data)
+				if len(batch) >= t.opts.batch {
+					send()
+				}
+			default:
+				send()
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) >= t.opts.batch {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case done := <-t.flush:
+			drain()
+			close(done)
+		case <-t.close:
+			drain()
+			return
+		}
+	}
+}
+
+func (t *Tracer) send(batch []SpanData) {
+	if err := t.exporter.Export(batch); err != nil {
+		t.logger.Warnf("tracing: exporting %v spans: %v", len(batch), err)
+		stats.spans.WithLabelValues("failed").Add(float64(len(batch)))
+		return
+	}
+	stats.spans.WithLabelValues("exported").Add(float64(len(batch)))
+}
+
+func normalize(v interface{}) interface{} {
+	switch value := v.(type) {
+	case string, bool, int64, float64:
+		return value
+	case int:
+		return int64(value)
+	case int32:
+		return int64(value)
+	case uint32:
+		return int64(value)
+	case float32:
+		return float64(value)
+	case fmt.Stringer:
+		return value.String()
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// stats is a global structure.
+var stats struct {
+	spans *cliprom.CounterVec
+}
+
+func init() {
+	stats.spans = cliprom.NewCounterVec(
+		cliprom.CounterOpts{
+			Name: "tracing_spans_total",
+			Help: "How many spans finished, partitioned by status",
+		},
+		[]string{"status"})
+
+	cliprom.MustRegister(stats.spans)
+}
@@ -0,0 +1,184 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package tracing provides a lightweight distributed tracing implementation
+// compatible with W3C trace context propagation and OpenTelemetry OTLP/HTTP
+// exporters.
+//
+// This package is a work in progress and makes no API stability promises.
+package tracing
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// IsValid returns true if trace id is not zero.
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// String returns the hex encoding of the id.
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID identifies a span.
+type SpanID [8]byte
+
+// IsValid returns true if span id is not zero.
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// String returns the hex encoding of the id.
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext stores the information propagated between processes.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid returns true if span context has valid ids.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// TraceParent returns the span context in W3C traceparent format.
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// ErrInvalidTraceParent is returned when parsing a malformed traceparent.
+var ErrInvalidTraceParent = errors.New("tracing: invalid traceparent")
+
+// ParseTraceParent parses a W3C traceparent header.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+	fields := strings.Split(strings.TrimSpace(s), "-")
+	if len(fields) < 4 || len(fields[0]) != 2 || fields[0] == "ff" ||
+		len(fields[1]) != 32 || len(fields[2]) != 16 || len(fields[3]) != 2 {
+		return sc, ErrInvalidTraceParent
+	}
+	if fields[0] == "00" && len(fields) != 4 {
+		return sc, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(fields[1])); err != nil {
+		return sc, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(fields[2])); err != nil {
+		return sc, ErrInvalidTraceParent
+	}
+	flags, err := hex.DecodeString(fields[3])
+	if err != nil {
+		return sc, ErrInvalidTraceParent
+	}
+	if !sc.IsValid() {
+		return sc, ErrInvalidTraceParent
+	}
+	sc.Sampled = flags[0]&0x01 == 0x01
+	return sc, nil
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// ContextWithSpan returns a context that stores the span passed.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the current span stored in the context, it
+// returns nil if it doesn't exist.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemote returns a context that stores a span context received
+// from another process.
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context that must be used as
+// parent or propagated to other processes.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext(), true
+	}
+	sc, ok := ctx.Value(remoteKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// Start creates a child span of the current span stored in the context. If
+// there is no span in the context, or it's not sampled, it returns the same
+// context and a nil span. Methods of a nil span are noops.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil || parent.tracer == nil {
+		return ctx, nil
+	}
+	return parent.tracer.start(ctx, name, KindInternal, parent.SpanContext())
+}
+
+// ids generator
+var idgen = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(seed()))}
+
+func seed() int64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		return 1
+	}
+	return int64(binary.LittleEndian.Uint64(b[:]))
+}
+
+func newTraceID() (t TraceID) {
+	idgen.Lock()
+	for !t.IsValid() {
+		idgen.Read(t[:])
+	}
+	idgen.Unlock()
+	return
+}
+
+func newSpanID() (s SpanID) {
+	idgen.Lock()
+	for !s.IsValid() {
+		idgen.Read(s[:])
+	}
+	idgen.Unlock()
+	return
+}
+
+func sample(ratio float64) bool {
+	if ratio >= 1 {
+		return true
+	}
+	if ratio <= 0 {
+		return false
+	}
+	idgen.Lock()
+	defer idgen.Unlock()
+	return idgen.Float64() < ratio
+}
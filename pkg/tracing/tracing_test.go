@@ -0,0 +1,198 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package tracing_test
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/luids-io/api/xlist"
+	checkapi "github.com/luids-io/api/xlist/grpc/check"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/tracing"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/grpcxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/memxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/sequencexl"
+	"github.com/luids-io/xlist/pkg/xlistd/wrappers/timeoutwr"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	var tests = []struct {
+		in      string
+		sampled bool
+		wantErr bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", false, false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, true},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, true},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01", false, true},
+		{"invalid", false, true},
+	}
+	for idx, test := range tests {
+		sc, err := tracing.ParseTraceParent(test.in)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("idx[%v] ParseTraceParent(): expected error", idx)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("idx[%v] ParseTraceParent(): err=%v", idx, err)
+			continue
+		}
+		if sc.Sampled != test.sampled {
+			t.Errorf("idx[%v] ParseTraceParent(): unexpected sampled %v", idx, sc.Sampled)
+		}
+		if test.in[:2] == "00" && sc.TraceParent() != test.in {
+			t.Errorf("idx[%v] TraceParent(): got=%s", idx, sc.TraceParent())
+		}
+	}
+}
+
+func TestTracer_Sampling(t *testing.T) {
+	collector := tracing.NewCollector()
+	hs := httptest.NewServer(collector)
+	defer hs.Close()
+	tracer := tracing.NewTracer(tracing.NewOTLPExporter(hs.URL, "test", nil, time.Second),
+		tracing.SampleRatio(0))
+	defer tracer.Close()
+
+	ctx, span := tracer.Start(context.Background(), "root", tracing.KindServer)
+	if span != nil {
+		t.Fatal("Start(): span should not be sampled")
+	}
+	sc, ok := tracing.SpanContextFromContext(ctx)
+	if !ok || sc.Sampled {
+		t.Errorf("SpanContextFromContext(): unexpected %v %v", sc, ok)
+	}
+	// noop on nil spans
+	_, child := tracing.Start(ctx, "child")
+	child.SetAttribute("key", "value")
+	child.End()
+	tracer.Flush()
+	if spans := collector.Spans(); len(spans) != 0 {
+		t.Errorf("unexpected spans: %v", spans)
+	}
+}
+
+func TestTracing_Tree(t *testing.T) {
+	collector := tracing.NewCollector()
+	hs := httptest.NewServer(collector)
+	defer hs.Close()
+
+	// remote xlistd
+	tracerB := tracing.NewTracer(tracing.NewOTLPExporter(hs.URL, "remote", nil, time.Second))
+	defer tracerB.Close()
+	bB := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.Tracing(true))
+	defer bB.Shutdown()
+	remote, err := bB.Build(xlistd.ListDef{ID: "remote", Class: memxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4},
+		Opts: map[string]interface{}{"data": []interface{}{
+			map[string]interface{}{"resource": "ip4", "format": "plain", "value": "10.0.0.1"}}}})
+	if err != nil {
+		t.Fatalf("building remote: %v", err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	gsrv := grpc.NewServer(grpc.ChainUnaryInterceptor(tracerB.UnaryServerInterceptor))
+	checkapi.RegisterServer(gsrv, checkapi.NewService(remote))
+	go gsrv.Serve(lis)
+	defer gsrv.Stop()
+
+	// local xlistd
+	tracerA := tracing.NewTracer(tracing.NewOTLPExporter(hs.URL, "local", nil, time.Second))
+	defer tracerA.Close()
+	bA := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.Tracing(true))
+	defer bA.Shutdown()
+	ip4 := []xlist.Resource{xlist.IPv4}
+	root, err := bA.Build(xlistd.ListDef{ID: "root", Class: sequencexl.ComponentClass, Resources: ip4,
+		Contains: []xlistd.ListDef{
+			{ID: "local", Class: memxl.ComponentClass, Resources: ip4},
+			{ID: "grpc1", Class: grpcxl.ComponentClass, Resources: ip4, Source: "tcp://" + lis.Addr().String()},
+		},
+		Wrappers: []xlistd.WrapperDef{{Class: timeoutwr.WrapperClass}}})
+	if err != nil {
+		t.Fatalf("building root: %v", err)
+	}
+
+	ctx, span := tracerA.Start(context.Background(), "check", tracing.KindServer)
+	resp, err := root.Check(ctx, "10.0.0.1", xlist.IPv4)
+	span.End()
+	if err != nil || !resp.Result {
+		t.Fatalf("root.Check(): unexpected resp=%v err=%v", resp, err)
+	}
+	// server span ends after the response is sent
+	var spans []tracing.SpanData
+	for i := 0; i < 50; i++ {
+		tracerA.Flush()
+		tracerB.Flush()
+		spans = collector.Spans()
+		if len(spans) >= 7 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	byName := make(map[string]tracing.SpanData)
+	for _, s := range spans {
+		if s.TraceID != span.SpanContext().TraceID {
+			t.Errorf("unexpected trace in span %v", s)
+		}
+		byName[s.Name] = s
+	}
+	method := "/" + checkapi.ServiceName() + "/Check"
+	var tests = []struct {
+		name    string
+		parent  string
+		service string
+		result  interface{}
+	}{
+		{"check", "", "local", nil},
+		{"wrapper timeout root", "check", "local", true},
+		{"list root", "wrapper timeout root", "local", true},
+		{"list local", "list root", "local", false},
+		{"list grpc1", "list root", "local", true},
+		{method, "list grpc1", "remote", nil},
+		{"list remote", method, "remote", true},
+	}
+	if len(spans) != len(tests) {
+		t.Fatalf("unexpected spans: %v", spans)
+	}
+	for _, test := range tests {
+		s, ok := byName[test.name]
+		if !ok {
+			t.Errorf("span '%s' not found", test.name)
+			continue
+		}
+		if test.parent != "" {
+			if s.Parent != byName[test.parent].SpanID {
+				t.Errorf("span '%s': unexpected parent", test.name)
+			}
+		}
+		if got := collector.Service(s.SpanID); got != test.service {
+			t.Errorf("span '%s': unexpected service %s", test.name, got)
+		}
+		if test.result != nil && s.Attributes["xlist.result"] != test.result {
+			t.Errorf("span '%s': unexpected attributes %v", test.name, s.Attributes)
+		}
+	}
+	grpc1 := byName["list grpc1"]
+	if grpc1.Attributes["xlist.class"] != grpcxl.ComponentClass || grpc1.Attributes["xlist.resource"] != "ip4" ||
+		grpc1.Attributes["xlist.id"] != "grpc1" {
+		t.Errorf("unexpected attributes %v", grpc1.Attributes)
+	}
+	if byName["wrapper timeout root"].Attributes["xlist.wrapper"] != timeoutwr.WrapperClass {
+		t.Errorf("unexpected attributes %v", byName["wrapper timeout root"].Attributes)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package xlindex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/luids-io/api/xlist"
+)
+
+// Index is a read only index mapped in memory. It's safe for concurrent
+// use, but it must not be used after Close.
+type Index struct {
+	data     []byte
+	unmap    func() error
+	sections map[Kind]view
+}
+
+type view struct {
+	count uint64
+	data  []byte
+}
+
+// Open maps the index file in memory and validates its structure.
+func Open(filename string) (*Index, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() < headerSize || int64(int(stat.Size())) != stat.Size() {
+		return nil, ErrInvalidFormat
+	}
+	data, unmap, err := mmap(f, int(stat.Size()))
+	if err != nil {
+		return nil, fmt.Errorf("mapping file: %v", err)
+	}
+	idx, err := parse(data)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	idx.unmap = unmap
+	return idx, nil
+}
+
+func parse(data []byte) (*Index, error) {
+	if len(data) < headerSize || string(data[:8]) != Magic {
+		return nil, ErrInvalidFormat
+	}
+	if binary.LittleEndian.Uint32(data[8:]) != Version {
+		return nil, ErrVersion
+	}
+	n := uint64(binary.LittleEndian.Uint32(data[12:]))
+	if uint64(len(data)) < headerSize+n*entrySize {
+		return nil, ErrInvalidFormat
+	}
+	idx := &Index{data: data, sections: make(map[Kind]view)}
+	for i := uint64(0); i < n; i++ {
+		entry := data[headerSize+i*entrySize:]
+		kind := Kind(binary.LittleEndian.Uint32(entry))
+		recsize := uint64(binary.LittleEndian.Uint32(entry[4:]))
+		count := binary.LittleEndian.Uint64(entry[8:])
+		offset := binary.LittleEndian.Uint64(entry[16:])
+		size := binary.LittleEndian.Uint64(entry[24:])
+		if kind.String() == "unknown" || recsize != uint64(kind.recordSize()) {
+			return nil, ErrInvalidFormat
+		}
+		if offset > uint64(len(data)) || size > uint64(len(data))-offset {
+			return nil, ErrInvalidFormat
+		}
+		v := view{count: count, data: data[offset : offset+size]}
+		if recsize > 0 {
+			if count > size/recsize || count*recsize != size {
+				return nil, ErrInvalidFormat
+			}
+		} else {
+			if count >= size/4 || (count+1)*4 > size {
+				return nil, ErrInvalidFormat
+			}
+			if !validOffsets(v.data, count) {
+				return nil, ErrInvalidFormat
+			}
+		}
+		idx.sections[kind] = v
+	}
+	return idx, nil
+}
+
+// validOffsets checks that the offsets of a strings section are in order
+// and within its data, so strings can be sliced without bounds checks.
+func validOffsets(data []byte, count uint64) bool {
+	size := uint64(len(data)) - (count+1)*4
+	prev := uint64(0)
+	for j := uint64(0); j <= count; j++ {
+		offset := uint64(binary.LittleEndian.Uint32(data[j*4:]))
+		if offset < prev || offset > size {
+			return false
+		}
+		prev = offset
+	}
+	return binary.LittleEndian.Uint32(data) == 0 && prev == size
+}
+
+// Close unmaps the index.
+func (i *Index) Close() error {
+	if i.unmap == nil {
+		return nil
+	}
+	err := i.unmap()
+	i.unmap, i.data, i.sections = nil, nil, nil
+	return err
+}
+
+// Count returns the number of items of the section.
+func (i *Index) Count(kind Kind) uint64 {
+	return i.sections[kind].count
+}
+
+// Check returns true if the name is in the index. Name must be canonical.
+func (i *Index) Check(name string, resource xlist.Resource) bool {
+	switch resource {
+	case xlist.IPv4:
+		ip := net.ParseIP(name).To4()
+		return ip != nil && i.inRanges(IP4Ranges, ip)
+	case xlist.IPv6:
+		ip := net.ParseIP(name)
+		return ip != nil && i.inRanges(IP6Ranges, ip.To16())
+	case xlist.Domain:
+		return i.checkDomain(name)
+	case xlist.MD5, xlist.SHA1, xlist.SHA256:
+		kind, _ := hashKind(resource)
+		h, err := hex.DecodeString(name)
+		if err != nil || len(h) != kind.recordSize() {
+			return false
+		}
+		return i.hasRecord(kind, h)
+	}
+	return false
+}
+
+func (i *Index) checkDomain(name string) bool {
+	if i.hasString(Domains, name) {
+		return true
+	}
+	if i.sections[Subdomains].count == 0 {
+		return false
+	}
+	// name and all its parents
+	for s := name; ; {
+		if i.hasString(Subdomains, s) {
+			return true
+		}
+		dot := strings.IndexByte(s, '.')
+		if dot < 0 {
+			return false
+		}
+		s = s[dot+1:]
+	}
+}
+
+func (i *Index) inRanges(kind Kind, ip []byte) bool {
+	v := i.sections[kind]
+	size := len(ip)
+	// first range with start > ip
+	n := sort.Search(int(v.count), func(j int) bool {
+		start := v.data[j*2*size : j*2*size+size]
+		return bytes.Compare(start, ip) > 0
+	})
+	if n == 0 {
+		return false
+	}
+	end := v.data[(n-1)*2*size+size : n*2*size]
+	return bytes.Compare(ip, end) <= 0
+}
+
+func (i *Index) hasRecord(kind Kind, key []byte) bool {
+	v := i.sections[kind]
+	size := len(key)
+	n := sort.Search(int(v.count), func(j int) bool {
+		return bytes.Compare(v.data[j*size:(j+1)*size], key) >= 0
+	})
+	return n < int(v.count) && bytes.Equal(v.data[n*size:(n+1)*size], key)
+}
+
+func (i *Index) hasString(kind Kind, key string) bool {
+	v := i.sections[kind]
+	count := int(v.count)
+	if count == 0 {
+		return false
+	}
+	base := 4 * (count + 1)
+	get := func(j int) []byte {
+		start := binary.LittleEndian.Uint32(v.data[4*j:])
+		end := binary.LittleEndian.Uint32(v.data[4*(j+1):])
+		return v.data[base+int(start) : base+int(end)]
+	}
+	k := []byte(key)
+	n := sort.Search(count, func(j int) bool {
+		return bytes.Compare(get(j), k) >= 0
+	})
+	return n < count && bytes.Equal(get(n), k)
+}
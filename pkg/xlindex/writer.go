@@ -0,0 +1,348 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package xlindex
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Writer collects items in memory and writes them sorted in the index
+// format. It's intended for offline conversion.
+type Writer struct {
+	ip4     []ipRange
+	ip6     []ipRange
+	domains []string
+	subs    []string
+	hashes  map[Kind][][]byte
+}
+
+type ipRange struct {
+	start, end net.IP
+}
+
+// NewWriter returns a new writer.
+func NewWriter() *Writer {
+	return &Writer{hashes: make(map[Kind][][]byte)}
+}
+
+// Add adds an item to the index.
+func (w *Writer) Add(resource xlist.Resource, format xlistd.Format, value string) error {
+	switch resource {
+	case xlist.IPv4, xlist.IPv6:
+		var r ipRange
+		switch format {
+		case xlistd.Plain:
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return xlist.ErrBadRequest
+			}
+			r.start, r.end = ip, ip
+		case xlistd.CIDR:
+			_, ipnet, err := net.ParseCIDR(value)
+			if err != nil {
+				return xlist.ErrBadRequest
+			}
+			r.start, r.end = ipnet.IP, lastIP(ipnet)
+		default:
+			return xlist.ErrNotSupported
+		}
+		if resource == xlist.IPv4 {
+			r.start, r.end = r.start.To4(), r.end.To4()
+			if r.start == nil || r.end == nil {
+				return xlist.ErrBadRequest
+			}
+			w.ip4 = append(w.ip4, r)
+			return nil
+		}
+		if r.start.To4() != nil {
+			return xlist.ErrBadRequest
+		}
+		r.start, r.end = r.start.To16(), r.end.To16()
+		w.ip6 = append(w.ip6, r)
+		return nil
+	case xlist.Domain:
+		k, ok := xlist.Canonicalize(value, xlist.Domain)
+		if !ok {
+			return xlist.ErrBadRequest
+		}
+		switch format {
+		case xlistd.Plain:
+			w.domains = append(w.domains, k)
+		case xlistd.Sub:
+			w.subs = append(w.subs, k)
+		default:
+			return xlist.ErrNotSupported
+		}
+		return nil
+	case xlist.MD5, xlist.SHA1, xlist.SHA256:
+		if format != xlistd.Plain {
+			return xlist.ErrNotSupported
+		}
+		kind, _ := hashKind(resource)
+		h, err := hex.DecodeString(strings.TrimSpace(value))
+		if err != nil || len(h) != kind.recordSize() {
+			return xlist.ErrBadRequest
+		}
+		w.hashes[kind] = append(w.hashes[kind], h)
+		return nil
+	}
+	return xlist.ErrNotSupported
+}
+
+// LoadReader adds the items of a reader in the xlist file format
+// (resource,format,value), resources not in the list passed are ignored.
+func (w *Writer) LoadReader(ctx context.Context, in io.Reader, resources []xlist.Resource) error {
+	nline := 0
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		nline++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) < 3 {
+			return fmt.Errorf("line %v: invalid line", nline)
+		}
+		resource, err := xlist.ToResource(fields[0])
+		if err != nil {
+			return fmt.Errorf("line %v: invalid resource type '%s'", nline, fields[0])
+		}
+		format, err := xlistd.ToFormat(fields[1])
+		if err != nil {
+			return fmt.Errorf("line %v: invalid format type '%s'", nline, fields[1])
+		}
+		if !resource.InArray(resources) {
+			continue
+		}
+		if err := w.Add(resource, format, fields[2]); err != nil {
+			return fmt.Errorf("line %v: invalid '%v,%v,%s'", nline, resource, format, fields[2])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scanning input: %v", err)
+	}
+	return nil
+}
+
+type section struct {
+	kind  Kind
+	count uint64
+	data  []byte
+}
+
+// WriteTo implements io.WriterTo interface.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	sections := make([]section, 0, 7)
+	add := func(kind Kind, count int, data []byte) {
+		if count > 0 {
+			sections = append(sections, section{kind: kind, count: uint64(count), data: data})
+		}
+	}
+	ranges := mergeRanges(w.ip4)
+	add(IP4Ranges, len(ranges), encodeRanges(ranges))
+	ranges = mergeRanges(w.ip6)
+	add(IP6Ranges, len(ranges), encodeRanges(ranges))
+	for _, s := range []struct {
+		kind  Kind
+		items []string
+	}{{Domains, w.domains}, {Subdomains, w.subs}} {
+		items := uniqueStrings(s.items)
+		data, err := encodeStrings(items)
+		if err != nil {
+			return 0, err
+		}
+		add(s.kind, len(items), data)
+	}
+	for _, kind := range []Kind{MD5s, SHA1s, SHA256s} {
+		items := uniqueBytes(w.hashes[kind])
+		add(kind, len(items), bytes.Join(items, nil))
+	}
+	// header and table
+	var head bytes.Buffer
+	head.WriteString(Magic)
+	binary.Write(&head, binary.LittleEndian, uint32(Version))
+	binary.Write(&head, binary.LittleEndian, uint32(len(sections)))
+	offset := uint64(headerSize + entrySize*len(sections))
+	for _, s := range sections {
+		binary.Write(&head, binary.LittleEndian, uint32(s.kind))
+		binary.Write(&head, binary.LittleEndian, uint32(s.kind.recordSize()))
+		binary.Write(&head, binary.LittleEndian, s.count)
+		binary.Write(&head, binary.LittleEndian, offset)
+		binary.Write(&head, binary.LittleEndian, uint64(len(s.data)))
+		offset += uint64(len(s.data))
+	}
+	n, err := out.Write(head.Bytes())
+	total := int64(n)
+	if err != nil {
+		return total, err
+	}
+	for _, s := range sections {
+		n, err := out.Write(s.data)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// WriteFile writes the index to the filename replacing it atomically.
+func (w *Writer) WriteFile(filename string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".")
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriterSize(tmp, 1<<20)
+	if _, err := w.WriteTo(bw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+func lastIP(ipnet *net.IPNet) net.IP {
+	ip := make(net.IP, len(ipnet.IP))
+	for i := range ipnet.IP {
+		ip[i] = ipnet.IP[i] | ^ipnet.Mask[i]
+	}
+	return ip
+}
+
+// mergeRanges sorts and merges overlapping and adjacent ranges.
+func mergeRanges(ranges []ipRange) []ipRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return bytes.Compare(ranges[i].start, ranges[j].start) < 0
+	})
+	merged := []ipRange{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		next := nextIP(last.end)
+		if next == nil || bytes.Compare(r.start, next) <= 0 {
+			if bytes.Compare(r.end, last.end) > 0 {
+				last.end = r.end
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// nextIP returns the next ip address, nil if overflows.
+func nextIP(ip net.IP) net.IP {
+	next := make([]byte, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			return next
+		}
+	}
+	return nil
+}
+
+func encodeRanges(ranges []ipRange) []byte {
+	if len(ranges) == 0 {
+		return nil
+	}
+	size := len(ranges[0].start)
+	data := make([]byte, 0, 2*size*len(ranges))
+	for _, r := range ranges {
+		data = append(data, r.start...)
+		data = append(data, r.end...)
+	}
+	return data
+}
+
+// encodeStrings returns a table of count+1 offsets followed by the strings.
+func encodeStrings(items []string) ([]byte, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	size := 0
+	for _, s := range items {
+		size += len(s)
+	}
+	if size > math.MaxUint32 {
+		return nil, fmt.Errorf("xlindex: strings section too big (%v bytes)", size)
+	}
+	table := make([]byte, 4*(len(items)+1))
+	data := make([]byte, 0, len(table)+size)
+	offset := 0
+	for i, s := range items {
+		binary.LittleEndian.PutUint32(table[4*i:], uint32(offset))
+		offset += len(s)
+	}
+	binary.LittleEndian.PutUint32(table[4*len(items):], uint32(offset))
+	data = append(data, table...)
+	for _, s := range items {
+		data = append(data, s...)
+	}
+	return data, nil
+}
+
+func uniqueStrings(items []string) []string {
+	sort.Strings(items)
+	ret := items[:0]
+	for _, s := range items {
+		if len(ret) == 0 || s != ret[len(ret)-1] {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+func uniqueBytes(items [][]byte) [][]byte {
+	sort.Slice(items, func(i, j int) bool { return bytes.Compare(items[i], items[j]) < 0 })
+	ret := items[:0]
+	for _, b := range items {
+		if len(ret) == 0 || !bytes.Equal(b, ret[len(ret)-1]) {
+			ret = append(ret, b)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package xlindex implements a sorted and indexed file format for lists that
+// can be queried directly from a memory mapped file, without loading it.
+//
+// File starts with a header and a table of sections. Each section stores a
+// sorted array of fixed size records (ip ranges and hashes) or of variable
+// size strings (domains), so lookups are binary searches.
+//
+// This package is a work in progress and makes no API stability promises.
+package xlindex
+
+import (
+	"errors"
+
+	"github.com/luids-io/api/xlist"
+)
+
+// Magic bytes of the index files.
+const Magic = "XLINDEX1"
+
+// Version of the format.
+const Version = 1
+
+// Kind of sections stored in an index.
+type Kind uint32
+
+// List of sections.
+const (
+	IP4Ranges Kind = iota + 1
+	IP6Ranges
+	Domains
+	Subdomains
+	MD5s
+	SHA1s
+	SHA256s
+)
+
+// Kinds is the list of sections in the order they are written.
+var Kinds = []Kind{IP4Ranges, IP6Ranges, Domains, Subdomains, MD5s, SHA1s, SHA256s}
+
+// String implements stringer interface.
+func (k Kind) String() string {
+	switch k {
+	case IP4Ranges:
+		return "ip4"
+	case IP6Ranges:
+		return "ip6"
+	case Domains:
+		return "domain"
+	case Subdomains:
+		return "subdomain"
+	case MD5s:
+		return "md5"
+	case SHA1s:
+		return "sha1"
+	case SHA256s:
+		return "sha256"
+	default:
+		return "unknown"
+	}
+}
+
+// recordSize returns the size of fixed records, 0 if size is variable.
+func (k Kind) recordSize() int {
+	switch k {
+	case IP4Ranges:
+		return 8
+	case IP6Ranges:
+		return 32
+	case MD5s:
+		return 16
+	case SHA1s:
+		return 20
+	case SHA256s:
+		return 32
+	default:
+		return 0
+	}
+}
+
+func hashKind(r xlist.Resource) (Kind, bool) {
+	switch r {
+	case xlist.MD5:
+		return MD5s, true
+	case xlist.SHA1:
+		return SHA1s, true
+	case xlist.SHA256:
+		return SHA256s, true
+	default:
+		return 0, false
+	}
+}
+
+// sizes of the structures
+const (
+	headerSize = 16
+	entrySize  = 32
+)
+
+// Errors returned by the package.
+var (
+	ErrInvalidFormat = errors.New("xlindex: invalid format")
+	ErrVersion       = errors.New("xlindex: unsupported version")
+)
@@ -0,0 +1,202 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package xlindex_test
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlindex"
+)
+
+const testdata = `# test data
+ip4,plain,10.0.0.1
+ip4,cidr,192.168.0.0/24
+ip4,cidr,192.168.1.0/24
+ip4,plain,192.168.2.0
+ip6,cidr,fe80::/16
+ip6,plain,2001:db8::1
+domain,plain,www.example.com
+domain,sub,evil.org
+domain,plain,WWW.Google.es
+md5,plain,d41d8cd98f00b204e9800998ecf8427e
+sha256,plain,e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+`
+
+func TestIndex_Check(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xlindex")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.idx")
+
+	w := xlindex.NewWriter()
+	err = w.LoadReader(context.Background(), strings.NewReader(testdata), xlist.Resources)
+	if err != nil {
+		t.Fatalf("xlindex.LoadReader(): err=%v", err)
+	}
+	if err := w.WriteFile(filename); err != nil {
+		t.Fatalf("xlindex.WriteFile(): err=%v", err)
+	}
+	index, err := xlindex.Open(filename)
+	if err != nil {
+		t.Fatalf("xlindex.Open(): err=%v", err)
+	}
+	defer index.Close()
+
+	var tests = []struct {
+		name     string
+		resource xlist.Resource
+		want     bool
+	}{
+		{"10.0.0.1", xlist.IPv4, true},
+		{"10.0.0.2", xlist.IPv4, false},
+		{"192.168.0.0", xlist.IPv4, true},
+		{"192.168.1.255", xlist.IPv4, true},
+		{"192.168.2.0", xlist.IPv4, true},
+		{"192.168.2.1", xlist.IPv4, false},
+		{"192.167.255.255", xlist.IPv4, false},
+		{"fe80::1", xlist.IPv6, true},
+		{"fe81::1", xlist.IPv6, false},
+		{"2001:db8::1", xlist.IPv6, true},
+		{"2001:db8::2", xlist.IPv6, false},
+		{"www.example.com", xlist.Domain, true},
+		{"example.com", xlist.Domain, false},
+		{"a.www.example.com", xlist.Domain, false},
+		{"evil.org", xlist.Domain, true},
+		{"www.a.evil.org", xlist.Domain, true},
+		{"notevil.org", xlist.Domain, false},
+		{"www.google.es", xlist.Domain, true},
+		{"d41d8cd98f00b204e9800998ecf8427e", xlist.MD5, true},
+		{"d41d8cd98f00b204e9800998ecf8427f", xlist.MD5, false},
+		{"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", xlist.SHA256, true},
+		{"da39a3ee5e6b4b0d3255bfef95601890afd80709", xlist.SHA1, false},
+	}
+	for idx, test := range tests {
+		if got := index.Check(test.name, test.resource); got != test.want {
+			t.Errorf("idx[%v] xlindex.Check(%s): want=%v got=%v", idx, test.name, test.want, got)
+		}
+	}
+	// adjacent ranges are merged
+	if got := index.Count(xlindex.IP4Ranges); got != 2 {
+		t.Errorf("xlindex.Count(): unexpected ip4 ranges %v", got)
+	}
+	if got := index.Count(xlindex.Subdomains); got != 1 {
+		t.Errorf("xlindex.Count(): unexpected subdomains %v", got)
+	}
+}
+
+func TestOpen_Invalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xlindex")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var tests = []struct {
+		content string
+		wantErr error
+	}{
+		{"", xlindex.ErrInvalidFormat},
+		{"ip4,plain,10.0.0.1\n", xlindex.ErrInvalidFormat},
+		{xlindex.Magic + "\x02\x00\x00\x00\x00\x00\x00\x00", xlindex.ErrVersion},
+		{xlindex.Magic + "\x01\x00\x00\x00\x01\x00\x00\x00", xlindex.ErrInvalidFormat},
+		{xlindex.Magic + "\x01\x00\x00\x00\x00\x00\x00\x00", nil},
+	}
+	for idx, test := range tests {
+		filename := filepath.Join(dir, "test.idx")
+		if err := ioutil.WriteFile(filename, []byte(test.content), 0644); err != nil {
+			t.Fatalf("writing file: %v", err)
+		}
+		index, err := xlindex.Open(filename)
+		if err != test.wantErr {
+			t.Errorf("idx[%v] xlindex.Open(): unexpected err=%v", idx, err)
+		}
+		if err == nil {
+			index.Close()
+		}
+	}
+}
+
+func TestOpen_Corrupt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xlindex")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.idx")
+
+	w := xlindex.NewWriter()
+	data := "domain,plain,a.com\ndomain,plain,b.com\ndomain,plain,c.com\n"
+	err = w.LoadReader(context.Background(), strings.NewReader(data), xlist.Resources)
+	if err != nil {
+		t.Fatalf("xlindex.LoadReader(): err=%v", err)
+	}
+	if err := w.WriteFile(filename); err != nil {
+		t.Fatalf("xlindex.WriteFile(): err=%v", err)
+	}
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("reading file: %v", err)
+	}
+	// offset of the domains section from the header
+	var section int
+	for i := 0; i < int(binary.LittleEndian.Uint32(content[12:])); i++ {
+		entry := content[16+i*32:]
+		if binary.LittleEndian.Uint32(entry) == uint32(xlindex.Domains) {
+			section = int(binary.LittleEndian.Uint64(entry[16:]))
+		}
+	}
+	if section == 0 {
+		t.Fatalf("domains section not found")
+	}
+
+	var tests = []struct {
+		pos   int
+		value uint32
+	}{
+		{0, 0},          // unmodified
+		{0, 1},          // first offset
+		{4, 0xffffffff}, // offset out of data
+		{8, 1},          // offsets not in order
+		{12, 4},         // last offset
+		{-1, 0},         // truncated
+	}
+	for idx, test := range tests {
+		corrupt := make([]byte, len(content))
+		copy(corrupt, content)
+		switch {
+		case test.pos < 0:
+			corrupt = corrupt[:len(corrupt)-1]
+		case idx > 0:
+			binary.LittleEndian.PutUint32(corrupt[section+test.pos:], test.value)
+		}
+		if err := ioutil.WriteFile(filename, corrupt, 0644); err != nil {
+			t.Fatalf("writing file: %v", err)
+		}
+		index, err := xlindex.Open(filename)
+		if idx == 0 {
+			if err != nil {
+				t.Fatalf("idx[%v] xlindex.Open(): err=%v", idx, err)
+			}
+			if !index.Check("b.com", xlist.Domain) {
+				t.Errorf("idx[%v] xlindex.Check(): expected true", idx)
+			}
+			index.Close()
+			continue
+		}
+		if err != xlindex.ErrInvalidFormat {
+			t.Errorf("idx[%v] xlindex.Open(): unexpected err=%v", idx, err)
+		}
+		if err == nil {
+			index.Close()
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package admin provides an http api and a client for the administration of
+// the lists built by a xlistd.Builder at runtime.
+//
+// This package is a work in progress and makes no API stability promises.
+package admin
+
+import (
+	"errors"
+
+	"github.com/luids-io/api/xlist"
+)
+
+// Operations available in the api.
+const (
+	OpRead    = "read"
+	OpPing    = "ping"
+	OpReload  = "reload"
+	OpFlush   = "flush"
+	OpEnable  = "enable"
+	OpDisable = "disable"
+	OpEdit    = "edit"
+)
+
+// Some standard errors returned by the api.
+var (
+	ErrNotFound     = errors.New("admin: list not found")
+	ErrNotSupported = errors.New("admin: operation not supported by list")
+	ErrUnauthorized = errors.New("admin: unauthorized")
+	ErrForbidden    = errors.New("admin: forbidden")
+	ErrBadRequest   = errors.New("admin: bad request")
+	ErrItemNotFound = errors.New("admin: item not found")
+	ErrInvalidItem  = errors.New("admin: invalid item")
+	ErrResource     = errors.New("admin: resource not provided by list")
+	ErrReadOnly     = errors.New("admin: list is read only")
+)
+
+// ListInfo stores information about a list.
+type ListInfo struct {
+	ID        string                 `json:"id"`
+	Class     string                 `json:"class"`
+	Enabled   bool                   `json:"enabled"`
+	Resources []xlist.Resource       `json:"resources,omitempty"`
+	Supports  []string               `json:"supports,omitempty"`
+	Ping      *PingResult            `json:"ping,omitempty"`
+	Info      map[string]interface{} `json:"info,omitempty"`
+	Childs    []ListInfo             `json:"childs,omitempty"`
+}
+
+// PingResult stores the result of a ping.
+type PingResult struct {
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+	Latency string `json:"latency"`
+}
+
+// errorResponse is returned by the server when an operation fails.
+type errorResponse struct {
+	Error string `json:"error"`
+}
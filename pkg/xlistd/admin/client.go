@@ -0,0 +1,212 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package admin
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// ClientOption is used for client configuration.
+type ClientOption func(*clientOpts)
+
+type clientOpts struct {
+	token   string
+	timeout time.Duration
+	tls     *tls.Config
+}
+
+var defaultClientOpts = clientOpts{timeout: 30 * time.Second}
+
+// SetToken option sets the bearer token used by the client.
+func SetToken(token string) ClientOption {
+	return func(o *clientOpts) {
+		o.token = token
+	}
+}
+
+// SetTimeout option sets the timeout of the requests.
+func SetTimeout(d time.Duration) ClientOption {
+	return func(o *clientOpts) {
+		o.timeout = d
+	}
+}
+
+// SetClientTLS option sets the tls configuration used for tcp connections.
+func SetClientTLS(cfg *tls.Config) ClientOption {
+	return func(o *clientOpts) {
+		o.tls = cfg
+	}
+}
+
+// Client implements a client for the administration api.
+type Client struct {
+	opts    clientOpts
+	baseURL string
+	client  *http.Client
+}
+
+// NewClient returns a new client for the uri passed. Uri must be in the form
+// tcp://host:port or unix:///path/to/socket.
+func NewClient(uri string, opt ...ClientOption) (*Client, error) {
+	opts := defaultClientOpts
+	for _, o := range opt {
+		o(&opts)
+	}
+	c := &Client{opts: opts}
+	transport := &http.Transport{}
+	switch {
+	case strings.HasPrefix(uri, "tcp://"):
+		c.baseURL = "http://" + uri[6:]
+		if opts.tls != nil {
+			c.baseURL = "https://" + uri[6:]
+			transport.TLSClientConfig = opts.tls
+		}
+	case strings.HasPrefix(uri, "unix://"):
+		path := uri[7:]
+		c.baseURL = "http://unix"
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		}
+	default:
+		return nil, fmt.Errorf("invalid uri '%s'", uri)
+	}
+	c.client = &http.Client{Transport: transport, Timeout: opts.timeout}
+	return c, nil
+}
+
+// Lists returns the tree of lists.
+func (c *Client) Lists(ctx context.Context, doPing bool) ([]ListInfo, error) {
+	path := "/v1/lists"
+	if doPing {
+		path = path + "?ping=true"
+	}
+	var infos []ListInfo
+	err := c.do(ctx, "GET", path, nil, &infos)
+	return infos, err
+}
+
+// Info returns information about a list.
+func (c *Client) Info(ctx context.Context, id string) (ListInfo, error) {
+	var info ListInfo
+	err := c.do(ctx, "GET", listPath(id, ""), nil, &info)
+	return info, err
+}
+
+// Ping pings the list.
+func (c *Client) Ping(ctx context.Context, id string) (PingResult, error) {
+	var result PingResult
+	err := c.do(ctx, "POST", listPath(id, OpPing), nil, &result)
+	return result, err
+}
+
+// Reload reloads the list.
+func (c *Client) Reload(ctx context.Context, id string) error {
+	return c.do(ctx, "POST", listPath(id, OpReload), nil, nil)
+}
+
+// Flush flushes the caches of the list.
+func (c *Client) Flush(ctx context.Context, id string) error {
+	return c.do(ctx, "POST", listPath(id, OpFlush), nil, nil)
+}
+
+// Enable enables the list.
+func (c *Client) Enable(ctx context.Context, id string) error {
+	return c.do(ctx, "POST", listPath(id, OpEnable), nil, nil)
+}
+
+// Disable disables the list.
+func (c *Client) Disable(ctx context.Context, id string) error {
+	return c.do(ctx, "POST", listPath(id, OpDisable), nil, nil)
+}
+
+// Items returns the items of an editable list.
+func (c *Client) Items(ctx context.Context, id string) ([]xlistd.Item, error) {
+	var items []xlistd.Item
+	err := c.do(ctx, "GET", listPath(id, "items"), nil, &items)
+	return items, err
+}
+
+// AddItem adds an item to an editable list.
+func (c *Client) AddItem(ctx context.Context, id string, item xlistd.Item) error {
+	return c.do(ctx, "POST", listPath(id, "items"), item, nil)
+}
+
+// RemoveItem removes an item from an editable list.
+func (c *Client) RemoveItem(ctx context.Context, id string, item xlistd.Item) error {
+	return c.do(ctx, "DELETE", listPath(id, "items"), item, nil)
+}
+
+// Close closes idle connections.
+func (c *Client) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encoding request: %v", err)
+		}
+		body = strings.NewReader(string(data))
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.opts.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var e errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error == "" {
+			return fmt.Errorf("admin: %s", resp.Status)
+		}
+		return mapError(e.Error)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func listPath(id, op string) string {
+	p := "/v1/lists/" + url.PathEscape(id)
+	if op != "" {
+		p = p + "/" + op
+	}
+	return p
+}
+
+func mapError(msg string) error {
+	for _, err := range []error{ErrNotFound, ErrNotSupported, ErrUnauthorized, ErrForbidden, ErrBadRequest,
+		ErrItemNotFound, ErrInvalidItem, ErrResource, ErrReadOnly} {
+		if msg == err.Error() {
+			return err
+		}
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,490 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/ipfilter"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/authz"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Option encapsules server options.
+type Option func(*options)
+
+type options struct {
+	logger   yalogi.Logger
+	ipfilter ipfilter.Filter
+	tokens   []string
+	authz    *authz.Authorizer
+	tls      *tls.Config
+}
+
+var defaultOptions = options{logger: yalogi.LogNull}
+
+// SetLogger option sets a logger for the component.
+func SetLogger(l yalogi.Logger) Option {
+	return func(o *options) {
+		if l != nil {
+			o.logger = l
+		}
+	}
+}
+
+// SetIPFilter option sets an ip filter.
+func SetIPFilter(f ipfilter.Filter) Option {
+	return func(o *options) {
+		o.ipfilter = f
+	}
+}
+
+// Tokens option sets the bearer tokens accepted by the server.
+func Tokens(tokens ...string) Option {
+	return func(o *options) {
+		o.tokens = append(o.tokens, tokens...)
+	}
+}
+
+// SetAuthorizer option sets an authorizer that identifies callers and checks
+// their permissions on operations. If it's set, tokens are ignored.
+func SetAuthorizer(a *authz.Authorizer) Option {
+	return func(o *options) {
+		o.authz = a
+	}
+}
+
+// SetTLS option sets the tls configuration used for serving. Client
+// certificates are available for the authorizer.
+func SetTLS(cfg *tls.Config) Option {
+	return func(o *options) {
+		o.tls = cfg
+	}
+}
+
+// Server is an http server that provides the administration api.
+// It must be constructed using New.
+type Server struct {
+	opts    options
+	logger  yalogi.Logger
+	server  *http.Server
+	builder *xlistd.Builder
+}
+
+// New constructs a new server for the lists created by the builder.
+func New(builder *xlistd.Builder, opt ...Option) *Server {
+	opts := defaultOptions
+	for _, o := range opt {
+		o(&opts)
+	}
+	s := &Server{
+		opts:    opts,
+		logger:  opts.logger,
+		server:  &http.Server{},
+		builder: builder,
+	}
+	s.server.Handler = s.handler()
+	return s
+}
+
+// Serve http.
+func (s *Server) Serve(lis net.Listener) error {
+	s.logger.Infof("starting admin server %v", lis.Addr().String())
+	if s.opts.tls != nil {
+		lis = tls.NewListener(lis, s.opts.tls)
+	}
+	return s.server.Serve(lis)
+}
+
+// Close immediately server. See http.Server doc.
+func (s *Server) Close() error {
+	s.logger.Infof("closing admin server")
+	return s.server.Close()
+}
+
+// Shutdown waits all pending operations to shutdown. See http.Server doc.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.logger.Infof("shutting down admin server")
+	return s.server.Shutdown(ctx)
+}
+
+// ServeHTTP implements http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.server.Handler.ServeHTTP(w, r)
+}
+
+// returns the server handler with enabled resources
+func (s *Server) handler() http.Handler {
+	router := mux.NewRouter()
+	api := router.PathPrefix("/v1").Subrouter()
+	api.HandleFunc("/lists", s.auth(OpRead, s.doLists)).Methods("GET")
+	api.HandleFunc("/lists/{id}", s.auth(OpRead, s.doList)).Methods("GET")
+	api.HandleFunc("/lists/{id}/ping", s.auth(OpPing, s.doPing)).Methods("POST")
+	api.HandleFunc("/lists/{id}/reload", s.auth(OpReload, s.doReload)).Methods("POST")
+	api.HandleFunc("/lists/{id}/flush", s.auth(OpFlush, s.doFlush)).Methods("POST")
+	api.HandleFunc("/lists/{id}/enable", s.auth(OpEnable, s.doEnable)).Methods("POST")
+	api.HandleFunc("/lists/{id}/disable", s.auth(OpDisable, s.doDisable)).Methods("POST")
+	api.HandleFunc("/lists/{id}/items", s.auth(OpRead, s.doItems)).Methods("GET")
+	api.HandleFunc("/lists/{id}/items", s.auth(OpEdit, s.doAddItem)).Methods("POST")
+	api.HandleFunc("/lists/{id}/items", s.auth(OpEdit, s.doRemoveItem)).Methods("DELETE")
+
+	if !s.opts.ipfilter.Empty() {
+		filtered := s.opts.ipfilter
+		filtered.Wrapped = router
+		return filtered
+	}
+	return router
+}
+
+func (s *Server) auth(op string, next http.HandlerFunc) http.HandlerFunc {
+	if s.opts.authz != nil {
+		return s.authorize(op, next)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.validToken(bearerToken(r)) {
+			s.logger.Warnf("admin: [peer=%s] %s '%s': unauthorized", r.RemoteAddr, op, mux.Vars(r)["id"])
+			s.writeError(w, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) authorize(op string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := authz.Request{Token: bearerToken(r)}
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			req.IP = net.ParseIP(host)
+		}
+		if r.TLS != nil {
+			req.Certs = r.TLS.PeerCertificates
+		}
+		service := "admin." + op
+		identity, ok := s.opts.authz.Identify(req)
+		if !ok {
+			s.opts.authz.Denied(service, "", authz.Unauthenticated, req)
+			s.writeError(w, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+		if !s.opts.authz.AllowOp(identity, op) {
+			s.opts.authz.Denied(service, identity, authz.Forbidden, req)
+			s.writeError(w, http.StatusForbidden, ErrForbidden)
+			return
+		}
+		next(w, r.WithContext(authz.NewContext(r.Context(), identity)))
+	}
+}
+
+func (s *Server) validToken(token string) bool {
+	if token == "" {
+		return false
+	}
+	for _, t := range s.opts.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) doLists(w http.ResponseWriter, r *http.Request) {
+	ping := r.URL.Query().Get("ping") == "true"
+	roots := s.builder.Roots()
+	infos := make([]ListInfo, 0, len(roots))
+	for _, id := range roots {
+		info, ok := s.listInfo(r.Context(), id, ping, true)
+		if ok {
+			infos = append(infos, info)
+		}
+	}
+	s.writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *Server) doList(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	info, ok := s.listInfo(r.Context(), id, true, true)
+	if !ok {
+		s.writeError(w, http.StatusNotFound, ErrNotFound)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) doPing(w http.ResponseWriter, r *http.Request) {
+	list, ok := s.getList(w, r)
+	if !ok {
+		return
+	}
+	s.writeJSON(w, http.StatusOK, ping(list))
+}
+
+func (s *Server) doReload(w http.ResponseWriter, r *http.Request) {
+	list, ok := s.getList(w, r)
+	if !ok {
+		return
+	}
+	var reloader xlistd.Reloader
+	for _, l := range xlistd.Chain(list) {
+		if rl, ok := l.(xlistd.Reloader); ok {
+			reloader = rl
+			break
+		}
+	}
+	if reloader == nil {
+		s.writeError(w, http.StatusBadRequest, ErrNotSupported)
+		return
+	}
+	id := mux.Vars(r)["id"]
+	s.logger.Infof("admin: [peer=%s] reloading '%s'", r.RemoteAddr, id)
+	if err := reloader.Reload(); err != nil {
+		s.logger.Warnf("admin: reloading '%s': %v", id, err)
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) doFlush(w http.ResponseWriter, r *http.Request) {
+	list, ok := s.getList(w, r)
+	if !ok {
+		return
+	}
+	flushed := false
+	for _, l := range xlistd.Chain(list) {
+		if fl, ok := l.(xlistd.Flusher); ok {
+			fl.Flush()
+			flushed = true
+		}
+	}
+	if !flushed {
+		s.writeError(w, http.StatusBadRequest, ErrNotSupported)
+		return
+	}
+	s.logger.Infof("admin: [peer=%s] flushed '%s'", r.RemoteAddr, mux.Vars(r)["id"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) doEnable(w http.ResponseWriter, r *http.Request) {
+	s.setEnabled(w, r, true)
+}
+
+func (s *Server) doDisable(w http.ResponseWriter, r *http.Request) {
+	s.setEnabled(w, r, false)
+}
+
+func (s *Server) setEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	id := mux.Vars(r)["id"]
+	if _, ok := s.builder.List(id); !ok {
+		s.writeError(w, http.StatusNotFound, ErrNotFound)
+		return
+	}
+	if err := s.builder.SetEnabled(id, enabled); err != nil {
+		s.writeError(w, http.StatusBadRequest, ErrNotSupported)
+		return
+	}
+	s.logger.Infof("admin: [peer=%s] set '%s' enabled=%v", r.RemoteAddr, id, enabled)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) doItems(w http.ResponseWriter, r *http.Request) {
+	editor, ok := s.getEditor(w, r)
+	if !ok {
+		return
+	}
+	items, err := editor.Items(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) doAddItem(w http.ResponseWriter, r *http.Request) {
+	s.editItem(w, r, true)
+}
+
+func (s *Server) doRemoveItem(w http.ResponseWriter, r *http.Request) {
+	s.editItem(w, r, false)
+}
+
+func (s *Server) editItem(w http.ResponseWriter, r *http.Request, add bool) {
+	editor, ok := s.getEditor(w, r)
+	if !ok {
+		return
+	}
+	var item xlistd.Item
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		s.writeError(w, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if item.Author == "" {
+		// defaults to the identity of the caller
+		item.Author, _ = authz.FromContext(r.Context())
+	}
+	if item.Author == "" {
+		s.writeError(w, http.StatusBadRequest, ErrInvalidItem)
+		return
+	}
+	item.Timestamp = time.Now()
+	var err error
+	op := "add"
+	if add {
+		err = editor.Add(r.Context(), item)
+	} else {
+		op = "remove"
+		err = editor.Remove(r.Context(), item)
+	}
+	id := mux.Vars(r)["id"]
+	switch err {
+	case nil:
+	case xlist.ErrNotSupported:
+		s.writeError(w, http.StatusBadRequest, ErrResource)
+		return
+	case xlist.ErrBadRequest:
+		s.writeError(w, http.StatusBadRequest, ErrInvalidItem)
+		return
+	case xlistd.ErrItemNotFound:
+		s.writeError(w, http.StatusNotFound, ErrItemNotFound)
+		return
+	case xlistd.ErrReadOnly:
+		s.writeError(w, http.StatusConflict, ErrReadOnly)
+		return
+	default:
+		s.logger.Warnf("admin: %s item in '%s': %v", op, id, err)
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.logger.Infof("admin: [peer=%s] %s '%v,%v,%s' in '%s' by '%s'",
+		r.RemoteAddr, op, item.Resource, item.Format, item.Value, id, item.Author)
+	// flush caches of the list
+	list, _ := s.builder.List(id)
+	for _, l := range xlistd.Chain(list) {
+		if fl, ok := l.(xlistd.Flusher); ok {
+			fl.Flush()
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getEditor(w http.ResponseWriter, r *http.Request) (xlistd.Editor, bool) {
+	list, ok := s.getList(w, r)
+	if !ok {
+		return nil, false
+	}
+	for _, l := range xlistd.Chain(list) {
+		if editor, ok := l.(xlistd.Editor); ok {
+			return editor, true
+		}
+	}
+	s.writeError(w, http.StatusBadRequest, ErrNotSupported)
+	return nil, false
+}
+
+func (s *Server) getList(w http.ResponseWriter, r *http.Request) (xlistd.List, bool) {
+	id := mux.Vars(r)["id"]
+	list, ok := s.builder.List(id)
+	if !ok {
+		s.writeError(w, http.StatusNotFound, ErrNotFound)
+		return nil, false
+	}
+	return list, true
+}
+
+func (s *Server) listInfo(ctx context.Context, id string, doPing, recursive bool) (ListInfo, bool) {
+	list, ok := s.builder.List(id)
+	if !ok {
+		return ListInfo{}, false
+	}
+	enabled, _ := s.builder.Enabled(id)
+	info := ListInfo{
+		ID:      id,
+		Class:   list.Class(),
+		Enabled: enabled,
+	}
+	info.Resources, _ = list.Resources(ctx)
+	supports := []string{OpPing, OpEnable, OpDisable}
+	reload, flush, edit := false, false, false
+	for _, l := range xlistd.Chain(list) {
+		if _, ok := l.(xlistd.Editor); ok {
+			edit = true
+		}
+		if _, ok := l.(xlistd.Reloader); ok {
+			reload = true
+		}
+		if _, ok := l.(xlistd.Flusher); ok {
+			flush = true
+		}
+		if i, ok := l.(xlistd.Inspector); ok {
+			if info.Info == nil {
+				info.Info = make(map[string]interface{})
+			}
+			for k, v := range i.Inspect() {
+				info.Info[k] = v
+			}
+		}
+	}
+	if reload {
+		supports = append(supports, OpReload)
+	}
+	if flush {
+		supports = append(supports, OpFlush)
+	}
+	if edit {
+		supports = append(supports, OpEdit)
+	}
+	info.Supports = supports
+	if doPing {
+		result := ping(list)
+		info.Ping = &result
+	}
+	if recursive {
+		for _, child := range s.builder.Childs(id) {
+			cinfo, ok := s.listInfo(ctx, child, doPing, recursive)
+			if ok {
+				info.Childs = append(info.Childs, cinfo)
+			}
+		}
+	}
+	return info, true
+}
+
+func ping(list xlistd.List) PingResult {
+	start := time.Now()
+	err := list.Ping()
+	result := PingResult{Healthy: err == nil, Latency: time.Since(start).String()}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Warnf("admin: encoding response: %v", err)
+	}
+}
+
+func (s *Server) writeError(w http.ResponseWriter, code int, err error) {
+	s.writeJSON(w, code, errorResponse{Error: err.Error()})
+}
+
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
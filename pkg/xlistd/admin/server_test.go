@@ -0,0 +1,416 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package admin_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/authz"
+	"github.com/luids-io/xlist/pkg/tlsreload"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/admin"
+	"github.com/luids-io/xlist/pkg/xlistd/components/dynxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/sequencexl"
+	"github.com/luids-io/xlist/pkg/xlistd/wrappers/cachewr"
+)
+
+var onlyIPv4 = []xlist.Resource{xlist.IPv4}
+
+var testdatabase1 = []xlistd.ListDef{
+	{ID: "root",
+		Class:     sequencexl.ComponentClass,
+		Resources: onlyIPv4,
+		Contains: []xlistd.ListDef{
+			{ID: "mock1",
+				Class:     mockxl.ComponentClass,
+				Resources: onlyIPv4,
+				Source:    "true",
+				Wrappers:  []xlistd.WrapperDef{{Class: cachewr.WrapperClass}}},
+			{ID: "mock2",
+				Class:     mockxl.ComponentClass,
+				Resources: onlyIPv4},
+		}},
+}
+
+func testServer(t *testing.T) (*xlistd.Builder, *admin.Client, func()) {
+	b := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.RuntimeControl(true))
+	for _, def := range testdatabase1 {
+		_, err := b.Build(def)
+		if err != nil {
+			t.Fatalf("building %s: %v", def.ID, err)
+		}
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	srv := admin.New(b, admin.Tokens("secret"))
+	go srv.Serve(lis)
+	client, err := admin.NewClient("tcp://"+lis.Addr().String(), admin.SetToken("secret"))
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	return b, client, func() { client.Close(); srv.Close() }
+}
+
+func TestServer_Lists(t *testing.T) {
+	_, client, closeFn := testServer(t)
+	defer closeFn()
+
+	infos, err := client.Lists(context.Background(), true)
+	if err != nil {
+		t.Fatalf("client.Lists(): %v", err)
+	}
+	if len(infos) != 1 || infos[0].ID != "root" {
+		t.Fatalf("client.Lists(): unexpected %v", infos)
+	}
+	if len(infos[0].Childs) != 2 {
+		t.Fatalf("client.Lists(): unexpected childs %v", infos[0].Childs)
+	}
+	if infos[0].Ping == nil || !infos[0].Ping.Healthy {
+		t.Errorf("client.Lists(): unexpected ping %v", infos[0].Ping)
+	}
+	_, err = client.Info(context.Background(), "noexists")
+	if err != admin.ErrNotFound {
+		t.Errorf("client.Info(): unexpected error %v", err)
+	}
+}
+
+func TestServer_Operations(t *testing.T) {
+	b, client, closeFn := testServer(t)
+	defer closeFn()
+
+	ctx := context.Background()
+	if err := client.Flush(ctx, "mock1"); err != nil {
+		t.Errorf("client.Flush(): %v", err)
+	}
+	if err := client.Flush(ctx, "mock2"); err != admin.ErrNotSupported {
+		t.Errorf("client.Flush(): unexpected error %v", err)
+	}
+	if err := client.Reload(ctx, "mock1"); err != admin.ErrNotSupported {
+		t.Errorf("client.Reload(): unexpected error %v", err)
+	}
+
+	root, _ := b.List("root")
+	resp, _ := root.Check(ctx, "10.10.10.10", xlist.IPv4)
+	if !resp.Result {
+		t.Fatalf("root.Check(): expected positive")
+	}
+	if err := client.Disable(ctx, "mock1"); err != nil {
+		t.Fatalf("client.Disable(): %v", err)
+	}
+	resp, _ = root.Check(ctx, "10.10.10.10", xlist.IPv4)
+	if resp.Result {
+		t.Errorf("root.Check(): expected negative with mock1 disabled")
+	}
+	info, err := client.Info(ctx, "mock1")
+	if err != nil || info.Enabled {
+		t.Errorf("client.Info(): unexpected %v %v", info, err)
+	}
+	if err := client.Enable(ctx, "mock1"); err != nil {
+		t.Fatalf("client.Enable(): %v", err)
+	}
+	resp, _ = root.Check(ctx, "10.10.10.10", xlist.IPv4)
+	if !resp.Result {
+		t.Errorf("root.Check(): expected positive with mock1 enabled")
+	}
+}
+
+func TestServer_Unauthorized(t *testing.T) {
+	b := xlistd.NewBuilder(apiservice.NewRegistry())
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	srv := admin.New(b, admin.Tokens("secret"))
+	go srv.Serve(lis)
+	defer srv.Close()
+
+	for _, token := range []string{"", "badtoken"} {
+		client, _ := admin.NewClient("tcp://"+lis.Addr().String(), admin.SetToken(token))
+		_, err = client.Lists(context.Background(), false)
+		if err != admin.ErrUnauthorized {
+			t.Errorf("client.Lists(): token '%s' unexpected error %v", token, err)
+		}
+		client.Close()
+	}
+}
+
+func TestServer_Items(t *testing.T) {
+	dir, err := ioutil.TempDir("", "admin")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	b := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.DataDir(dir))
+	_, err = b.Build(xlistd.ListDef{ID: "dyn", Class: dynxl.ComponentClass, Resources: onlyIPv4})
+	if err != nil {
+		t.Fatalf("building dyn: %v", err)
+	}
+	if err := b.Start(); err != nil {
+		t.Fatalf("starting builder: %v", err)
+	}
+	defer b.Shutdown()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	srv := admin.New(b, admin.Tokens("secret"))
+	go srv.Serve(lis)
+	defer srv.Close()
+	client, _ := admin.NewClient("tcp://"+lis.Addr().String(), admin.SetToken("secret"))
+	defer client.Close()
+
+	ctx := context.Background()
+	var tests = []struct {
+		item    xlistd.Item
+		remove  bool
+		wantErr error
+	}{
+		{xlistd.Item{Resource: xlist.IPv4, Value: "10.10.10.10", Author: "test"}, false, nil},
+		{xlistd.Item{Resource: xlist.IPv4, Value: "10.10.10.11", Author: "test", Comment: "todelete"}, false, nil},
+		{xlistd.Item{Resource: xlist.IPv4, Value: "10.10.10.12"}, false, admin.ErrInvalidItem},
+		{xlistd.Item{Resource: xlist.IPv4, Value: "invalid", Author: "test"}, false, admin.ErrInvalidItem},
+		{xlistd.Item{Resource: xlist.Domain, Value: "www.google.com", Author: "test"}, false, admin.ErrResource},
+		{xlistd.Item{Resource: xlist.IPv4, Value: "10.10.10.11", Author: "test"}, true, nil},
+		{xlistd.Item{Resource: xlist.IPv4, Value: "10.10.10.11", Author: "test"}, true, admin.ErrItemNotFound},
+	}
+	for idx, test := range tests {
+		if test.remove {
+			err = client.RemoveItem(ctx, "dyn", test.item)
+		} else {
+			err = client.AddItem(ctx, "dyn", test.item)
+		}
+		if err != test.wantErr {
+			t.Errorf("idx[%v] unexpected error: want=%v got=%v", idx, test.wantErr, err)
+		}
+	}
+	items, err := client.Items(ctx, "dyn")
+	if err != nil {
+		t.Fatalf("getting items: %v", err)
+	}
+	if len(items) != 1 || items[0].Value != "10.10.10.10" || items[0].Author != "test" {
+		t.Errorf("unexpected items: %v", items)
+	}
+	list, _ := b.List("dyn")
+	resp, _ := list.Check(ctx, "10.10.10.10", xlist.IPv4)
+	if !resp.Result {
+		t.Error("added item not found in list")
+	}
+	_, err = client.Items(ctx, "notexists")
+	if err != admin.ErrNotFound {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestServer_ItemsAuthor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "admin")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	b := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.DataDir(dir))
+	_, err = b.Build(xlistd.ListDef{ID: "dyn", Class: dynxl.ComponentClass, Resources: onlyIPv4})
+	if err != nil {
+		t.Fatalf("building dyn: %v", err)
+	}
+	if err := b.Start(); err != nil {
+		t.Fatalf("starting builder: %v", err)
+	}
+	defer b.Shutdown()
+	a, err := authz.New([]authz.Rule{
+		{Name: "editor", Tokens: []string{"secret1"}, Ops: []string{admin.OpRead, admin.OpEdit}},
+	})
+	if err != nil {
+		t.Fatalf("creating authorizer: %v", err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	srv := admin.New(b, admin.SetAuthorizer(a))
+	go srv.Serve(lis)
+	defer srv.Close()
+	client, _ := admin.NewClient("tcp://"+lis.Addr().String(), admin.SetToken("secret1"))
+	defer client.Close()
+
+	// author defaults to the identity of the caller
+	ctx := context.Background()
+	err = client.AddItem(ctx, "dyn", xlistd.Item{Resource: xlist.IPv4, Value: "10.10.10.10"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = client.AddItem(ctx, "dyn", xlistd.Item{Resource: xlist.IPv4, Value: "10.10.10.11", Author: "test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	items, err := client.Items(ctx, "dyn")
+	if err != nil {
+		t.Fatalf("getting items: %v", err)
+	}
+	if len(items) != 2 || items[0].Author != "editor" || items[1].Author != "test" {
+		t.Errorf("unexpected items: %v", items)
+	}
+}
+
+func TestServer_Authorizer(t *testing.T) {
+	b := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.RuntimeControl(true))
+	for _, def := range testdatabase1 {
+		_, err := b.Build(def)
+		if err != nil {
+			t.Fatalf("building %s: %v", def.ID, err)
+		}
+	}
+	a, err := authz.New([]authz.Rule{
+		{Name: "operator", Tokens: []string{"secret1"}, Ops: []string{admin.OpRead, admin.OpFlush}},
+	})
+	if err != nil {
+		t.Fatalf("creating authorizer: %v", err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	srv := admin.New(b, admin.SetAuthorizer(a))
+	go srv.Serve(lis)
+	defer srv.Close()
+
+	ctx := context.Background()
+	client, _ := admin.NewClient("tcp://"+lis.Addr().String(), admin.SetToken("secret1"))
+	defer client.Close()
+	if _, err := client.Lists(ctx, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := client.Flush(ctx, "mock1"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := client.Disable(ctx, "mock1"); err != admin.ErrForbidden {
+		t.Errorf("unexpected error: %v", err)
+	}
+	client2, _ := admin.NewClient("tcp://"+lis.Addr().String(), admin.SetToken("bad"))
+	defer client2.Close()
+	if _, err := client2.Lists(ctx, false); err != admin.ErrUnauthorized {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// writeCert writes a self-signed certificate that can be used as its own CA
+func writeCert(t *testing.T, dir, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshalling key: %v", err)
+	}
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	return certFile, keyFile
+}
+
+func TestServer_AuthorizerTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "admin")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	srvCert, srvKey := writeCert(t, dir, "server")
+	opCert, opKey := writeCert(t, dir, "operator")
+	otherCert, otherKey := writeCert(t, dir, "other")
+	// client CA pool with both client certificates
+	ca := filepath.Join(dir, "ca.crt")
+	opData, _ := ioutil.ReadFile(opCert)
+	otherData, _ := ioutil.ReadFile(otherCert)
+	ioutil.WriteFile(ca, append(opData, otherData...), 0644)
+
+	b := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.RuntimeControl(true))
+	for _, def := range testdatabase1 {
+		_, err := b.Build(def)
+		if err != nil {
+			t.Fatalf("building %s: %v", def.ID, err)
+		}
+	}
+	a, err := authz.New([]authz.Rule{
+		{Name: "operator", Subjects: []string{"operator"}, Ops: []string{admin.OpRead}},
+	})
+	if err != nil {
+		t.Fatalf("creating authorizer: %v", err)
+	}
+	w, err := tlsreload.NewWatcher(srvCert, srvKey, ca, tlsreload.Interval(0))
+	if err != nil {
+		t.Fatalf("creating watcher: %v", err)
+	}
+	defer w.Close()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	srv := admin.New(b, admin.SetAuthorizer(a), admin.SetTLS(tlsreload.HTTPServerConfig(w, true)))
+	go srv.Serve(lis)
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	data, _ := ioutil.ReadFile(srvCert)
+	roots.AppendCertsFromPEM(data)
+	newClient := func(certFile, keyFile string) *admin.Client {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			t.Fatalf("loading key pair: %v", err)
+		}
+		client, err := admin.NewClient("tcp://"+lis.Addr().String(),
+			admin.SetClientTLS(&tls.Config{RootCAs: roots, Certificates: []tls.Certificate{cert}}))
+		if err != nil {
+			t.Fatalf("creating client: %v", err)
+		}
+		return client
+	}
+	ctx := context.Background()
+	client := newClient(opCert, opKey)
+	defer client.Close()
+	if _, err := client.Lists(ctx, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := client.Flush(ctx, "mock1"); err != admin.ErrForbidden {
+		t.Errorf("unexpected error: %v", err)
+	}
+	client2 := newClient(otherCert, otherKey)
+	defer client2.Close()
+	if _, err := client2.Lists(ctx, false); err != admin.ErrUnauthorized {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -21,6 +21,9 @@ type Builder struct {
 
 	services apiservice.Discover
 	lists    map[string]List
+	managed  map[string]*managedList
+	roots    []string
+	childs   map[string][]string
 
 	startup  []func() error
 	shutdown []func() error
@@ -40,6 +43,8 @@ type builderOpts struct {
 	certsDir string
 	dataDir  string
 	logger   yalogi.Logger
+	control  bool
+	tracing  bool
 }
 
 var defaultOptions = builderOpts{logger: yalogi.LogNull}
@@ -58,6 +63,22 @@ func CertsDir(s string) BuilderOption {
 	}
 }
 
+// RuntimeControl option allows enabling and disabling the lists at runtime.
+func RuntimeControl(b bool) BuilderOption {
+	return func(o *builderOpts) {
+		o.control = b
+	}
+}
+
+// Tracing option creates a span for each list and wrapper on checks. Spans
+// are children of the span stored in the context of the request, see
+// tracing package.
+func Tracing(b bool) BuilderOption {
+	return func(o *builderOpts) {
+		o.tracing = b
+	}
+}
+
 // SetLogger option sets a logger for the component.
 func SetLogger(l yalogi.Logger) BuilderOption {
 	return func(o *builderOpts) {
@@ -76,6 +97,9 @@ func NewBuilder(services apiservice.Discover, opt ...BuilderOption) *Builder {
 		logger:   opts.logger,
 		services: services,
 		lists:    make(map[string]List),
+		managed:  make(map[string]*managedList),
+		roots:    make([]string, 0),
+		childs:   make(map[string][]string),
 		startup:  make([]func() error, 0),
 		shutdown: make([]func() error, 0),
 	}
@@ -87,6 +111,48 @@ func (b *Builder) List(id string) (List, bool) {
 	return bl, ok
 }
 
+// Roots returns the ids of the lists that were built without parents.
+func (b *Builder) Roots() []string {
+	ret := make([]string, len(b.roots), len(b.roots))
+	copy(ret, b.roots)
+	return ret
+}
+
+// Childs returns the ids of the child lists of the list with the id passed.
+func (b *Builder) Childs(id string) []string {
+	childs := b.childs[id]
+	ret := make([]string, len(childs), len(childs))
+	copy(ret, childs)
+	return ret
+}
+
+// Enabled returns true if the list with the id passed is enabled.
+func (b *Builder) Enabled(id string) (bool, error) {
+	if _, ok := b.lists[id]; !ok {
+		return false, fmt.Errorf("list '%s' not found", id)
+	}
+	ml, ok := b.managed[id]
+	if !ok {
+		return true, nil
+	}
+	return ml.enabled(), nil
+}
+
+// SetEnabled enables or disables at runtime the list with the id passed.
+// A disabled list returns negative responses on checks and its ping is
+// always successful. Builder must be created with RuntimeControl option.
+func (b *Builder) SetEnabled(id string, enabled bool) error {
+	if !b.opts.control {
+		return errors.New("runtime control is not enabled")
+	}
+	ml, ok := b.managed[id]
+	if !ok {
+		return fmt.Errorf("list '%s' not found", id)
+	}
+	ml.setEnabled(enabled)
+	return nil
+}
+
 // Build creates a RBL using the metadata passed as param.
 func (b *Builder) Build(def ListDef) (List, error) {
 	return b.BuildChild(make([]string, 0), def)
@@ -135,8 +201,7 @@ func (b *Builder) BuildChild(parents []string, def ListDef) (List, error) {
 			resources: xlist.ClearResourceDups(def.Resources, true),
 		}
 		//register new created list
-		b.lists[def.ID] = bl
-		return bl, nil
+		return b.register(parents, def, bl), nil
 	}
 	// check if deprecated, prints a warning
 	if def.Deprecated {
@@ -157,6 +222,9 @@ func (b *Builder) BuildChild(parents []string, def ListDef) (List, error) {
 	if err != nil {
 		return nil, fmt.Errorf("building '%s': %v", def.ID, err)
 	}
+	if b.opts.tracing {
+		bl = traceList(bl)
+	}
 	// create with wrappers
 	if def.Wrappers != nil && len(def.Wrappers) > 0 {
 		for _, w := range def.Wrappers {
@@ -167,8 +235,28 @@ func (b *Builder) BuildChild(parents []string, def ListDef) (List, error) {
 		}
 	}
 	//register new created list
+	return b.register(parents, def, bl), nil
+}
+
+// register stores the list and its relations with other lists
+func (b *Builder) register(parents []string, def ListDef, bl List) List {
+	if b.opts.control {
+		ml := &managedList{list: bl}
+		b.managed[def.ID] = ml
+		bl = ml
+	}
 	b.lists[def.ID] = bl
-	return bl, nil
+	if len(parents) == 0 {
+		b.roots = append(b.roots, def.ID)
+	}
+	childs := make([]string, 0, len(def.Contains))
+	for _, c := range def.Contains {
+		if _, ok := b.lists[c.ID]; ok {
+			childs = append(childs, c.ID)
+		}
+	}
+	b.childs[def.ID] = childs
+	return bl
 }
 
 func (b *Builder) buildWrapper(def WrapperDef, bl List) (List, error) {
@@ -181,6 +269,9 @@ func (b *Builder) buildWrapper(def WrapperDef, bl List) (List, error) {
 	if err != nil {
 		return nil, err
 	}
+	if b.opts.tracing {
+		blc = traceWrapper(def.Class, blc)
+	}
 	return blc, nil
 }
 
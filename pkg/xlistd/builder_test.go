@@ -354,3 +354,64 @@ func TestBuilderWrapper(t *testing.T) {
 		}
 	}
 }
+
+func TestBuilderControl(t *testing.T) {
+	//register builders
+	xlistd.RegisterListBuilder("list", testBuilderList())
+	xlistd.RegisterListBuilder("comp", testBuilderCompo())
+
+	b := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.RuntimeControl(true))
+	for _, def := range testbuilder2 {
+		_, err := b.Build(def)
+		if err != nil {
+			t.Fatalf("creating lists: %v", err)
+		}
+	}
+	roots := b.Roots()
+	if len(roots) != 2 || roots[0] != "id-list1" || roots[1] != "id-list2" {
+		t.Errorf("unexpected roots: %v", roots)
+	}
+	childs := b.Childs("id-list2")
+	if len(childs) != 3 || childs[2] != "id-list5" {
+		t.Errorf("unexpected childs: %v", childs)
+	}
+	// disable child and check parent
+	err := b.SetEnabled("id-list6", false)
+	if err != nil {
+		t.Fatalf("disabling list: %v", err)
+	}
+	if enabled, _ := b.Enabled("id-list6"); enabled {
+		t.Error("list id-list6 enabled")
+	}
+	list6, _ := b.List("id-list6")
+	if got, err := list6.Check(context.Background(), "10.10.10.10", xlist.IPv4); err != nil || got.Result {
+		t.Errorf("unexpected check result with disabled list: %v %v", got, err)
+	}
+	if _, err := list6.Check(context.Background(), "www.google.com", xlist.Domain); err != xlist.ErrNotSupported {
+		t.Errorf("unexpected error with disabled list: %v", err)
+	}
+	list, _ := b.List("id-list2")
+	got, _ := list.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+	if got.Reason != "source list7" {
+		t.Errorf("unexpected check result with disabled list: %v", got.Reason)
+	}
+	err = b.SetEnabled("id-list6", true)
+	if err != nil {
+		t.Fatalf("enabling list: %v", err)
+	}
+	got, _ = list.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+	if got.Reason != "source list6" {
+		t.Errorf("unexpected check result with enabled list: %v", got.Reason)
+	}
+	if err := b.SetEnabled("id-notexists", false); err == nil {
+		t.Error("expected error disabling not existing list")
+	}
+	// without runtime control
+	b = xlistd.NewBuilder(apiservice.NewRegistry())
+	if _, err := b.Build(testbuilder2[0]); err != nil {
+		t.Fatalf("creating lists: %v", err)
+	}
+	if err := b.SetEnabled("id-list1", false); err == nil {
+		t.Error("expected error without runtime control")
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package conditionalxl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Builder returns a builder function. Childs are, in order, the condition
+// list, the then list and the optional else list.
+func Builder(defaultCfg Config) xlistd.BuildListFn {
+	return func(b *xlistd.Builder, parents []string, def xlistd.ListDef) (xlistd.List, error) {
+		cfg := defaultCfg
+		if len(def.Contains) != 2 && len(def.Contains) != 3 {
+			return nil, errors.New("number of childs must be 2 or 3")
+		}
+		childs := make([]xlistd.List, 3, 3)
+		for idx, sublist := range def.Contains {
+			child, err := b.BuildChild(append(parents, def.ID), sublist)
+			if err != nil {
+				return nil, fmt.Errorf("constructing child '%s': %v", sublist.ID, err)
+			}
+			childres, err := child.Resources(context.Background())
+			if err != nil {
+				return nil, fmt.Errorf("constructing child '%s': %v", sublist.ID, err)
+			}
+			for _, r := range def.Resources {
+				if !r.InArray(childres) {
+					return nil, fmt.Errorf("child '%s' doesn't checks resource '%s'", sublist.ID, r)
+				}
+			}
+			childs[idx] = child
+		}
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return New(def.ID, childs[0], childs[1], childs[2], def.Resources, cfg), nil
+	}
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	reason, ok, err := option.String(opts, "reason")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Reason = reason
+	}
+	negate, ok, err := option.Bool(opts, "negate")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Negate = negate
+	}
+	merge, ok, err := option.Bool(opts, "mergereason")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.MergeReason = merge
+	}
+	return dst, nil
+}
+
+func init() {
+	xlistd.RegisterListBuilder(ComponentClass, Builder(Config{}))
+}
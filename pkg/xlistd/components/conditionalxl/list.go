@@ -0,0 +1,145 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package conditionalxl provides a composite xlistd.List implementation
+// that uses the result of a condition list for selecting the child list
+// that answers.
+//
+// This package is a work in progress and makes no API stability promises.
+package conditionalxl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// ComponentClass registered.
+const ComponentClass = "conditional"
+
+// Config options.
+type Config struct {
+	// Negate the result of the condition.
+	Negate bool
+	// MergeReason prepends the reason of a positive condition to the
+	// reason of a positive response.
+	MergeReason     bool
+	ForceValidation bool
+	Reason          string
+}
+
+// List implements a composite list that checks the condition list and, if
+// the result is positive, returns the response of the then list. In other
+// case, it returns the response of the else list or a negative response if
+// there is no else list.
+type List struct {
+	id        string
+	cfg       Config
+	provides  []bool
+	resources []xlist.Resource
+
+	cond, then, els xlistd.List
+}
+
+// New constructs a new conditional list, else list is optional.
+func New(id string, cond, then, els xlistd.List, resources []xlist.Resource, cfg Config) *List {
+	l := &List{
+		id:        id,
+		cfg:       cfg,
+		cond:      cond,
+		then:      then,
+		els:       els,
+		resources: xlist.ClearResourceDups(resources, true),
+		provides:  make([]bool, len(xlist.Resources), len(xlist.Resources)),
+	}
+	//set resource types that provides
+	for _, r := range l.resources {
+		l.provides[int(r)] = true
+	}
+	return l
+}
+
+// ID implements xlistd.List interface.
+func (l *List) ID() string {
+	return l.id
+}
+
+// Class implements xlistd.List interface.
+func (l *List) Class() string {
+	return ComponentClass
+}
+
+// Check implements xlist.Checker interface.
+func (l *List) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	if !l.checks(resource) {
+		return xlist.Response{}, xlist.ErrNotSupported
+	}
+	name, ctx, err := xlist.DoValidation(ctx, name, resource, l.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	cond, err := l.cond.Check(ctx, name, resource)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	branch := l.els
+	if cond.Result != l.cfg.Negate {
+		branch = l.then
+	}
+	select {
+	case <-ctx.Done():
+		return xlist.Response{}, xlist.ErrCanceledRequest
+	default:
+	}
+	if branch == nil {
+		return xlist.Response{TTL: cond.TTL}, nil
+	}
+	resp, err := branch.Check(ctx, name, resource)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	// the response is valid only while the condition is valid
+	resp.TTL = xlistd.MinTTL(resp.TTL, cond.TTL)
+	if resp.Result {
+		if l.cfg.Reason != "" {
+			resp.Reason = l.cfg.Reason
+		} else if l.cfg.MergeReason && cond.Result && cond.Reason != "" {
+			resp.Reason = cond.Reason + ";" + resp.Reason
+		}
+	}
+	return resp, nil
+}
+
+// Resources implements xlist.Checker interface.
+func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	resources := make([]xlist.Resource, len(l.resources), len(l.resources))
+	copy(resources, l.resources)
+	return resources, nil
+}
+
+// Ping implements xlistd.List interface.
+func (l *List) Ping() error {
+	msgErr := make([]string, 0, 3)
+	for _, child := range []xlistd.List{l.cond, l.then, l.els} {
+		if child == nil {
+			continue
+		}
+		if err := child.Ping(); err != nil {
+			msgErr = append(msgErr, fmt.Sprintf("%s: %v", child.ID(), err))
+		}
+	}
+	if len(msgErr) > 0 {
+		return errors.New(strings.Join(msgErr, ";"))
+	}
+	return nil
+}
+
+func (l *List) checks(r xlist.Resource) bool {
+	if r.IsValid() {
+		return l.provides[int(r)]
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package conditionalxl_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/conditionalxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+)
+
+func TestList_Check(t *testing.T) {
+	ip4 := []xlist.Resource{xlist.IPv4}
+
+	condTrue := &mockxl.List{ResourceList: ip4, Results: []bool{true}, Reason: "cond"}
+	condFalse := &mockxl.List{ResourceList: ip4}
+	condFail := &mockxl.List{ResourceList: ip4, Fail: true}
+	thenTrue := &mockxl.List{ResourceList: ip4, Results: []bool{true}, Reason: "then"}
+	thenFalse := &mockxl.List{ResourceList: ip4}
+	elseTrue := &mockxl.List{ResourceList: ip4, Results: []bool{true}, Reason: "else"}
+	elseFail := &mockxl.List{ResourceList: ip4, Fail: true}
+
+	var tests = []struct {
+		cond, then, els xlistd.List
+		cfg             conditionalxl.Config
+
+		want       bool
+		wantReason string
+		wantErr    bool
+	}{
+		{condTrue, thenTrue, elseTrue, conditionalxl.Config{}, true, "then", false},                  //0
+		{condFalse, thenTrue, elseTrue, conditionalxl.Config{}, true, "else", false},                 //1
+		{condTrue, thenFalse, elseTrue, conditionalxl.Config{}, false, "", false},                    //2
+		{condFalse, thenTrue, nil, conditionalxl.Config{}, false, "", false},                         //3
+		{condTrue, thenTrue, elseTrue, conditionalxl.Config{Negate: true}, true, "else", false},      //4
+		{condFalse, thenTrue, elseTrue, conditionalxl.Config{Negate: true}, true, "then", false},     //5
+		{condTrue, thenTrue, nil, conditionalxl.Config{MergeReason: true}, true, "cond;then", false}, //6
+		{condFalse, thenTrue, nil, conditionalxl.Config{MergeReason: true, Negate: true}, true, "then", false},
+		{condTrue, thenTrue, nil, conditionalxl.Config{MergeReason: true, Reason: "hey"}, true, "hey", false},
+		{condTrue, thenTrue, elseFail, conditionalxl.Config{}, true, "then", false}, //9
+		{condFalse, thenTrue, elseFail, conditionalxl.Config{}, false, "", true},    //10
+		{condFail, thenTrue, elseTrue, conditionalxl.Config{}, false, "", true},     //11
+	}
+	for idx, test := range tests {
+		l := conditionalxl.New("test", test.cond, test.then, test.els, ip4, test.cfg)
+		resp, err := l.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+		if test.wantErr && err == nil {
+			t.Errorf("conditional.Check idx[%v] expected error", idx)
+		} else if !test.wantErr && err != nil {
+			t.Errorf("conditional.Check idx[%v] unexpected error: %v", idx, err)
+		}
+		if test.want != resp.Result {
+			t.Errorf("conditional.Check idx[%v] want=%v got=%v", idx, test.want, resp.Result)
+		}
+		if test.wantReason != resp.Reason {
+			t.Errorf("conditional.Check idx[%v] reason want=%v got=%v", idx, test.wantReason, resp.Reason)
+		}
+	}
+}
+
+func ExampleList() {
+	ip4 := []xlist.Resource{xlist.IPv4}
+
+	// condition list returns true, false, true...
+	cond := &mockxl.List{ResourceList: ip4, Results: []bool{true, false}, Reason: "outside eu"}
+	strict := &mockxl.List{ResourceList: ip4, Results: []bool{true}, Reason: "strict"}
+	lenient := &mockxl.List{ResourceList: ip4, Results: []bool{false}}
+
+	rbl := conditionalxl.New("test", cond, strict, lenient, ip4, conditionalxl.Config{MergeReason: true})
+	for i := 0; i < 2; i++ {
+		resp, _ := rbl.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+		fmt.Println(resp.Result, resp.Reason)
+	}
+
+	// Output:
+	// true outside eu;strict
+	// false
+}
@@ -19,6 +19,11 @@ func DefaultResolver(resolver Resolver) {
 	defaultResolver = resolver
 }
 
+//GetDefaultResolver returns the default resolver
+func GetDefaultResolver() Resolver {
+	return defaultResolver
+}
+
 //Resolver is an interface for dns resolvers
 type Resolver interface {
 	Resolver() string
@@ -0,0 +1,187 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package dynxl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/authz"
+	"github.com/luids-io/xlist/pkg/tlsreload"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/replication"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildListFn {
+	return func(b *xlistd.Builder, parents []string, def xlistd.ListDef) (xlistd.List, error) {
+		cfg := defaultCfg
+		if def.Source == "" {
+			def.Source = fmt.Sprintf("%s.journal", def.ID)
+		}
+		journal := b.DataPath(def.Source)
+		if !dirExists(filepath.Dir(journal)) {
+			return nil, fmt.Errorf("dir '%s' doesn't exists", filepath.Dir(journal))
+		}
+		repl := replCfg{remote: def.ID}
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+			repl, err = parseReplOptions(repl, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if repl.leader != "" {
+			cfg.ReadOnly = true
+		}
+
+		bl := New(def.ID, journal, def.Resources, cfg, b.Logger())
+		if repl.leader != "" {
+			clientCfg := def.ClientCfg()
+			if err := clientCfg.Validate(); err != nil {
+				return nil, fmt.Errorf("bad TLS config: %v", err)
+			}
+			var dopts []grpc.DialOption
+			if repl.token != "" {
+				if !clientCfg.UseTLS() {
+					return nil, errors.New("'token' requires tls")
+				}
+				dopts = append(dopts, grpc.WithPerRPCCredentials(authz.TokenCredentials(repl.token)))
+			}
+			dial, watcher, err := tlsreload.Dial(repl.leader, clientCfg,
+				[]tlsreload.Option{tlsreload.SetLogger(b.Logger())}, dopts...)
+			if err != nil {
+				return nil, fmt.Errorf("dialing leader: %v", err)
+			}
+			bl.leader = repl.leader
+			bl.follower = replication.NewFollower(def.ID, dial, repl.remote, bl,
+				replication.SetFollowerLogger(b.Logger()),
+				replication.OnChange(func() { flush(b, def.ID) }))
+			b.OnShutdown(func() error {
+				if watcher != nil {
+					watcher.Close()
+				}
+				return dial.Close()
+			})
+		}
+		//register startup
+		b.OnStartup(func() error {
+			err := bl.Open()
+			if err != nil || bl.follower == nil {
+				return err
+			}
+			return bl.follower.Start()
+		})
+		//register shutdown
+		b.OnShutdown(func() error {
+			if bl.follower != nil {
+				bl.follower.Close()
+			}
+			return bl.Close()
+		})
+
+		return bl, nil
+	}
+}
+
+// flush caches of the wrappers of the list
+func flush(b *xlistd.Builder, id string) {
+	list, ok := b.List(id)
+	if !ok {
+		return
+	}
+	for _, l := range xlistd.Chain(list) {
+		if fl, ok := l.(xlistd.Flusher); ok {
+			fl.Flush()
+		}
+	}
+}
+
+func dirExists(dirname string) bool {
+	info, err := os.Stat(dirname)
+	if err != nil {
+		return false
+	}
+	return info.IsDir()
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	reason, ok, err := option.String(opts, "reason")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Reason = reason
+	}
+
+	compact, ok, err := option.Bool(opts, "compact")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Compact = compact
+	}
+
+	logsize, ok, err := option.Int(opts, "logsize")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if logsize <= 0 {
+			return dst, fmt.Errorf("invalid 'logsize'")
+		}
+		dst.LogSize = logsize
+	}
+
+	return dst, nil
+}
+
+// replCfg stores replication options
+type replCfg struct {
+	leader string
+	remote string
+	token  string
+}
+
+func parseReplOptions(src replCfg, opts map[string]interface{}) (replCfg, error) {
+	dst := src
+	leader, ok, err := option.String(opts, "leader")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.leader = leader
+	}
+
+	remote, ok, err := option.String(opts, "remote")
+	if err != nil {
+		return dst, err
+	}
+	if ok && remote != "" {
+		dst.remote = remote
+	}
+
+	token, ok, err := option.String(opts, "token")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.token = token
+	}
+
+	return dst, nil
+}
+
+func init() {
+	xlistd.RegisterListBuilder(ComponentClass, Builder(DefaultConfig()))
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package dynxl_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/dynxl"
+)
+
+var testdatabase1 = []xlistd.ListDef{
+	{ID: "list1",
+		Class:     dynxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4}},
+	{ID: "list2",
+		Class:     dynxl.ComponentClass,
+		Source:    "nonexistent/list2.journal",
+		Resources: []xlist.Resource{xlist.IPv4}},
+	{ID: "list3",
+		Class:     dynxl.ComponentClass,
+		Resources: xlist.Resources,
+		Opts:      map[string]interface{}{"reason": "aaaa", "compact": false}},
+	{ID: "list4",
+		Class:     dynxl.ComponentClass,
+		Resources: xlist.Resources,
+		Opts:      map[string]interface{}{"compact": 10}},
+	{ID: "list5",
+		Class:     dynxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4},
+		Opts:      map[string]interface{}{"leader": "tcp://127.0.0.1:5801", "token": "secret"}},
+}
+
+func TestBuild(t *testing.T) {
+	b := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.DataDir("../../../../test/testdata"))
+	//define and do tests
+	var tests = []struct {
+		listid  string
+		wantErr string
+	}{
+		{"list1", ""},
+		{"list2", "doesn't exists"},
+		{"list3", ""},
+		{"list4", "invalid 'compact'"},
+		{"list5", "'token' requires tls"},
+	}
+	for _, test := range tests {
+		def, ok := xlistd.FilterID(test.listid, testdatabase1)
+		if !ok {
+			t.Errorf("can't find id %s in database tests", test.listid)
+			continue
+		}
+		_, err := b.Build(def)
+		switch {
+		case test.wantErr == "" && err == nil:
+			//
+		case test.wantErr == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("expected error for %s", test.listid)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package dynxl
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/replication"
+)
+
+// Operations stored in the journal.
+const (
+	OpAdd    = replication.OpAdd
+	OpRemove = replication.OpRemove
+	// OpMark records the last offset of the mutation log, so it is kept
+	// when the journal is compacted.
+	OpMark = "mark"
+)
+
+// Entry is a mutation stored in the journal. Seq is the offset of the entry
+// in the mutation log.
+type Entry struct {
+	Seq uint64 `json:"seq,omitempty"`
+	Op  string `json:"op"`
+	xlistd.Item
+}
+
+// ReadJournal reads all entries from the reader. If the last line is
+// truncated, it is ignored and truncated is returned as true.
+func ReadJournal(in io.Reader) (entries []Entry, truncated bool, err error) {
+	entries = make([]Entry, 0)
+	nline := 0
+	var lastErr error
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		nline++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if lastErr != nil {
+			return nil, false, lastErr
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			lastErr = fmt.Errorf("line %v: %v", nline, err)
+			continue
+		}
+		if e.Op != OpAdd && e.Op != OpRemove && e.Op != OpMark {
+			return nil, false, fmt.Errorf("line %v: invalid op '%s'", nline, e.Op)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, fmt.Errorf("scanning journal: %v", err)
+	}
+	return entries, lastErr != nil, nil
+}
+
+// WriteJournal writes atomically to filename the entries passed.
+func WriteJournal(filename string, entries []Entry) error {
+	tmp, err := os.Create(filepath.Join(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp"))
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err = enc.Encode(e); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// canonical returns the item with its value in canonical form and a key
+// that identifies it.
+func canonical(item xlistd.Item) (xlistd.Item, string, error) {
+	switch item.Format {
+	case xlistd.Plain, xlistd.Sub:
+		if item.Format == xlistd.Sub && item.Resource != xlist.Domain {
+			return item, "", xlist.ErrBadRequest
+		}
+		value, ok := xlist.Canonicalize(item.Value, item.Resource)
+		if !ok {
+			return item, "", xlist.ErrBadRequest
+		}
+		item.Value = value
+	case xlistd.CIDR:
+		if item.Resource != xlist.IPv4 && item.Resource != xlist.IPv6 {
+			return item, "", xlist.ErrBadRequest
+		}
+		ip, ipnet, err := net.ParseCIDR(item.Value)
+		if err != nil {
+			return item, "", xlist.ErrBadRequest
+		}
+		if (ip.To4() != nil) != (item.Resource == xlist.IPv4) {
+			return item, "", xlist.ErrBadRequest
+		}
+		item.Value = ipnet.String()
+	default:
+		return item, "", xlist.ErrBadRequest
+	}
+	return item, fmt.Sprintf("%v,%v,%s", item.Resource, item.Format, item.Value), nil
+}
+
+// newer returns true if entry a prevails over b
+func newer(a, b Entry) bool {
+	return replication.Newer(replication.Entry(a), replication.Entry(b))
+}
+
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Seq < entries[j].Seq
+	})
+}
+
+func sortItems(items []xlistd.Item) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Timestamp.Equal(items[j].Timestamp) {
+			return items[i].Value < items[j].Value
+		}
+		return items[i].Timestamp.Before(items[j].Timestamp)
+	})
+}
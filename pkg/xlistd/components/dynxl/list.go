@@ -0,0 +1,581 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package dynxl provides a xlistd.List implementation that can be modified
+// at runtime. All mutations are recorded in an append-only journal that is
+// replayed and compacted on startup. Lists can be replicated between
+// instances using the replication package.
+//
+// This package is a work in progress and makes no API stability promises.
+package dynxl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/memxl"
+	"github.com/luids-io/xlist/pkg/xlistd/replication"
+)
+
+// ComponentClass registered.
+const ComponentClass = "dynamic"
+
+// DefaultConfig returns default configuration.
+func DefaultConfig() Config {
+	return Config{Compact: true, LogSize: 10000}
+}
+
+// Config options.
+type Config struct {
+	ForceValidation bool
+	Reason          string
+	Compact         bool
+	// ReadOnly disables local mutations, it's used by followers.
+	ReadOnly bool
+	// LogSize is the number of mutations kept in memory for replication.
+	LogSize int
+}
+
+// List stores items in memory and records mutations in a journal file.
+type List struct {
+	id        string
+	cfg       Config
+	logger    yalogi.Logger
+	journal   string
+	resources []xlist.Resource
+	provides  []bool
+
+	mu       sync.RWMutex
+	list     *memxl.List
+	items    map[string]Entry
+	tombs    map[string]Entry
+	seq      uint64
+	logStart uint64
+	log      []Entry
+	changes  chan struct{}
+	file     *os.File
+	started  bool
+	follower *replication.Follower
+	leader   string
+}
+
+// New creates a new List with the journal file passed.
+func New(id, journal string, resources []xlist.Resource, cfg Config, logger yalogi.Logger) *List {
+	l := &List{
+		id:        id,
+		journal:   journal,
+		cfg:       cfg,
+		logger:    logger,
+		resources: xlist.ClearResourceDups(resources, true),
+		provides:  make([]bool, len(xlist.Resources), len(xlist.Resources)),
+		items:     make(map[string]Entry),
+		tombs:     make(map[string]Entry),
+		changes:   make(chan struct{}),
+	}
+	for _, r := range l.resources {
+		l.provides[int(r)] = true
+	}
+	l.list = memxl.New(l.id, l.resources,
+		memxl.Config{
+			ForceValidation: l.cfg.ForceValidation,
+			Reason:          l.cfg.Reason,
+		})
+	if l.logger == nil {
+		l.logger = yalogi.LogNull
+	}
+	return l
+}
+
+// ID implements xlistd.List interface.
+func (l *List) ID() string {
+	return l.id
+}
+
+// Class implements xlistd.List interface.
+func (l *List) Class() string {
+	return ComponentClass
+}
+
+// Check implements xlist.Checker interface.
+func (l *List) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if !l.started {
+		return xlist.Response{}, xlist.ErrUnavailable
+	}
+	return l.list.Check(ctx, name, resource)
+}
+
+// Resources implements xlist.Checker interface.
+func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	resources := make([]xlist.Resource, len(l.resources), len(l.resources))
+	copy(resources, l.resources)
+	return resources, nil
+}
+
+// Ping implements xlistd.List interface.
+func (l *List) Ping() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if !l.started {
+		return errors.New("list is closed")
+	}
+	return nil
+}
+
+// Open replays the journal, compacts it if configured and opens it for
+// appending new mutations.
+func (l *List) Open() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.started {
+		return errors.New("list is already opened")
+	}
+	l.logger.Debugf("%s: opening journal '%s'", l.id, l.journal)
+	err := l.replay()
+	if err != nil {
+		return err
+	}
+	if l.cfg.Compact {
+		err = WriteJournal(l.journal, compacted(l.sortedEntries(false), l.seq))
+		if err != nil {
+			return fmt.Errorf("compacting journal: %v", err)
+		}
+	}
+	l.file, err = os.OpenFile(l.journal, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening journal: %v", err)
+	}
+	l.logStart = l.seq
+	l.started = true
+	return nil
+}
+
+// Close closes the journal and releases memory.
+func (l *List) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.started {
+		return nil
+	}
+	l.logger.Debugf("%s: closing journal '%s'", l.id, l.journal)
+	l.started = false
+	l.list.Clear()
+	l.items = make(map[string]Entry)
+	l.tombs = make(map[string]Entry)
+	l.seq, l.logStart, l.log = 0, 0, nil
+	return l.file.Close()
+}
+
+// Add implements xlistd.Editor interface.
+func (l *List) Add(ctx context.Context, item xlistd.Item) error {
+	return l.apply(OpAdd, item)
+}
+
+// Remove implements xlistd.Editor interface.
+func (l *List) Remove(ctx context.Context, item xlistd.Item) error {
+	return l.apply(OpRemove, item)
+}
+
+// Items implements xlistd.Editor interface.
+func (l *List) Items(ctx context.Context) ([]xlistd.Item, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if !l.started {
+		return nil, xlist.ErrUnavailable
+	}
+	items := make([]xlistd.Item, 0, len(l.items))
+	for _, e := range l.items {
+		items = append(items, e.Item)
+	}
+	sortItems(items)
+	return items, nil
+}
+
+// Inspect implements xlistd.Inspector interface.
+func (l *List) Inspect() map[string]interface{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	info := map[string]interface{}{
+		"journal": l.journal,
+		"items":   len(l.items),
+		"offset":  l.seq,
+	}
+	if l.follower != nil {
+		connected, err := l.follower.Status()
+		info["leader"] = l.leader
+		info["connected"] = connected
+		if err != nil {
+			info["error"] = err.Error()
+		}
+	}
+	return info
+}
+
+// Snapshot implements replication.Source interface.
+func (l *List) Snapshot() ([]replication.Entry, uint64) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	entries := l.sortedEntries(true)
+	snapshot := make([]replication.Entry, 0, len(entries))
+	for _, e := range entries {
+		snapshot = append(snapshot, replication.Entry(e))
+	}
+	return snapshot, l.seq
+}
+
+// Since implements replication.Source interface.
+func (l *List) Since(offset uint64) ([]replication.Entry, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if !l.started {
+		return nil, true
+	}
+	if offset < l.logStart || offset > l.seq {
+		return nil, false
+	}
+	idx := sort.Search(len(l.log), func(i int) bool { return l.log[i].Seq > offset })
+	entries := make([]replication.Entry, 0, len(l.log)-idx)
+	for _, e := range l.log[idx:] {
+		entries = append(entries, replication.Entry(e))
+	}
+	return entries, true
+}
+
+// Changes implements replication.Source interface.
+func (l *List) Changes() <-chan struct{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.changes
+}
+
+// Offset implements replication.Sink interface.
+func (l *List) Offset() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.seq
+}
+
+// Replicate implements replication.Sink interface.
+func (l *List) Replicate(entries []replication.Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.started {
+		return xlist.ErrUnavailable
+	}
+	applied := make([]Entry, 0, len(entries))
+	keys := make([]string, 0, len(entries))
+	pending := make(map[string]Entry)
+	for _, re := range entries {
+		e, key, ok := l.replicated(Entry(re))
+		if !ok {
+			continue
+		}
+		cur, ok := pending[key]
+		if !ok {
+			cur, ok = l.current(key)
+		}
+		if ok && !newer(e, cur) {
+			continue
+		}
+		pending[key] = e
+		applied = append(applied, e)
+		keys = append(keys, key)
+	}
+	// skipped entries advance the offset, so it is marked in the journal
+	var last, lastApplied uint64
+	for _, re := range entries {
+		if re.Seq > last {
+			last = re.Seq
+		}
+	}
+	for _, e := range applied {
+		if e.Seq > lastApplied {
+			lastApplied = e.Seq
+		}
+	}
+	written := applied
+	if last > l.seq && last > lastApplied {
+		written = append(written[:len(written):len(written)], Entry{Seq: last, Op: OpMark})
+	}
+	if err := l.write(written); err != nil {
+		return err
+	}
+	for i, e := range applied {
+		if err := l.update(keys[i], e); err != nil {
+			return err
+		}
+	}
+	for _, re := range entries {
+		if re.Seq > l.seq {
+			l.record(Entry(re))
+		}
+	}
+	return nil
+}
+
+// Restore implements replication.Sink interface.
+func (l *List) Restore(entries []replication.Entry, offset uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.started {
+		return xlist.ErrUnavailable
+	}
+	snapshot := make([]Entry, 0, len(entries))
+	for _, re := range entries {
+		if e, _, ok := l.replicated(Entry(re)); ok {
+			snapshot = append(snapshot, e)
+		}
+	}
+	sortEntries(snapshot)
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("closing journal: %v", err)
+	}
+	// journal is replaced atomically, on errors the previous one is reopened
+	werr := WriteJournal(l.journal, compacted(snapshot, offset))
+	var err error
+	l.file, err = os.OpenFile(l.journal, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		l.started = false
+		return fmt.Errorf("opening journal: %v", err)
+	}
+	if werr != nil {
+		return fmt.Errorf("writing journal: %v", werr)
+	}
+	l.list.Clear()
+	l.items = make(map[string]Entry)
+	l.tombs = make(map[string]Entry)
+	for _, e := range snapshot {
+		_, key, _ := canonical(e.Item)
+		if err := l.update(key, e); err != nil {
+			return err
+		}
+	}
+	l.seq, l.logStart, l.log = offset, offset, nil
+	close(l.changes)
+	l.changes = make(chan struct{})
+	return nil
+}
+
+func (l *List) apply(op string, item xlistd.Item) error {
+	if l.cfg.ReadOnly {
+		return xlistd.ErrReadOnly
+	}
+	if !l.checks(item.Resource) {
+		return xlist.ErrNotSupported
+	}
+	item, key, err := canonical(item)
+	if err != nil {
+		return err
+	}
+	if item.Timestamp.IsZero() {
+		item.Timestamp = time.Now()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.started {
+		return xlist.ErrUnavailable
+	}
+	if _, ok := l.items[key]; op == OpRemove && !ok {
+		return xlistd.ErrItemNotFound
+	}
+	e := Entry{Seq: l.seq + 1, Op: op, Item: item}
+	if cur, ok := l.current(key); ok && !newer(e, cur) {
+		l.logger.Debugf("%s: ignoring outdated %s %v,%v,%s", l.id, op, item.Resource, item.Format, item.Value)
+		return nil
+	}
+	//first write to journal
+	if err := l.write([]Entry{e}); err != nil {
+		return err
+	}
+	l.logger.Infof("%s: %s %v,%v,%s (author='%s' comment='%s')",
+		l.id, op, item.Resource, item.Format, item.Value, item.Author, item.Comment)
+	if err := l.update(key, e); err != nil {
+		return err
+	}
+	l.record(e)
+	return nil
+}
+
+// write entries to journal, warning! no lock
+func (l *List) write(entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	data := make([]byte, 0)
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+	if _, err := l.file.Write(data); err != nil {
+		return fmt.Errorf("writing journal: %v", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("writing journal: %v", err)
+	}
+	return nil
+}
+
+// record entry in the mutation log and notify changes, warning! no lock
+func (l *List) record(e Entry) {
+	l.seq = e.Seq
+	l.log = append(l.log, e)
+	if l.cfg.LogSize > 0 && len(l.log) > l.cfg.LogSize {
+		drop := len(l.log) - l.cfg.LogSize
+		l.logStart = l.log[drop-1].Seq
+		l.log = append([]Entry(nil), l.log[drop:]...)
+	}
+	close(l.changes)
+	l.changes = make(chan struct{})
+}
+
+// replicated returns the canonical entry received from a leader, warning! no lock
+func (l *List) replicated(e Entry) (Entry, string, bool) {
+	if (e.Op != OpAdd && e.Op != OpRemove) || !l.checks(e.Resource) {
+		l.logger.Warnf("%s: ignoring replicated entry %v '%s %v,%v,%s'", l.id, e.Seq, e.Op, e.Resource, e.Format, e.Value)
+		return e, "", false
+	}
+	item, key, err := canonical(e.Item)
+	if err != nil {
+		l.logger.Warnf("%s: ignoring replicated entry %v '%s %v,%v,%s'", l.id, e.Seq, e.Op, e.Resource, e.Format, e.Value)
+		return e, "", false
+	}
+	e.Item = item
+	return e, key, true
+}
+
+// current returns the last entry applied for the key, warning! no lock
+func (l *List) current(key string) (Entry, bool) {
+	if e, ok := l.items[key]; ok {
+		return e, true
+	}
+	e, ok := l.tombs[key]
+	return e, ok
+}
+
+// replay reads journal and loads items, warning! no lock
+func (l *List) replay() error {
+	file, err := os.Open(l.journal)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("opening journal: %v", err)
+	}
+	defer file.Close()
+	entries, truncated, err := ReadJournal(file)
+	if err != nil {
+		return fmt.Errorf("reading journal: %v", err)
+	}
+	if truncated {
+		l.logger.Warnf("%s: journal '%s' has a truncated last entry", l.id, l.journal)
+	}
+	for _, e := range entries {
+		if e.Op == OpMark {
+			if e.Seq > l.seq {
+				l.seq = e.Seq
+			}
+			continue
+		}
+		if e.Seq == 0 {
+			e.Seq = l.seq + 1
+		}
+		if e.Seq > l.seq {
+			l.seq = e.Seq
+		}
+		if !l.checks(e.Resource) {
+			l.logger.Warnf("%s: ignoring journal entry '%v,%v,%s': resource not supported",
+				l.id, e.Resource, e.Format, e.Value)
+			continue
+		}
+		item, key, err := canonical(e.Item)
+		if err != nil {
+			return fmt.Errorf("invalid journal entry '%v,%v,%s'", e.Resource, e.Format, e.Value)
+		}
+		e.Item = item
+		if err := l.update(key, e); err != nil {
+			return fmt.Errorf("applying journal entry '%v,%v,%s': %v", e.Resource, e.Format, e.Value, err)
+		}
+	}
+	// tombstones are only required for resolving conflicts of the log
+	l.tombs = make(map[string]Entry)
+	return nil
+}
+
+// update memory list, warning! no lock
+func (l *List) update(key string, e Entry) error {
+	ctx := context.Background()
+	item := e.Item
+	if e.Op == OpAdd {
+		if err := l.list.Append(ctx, item.Value, item.Resource, item.Format); err != nil {
+			return err
+		}
+		l.items[key] = e
+		delete(l.tombs, key)
+		return nil
+	}
+	l.tombs[key] = e
+	if _, ok := l.items[key]; !ok {
+		return nil
+	}
+	if err := l.list.Remove(ctx, item.Value, item.Resource, item.Format); err != nil {
+		return err
+	}
+	delete(l.items, key)
+	//domains and subdomains share storage in memxl
+	if item.Resource == xlist.Domain {
+		for _, f := range []xlistd.Format{xlistd.Plain, xlistd.Sub} {
+			other := item
+			other.Format = f
+			if _, ok := l.items[fmt.Sprintf("%v,%v,%s", other.Resource, f, other.Value)]; ok {
+				return l.list.Append(ctx, other.Value, other.Resource, f)
+			}
+		}
+	}
+	return nil
+}
+
+// compacted returns the sorted entries prefixed with a mark of the offset if
+// it was recorded by a dropped entry, so the offset never goes backwards
+func compacted(entries []Entry, offset uint64) []Entry {
+	if offset == 0 || (len(entries) > 0 && entries[len(entries)-1].Seq >= offset) {
+		return entries
+	}
+	marked := make([]Entry, 0, len(entries)+1)
+	marked = append(marked, Entry{Seq: offset, Op: OpMark})
+	return append(marked, entries...)
+}
+
+// sortedEntries returns items and optionally tombstones, warning! no lock
+func (l *List) sortedEntries(tombs bool) []Entry {
+	entries := make([]Entry, 0, len(l.items)+len(l.tombs))
+	for _, e := range l.items {
+		entries = append(entries, e)
+	}
+	if tombs {
+		for _, e := range l.tombs {
+			entries = append(entries, e)
+		}
+	}
+	sortEntries(entries)
+	return entries
+}
+
+func (l *List) checks(r xlist.Resource) bool {
+	if r >= xlist.IPv4 && r <= xlist.SHA256 {
+		return l.provides[int(r)]
+	}
+	return false
+}
@@ -0,0 +1,311 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package dynxl_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/dynxl"
+	"github.com/luids-io/xlist/pkg/xlistd/replication"
+)
+
+func TestList_Edit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynxl")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	journal := filepath.Join(dir, "test.journal")
+
+	list := dynxl.New("test1", journal, []xlist.Resource{xlist.IPv4, xlist.Domain},
+		dynxl.DefaultConfig(), yalogi.LogNull)
+	err = list.Open()
+	if err != nil {
+		t.Fatalf("dynxl.Open(): err=%v", err)
+	}
+	ctx := context.Background()
+	var adds = []struct {
+		item    xlistd.Item
+		wantErr error
+	}{
+		{xlistd.Item{Resource: xlist.IPv4, Format: xlistd.Plain, Value: "10.10.10.10", Author: "test"}, nil},
+		{xlistd.Item{Resource: xlist.IPv4, Format: xlistd.CIDR, Value: "192.168.1.0/24", Author: "test"}, nil},
+		{xlistd.Item{Resource: xlist.Domain, Format: xlistd.Sub, Value: "example.com", Author: "test"}, nil},
+		{xlistd.Item{Resource: xlist.Domain, Format: xlistd.Plain, Value: "www.example.org", Author: "test"}, nil},
+		{xlistd.Item{Resource: xlist.Domain, Format: xlistd.Plain, Value: "todelete.org", Author: "test"}, nil},
+		{xlistd.Item{Resource: xlist.IPv6, Format: xlistd.Plain, Value: "fe80::1", Author: "test"}, xlist.ErrNotSupported},
+		{xlistd.Item{Resource: xlist.IPv4, Format: xlistd.Sub, Value: "10.10.10.10", Author: "test"}, xlist.ErrBadRequest},
+		{xlistd.Item{Resource: xlist.IPv4, Format: xlistd.Plain, Value: "invalid", Author: "test"}, xlist.ErrBadRequest},
+	}
+	for idx, test := range adds {
+		err := list.Add(ctx, test.item)
+		if err != test.wantErr {
+			t.Errorf("idx[%v] dynxl.Add(): want=%v got=%v", idx, test.wantErr, err)
+		}
+	}
+	err = list.Remove(ctx, xlistd.Item{Resource: xlist.Domain, Format: xlistd.Plain, Value: "todelete.org", Author: "test"})
+	if err != nil {
+		t.Errorf("dynxl.Remove(): err=%v", err)
+	}
+	err = list.Remove(ctx, xlistd.Item{Resource: xlist.Domain, Format: xlistd.Plain, Value: "notexists.org", Author: "test"})
+	if err != xlistd.ErrItemNotFound {
+		t.Errorf("dynxl.Remove(): unexpected err=%v", err)
+	}
+
+	var tests = []struct {
+		name     string
+		resource xlist.Resource
+		want     bool
+	}{
+		{"10.10.10.10", xlist.IPv4, true},
+		{"10.10.10.11", xlist.IPv4, false},
+		{"192.168.1.20", xlist.IPv4, true},
+		{"www.example.com", xlist.Domain, true},
+		{"www.example.org", xlist.Domain, true},
+		{"todelete.org", xlist.Domain, false},
+	}
+	check := func(list *dynxl.List) {
+		for idx, test := range tests {
+			got, err := list.Check(ctx, test.name, test.resource)
+			if err != nil {
+				t.Errorf("idx[%v] dynxl.Check(): err=%v", idx, err)
+			}
+			if got.Result != test.want {
+				t.Errorf("idx[%v] dynxl.Check(): want=%v got=%v", idx, test.want, got)
+			}
+		}
+	}
+	check(list)
+	items, _ := list.Items(ctx)
+	if len(items) != 4 {
+		t.Errorf("dynxl.Items(): unexpected items %v", items)
+	}
+	list.Close()
+
+	// replay and compact
+	data, _ := ioutil.ReadFile(journal)
+	if got := strings.Count(string(data), "\n"); got != 6 {
+		t.Errorf("unexpected journal lines before compaction: %v", got)
+	}
+	list = dynxl.New("test1", journal, []xlist.Resource{xlist.IPv4, xlist.Domain},
+		dynxl.DefaultConfig(), yalogi.LogNull)
+	err = list.Open()
+	if err != nil {
+		t.Fatalf("dynxl.Open(): err=%v", err)
+	}
+	defer list.Close()
+	check(list)
+	items, _ = list.Items(ctx)
+	if len(items) != 4 || items[0].Author != "test" || items[0].Timestamp.IsZero() {
+		t.Errorf("dynxl.Items(): unexpected items after replay %v", items)
+	}
+	// items and the mark of the offset of the last remove
+	data, _ = ioutil.ReadFile(journal)
+	if got := strings.Count(string(data), "\n"); got != 5 {
+		t.Errorf("unexpected journal lines after compaction: %v", got)
+	}
+}
+
+func TestList_Replay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynxl")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	journal := filepath.Join(dir, "test.journal")
+
+	var tests = []struct {
+		content string
+		wantErr string
+		want    int
+	}{
+		{``, "", 0},
+		{`{"op":"add","resource":"ip4","format":"plain","value":"10.10.10.10","timestamp":"2020-01-01T00:00:00Z"}
+{"op":"add","resource":"md5","format":"plain","value":"d41d8cd98f00b204e9800998ecf8427e","timestamp":"2020-01-01T00:00:00Z"}
+{"op":"add","resource":"ip4","format":"plain","value":"10.10.10.1`, "", 1},
+		{`{"op":"add","resource":"ip4","format":"plain","value":"10.10.10.10","timestamp":"2020-01-01T00:00:00Z"}
+{"op":"remove","resource":"ip4","format":"plain","value":"10.10.10.10","timestamp":"2020-01-01T00:00:01Z"}`, "", 0},
+		{`{"op":"add","resource":"ip4","format":"plain","value":"10.10.10.1
+{"op":"add","resource":"ip4","format":"plain","value":"10.10.10.10","timestamp":"2020-01-01T00:00:00Z"}`, "line 1", 0},
+		{`{"op":"xxx","resource":"ip4","format":"plain","value":"10.10.10.10","timestamp":"2020-01-01T00:00:00Z"}`, "invalid op", 0},
+		{`{"op":"add","resource":"ip4","format":"plain","value":"invalid","timestamp":"2020-01-01T00:00:00Z"}`, "invalid journal entry", 0},
+	}
+	for idx, test := range tests {
+		err := ioutil.WriteFile(journal, []byte(test.content), 0644)
+		if err != nil {
+			t.Fatalf("writing journal: %v", err)
+		}
+		list := dynxl.New("test1", journal, []xlist.Resource{xlist.IPv4},
+			dynxl.DefaultConfig(), yalogi.LogNull)
+		err = list.Open()
+		switch {
+		case test.wantErr == "" && err != nil:
+			t.Errorf("idx[%v] dynxl.Open(): unexpected err=%v", idx, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("idx[%v] dynxl.Open(): expected error", idx)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("idx[%v] dynxl.Open(): unexpected err=%v", idx, err)
+		}
+		if err != nil {
+			continue
+		}
+		items, _ := list.Items(context.Background())
+		if len(items) != test.want {
+			t.Errorf("idx[%v] dynxl.Items(): want=%v got=%v", idx, test.want, len(items))
+		}
+		list.Close()
+	}
+}
+
+func TestList_Conflicts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynxl")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(seq uint64, op, value string, secs int, author string) replication.Entry {
+		return replication.Entry{Seq: seq, Op: op, Item: xlistd.Item{Resource: xlist.IPv4, Format: xlistd.Plain,
+			Value: value, Author: author, Timestamp: t0.Add(time.Duration(secs) * time.Second)}}
+	}
+	entries := []replication.Entry{
+		entry(1, dynxl.OpAdd, "10.0.0.1", 0, "a"),
+		entry(2, dynxl.OpRemove, "10.0.0.1", 2, "a"),
+		entry(3, dynxl.OpAdd, "10.0.0.1", 1, "b"),
+		entry(4, dynxl.OpAdd, "10.0.0.2", 3, "a"),
+		entry(5, dynxl.OpRemove, "10.0.0.2", 3, "b"),
+		entry(6, dynxl.OpAdd, "10.0.0.3", 4, "a"),
+		entry(7, dynxl.OpAdd, "10.0.0.3", 4, "b"),
+	}
+	orders := [][]int{{0, 1, 2, 3, 4, 5, 6}, {6, 5, 4, 3, 2, 1, 0}, {2, 0, 4, 6, 1, 3, 5}}
+	for idx, order := range orders {
+		list := dynxl.New("test1", filepath.Join(dir, fmt.Sprintf("test%v.journal", idx)),
+			[]xlist.Resource{xlist.IPv4}, dynxl.DefaultConfig(), yalogi.LogNull)
+		err = list.Open()
+		if err != nil {
+			t.Fatalf("dynxl.Open(): err=%v", err)
+		}
+		for _, i := range order {
+			if err := list.Replicate([]replication.Entry{entries[i]}); err != nil {
+				t.Errorf("idx[%v] dynxl.Replicate(): err=%v", idx, err)
+			}
+		}
+		items, _ := list.Items(context.Background())
+		if len(items) != 1 || items[0].Value != "10.0.0.3" || items[0].Author != "b" {
+			t.Errorf("idx[%v] dynxl.Items(): unexpected items %v", idx, items)
+		}
+		list.Close()
+	}
+}
+
+func TestList_ReplicateRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynxl")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	journal := filepath.Join(dir, "test.journal")
+
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	batches := [][]replication.Entry{
+		{{Seq: 1, Op: dynxl.OpAdd, Item: xlistd.Item{Resource: xlist.IPv4, Format: xlistd.Plain, Value: "10.0.0.1", Timestamp: t0}},
+			// resource not supported
+			{Seq: 2, Op: dynxl.OpAdd, Item: xlistd.Item{Resource: xlist.IPv6, Format: xlistd.Plain, Value: "fe80::1", Timestamp: t0}}},
+		// outdated
+		{{Seq: 3, Op: dynxl.OpRemove, Item: xlistd.Item{Resource: xlist.IPv4, Format: xlistd.Plain, Value: "10.0.0.1",
+			Timestamp: t0.Add(-time.Second)}}},
+	}
+	for idx, batch := range batches {
+		list := dynxl.New("test1", journal, []xlist.Resource{xlist.IPv4}, dynxl.DefaultConfig(), yalogi.LogNull)
+		if err := list.Open(); err != nil {
+			t.Fatalf("idx[%v] dynxl.Open(): err=%v", idx, err)
+		}
+		if err := list.Replicate(batch); err != nil {
+			t.Errorf("idx[%v] dynxl.Replicate(): err=%v", idx, err)
+		}
+		want := batch[len(batch)-1].Seq
+		list.Close()
+		// offset acknowledged to the leader is kept after restart
+		list = dynxl.New("test1", journal, []xlist.Resource{xlist.IPv4}, dynxl.DefaultConfig(), yalogi.LogNull)
+		if err := list.Open(); err != nil {
+			t.Fatalf("idx[%v] dynxl.Open(): err=%v", idx, err)
+		}
+		if got := list.Offset(); got != want {
+			t.Errorf("idx[%v] dynxl.Offset() after restart: want=%v got=%v", idx, want, got)
+		}
+		items, _ := list.Items(context.Background())
+		if len(items) != 1 || items[0].Value != "10.0.0.1" {
+			t.Errorf("idx[%v] dynxl.Items(): unexpected items %v", idx, items)
+		}
+		list.Close()
+	}
+}
+
+func TestList_ReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynxl")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := dynxl.DefaultConfig()
+	cfg.ReadOnly = true
+	list := dynxl.New("test1", filepath.Join(dir, "test.journal"), []xlist.Resource{xlist.IPv4}, cfg, yalogi.LogNull)
+	err = list.Open()
+	if err != nil {
+		t.Fatalf("dynxl.Open(): err=%v", err)
+	}
+	defer list.Close()
+	err = list.Add(context.Background(), xlistd.Item{Resource: xlist.IPv4, Format: xlistd.Plain, Value: "10.10.10.10"})
+	if err != xlistd.ErrReadOnly {
+		t.Errorf("dynxl.Add(): unexpected err=%v", err)
+	}
+}
+
+func TestList_OffsetRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynxl")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	journal := filepath.Join(dir, "test.journal")
+
+	ctx := context.Background()
+	item := xlistd.Item{Resource: xlist.IPv4, Format: xlistd.Plain, Value: "10.10.10.10"}
+	var last uint64
+	for i := 0; i < 3; i++ {
+		list := dynxl.New("test1", journal, []xlist.Resource{xlist.IPv4},
+			dynxl.DefaultConfig(), yalogi.LogNull)
+		if err := list.Open(); err != nil {
+			t.Fatalf("idx[%v] dynxl.Open(): err=%v", i, err)
+		}
+		if got := list.Offset(); got != last {
+			t.Errorf("idx[%v] dynxl.Offset() after restart: want=%v got=%v", i, last, got)
+		}
+		if err := list.Add(ctx, item); err != nil {
+			t.Fatalf("idx[%v] dynxl.Add(): err=%v", i, err)
+		}
+		if err := list.Remove(ctx, item); err != nil {
+			t.Fatalf("idx[%v] dynxl.Remove(): err=%v", i, err)
+		}
+		if got := list.Offset(); got != last+2 {
+			t.Errorf("idx[%v] dynxl.Offset(): want=%v got=%v", i, last+2, got)
+		}
+		last = list.Offset()
+		if _, ok := list.Since(last); !ok {
+			t.Errorf("idx[%v] dynxl.Since(%v): expected ok", i, last)
+		}
+		list.Close()
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package failoverxl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildListFn {
+	return func(b *xlistd.Builder, parents []string, def xlistd.ListDef) (xlistd.List, error) {
+		cfg := defaultCfg
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		childs := make([]xlistd.List, 0, len(def.Contains))
+		for _, sublist := range def.Contains {
+			if sublist.Disabled {
+				continue
+			}
+			child, err := b.BuildChild(append(parents, def.ID), sublist)
+			if err != nil {
+				return nil, fmt.Errorf("constructing child '%s': %v", sublist.ID, err)
+			}
+			childres, err := child.Resources(context.Background())
+			if err != nil {
+				return nil, fmt.Errorf("constructing child '%s': %v", sublist.ID, err)
+			}
+			for _, r := range def.Resources {
+				if !r.InArray(childres) {
+					return nil, fmt.Errorf("child '%s' doesn't checks resource '%s'", sublist.ID, r)
+				}
+			}
+			childs = append(childs, child)
+		}
+		return New(def.ID, childs, def.Resources, cfg), nil
+	}
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	timeout, ok, err := option.Int(opts, "timeout")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if timeout < 0 {
+			return dst, errors.New("invalid 'timeout'")
+		}
+		dst.Timeout = time.Duration(timeout) * time.Millisecond
+	}
+	cooldown, ok, err := option.Int(opts, "cooldown")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if cooldown < 0 {
+			return dst, errors.New("invalid 'cooldown'")
+		}
+		dst.Cooldown = time.Duration(cooldown) * time.Second
+	}
+	return dst, nil
+}
+
+func init() {
+	xlistd.RegisterListBuilder(ComponentClass, Builder(Config{}))
+}
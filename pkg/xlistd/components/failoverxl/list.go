@@ -0,0 +1,213 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package failoverxl provides a composite xlistd.List implementation that
+// checks on the first child component available and falls back to the next
+// ones on errors.
+//
+// This package is a work in progress and makes no API stability promises.
+package failoverxl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// ComponentClass registered.
+const ComponentClass = "failover"
+
+// Config options.
+type Config struct {
+	// Timeout for each child check, zero means no timeout.
+	Timeout time.Duration
+	// Cooldown is the time that a failed child is skipped before being
+	// probed again, zero disables health tracking.
+	Cooldown        time.Duration
+	ForceValidation bool
+}
+
+// List is a composite list that checks on the first healthy child.
+type List struct {
+	id        string
+	cfg       Config
+	childs    []xlistd.List
+	provides  []bool
+	resources []xlist.Resource
+
+	mu       sync.Mutex
+	failedAt []time.Time
+}
+
+// New returns a new failover component with the resources passed.
+func New(id string, childs []xlistd.List, resources []xlist.Resource, cfg Config) *List {
+	l := &List{
+		id:        id,
+		cfg:       cfg,
+		resources: xlist.ClearResourceDups(resources, true),
+		provides:  make([]bool, len(xlist.Resources), len(xlist.Resources)),
+	}
+	//set resource types that provides
+	for _, r := range l.resources {
+		l.provides[int(r)] = true
+	}
+	//set childs
+	l.childs = make([]xlistd.List, len(childs), len(childs))
+	copy(l.childs, childs)
+	l.failedAt = make([]time.Time, len(childs), len(childs))
+	return l
+}
+
+// ID implements xlistd.List interface.
+func (l *List) ID() string {
+	return l.id
+}
+
+// Class implements xlistd.List interface.
+func (l *List) Class() string {
+	return ComponentClass
+}
+
+// Check implements xlist.Checker interface.
+func (l *List) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	if !l.checks(resource) {
+		return xlist.Response{}, xlist.ErrNotSupported
+	}
+	name, ctx, err := xlist.DoValidation(ctx, name, resource, l.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	if len(l.childs) == 0 {
+		return xlist.Response{}, nil
+	}
+	// healthy childs first, childs in cooldown are used as last resort
+	order := l.order(time.Now())
+	var lastErr error
+	for _, idx := range order {
+		resp, err := l.checkChild(ctx, idx, name, resource)
+		if err == nil {
+			l.setFailed(idx, false)
+			return resp, nil
+		}
+		// check if a cancellation has been done
+		select {
+		case <-ctx.Done():
+			return xlist.Response{}, xlist.ErrCanceledRequest
+		default:
+		}
+		// a bad request will fail in all childs
+		if err == xlist.ErrBadRequest {
+			return xlist.Response{}, err
+		}
+		l.setFailed(idx, true)
+		lastErr = err
+	}
+	// all childs failed, error of the last one is returned
+	return xlist.Response{}, lastErr
+}
+
+func (l *List) checkChild(ctx context.Context, idx int, name string, resource xlist.Resource) (xlist.Response, error) {
+	if l.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.cfg.Timeout)
+		defer cancel()
+	}
+	return l.childs[idx].Check(ctx, name, resource)
+}
+
+// order returns the index of the childs in the order they must be checked.
+func (l *List) order(now time.Time) []int {
+	order := make([]int, 0, len(l.childs))
+	if l.cfg.Cooldown <= 0 {
+		for idx := range l.childs {
+			order = append(order, idx)
+		}
+		return order
+	}
+	cooling := make([]int, 0)
+	l.mu.Lock()
+	for idx := range l.childs {
+		if !l.failedAt[idx].IsZero() && now.Sub(l.failedAt[idx]) < l.cfg.Cooldown {
+			cooling = append(cooling, idx)
+			continue
+		}
+		order = append(order, idx)
+	}
+	l.mu.Unlock()
+	return append(order, cooling...)
+}
+
+func (l *List) setFailed(idx int, failed bool) {
+	if l.cfg.Cooldown <= 0 {
+		return
+	}
+	l.mu.Lock()
+	if failed {
+		l.failedAt[idx] = time.Now()
+	} else {
+		l.failedAt[idx] = time.Time{}
+	}
+	l.mu.Unlock()
+}
+
+// Resources implements xlist.Checker interface.
+func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	resources := make([]xlist.Resource, len(l.resources), len(l.resources))
+	copy(resources, l.resources)
+	return resources, nil
+}
+
+// Ping implements xlist.Checker interface. It returns an error only if all
+// the childs fail. Failed pings put the childs in cooldown, but successful
+// ones don't end it, because a child may answer pings and fail checks.
+func (l *List) Ping() error {
+	if len(l.childs) == 0 {
+		return nil
+	}
+	msgErr := make([]string, 0, len(l.childs))
+	for idx, child := range l.childs {
+		err := child.Ping()
+		if err != nil {
+			l.setFailed(idx, true)
+			msgErr = append(msgErr, fmt.Sprintf("%s: %v", child.ID(), err))
+		}
+	}
+	if len(msgErr) == len(l.childs) {
+		return errors.New(strings.Join(msgErr, ";"))
+	}
+	return nil
+}
+
+// Inspect implements xlistd.Inspector interface.
+func (l *List) Inspect() map[string]interface{} {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	childs := make([]map[string]interface{}, 0, len(l.childs))
+	for idx, child := range l.childs {
+		info := map[string]interface{}{
+			"id":      child.ID(),
+			"healthy": true,
+		}
+		if !l.failedAt[idx].IsZero() {
+			info["failed"] = l.failedAt[idx]
+			if now.Sub(l.failedAt[idx]) < l.cfg.Cooldown {
+				info["healthy"] = false
+			}
+		}
+		childs = append(childs, info)
+	}
+	return map[string]interface{}{"childs": childs}
+}
+
+func (l *List) checks(r xlist.Resource) bool {
+	if r.IsValid() {
+		return l.provides[int(r)]
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package failoverxl_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/failoverxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+)
+
+func TestList_Check(t *testing.T) {
+	ip4 := []xlist.Resource{xlist.IPv4}
+	t50ms := 50 * time.Millisecond
+
+	rblFalse := &mockxl.List{Identifier: "false", ResourceList: ip4}
+	rblTrue := &mockxl.List{Identifier: "true", ResourceList: ip4, Results: []bool{true}}
+	rblFail := &mockxl.List{Identifier: "fail", ResourceList: ip4, Fail: true}
+	rblLazyT := &mockxl.List{Identifier: "lazyt", ResourceList: ip4, Lazy: t50ms, Results: []bool{true}}
+
+	var tests = []struct {
+		childs []xlistd.List
+		cfg    failoverxl.Config
+
+		want    bool
+		wantErr error
+	}{
+		{[]xlistd.List{}, failoverxl.Config{}, false, nil},                                                 //0
+		{[]xlistd.List{rblFalse, rblTrue}, failoverxl.Config{}, false, nil},                                //1
+		{[]xlistd.List{rblTrue, rblFalse}, failoverxl.Config{}, true, nil},                                 //2
+		{[]xlistd.List{rblFail, rblTrue}, failoverxl.Config{}, true, nil},                                  //3
+		{[]xlistd.List{rblFail, rblFail, rblFalse}, failoverxl.Config{}, false, nil},                       //4
+		{[]xlistd.List{rblFail, rblFail}, failoverxl.Config{}, false, xlist.ErrInternal},                   //5
+		{[]xlistd.List{rblLazyT, rblFalse}, failoverxl.Config{Timeout: 5 * time.Millisecond}, false, nil},  //6
+		{[]xlistd.List{rblLazyT, rblFalse}, failoverxl.Config{Timeout: 100 * time.Millisecond}, true, nil}, //7
+		{[]xlistd.List{rblLazyT}, failoverxl.Config{Timeout: 5 * time.Millisecond}, false, xlist.ErrCanceledRequest},
+	}
+	for idx, test := range tests {
+		l := failoverxl.New("test", test.childs, ip4, test.cfg)
+		resp, err := l.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+		if err != test.wantErr {
+			t.Errorf("failover.Check idx[%v] err want=%v got=%v", idx, test.wantErr, err)
+		}
+		if test.want != resp.Result {
+			t.Errorf("failover.Check idx[%v] want=%v got=%v", idx, test.want, resp.Result)
+		}
+	}
+	//bad requests don't fail over
+	l := failoverxl.New("test", []xlistd.List{rblFail, rblTrue}, ip4, failoverxl.Config{ForceValidation: true})
+	_, err := l.Check(context.Background(), "10.10.10", xlist.IPv4)
+	if err != xlist.ErrBadRequest {
+		t.Errorf("failover.Check unexpected error: %v", err)
+	}
+	//canceled requests
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	l = failoverxl.New("test", []xlistd.List{rblLazyT, rblTrue}, ip4, failoverxl.Config{})
+	_, err = l.Check(ctx, "10.10.10.10", xlist.IPv4)
+	if err != xlist.ErrCanceledRequest {
+		t.Errorf("failover.Check unexpected error: %v", err)
+	}
+}
+
+func TestList_Cooldown(t *testing.T) {
+	ip4 := []xlist.Resource{xlist.IPv4}
+	primary := &mockxl.List{Identifier: "primary", ResourceList: ip4, Results: []bool{true}, Reason: "primary"}
+	backup := &mockxl.List{Identifier: "backup", ResourceList: ip4, Results: []bool{true}, Reason: "backup"}
+
+	cooldown := 50 * time.Millisecond
+	l := failoverxl.New("test", []xlistd.List{primary, backup}, ip4, failoverxl.Config{Cooldown: cooldown})
+	check := func(want string) {
+		t.Helper()
+		resp, err := l.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+		if err != nil {
+			t.Fatalf("failover.Check unexpected error: %v", err)
+		}
+		if resp.Reason != want {
+			t.Errorf("failover.Check want=%v got=%v", want, resp.Reason)
+		}
+	}
+	healthy := func(idx int, want bool) {
+		t.Helper()
+		childs := l.Inspect()["childs"].([]map[string]interface{})
+		if childs[idx]["healthy"] != want {
+			t.Errorf("failover.Inspect child %v healthy want=%v got=%v", idx, want, childs[idx]["healthy"])
+		}
+	}
+
+	check("primary")
+	primary.Fail = true
+	check("backup")
+	healthy(0, false)
+	// primary recovers but it's skipped during cooldown
+	primary.Fail = false
+	check("backup")
+	time.Sleep(cooldown)
+	healthy(0, true)
+	check("primary")
+	// all childs in cooldown are used as last resort
+	primary.Fail = true
+	backup.Fail = true
+	_, err := l.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+	if err != xlist.ErrInternal {
+		t.Errorf("failover.Check unexpected error: %v", err)
+	}
+	backup.Fail = false
+	check("backup")
+	healthy(1, true)
+}
+
+// pingable is a child that answers pings even if its checks fail
+type pingable struct {
+	*mockxl.List
+}
+
+func (p pingable) Ping() error {
+	return nil
+}
+
+func TestList_PingCooldown(t *testing.T) {
+	ip4 := []xlist.Resource{xlist.IPv4}
+	primary := &mockxl.List{Identifier: "primary", ResourceList: ip4, Results: []bool{true}, Reason: "primary", Fail: true}
+	backup := &mockxl.List{Identifier: "backup", ResourceList: ip4, Results: []bool{true}, Reason: "backup"}
+
+	l := failoverxl.New("test", []xlistd.List{pingable{primary}, backup}, ip4, failoverxl.Config{Cooldown: time.Minute})
+	l.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+	// successful pings don't end the cooldown
+	primary.Fail = false
+	if err := l.Ping(); err != nil {
+		t.Errorf("failover.Ping unexpected error: %v", err)
+	}
+	resp, err := l.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+	if err != nil || resp.Reason != "backup" {
+		t.Errorf("failover.Check unexpected response: %v %v", resp, err)
+	}
+	// failed pings start it
+	backup.Fail = true
+	l.Ping()
+	childs := l.Inspect()["childs"].([]map[string]interface{})
+	if childs[1]["healthy"] != false {
+		t.Errorf("failover.Inspect unexpected childs: %v", childs)
+	}
+}
+
+func TestList_Ping(t *testing.T) {
+	ip4 := []xlist.Resource{xlist.IPv4}
+	rblFail := &mockxl.List{Identifier: "fail", ResourceList: ip4, Fail: true}
+	rblOk := &mockxl.List{Identifier: "ok", ResourceList: ip4}
+
+	l := failoverxl.New("test", []xlistd.List{rblFail, rblOk}, ip4, failoverxl.Config{})
+	if err := l.Ping(); err != nil {
+		t.Errorf("failover.Ping unexpected error: %v", err)
+	}
+	l = failoverxl.New("test", []xlistd.List{rblFail, rblFail}, ip4, failoverxl.Config{})
+	if err := l.Ping(); err == nil {
+		t.Error("failover.Ping expected error")
+	}
+}
+
+func ExampleList() {
+	ip4 := []xlist.Resource{xlist.IPv4}
+
+	childs := []xlistd.List{
+		&mockxl.List{Identifier: "primary", Fail: true, ResourceList: ip4},
+		&mockxl.List{Identifier: "backup", Results: []bool{true}, ResourceList: ip4, Reason: "backup"},
+	}
+	rbl := failoverxl.New("test", childs, ip4, failoverxl.Config{Cooldown: time.Minute})
+	resp, err := rbl.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+	if err != nil {
+		log.Fatalln("this should not happen")
+	}
+	fmt.Println(resp.Result, resp.Reason)
+
+	// Output:
+	// true backup
+}
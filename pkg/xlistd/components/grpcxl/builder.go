@@ -6,9 +6,14 @@ import (
 	"errors"
 	"fmt"
 
+	"google.golang.org/grpc"
+
 	"github.com/luids-io/api/xlist"
 	checkapi "github.com/luids-io/api/xlist/grpc/check"
-	"github.com/luids-io/core/grpctls"
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/authz"
+	"github.com/luids-io/xlist/pkg/tlsreload"
+	"github.com/luids-io/xlist/pkg/tracing"
 	"github.com/luids-io/xlist/pkg/xlistd"
 )
 
@@ -23,10 +28,31 @@ func Builder() xlistd.BuildListFn {
 		if err != nil {
 			return nil, fmt.Errorf("bad TLS config: %v", err)
 		}
-		dial, err := grpctls.Dial(def.Source, clientCfg)
+		// propagates trace context if request is traced
+		dopts := []grpc.DialOption{grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor())}
+		if def.Opts != nil {
+			token, ok, err := option.String(def.Opts, "token")
+			if err != nil {
+				return nil, err
+			}
+			if ok && token != "" {
+				if !clientCfg.UseTLS() {
+					return nil, errors.New("'token' requires tls")
+				}
+				dopts = append(dopts, grpc.WithPerRPCCredentials(authz.TokenCredentials(token)))
+			}
+		}
+		dial, watcher, err := tlsreload.Dial(def.Source, clientCfg,
+			[]tlsreload.Option{tlsreload.SetLogger(b.Logger())}, dopts...)
 		if err != nil {
 			return nil, fmt.Errorf("dialing: %v", err)
 		}
+		if watcher != nil {
+			b.OnShutdown(func() error {
+				watcher.Close()
+				return nil
+			})
+		}
 		bl := checkapi.NewClient(dial, checkapi.SetLogger(b.Logger()))
 		if err != nil {
 			return nil, fmt.Errorf("creating rpcxl: %v", err)
@@ -0,0 +1,185 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package httpxl
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/tlsreload"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildListFn {
+	return func(b *xlistd.Builder, parents []string, def xlistd.ListDef) (xlistd.List, error) {
+		if def.Source == "" {
+			return nil, errors.New("source is empty")
+		}
+		cfg := defaultCfg
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		clientCfg := def.ClientCfg()
+		if !clientCfg.Empty() {
+			// server name is used for verifying the server certificate
+			if clientCfg.ServerName == "" {
+				if u, err := url.Parse(def.Source); err == nil {
+					clientCfg.ServerName = u.Hostname()
+				}
+			}
+			tlsConfig, watcher, err := tlsreload.ClientConfig(clientCfg, tlsreload.SetLogger(b.Logger()))
+			if err != nil {
+				return nil, fmt.Errorf("bad TLS config: %v", err)
+			}
+			if watcher != nil {
+				b.OnShutdown(func() error {
+					watcher.Close()
+					return nil
+				})
+			}
+			transport.TLSClientConfig = tlsConfig
+		}
+		client := &http.Client{Transport: transport, Timeout: cfg.Timeout}
+		bl, err := New(def.ID, def.Source, def.Resources, cfg, client)
+		if err != nil {
+			return nil, err
+		}
+		b.OnShutdown(func() error {
+			transport.CloseIdleConnections()
+			return nil
+		})
+		return bl, nil
+	}
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	method, ok, err := option.String(opts, "method")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		method = strings.ToUpper(method)
+		if method != http.MethodGet && method != http.MethodPost {
+			return dst, errors.New("invalid 'method'")
+		}
+		dst.Method = method
+	}
+
+	body, ok, err := option.String(opts, "body")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Body = body
+	}
+
+	headers, ok, err := option.HashString(opts, "headers")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Headers = make(map[string]string, len(headers))
+		for k, v := range headers {
+			dst.Headers[k] = v
+		}
+	}
+
+	token, ok, err := option.String(opts, "token")
+	if err != nil {
+		return dst, err
+	}
+	if ok && token != "" {
+		if dst.Headers == nil {
+			dst.Headers = make(map[string]string)
+		}
+		dst.Headers["Authorization"] = "Bearer " + token
+	}
+
+	timeout, ok, err := option.Int(opts, "timeout")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if timeout <= 0 {
+			return dst, errors.New("invalid 'timeout'")
+		}
+		dst.Timeout = time.Duration(timeout) * time.Second
+	}
+
+	resultPath, ok, err := option.String(opts, "resultpath")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.ResultPath = resultPath
+	}
+
+	reasonPath, ok, err := option.String(opts, "reasonpath")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.ReasonPath = reasonPath
+	}
+
+	ttlPath, ok, err := option.String(opts, "ttlpath")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.TTLPath = ttlPath
+	}
+
+	positives, ok, err := option.SliceString(opts, "positives")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Positives = positives
+	}
+
+	reason, ok, err := option.String(opts, "reason")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Reason = reason
+	}
+
+	ping, ok, err := option.String(opts, "ping")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.PingURL = ping
+	}
+
+	maxBody, ok, err := option.Int(opts, "maxbody")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if maxBody <= 0 {
+			return dst, errors.New("invalid 'maxbody'")
+		}
+		dst.MaxBody = int64(maxBody)
+	}
+
+	return dst, nil
+}
+
+func init() {
+	xlistd.RegisterListBuilder(ComponentClass, Builder(DefaultConfig()))
+}
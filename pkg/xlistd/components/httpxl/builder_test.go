@@ -0,0 +1,137 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package httpxl_test
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/core/grpctls"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/httpxl"
+)
+
+func TestBuild(t *testing.T) {
+	srv := httptest.NewTLSServer(reputation())
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "httpxl")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	servercert := filepath.Join(dir, "server.crt")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := ioutil.WriteFile(servercert, certPEM, 0644); err != nil {
+		t.Fatalf("writing cert: %v", err)
+	}
+
+	var testdatabase1 = []xlistd.ListDef{
+		{ID: "list1",
+			Class:     httpxl.ComponentClass,
+			Resources: []xlist.Resource{xlist.Domain}},
+		{ID: "list2",
+			Class:     httpxl.ComponentClass,
+			Source:    srv.URL + "/v1/check/{{.Name}}",
+			Resources: []xlist.Resource{xlist.Domain},
+			Client:    &grpctls.ClientCfg{ServerCert: servercert},
+			Opts: map[string]interface{}{
+				"token": "secret", "timeout": 1, "resultpath": "data.malicious",
+				"reasonpath": "data.verdict", "ttlpath": "ttl", "ping": srv.URL + "/health"}},
+		{ID: "list3",
+			Class:     httpxl.ComponentClass,
+			Source:    srv.URL + "/v1/lookup",
+			Resources: []xlist.Resource{xlist.Domain},
+			Client:    &grpctls.ClientCfg{ServerCert: servercert},
+			Opts: map[string]interface{}{
+				"method": "post", "body": `{"indicator": {{json .Name}}, "type": "domain"}`,
+				"resultpath": "results[0].found", "reason": "found", "maxbody": 1024,
+				"headers": map[string]interface{}{"X-Api-Key": "secret"}}},
+		{ID: "list4",
+			Class:     httpxl.ComponentClass,
+			Source:    srv.URL + "/v1/check/{{.Name}}",
+			Resources: []xlist.Resource{xlist.Domain},
+			Opts:      map[string]interface{}{"method": "delete"}},
+		{ID: "list5",
+			Class:     httpxl.ComponentClass,
+			Source:    srv.URL + "/v1/check/{{.Name}}",
+			Resources: []xlist.Resource{xlist.Domain},
+			Opts:      map[string]interface{}{"timeout": 0}},
+		{ID: "list6",
+			Class:     httpxl.ComponentClass,
+			Source:    srv.URL + "/v1/check/{{.Name}}",
+			Resources: []xlist.Resource{xlist.Domain},
+			Opts:      map[string]interface{}{"resultpath": "data..malicious"}},
+		{ID: "list7",
+			Class:     httpxl.ComponentClass,
+			Source:    srv.URL + "/v1/check/{{.Name}}",
+			Resources: []xlist.Resource{xlist.Domain},
+			Client:    &grpctls.ClientCfg{ServerCert: filepath.Join(dir, "notexists.crt")}},
+		{ID: "list8",
+			Class:     httpxl.ComponentClass,
+			Source:    srv.URL + "/v1/check/{{.Name}}",
+			Resources: []xlist.Resource{xlist.Domain},
+			Opts:      map[string]interface{}{"positives": "malware"}},
+	}
+
+	b := xlistd.NewBuilder(apiservice.NewRegistry())
+	defer b.Shutdown()
+
+	//define and do tests
+	var tests = []struct {
+		listid  string
+		wantErr string
+	}{
+		{"list1", "source is empty"},
+		{"list2", ""},
+		{"list3", ""},
+		{"list4", "invalid 'method'"},
+		{"list5", "invalid 'timeout'"},
+		{"list6", "invalid result path"},
+		{"list7", "bad TLS config"},
+		{"list8", "invalid 'positives'"},
+	}
+	for _, test := range tests {
+		def, ok := xlistd.FilterID(test.listid, testdatabase1)
+		if !ok {
+			t.Errorf("can't find id %s in database tests", test.listid)
+			continue
+		}
+		_, err := b.Build(def)
+		switch {
+		case test.wantErr == "" && err == nil:
+			//
+		case test.wantErr == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("expected error for %s", test.listid)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		}
+	}
+	if err := b.Start(); err != nil {
+		t.Errorf("starting lists: %v", err)
+	}
+
+	// checks using tls
+	for _, id := range []string{"list2", "list3"} {
+		list, ok := b.List(id)
+		if !ok {
+			t.Fatalf("list %s not found", id)
+		}
+		if err := list.Ping(); err != nil {
+			t.Errorf("%s Ping(): err=%v", id, err)
+		}
+		resp, err := list.Check(context.Background(), "www.malware.com", xlist.Domain)
+		if err != nil || !resp.Result {
+			t.Errorf("%s Check(): got=%v err=%v", id, resp, err)
+		}
+	}
+}
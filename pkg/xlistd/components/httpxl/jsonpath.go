@@ -0,0 +1,125 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package httpxl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Path is a compiled path expression for selecting a field of a json
+// document. Expressions are dot separated keys with optional array indexes,
+// ex: "data.attributes.score", "$.results[0].malicious" or "results.0.malicious".
+type Path []interface{}
+
+// ParsePath compiles a path expression.
+func ParsePath(expr string) (Path, error) {
+	expr = strings.TrimPrefix(strings.TrimPrefix(expr, "$"), ".")
+	if expr == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+	p := make(Path, 0)
+	for _, segment := range strings.Split(expr, ".") {
+		key := segment
+		idx := strings.IndexByte(segment, '[')
+		if idx >= 0 {
+			key = segment[:idx]
+		}
+		if key != "" {
+			p = append(p, key)
+		}
+		for rest := segment[len(key):]; rest != ""; {
+			end := strings.IndexByte(rest, ']')
+			if rest[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("invalid path '%s'", expr)
+			}
+			n, err := strconv.Atoi(rest[1:end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid index in path '%s'", expr)
+			}
+			p = append(p, n)
+			rest = rest[end+1:]
+		}
+		if key == "" && idx < 0 {
+			return nil, fmt.Errorf("invalid path '%s'", expr)
+		}
+	}
+	return p, nil
+}
+
+// Lookup returns the value selected by the path in a document decoded with
+// encoding/json.
+func (p Path) Lookup(doc interface{}) (interface{}, bool) {
+	v := doc
+	for _, s := range p {
+		switch sel := s.(type) {
+		case string:
+			switch t := v.(type) {
+			case map[string]interface{}:
+				var ok bool
+				v, ok = t[sel]
+				if !ok {
+					return nil, false
+				}
+			case []interface{}:
+				// numeric keys are indexes of arrays
+				n, err := strconv.Atoi(sel)
+				if err != nil || n < 0 || n >= len(t) {
+					return nil, false
+				}
+				v = t[n]
+			default:
+				return nil, false
+			}
+		case int:
+			a, ok := v.([]interface{})
+			if !ok || sel >= len(a) {
+				return nil, false
+			}
+			v = a[sel]
+		}
+	}
+	return v, true
+}
+
+// toString returns a string representation of a json value.
+func toString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case bool:
+		return strconv.FormatBool(t)
+	case json.Number:
+		return t.String()
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// toBool returns the truth value of a json value.
+func toBool(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case string:
+		switch strings.ToLower(t) {
+		case "", "0", "false", "no", "none", "null":
+			return false
+		}
+		return true
+	case json.Number:
+		f, err := t.Float64()
+		return err == nil && f != 0
+	case []interface{}:
+		return len(t) > 0
+	case map[string]interface{}:
+		return len(t) > 0
+	}
+	return false
+}
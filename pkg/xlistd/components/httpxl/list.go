@@ -0,0 +1,277 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package httpxl provides a xlistd.List implementation that checks names
+// against a http service with a json api.
+//
+// This package is a work in progress and makes no API stability promises.
+package httpxl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/tracing"
+)
+
+// ComponentClass defines default class for component builder
+const ComponentClass = "http"
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		Method:     http.MethodGet,
+		Timeout:    5 * time.Second,
+		ResultPath: "result",
+		MaxBody:    1 << 20,
+	}
+}
+
+// Config options. URL and Body are templates that receive the fields Name
+// and Resource of the check. Paths select the fields of the json response
+// mapped to the response of the check.
+type Config struct {
+	Method          string
+	Body            string
+	Headers         map[string]string
+	Timeout         time.Duration
+	ResultPath      string
+	ReasonPath      string
+	TTLPath         string
+	Positives       []string
+	Reason          string
+	PingURL         string
+	MaxBody         int64
+	ForceValidation bool
+}
+
+// List is a list that checks names using a http json api. Not found (404)
+// responses are negatives.
+type List struct {
+	id        string
+	cfg       Config
+	resources []xlist.Resource
+	client    *http.Client
+	url       *template.Template
+	body      *template.Template
+	result    Path
+	reason    Path
+	ttl       Path
+}
+
+// query is the data passed to templates.
+type query struct {
+	Name     string
+	Resource string
+}
+
+var funcs = template.FuncMap{
+	"json": func(s string) (string, error) {
+		b, err := json.Marshal(s)
+		return string(b), err
+	},
+}
+
+// New creates a new list that requests the url template. If client is nil
+// a http client with the timeout of the configuration is used.
+func New(id, url string, resources []xlist.Resource, cfg Config, client *http.Client) (*List, error) {
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+	l := &List{
+		id:        id,
+		cfg:       cfg,
+		resources: xlist.ClearResourceDups(resources, true),
+		client:    client,
+	}
+	var err error
+	l.url, err = template.New("url").Funcs(funcs).Parse(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url template: %v", err)
+	}
+	if cfg.Body != "" {
+		l.body, err = template.New("body").Funcs(funcs).Parse(cfg.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body template: %v", err)
+		}
+	}
+	l.result, err = ParsePath(cfg.ResultPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid result path: %v", err)
+	}
+	if cfg.ReasonPath != "" {
+		l.reason, err = ParsePath(cfg.ReasonPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reason path: %v", err)
+		}
+	}
+	if cfg.TTLPath != "" {
+		l.ttl, err = ParsePath(cfg.TTLPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ttl path: %v", err)
+		}
+	}
+	return l, nil
+}
+
+// ID implements xlistd.List interface
+func (l *List) ID() string {
+	return l.id
+}
+
+// Class implements xlistd.List interface
+func (l *List) Class() string {
+	return ComponentClass
+}
+
+// Check implements xlist.Checker interface
+func (l *List) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	if !resource.InArray(l.resources) {
+		return xlist.Response{}, xlist.ErrNotSupported
+	}
+	name, ctx, err := xlist.DoValidation(ctx, name, resource, l.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	req, err := l.request(ctx, query{Name: name, Resource: resource.String()})
+	if err != nil {
+		return xlist.Response{}, xlist.ErrInternal
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return xlist.Response{}, xlist.ErrCanceledRequest
+		}
+		return xlist.Response{}, fmt.Errorf("requesting: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, l.cfg.MaxBody))
+		return xlist.Response{}, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, l.cfg.MaxBody))
+		return xlist.Response{}, fmt.Errorf("unexpected status %v", resp.StatusCode)
+	}
+	var doc interface{}
+	decoder := json.NewDecoder(io.LimitReader(resp.Body, l.cfg.MaxBody))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return xlist.Response{}, fmt.Errorf("decoding response: %v", err)
+	}
+	return l.response(doc), nil
+}
+
+func (l *List) request(ctx context.Context, q query) (*http.Request, error) {
+	var url bytes.Buffer
+	if err := l.url.Execute(&url, q); err != nil {
+		return nil, err
+	}
+	var body io.Reader
+	if l.body != nil {
+		var b bytes.Buffer
+		if err := l.body.Execute(&b, q); err != nil {
+			return nil, err
+		}
+		body = &b
+	}
+	req, err := http.NewRequestWithContext(ctx, l.cfg.Method, url.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range l.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	// propagates trace context if request is traced
+	if sc, ok := tracing.SpanContextFromContext(ctx); ok {
+		req.Header.Set(tracing.TraceParentHeader, sc.TraceParent())
+	}
+	return req, nil
+}
+
+func (l *List) response(doc interface{}) xlist.Response {
+	v, ok := l.result.Lookup(doc)
+	if !ok {
+		return xlist.Response{}
+	}
+	var resp xlist.Response
+	if len(l.cfg.Positives) > 0 {
+		value := toString(v)
+		for _, p := range l.cfg.Positives {
+			if strings.EqualFold(value, p) {
+				resp.Result = true
+				break
+			}
+		}
+	} else {
+		resp.Result = toBool(v)
+	}
+	if l.ttl != nil {
+		if v, ok := l.ttl.Lookup(doc); ok {
+			if n, ok := v.(json.Number); ok {
+				if ttl, err := n.Int64(); err == nil && ttl >= int64(xlist.NeverCache) {
+					resp.TTL = int(ttl)
+				}
+			}
+		}
+	}
+	if !resp.Result {
+		return resp
+	}
+	if l.reason != nil {
+		if v, ok := l.reason.Lookup(doc); ok {
+			resp.Reason = toString(v)
+		}
+	}
+	if resp.Reason == "" {
+		resp.Reason = l.cfg.Reason
+	}
+	return resp
+}
+
+// Resources implements xlist.Checker interface
+func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	resources := make([]xlist.Resource, len(l.resources), len(l.resources))
+	copy(resources, l.resources)
+	return resources, nil
+}
+
+// Ping implements xlistd.List interface. It requests the ping url if it's
+// configured.
+func (l *List) Ping() error {
+	if l.cfg.PingURL == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), l.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.cfg.PingURL, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range l.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, l.cfg.MaxBody))
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package httpxl_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd/components/httpxl"
+)
+
+// reputation is a stand-in of a reputation service.
+func reputation() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/check/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, "/v1/check/")
+		switch name {
+		case "www.malware.com":
+			w.Write([]byte(`{"data":{"malicious":true,"categories":["malware","c2"],"verdict":"malware"},"ttl":60}`))
+		case "www.clean.com":
+			w.Write([]byte(`{"data":{"malicious":false,"verdict":"clean"},"ttl":300}`))
+		case "10.0.0.1":
+			w.Write([]byte(`{"data":{"malicious":1,"verdict":"spam"}}`))
+		case "www.slow.com":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(`{"data":{"malicious":true}}`))
+		case "www.broken.com":
+			w.Write([]byte(`{"data":`))
+		case "www.error.com":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	mux.HandleFunc("/v1/lookup", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Indicator, Type string }
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method != http.MethodPost || json.Unmarshal(body, &req) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		found := req.Indicator == "www.malware.com" && req.Type == "domain"
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []interface{}{map[string]interface{}{"found": found, "source": "feed1"}},
+		})
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+func TestList_Check(t *testing.T) {
+	srv := httptest.NewServer(reputation())
+	defer srv.Close()
+
+	cfg := httpxl.DefaultConfig()
+	cfg.Headers = map[string]string{"Authorization": "Bearer secret"}
+	cfg.ResultPath = "data.malicious"
+	cfg.ReasonPath = "data.categories[0]"
+	cfg.TTLPath = "$.ttl"
+	cfg.Reason = "reputation"
+	cfg.Timeout = 100 * time.Millisecond
+	list, err := httpxl.New("test1", srv.URL+"/v1/check/{{.Name}}",
+		[]xlist.Resource{xlist.IPv4, xlist.Domain}, cfg, nil)
+	if err != nil {
+		t.Fatalf("httpxl.New(): err=%v", err)
+	}
+
+	var tests = []struct {
+		name     string
+		resource xlist.Resource
+		want     xlist.Response
+		wantErr  bool
+	}{
+		{"www.malware.com", xlist.Domain, xlist.Response{Result: true, Reason: "malware", TTL: 60}, false},
+		{"WWW.MALWARE.COM", xlist.Domain, xlist.Response{Result: true, Reason: "malware", TTL: 60}, false},
+		{"www.clean.com", xlist.Domain, xlist.Response{TTL: 300}, false},
+		{"10.0.0.1", xlist.IPv4, xlist.Response{Result: true, Reason: "reputation"}, false},
+		{"www.unknown.com", xlist.Domain, xlist.Response{}, false},
+		{"www.slow.com", xlist.Domain, xlist.Response{}, true},
+		{"www.broken.com", xlist.Domain, xlist.Response{}, true},
+		{"www.error.com", xlist.Domain, xlist.Response{}, true},
+		{"d41d8cd98f00b204e9800998ecf8427e", xlist.MD5, xlist.Response{}, true},
+	}
+	for idx, test := range tests {
+		got, err := list.Check(context.Background(), test.name, test.resource)
+		switch {
+		case test.wantErr && err == nil:
+			t.Errorf("idx[%v] httpxl.Check(): expected error", idx)
+		case !test.wantErr && err != nil:
+			t.Errorf("idx[%v] httpxl.Check(): err=%v", idx, err)
+		case got != test.want:
+			t.Errorf("idx[%v] httpxl.Check(): want=%v got=%v", idx, test.want, got)
+		}
+	}
+}
+
+func TestList_Post(t *testing.T) {
+	srv := httptest.NewServer(reputation())
+	defer srv.Close()
+
+	cfg := httpxl.DefaultConfig()
+	cfg.Method = http.MethodPost
+	cfg.Body = `{"indicator": {{json .Name}}, "type": {{json .Resource}}}`
+	cfg.ResultPath = "results[0].found"
+	cfg.ReasonPath = "results.0.source"
+	list, err := httpxl.New("test1", srv.URL+"/v1/lookup", []xlist.Resource{xlist.Domain}, cfg, nil)
+	if err != nil {
+		t.Fatalf("httpxl.New(): err=%v", err)
+	}
+	got, err := list.Check(context.Background(), "www.malware.com", xlist.Domain)
+	if err != nil || !got.Result || got.Reason != "feed1" {
+		t.Errorf("httpxl.Check(): got=%v err=%v", got, err)
+	}
+	got, err = list.Check(context.Background(), "www.clean.com", xlist.Domain)
+	if err != nil || got.Result {
+		t.Errorf("httpxl.Check(): got=%v err=%v", got, err)
+	}
+}
+
+func TestList_Positives(t *testing.T) {
+	srv := httptest.NewServer(reputation())
+	defer srv.Close()
+
+	cfg := httpxl.DefaultConfig()
+	cfg.Headers = map[string]string{"Authorization": "Bearer secret"}
+	cfg.ResultPath = "data.verdict"
+	cfg.ReasonPath = "data.verdict"
+	cfg.Positives = []string{"malware", "phishing"}
+	list, err := httpxl.New("test1", srv.URL+"/v1/check/{{.Name | urlquery}}", []xlist.Resource{xlist.IPv4, xlist.Domain}, cfg, nil)
+	if err != nil {
+		t.Fatalf("httpxl.New(): err=%v", err)
+	}
+	var tests = []struct {
+		name     string
+		resource xlist.Resource
+		want     bool
+	}{
+		{"www.malware.com", xlist.Domain, true},
+		{"www.clean.com", xlist.Domain, false},
+		{"10.0.0.1", xlist.IPv4, false},
+	}
+	for idx, test := range tests {
+		got, err := list.Check(context.Background(), test.name, test.resource)
+		if err != nil || got.Result != test.want {
+			t.Errorf("idx[%v] httpxl.Check(): want=%v got=%v err=%v", idx, test.want, got, err)
+		}
+	}
+}
+
+func TestList_Ping(t *testing.T) {
+	srv := httptest.NewServer(reputation())
+	defer srv.Close()
+
+	var tests = []struct {
+		ping    string
+		wantErr bool
+	}{
+		{"", false},
+		{srv.URL + "/health", false},
+		{srv.URL + "/notfound", true},
+		{"http://127.0.0.1:1/health", true},
+	}
+	for idx, test := range tests {
+		cfg := httpxl.DefaultConfig()
+		cfg.PingURL = test.ping
+		list, err := httpxl.New("test1", srv.URL+"/v1/check/{{.Name}}", []xlist.Resource{xlist.Domain}, cfg, nil)
+		if err != nil {
+			t.Fatalf("httpxl.New(): err=%v", err)
+		}
+		err = list.Ping()
+		if test.wantErr != (err != nil) {
+			t.Errorf("idx[%v] httpxl.Ping(): err=%v", idx, err)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	var tests = []struct {
+		url    string
+		body   string
+		result string
+	}{
+		{"http://localhost/{{.Name", "", "result"},
+		{"http://localhost/", "{{json .Name", "result"},
+		{"http://localhost/", "", ""},
+		{"http://localhost/", "", "data..result"},
+		{"http://localhost/", "", "data[x]"},
+	}
+	for idx, test := range tests {
+		cfg := httpxl.DefaultConfig()
+		cfg.Body = test.body
+		cfg.ResultPath = test.result
+		_, err := httpxl.New("test1", test.url, []xlist.Resource{xlist.Domain}, cfg, nil)
+		if err == nil {
+			t.Errorf("idx[%v] httpxl.New(): expected error", idx)
+		}
+	}
+}
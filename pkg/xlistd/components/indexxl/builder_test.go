@@ -0,0 +1,85 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package indexxl_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/indexxl"
+)
+
+var testdatabase1 = []xlistd.ListDef{
+	{ID: "list1",
+		Class:     indexxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4}},
+	{ID: "list2",
+		Class:     indexxl.ComponentClass,
+		Source:    "testfile1.idx",
+		Resources: []xlist.Resource{xlist.IPv4, xlist.Domain}},
+	{ID: "list3",
+		Class:     indexxl.ComponentClass,
+		Source:    "testfile1.idx",
+		Resources: []xlist.Resource{xlist.IPv4},
+		Opts:      map[string]interface{}{"reason": "indexed", "autoreload": true, "reloadseconds": 10}},
+	{ID: "list4",
+		Class:     indexxl.ComponentClass,
+		Source:    "testfile1.idx",
+		Resources: []xlist.Resource{xlist.IPv4},
+		Opts:      map[string]interface{}{"reloadseconds": 0}},
+	{ID: "list5",
+		Class:     indexxl.ComponentClass,
+		Source:    "testfile1.idx",
+		Resources: []xlist.Resource{xlist.IPv4},
+		Opts:      map[string]interface{}{"autoreload": "yes"}},
+}
+
+func TestBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "indexxl")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	writeIndex(t, testfile1, dir+"/testfile1.idx")
+
+	b := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.DataDir(dir))
+	defer b.Shutdown()
+
+	//define and do tests
+	var tests = []struct {
+		listid  string
+		wantErr string
+	}{
+		{"list1", "doesn't exists"},
+		{"list2", ""},
+		{"list3", ""},
+		{"list4", "invalid 'reloadseconds'"},
+		{"list5", "invalid 'autoreload'"},
+	}
+	for _, test := range tests {
+		def, ok := xlistd.FilterID(test.listid, testdatabase1)
+		if !ok {
+			t.Errorf("can't find id %s in database tests", test.listid)
+			continue
+		}
+		_, err := b.Build(def)
+		switch {
+		case test.wantErr == "" && err == nil:
+			//
+		case test.wantErr == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("expected error for %s", test.listid)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		}
+	}
+	if err := b.Start(); err != nil {
+		t.Errorf("starting lists: %v", err)
+	}
+}
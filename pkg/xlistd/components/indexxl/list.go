@@ -0,0 +1,239 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package indexxl provides a xlistd.List implementation that checks names
+// against an index file created offline with the xlindex format. The file is
+// memory mapped, so it's suitable for very large feeds.
+//
+// This package is a work in progress and makes no API stability promises.
+package indexxl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/xlindex"
+)
+
+// ComponentClass defines default class for component builder
+const ComponentClass = "index"
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		ReloadTime: 30 * time.Second,
+	}
+}
+
+// Config options
+type Config struct {
+	ForceValidation bool
+	Reason          string
+	Autoreload      bool
+	ReloadTime      time.Duration
+}
+
+// List checks names against a memory mapped index file. With autoreload, a
+// new index file must be deployed atomically (ex: rename), then the list
+// swaps to the new index and unmaps the old one.
+type List struct {
+	id        string
+	cfg       Config
+	logger    yalogi.Logger
+	filename  string
+	resources []xlist.Resource
+
+	mu      sync.RWMutex
+	index   *xlindex.Index
+	stat    os.FileInfo
+	err     error
+	close   chan bool
+	started bool
+}
+
+// New creates a new List.
+func New(id, filename string, resources []xlist.Resource, cfg Config, logger yalogi.Logger) *List {
+	l := &List{
+		id:        id,
+		filename:  filename,
+		cfg:       cfg,
+		logger:    logger,
+		resources: xlist.ClearResourceDups(resources, true),
+		close:     make(chan bool),
+	}
+	if l.logger == nil {
+		l.logger = yalogi.LogNull
+	}
+	return l
+}
+
+// ID implements xlistd.List interface
+func (l *List) ID() string {
+	return l.id
+}
+
+// Class implements xlistd.List interface
+func (l *List) Class() string {
+	return ComponentClass
+}
+
+// Check implements xlist.Checker interface
+func (l *List) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	if !resource.InArray(l.resources) {
+		return xlist.Response{}, xlist.ErrNotSupported
+	}
+	name, ctx, err := xlist.DoValidation(ctx, name, resource, l.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	// lock is held while reading from the mapped memory
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.index == nil {
+		return xlist.Response{}, xlist.ErrUnavailable
+	}
+	if l.index.Check(name, resource) {
+		return xlist.Response{Result: true, Reason: l.cfg.Reason}, nil
+	}
+	return xlist.Response{}, nil
+}
+
+// Resources implements xlist.Checker interface
+func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	resources := make([]xlist.Resource, len(l.resources), len(l.resources))
+	copy(resources, l.resources)
+	return resources, nil
+}
+
+// Ping implements xlistd.Ping interface
+func (l *List) Ping() error {
+	if !l.started {
+		return errors.New("list is closed")
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.err
+}
+
+// Inspect implements xlistd.Inspector interface.
+func (l *List) Inspect() map[string]interface{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	items := make(map[string]uint64)
+	if l.index != nil {
+		for _, k := range xlindex.Kinds {
+			if n := l.index.Count(k); n > 0 {
+				items[k.String()] = n
+			}
+		}
+	}
+	info := map[string]interface{}{"items": items}
+	if l.stat != nil {
+		info["modified"] = l.stat.ModTime()
+		info["size"] = l.stat.Size()
+	}
+	return info
+}
+
+// Open maps the index file.
+func (l *List) Open() error {
+	l.logger.Debugf("%s: opening source '%s'", l.id, l.filename)
+	if err := l.load(); err != nil {
+		return err
+	}
+	if l.cfg.Autoreload {
+		go l.doReload()
+	}
+	l.started = true
+	return nil
+}
+
+// Close unmaps the index file.
+func (l *List) Close() {
+	l.logger.Debugf("%s: closing source '%s'", l.id, l.filename)
+	if l.started {
+		if l.cfg.Autoreload {
+			l.close <- true
+		}
+		l.started = false
+		l.mu.Lock()
+		if l.index != nil {
+			l.index.Close()
+			l.index = nil
+		}
+		l.mu.Unlock()
+	}
+}
+
+// Reload implements xlistd.Reloader interface, it maps the index file again.
+// Current index is kept if there are errors.
+func (l *List) Reload() error {
+	l.logger.Debugf("reloading source '%s'", l.filename)
+	return l.load()
+}
+
+func (l *List) load() error {
+	stat, err := os.Stat(l.filename)
+	if err != nil {
+		return err
+	}
+	index, err := xlindex.Open(l.filename)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	old := l.index
+	l.index, l.stat = index, stat
+	l.mu.Unlock()
+	// no checks are using the old index
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+func (l *List) doReload() {
+	ticker := time.NewTicker(l.cfg.ReloadTime)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.close:
+			return
+		case <-ticker.C:
+			l.logger.Debugf("checking source '%s'", l.filename)
+			changed, err := l.changed()
+			if err == nil && changed {
+				l.logger.Infof("source '%s' has changed", l.filename)
+				err = l.Reload()
+			}
+			l.setErr(err)
+		}
+	}
+}
+
+func (l *List) changed() (bool, error) {
+	stat, err := os.Stat(l.filename)
+	if err != nil {
+		return false, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.stat != nil && os.SameFile(l.stat, stat) &&
+		l.stat.ModTime().Equal(stat.ModTime()) && l.stat.Size() == stat.Size() {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (l *List) setErr(err error) {
+	l.mu.Lock()
+	l.err = err
+	l.mu.Unlock()
+	if err != nil {
+		l.logger.Warnf("%s: %v", l.id, err)
+	}
+}
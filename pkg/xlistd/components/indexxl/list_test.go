@@ -0,0 +1,158 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package indexxl_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/xlindex"
+	"github.com/luids-io/xlist/pkg/xlistd/components/indexxl"
+)
+
+var testfile1 = "../../../../test/testdata/testfile1.xlist"
+
+func writeIndex(t *testing.T, source, output string) {
+	t.Helper()
+	f, err := os.Open(source)
+	if err != nil {
+		t.Fatalf("opening source: %v", err)
+	}
+	defer f.Close()
+	w := xlindex.NewWriter()
+	if err := w.LoadReader(context.Background(), f, xlist.Resources); err != nil {
+		t.Fatalf("loading source: %v", err)
+	}
+	if err := w.WriteFile(output); err != nil {
+		t.Fatalf("writing index: %v", err)
+	}
+}
+
+func TestList_Check(t *testing.T) {
+	dir, err := ioutil.TempDir("", "indexxl")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "test.idx")
+	writeIndex(t, testfile1, source)
+
+	cfg := indexxl.DefaultConfig()
+	cfg.Reason = "indexed"
+	list := indexxl.New("test1", source, []xlist.Resource{xlist.IPv4, xlist.IPv6, xlist.Domain}, cfg, yalogi.LogNull)
+	if err := list.Open(); err != nil {
+		t.Fatalf("indexxl.Open(): err=%v", err)
+	}
+	defer list.Close()
+
+	var tests = []struct {
+		name     string
+		resource xlist.Resource
+		want     bool
+		wantErr  error
+	}{
+		{"11.22.33.44", xlist.IPv4, true, nil},
+		{"10.5.100.1", xlist.IPv4, true, nil},
+		{"10.6.0.1", xlist.IPv4, false, nil},
+		{"2001:d00::1", xlist.IPv6, true, nil},
+		{"www.micasa.com", xlist.Domain, true, nil},
+		{"micasa.com", xlist.Domain, false, nil},
+		{"www.sucasa.com", xlist.Domain, true, nil},
+		{"sucasa.com", xlist.Domain, true, nil},
+		{"d41d8cd98f00b204e9800998ecf8427e", xlist.MD5, false, xlist.ErrNotSupported},
+		{"not an ip", xlist.IPv4, false, xlist.ErrBadRequest},
+	}
+	for idx, test := range tests {
+		got, err := list.Check(context.Background(), test.name, test.resource)
+		if err != test.wantErr {
+			t.Errorf("idx[%v] indexxl.Check(): unexpected err=%v", idx, err)
+		}
+		if got.Result != test.want {
+			t.Errorf("idx[%v] indexxl.Check(): want=%v got=%v", idx, test.want, got)
+		}
+		if got.Result && got.Reason != "indexed" {
+			t.Errorf("idx[%v] indexxl.Check(): unexpected reason '%s'", idx, got.Reason)
+		}
+	}
+	items := list.Inspect()["items"].(map[string]uint64)
+	if items["ip4"] != 5 || items["subdomain"] != 1 {
+		t.Errorf("indexxl.Inspect(): unexpected %v", items)
+	}
+}
+
+func TestList_Swap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "indexxl")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "test.idx")
+	write := func(domains ...string) {
+		w := xlindex.NewWriter()
+		data := "domain,plain," + strings.Join(domains, "\ndomain,plain,") + "\n"
+		if err := w.LoadReader(context.Background(), strings.NewReader(data), xlist.Resources); err != nil {
+			t.Fatalf("loading data: %v", err)
+		}
+		if err := w.WriteFile(source); err != nil {
+			t.Fatalf("writing index: %v", err)
+		}
+	}
+	write("www.example.com")
+
+	cfg := indexxl.DefaultConfig()
+	cfg.Autoreload = true
+	cfg.ReloadTime = 50 * time.Millisecond
+	list := indexxl.New("test1", source, []xlist.Resource{xlist.Domain}, cfg, yalogi.LogNull)
+	if err := list.Open(); err != nil {
+		t.Fatalf("indexxl.Open(): err=%v", err)
+	}
+	defer list.Close()
+	check := func(name string, want bool) {
+		resp, err := list.Check(context.Background(), name, xlist.Domain)
+		if err != nil || resp.Result != want {
+			t.Errorf("indexxl.Check(%s): want=%v got=%v err=%v", name, want, resp.Result, err)
+		}
+	}
+	check("www.example.com", true)
+	check("www.example.org", false)
+
+	// checks running while the index is swapped
+	done := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				list.Check(context.Background(), "www.example.com", xlist.Domain)
+			}
+		}
+	}()
+	write("www.example.com", "www.example.org")
+	time.Sleep(200 * time.Millisecond)
+	close(done)
+	check("www.example.org", true)
+	if err := list.Ping(); err != nil {
+		t.Errorf("indexxl.Ping(): err=%v", err)
+	}
+
+	// invalid index keeps the previous one
+	if err := ioutil.WriteFile(source+".tmp", []byte("invalid"), 0644); err != nil {
+		t.Fatalf("writing file: %v", err)
+	}
+	if err := os.Rename(source+".tmp", source); err != nil {
+		t.Fatalf("renaming file: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	check("www.example.org", true)
+	if err := list.Ping(); err == nil {
+		t.Error("indexxl.Ping(): expected error")
+	}
+}
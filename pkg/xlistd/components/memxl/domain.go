@@ -58,6 +58,29 @@ func (l *domainList) checkDomain(name string) bool {
 	return false
 }
 
+// matchDomain returns the most specific item matching the name and if it's
+// a subdomain item
+func (l *domainList) matchDomain(name string) (string, bool, bool) {
+	if v, ok := l.hashmap[name]; ok {
+		return name, v, true
+	}
+	if l.maxDepth == 0 {
+		return "", false, false
+	}
+	idx, depth := getDomainIdx(name)
+	i := depth - 1
+	if i > l.maxDepth {
+		i = l.maxDepth
+	}
+	for ; i >= l.minDepth && i > 0; i-- {
+		subdomain := getSubdomain(i, name, idx)
+		if v := l.hashmap[subdomain]; v {
+			return subdomain, true, true
+		}
+	}
+	return "", false, false
+}
+
 // warning, functions without lock!
 func (l *domainList) addDomain(s string) error {
 	k, ok := xlist.Canonicalize(s, xlist.Domain)
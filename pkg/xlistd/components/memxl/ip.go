@@ -9,6 +9,7 @@ import (
 	"github.com/yl2chen/cidranger"
 
 	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd"
 )
 
 type ipList struct {
@@ -48,6 +49,38 @@ func (l *ipList) checkIP6(name string) bool {
 	return false
 }
 
+// matchIP4 returns the most specific item matching the ip, its format and
+// its prefix length
+func (l *ipList) matchIP4(name string) (string, xlistd.Format, int, bool) {
+	if l.inHashIP4(name) {
+		return name, xlistd.Plain, 8 * net.IPv4len, true
+	}
+	return matchCIDR(l.ranger4, name)
+}
+
+func (l *ipList) matchIP6(name string) (string, xlistd.Format, int, bool) {
+	if l.inHashIP6(name) {
+		return name, xlistd.Plain, 8 * net.IPv6len, true
+	}
+	return matchCIDR(l.ranger6, name)
+}
+
+func matchCIDR(ranger cidranger.Ranger, name string) (string, xlistd.Format, int, bool) {
+	entries, err := ranger.ContainingNetworks(net.ParseIP(name))
+	if err != nil || len(entries) == 0 {
+		return "", xlistd.CIDR, 0, false
+	}
+	var best net.IPNet
+	bestOnes := -1
+	for _, e := range entries {
+		n := e.Network()
+		if ones, _ := n.Mask.Size(); ones > bestOnes {
+			best, bestOnes = n, ones
+		}
+	}
+	return best.String(), xlistd.CIDR, bestOnes, true
+}
+
 // funcions for add, warning, functions without lock!
 func (l *ipList) addIP4(s string) error {
 	k, ok := xlist.Canonicalize(s, xlist.IPv4)
@@ -106,6 +106,48 @@ func (l *List) Check(ctx context.Context, name string, resource xlist.Resource)
 	return xlist.Response{Result: result, Reason: reason}, nil
 }
 
+// Match returns the most specific item that matches the name and its
+// specificity, greater values are more specific. Specificity of domains is
+// two times the labels of the item, plus one if it isn't a subdomain item.
+// Specificity of ips is the prefix length of the item and hashes are always
+// equally specific.
+func (l *List) Match(ctx context.Context, name string, resource xlist.Resource) (Data, int, bool, error) {
+	if !l.checks(resource) {
+		return Data{}, 0, false, xlist.ErrNotSupported
+	}
+	name, _, err := xlist.DoValidation(ctx, name, resource, l.cfg.ForceValidation)
+	if err != nil {
+		return Data{}, 0, false, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	item := Data{Resource: resource, Format: xlistd.Plain, Value: name}
+	var ok bool
+	var spec int
+	switch resource {
+	case xlist.IPv4:
+		item.Value, item.Format, spec, ok = l.iplist.matchIP4(name)
+	case xlist.IPv6:
+		item.Value, item.Format, spec, ok = l.iplist.matchIP6(name)
+	case xlist.Domain:
+		var sub bool
+		item.Value, sub, ok = l.domlist.matchDomain(name)
+		spec = 2 * getDepth(item.Value)
+		if sub {
+			item.Format = xlistd.Sub
+		} else {
+			spec++
+		}
+	case xlist.MD5, xlist.SHA1, xlist.SHA256:
+		ok = l.hashlist.check(name)
+	}
+	if !ok {
+		return Data{}, 0, false, nil
+	}
+	return item, spec, true, nil
+}
+
 // Resources implements xlist.Checker interface.
 func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
 	resources := make([]xlist.Resource, len(l.resources), len(l.resources))
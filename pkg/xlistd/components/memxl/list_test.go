@@ -115,6 +115,47 @@ func TestLoadFile(t *testing.T) {
 	}
 }
 
+func TestList_Match(t *testing.T) {
+	list := memxl.New("test1", []xlist.Resource{xlist.IPv4, xlist.IPv6, xlist.Domain}, memxl.Config{})
+	list.AddIP4s([]string{"10.1.1.1"})
+	list.AddCIDR4s([]string{"10.0.0.0/8", "10.1.0.0/16"})
+	list.AddCIDR6s([]string{"2001:db8::/32"})
+	list.AddDomains([]string{"www.example.com"})
+	list.AddSubdomains([]string{"com", "example.com", "corp.example.com"})
+
+	var tests = []struct {
+		name     string
+		resource xlist.Resource
+		want     memxl.Data
+		wantSpec int
+		wantOk   bool
+	}{
+		{"10.1.1.1", xlist.IPv4, memxl.Data{Resource: xlist.IPv4, Format: xlistd.Plain, Value: "10.1.1.1"}, 32, true},                    //0
+		{"10.1.1.2", xlist.IPv4, memxl.Data{Resource: xlist.IPv4, Format: xlistd.CIDR, Value: "10.1.0.0/16"}, 16, true},                  //1
+		{"10.2.1.1", xlist.IPv4, memxl.Data{Resource: xlist.IPv4, Format: xlistd.CIDR, Value: "10.0.0.0/8"}, 8, true},                    //2
+		{"11.1.1.1", xlist.IPv4, memxl.Data{}, 0, false},                                                                                 //3
+		{"2001:db8::1", xlist.IPv6, memxl.Data{Resource: xlist.IPv6, Format: xlistd.CIDR, Value: "2001:db8::/32"}, 32, true},             //4
+		{"www.example.com", xlist.Domain, memxl.Data{Resource: xlist.Domain, Format: xlistd.Plain, Value: "www.example.com"}, 7, true},   //5
+		{"a.www.example.com", xlist.Domain, memxl.Data{Resource: xlist.Domain, Format: xlistd.Sub, Value: "example.com"}, 4, true},       //6
+		{"example.com", xlist.Domain, memxl.Data{Resource: xlist.Domain, Format: xlistd.Sub, Value: "example.com"}, 4, true},             //7
+		{"a.corp.example.com", xlist.Domain, memxl.Data{Resource: xlist.Domain, Format: xlistd.Sub, Value: "corp.example.com"}, 6, true}, //8
+		{"example.org", xlist.Domain, memxl.Data{}, 0, false},                                                                            //9
+		{"example.net.com", xlist.Domain, memxl.Data{Resource: xlist.Domain, Format: xlistd.Sub, Value: "com"}, 2, true},                 //10
+	}
+	for idx, test := range tests {
+		got, spec, ok, err := list.Match(context.Background(), test.name, test.resource)
+		if err != nil {
+			t.Errorf("idx[%v] memxl.Match(): err=%v", idx, err)
+		}
+		if got != test.want || spec != test.wantSpec || ok != test.wantOk {
+			t.Errorf("idx[%v] memxl.Match(): want=%v,%v,%v got=%v,%v,%v", idx, test.want, test.wantSpec, test.wantOk, got, spec, ok)
+		}
+	}
+	if _, _, _, err := list.Match(context.Background(), "d41d8cd98f00b204e9800998ecf8427e", xlist.MD5); err != xlist.ErrNotSupported {
+		t.Errorf("memxl.Match(): unexpected err=%v", err)
+	}
+}
+
 func TestLoadData(t *testing.T) {
 	list := memxl.New("test1", []xlist.Resource{xlist.IPv4, xlist.Domain, xlist.IPv6}, memxl.Config{})
 	list.AddIP4s([]string{"2.2.2.2"})
@@ -17,7 +17,7 @@ import (
 func Builder() xlistd.BuildListFn {
 	return func(b *xlistd.Builder, parents []string, def xlistd.ListDef) (xlistd.List, error) {
 		//create mockup and sets source
-		bl := &List{ResourceList: xlist.ClearResourceDups(def.Resources, true)}
+		bl := &List{Identifier: def.ID, ResourceList: xlist.ClearResourceDups(def.Resources, true)}
 		if def.Source != "" {
 			results, err := sourceToResults(def.Source)
 			if err != nil {
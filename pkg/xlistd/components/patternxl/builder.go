@@ -0,0 +1,163 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package patternxl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildListFn {
+	return func(b *xlistd.Builder, parents []string, def xlistd.ListDef) (xlistd.List, error) {
+		cfg := defaultCfg
+		var patterns []Pattern
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+			patterns, err = getData(def.Opts)
+			if err != nil {
+				return nil, err
+			}
+			// validates patterns in construction
+			if _, err := newMatcher(patterns, cfg.Limits); err != nil {
+				return nil, fmt.Errorf("invalid 'data': %v", err)
+			}
+		}
+		if def.Source == "" && len(patterns) == 0 {
+			def.Source = fmt.Sprintf("%s.patterns", def.ID)
+		}
+		var source string
+		if def.Source != "" {
+			source = b.DataPath(def.Source)
+			if !fileExists(source) {
+				return nil, fmt.Errorf("file '%s' doesn't exists", source)
+			}
+		}
+		bl := New(def.ID, source, patterns, def.Resources, cfg, b.Logger())
+		//register startup
+		b.OnStartup(func() error {
+			return bl.Open()
+		})
+		//register shutdown
+		b.OnShutdown(func() error {
+			bl.Close()
+			return nil
+		})
+		return bl, nil
+	}
+}
+
+func fileExists(filename string) bool {
+	info, err := os.Stat(filename)
+	if err != nil || os.IsNotExist(err) {
+		return false
+	}
+	return !info.IsDir()
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	reason, ok, err := option.String(opts, "reason")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Reason = reason
+	}
+
+	autoreload, ok, err := option.Bool(opts, "autoreload")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Autoreload = autoreload
+	}
+
+	reloadSecs, ok, err := option.Int(opts, "reloadseconds")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if reloadSecs <= 0 {
+			return dst, errors.New("invalid 'reloadseconds'")
+		}
+		dst.ReloadTime = time.Duration(reloadSecs) * time.Second
+	}
+
+	maxLength, ok, err := option.Int(opts, "maxlength")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if maxLength <= 0 {
+			return dst, errors.New("invalid 'maxlength'")
+		}
+		dst.Limits.MaxLength = maxLength
+	}
+
+	maxProgram, ok, err := option.Int(opts, "maxprogram")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if maxProgram <= 0 {
+			return dst, errors.New("invalid 'maxprogram'")
+		}
+		dst.Limits.MaxProgram = maxProgram
+	}
+	return dst, nil
+}
+
+func getData(opts map[string]interface{}) ([]Pattern, error) {
+	data := make([]Pattern, 0)
+	value, ok, err := option.SliceHashString(opts, "data")
+	if err != nil {
+		return data, err
+	}
+	if ok {
+		for _, item := range value {
+			r, ok := item["resource"]
+			if !ok {
+				return data, errors.New("invalid 'data': required 'resource'")
+			}
+			resource, err := xlist.ToResource(r)
+			if err != nil {
+				return data, fmt.Errorf("invalid 'data': invalid 'resource': %v", err)
+			}
+			t, ok := item["type"]
+			if !ok {
+				return data, errors.New("invalid 'data': required 'type'")
+			}
+			ptype, err := ToType(t)
+			if err != nil {
+				return data, fmt.Errorf("invalid 'data': invalid 'type': %v", err)
+			}
+			v, ok := item["pattern"]
+			if !ok {
+				return data, errors.New("invalid 'data': required 'pattern'")
+			}
+			data = append(data,
+				Pattern{
+					Resource: resource,
+					Type:     ptype,
+					Value:    v,
+					Reason:   item["reason"],
+				})
+		}
+	}
+	return data, nil
+}
+
+func init() {
+	xlistd.RegisterListBuilder(ComponentClass, Builder(DefaultConfig()))
+}
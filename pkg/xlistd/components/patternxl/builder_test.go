@@ -0,0 +1,104 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package patternxl_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/patternxl"
+)
+
+var testdatabase1 = []xlistd.ListDef{
+	{ID: "list1",
+		Class:     patternxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.Domain}},
+	{ID: "list2",
+		Class:     patternxl.ComponentClass,
+		Source:    "testfile1.patterns",
+		Resources: []xlist.Resource{xlist.Domain}},
+	{ID: "list3",
+		Class:     patternxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.Domain},
+		Opts: map[string]interface{}{
+			"reason": "pattern", "autoreload": true, "reloadseconds": 10, "maxlength": 100, "maxprogram": 500,
+			"data": []interface{}{
+				map[string]interface{}{"resource": "domain", "type": "glob", "pattern": "ads*.example.*"},
+				map[string]interface{}{"resource": "domain", "type": "regex", "pattern": "[a-z]{12}\\.top", "reason": "dga"},
+			}}},
+	{ID: "list4",
+		Class:     patternxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.Domain},
+		Opts: map[string]interface{}{
+			"data": []interface{}{
+				map[string]interface{}{"resource": "domain", "type": "wildcard", "pattern": "ads*.example.*"},
+			}}},
+	{ID: "list5",
+		Class:     patternxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.Domain},
+		Opts: map[string]interface{}{
+			"data": []interface{}{
+				map[string]interface{}{"resource": "domain", "type": "regex", "pattern": ".*"},
+			}}},
+	{ID: "list6",
+		Class:     patternxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.Domain},
+		Opts: map[string]interface{}{
+			"maxlength": 5,
+			"data": []interface{}{
+				map[string]interface{}{"resource": "domain", "type": "glob", "pattern": "ads*.example.*"},
+			}}},
+	{ID: "list7",
+		Class:     patternxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.Domain},
+		Opts:      map[string]interface{}{"data": []interface{}{map[string]interface{}{"resource": "domain", "type": "glob"}}}},
+	{ID: "list8",
+		Class:     patternxl.ComponentClass,
+		Source:    "testfile1.patterns",
+		Resources: []xlist.Resource{xlist.Domain},
+		Opts:      map[string]interface{}{"reloadseconds": 0}},
+}
+
+func TestBuild(t *testing.T) {
+	b := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.DataDir(testdir))
+	defer b.Shutdown()
+
+	//define and do tests
+	var tests = []struct {
+		listid  string
+		wantErr string
+	}{
+		{"list1", "doesn't exists"},
+		{"list2", ""},
+		{"list3", ""},
+		{"list4", "invalid 'type'"},
+		{"list5", "empty string"},
+		{"list6", "too long"},
+		{"list7", "required 'pattern'"},
+		{"list8", "invalid 'reloadseconds'"},
+	}
+	for _, test := range tests {
+		def, ok := xlistd.FilterID(test.listid, testdatabase1)
+		if !ok {
+			t.Errorf("can't find id %s in database tests", test.listid)
+			continue
+		}
+		_, err := b.Build(def)
+		switch {
+		case test.wantErr == "" && err == nil:
+			//
+		case test.wantErr == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("expected error for %s", test.listid)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		}
+	}
+	if err := b.Start(); err != nil {
+		t.Errorf("starting lists: %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package patternxl provides a xlistd.List implementation that checks names
+// against glob and regular expression patterns.
+//
+// This package is a work in progress and makes no API stability promises.
+package patternxl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+)
+
+// ComponentClass defines default class for component builder
+const ComponentClass = "pattern"
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		ReloadTime: 30 * time.Second,
+		Limits: Limits{
+			MaxLength:  1024,
+			MaxProgram: 10000,
+		},
+	}
+}
+
+// Config options
+type Config struct {
+	ForceValidation bool
+	Reason          string
+	Autoreload      bool
+	ReloadTime      time.Duration
+	Limits          Limits
+}
+
+// List checks names against a set of patterns loaded from a file and from
+// the patterns passed in construction. With autoreload, the file should be
+// replaced atomically (ex: rename) to avoid loading partial contents.
+type List struct {
+	id        string
+	cfg       Config
+	logger    yalogi.Logger
+	filename  string
+	static    []Pattern
+	resources []xlist.Resource
+
+	mu      sync.RWMutex
+	mtime   time.Time
+	size    int64
+	matcher *matcher
+	err     error
+	close   chan bool
+	started bool
+}
+
+// New creates a new List, filename is optional.
+func New(id, filename string, patterns []Pattern, resources []xlist.Resource, cfg Config, logger yalogi.Logger) *List {
+	l := &List{
+		id:        id,
+		filename:  filename,
+		static:    patterns,
+		cfg:       cfg,
+		logger:    logger,
+		resources: xlist.ClearResourceDups(resources, true),
+		close:     make(chan bool),
+	}
+	if l.logger == nil {
+		l.logger = yalogi.LogNull
+	}
+	return l
+}
+
+// ID implements xlistd.List interface
+func (l *List) ID() string {
+	return l.id
+}
+
+// Class implements xlistd.List interface
+func (l *List) Class() string {
+	return ComponentClass
+}
+
+// Check implements xlist.Checker interface
+func (l *List) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	if !resource.InArray(l.resources) {
+		return xlist.Response{}, xlist.ErrNotSupported
+	}
+	name, ctx, err := xlist.DoValidation(ctx, name, resource, l.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	l.mu.RLock()
+	m := l.matcher
+	l.mu.RUnlock()
+	if m == nil {
+		return xlist.Response{}, xlist.ErrUnavailable
+	}
+	p, ok, err := m.match(ctx, name, resource)
+	if err != nil {
+		return xlist.Response{}, xlist.ErrCanceledRequest
+	}
+	if !ok {
+		return xlist.Response{}, nil
+	}
+	resp := xlist.Response{Result: true, Reason: p.Reason}
+	if resp.Reason == "" {
+		resp.Reason = l.cfg.Reason
+	}
+	if resp.Reason == "" {
+		resp.Reason = fmt.Sprintf("matches %v '%s'", p.Type, p.Value)
+	}
+	return resp, nil
+}
+
+// Resources implements xlist.Checker interface
+func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	resources := make([]xlist.Resource, len(l.resources), len(l.resources))
+	copy(resources, l.resources)
+	return resources, nil
+}
+
+// Ping implements xlistd.Ping interface
+func (l *List) Ping() error {
+	if !l.started {
+		return errors.New("list is closed")
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.err
+}
+
+// Inspect implements xlistd.Inspector interface.
+func (l *List) Inspect() map[string]interface{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	patterns := make(map[string]int)
+	if l.matcher != nil {
+		for r, n := range l.matcher.count {
+			patterns[r.String()] = n
+		}
+	}
+	return map[string]interface{}{"patterns": patterns}
+}
+
+// Open compiles the patterns.
+func (l *List) Open() error {
+	l.logger.Debugf("%s: opening source '%s'", l.id, l.filename)
+	if err := l.load(); err != nil {
+		return err
+	}
+	if l.cfg.Autoreload && l.filename != "" {
+		go l.doReload()
+	}
+	l.started = true
+	return nil
+}
+
+// Close releases the patterns.
+func (l *List) Close() {
+	l.logger.Debugf("%s: closing source '%s'", l.id, l.filename)
+	if l.started {
+		if l.cfg.Autoreload && l.filename != "" {
+			l.close <- true
+		}
+		l.started = false
+		l.mu.Lock()
+		l.matcher = nil
+		l.mu.Unlock()
+	}
+}
+
+// Reload implements xlistd.Reloader interface, it reloads the patterns from
+// the file. Current patterns are kept if there are errors.
+func (l *List) Reload() error {
+	l.logger.Debugf("reloading source '%s'", l.filename)
+	return l.load()
+}
+
+func (l *List) load() error {
+	var mtime time.Time
+	var size int64
+	patterns := make([]Pattern, 0, len(l.static))
+	patterns = append(patterns, l.static...)
+	if l.filename != "" {
+		file, err := os.Open(l.filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		stat, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		loaded, err := ReadPatterns(file)
+		if err != nil {
+			return err
+		}
+		mtime, size = stat.ModTime(), stat.Size()
+		patterns = append(patterns, loaded...)
+	}
+	// only patterns of the resources checked by the list
+	filtered := patterns[:0]
+	for _, p := range patterns {
+		if p.Resource.InArray(l.resources) {
+			filtered = append(filtered, p)
+		}
+	}
+	m, err := newMatcher(filtered, l.cfg.Limits)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.matcher, l.mtime, l.size = m, mtime, size
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *List) doReload() {
+	ticker := time.NewTicker(l.cfg.ReloadTime)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.close:
+			return
+		case <-ticker.C:
+			l.logger.Debugf("checking source '%s'", l.filename)
+			changed, err := l.changed()
+			if err == nil && changed {
+				l.logger.Infof("source '%s' has changed", l.filename)
+				err = l.Reload()
+				l.setErr(err)
+			}
+		}
+	}
+}
+
+func (l *List) changed() (bool, error) {
+	stat, err := os.Stat(l.filename)
+	if err != nil {
+		return false, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.mtime.Equal(stat.ModTime()) && l.size == stat.Size() {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (l *List) setErr(err error) {
+	l.mu.Lock()
+	l.err = err
+	l.mu.Unlock()
+	if err != nil {
+		l.logger.Warnf("%s: %v", l.id, err)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package patternxl_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/xlistd/components/patternxl"
+)
+
+var testdir = "../../../../test/testdata"
+var testfile1 = "../../../../test/testdata/testfile1.patterns"
+
+func TestList_Check(t *testing.T) {
+	list := patternxl.New("test1", testfile1,
+		[]patternxl.Pattern{{Resource: xlist.Domain, Type: patternxl.Glob, Value: "*.evil.com", Reason: "static"}},
+		[]xlist.Resource{xlist.Domain, xlist.MD5}, patternxl.DefaultConfig(), yalogi.LogNull)
+	err := list.Open()
+	if err != nil {
+		t.Fatalf("patternxl.Open(): err=%v", err)
+	}
+	defer list.Close()
+
+	var tests = []struct {
+		name     string
+		resource xlist.Resource
+		want     bool
+		reason   string
+	}{
+		{"ads1.example.com", xlist.Domain, true, "matches glob 'ads*.example.*'"},
+		{"ADS.example.org", xlist.Domain, true, "matches glob 'ads*.example.*'"},
+		{"www.ads.example.com", xlist.Domain, false, ""},
+		{"img.cdn-east.net", xlist.Domain, true, "cdn hosts"},
+		{"a.b.cdn-x.net", xlist.Domain, true, "cdn hosts"},
+		{"cdn-x.net", xlist.Domain, false, ""},
+		{"tracker7.example.org", xlist.Domain, true, ""},
+		{"trackerx.example.org", xlist.Domain, false, ""},
+		{"qwertyuiopasdf.top", xlist.Domain, true, "dga family x"},
+		{"short.top", xlist.Domain, false, ""},
+		{"xx.example.com", xlist.Domain, true, ""},
+		{"xxxx.example.com", xlist.Domain, false, ""},
+		{"www.evil.com", xlist.Domain, true, "static"},
+		{"d41d8cd98f00b204e9800998ecf8427e", xlist.MD5, true, ""},
+	}
+	for idx, test := range tests {
+		got, err := list.Check(context.Background(), test.name, test.resource)
+		if err != nil {
+			t.Errorf("idx[%v] patternxl.Check(): err=%v", idx, err)
+		}
+		if got.Result != test.want {
+			t.Errorf("idx[%v] patternxl.Check(): want=%v got=%v", idx, test.want, got)
+		}
+		if test.reason != "" && got.Reason != test.reason {
+			t.Errorf("idx[%v] patternxl.Check(): unexpected reason '%s'", idx, got.Reason)
+		}
+	}
+	_, err = list.Check(context.Background(), "10.0.0.1", xlist.IPv4)
+	if err != xlist.ErrNotSupported {
+		t.Errorf("patternxl.Check(): unexpected err=%v", err)
+	}
+	info := list.Inspect()["patterns"].(map[string]int)
+	if info["domain"] != 6 || info["md5"] != 1 {
+		t.Errorf("patternxl.Inspect(): unexpected %v", info)
+	}
+}
+
+func TestList_Guards(t *testing.T) {
+	var tests = []struct {
+		ptype   patternxl.Type
+		pattern string
+		wantErr string
+	}{
+		{patternxl.Glob, "*", "empty string"},
+		{patternxl.Regex, ".*", "empty string"},
+		{patternxl.Regex, "(a|b)*", "empty string"},
+		{patternxl.Regex, strings.Repeat("[a-z]{1000}", 11), "too complex"},
+		{patternxl.Regex, strings.Repeat("a", 2000), "too long"},
+		{patternxl.Regex, "(a", "missing closing"},
+		{patternxl.Glob, "ads[0-9.example.com", "unclosed"},
+		{patternxl.Regex, "(a+)+b", ""},
+	}
+	for idx, test := range tests {
+		list := patternxl.New("test1", "",
+			[]patternxl.Pattern{{Resource: xlist.Domain, Type: test.ptype, Value: test.pattern}},
+			[]xlist.Resource{xlist.Domain}, patternxl.DefaultConfig(), yalogi.LogNull)
+		err := list.Open()
+		switch {
+		case test.wantErr == "" && err != nil:
+			t.Errorf("idx[%v] patternxl.Open(): unexpected err=%v", idx, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("idx[%v] patternxl.Open(): expected error", idx)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("idx[%v] patternxl.Open(): unexpected err=%v", idx, err)
+		}
+		if err != nil {
+			continue
+		}
+		// linear time matching with patterns catastrophic for backtracking engines
+		start := time.Now()
+		list.Check(context.Background(), strings.Repeat("a", 200)+".com", xlist.Domain)
+		if time.Since(start) > time.Second {
+			t.Errorf("idx[%v] patternxl.Check(): too slow", idx)
+		}
+		list.Close()
+	}
+}
+
+func TestList_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "patternxl")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "test.patterns")
+	write := func(patterns ...string) {
+		content := ""
+		for _, p := range patterns {
+			content = content + fmt.Sprintf("domain,glob,%s\n", p)
+		}
+		// replaces atomically, a partial file would be loaded
+		if err := ioutil.WriteFile(source+".tmp", []byte(content), 0644); err != nil {
+			t.Fatalf("writing patterns: %v", err)
+		}
+		if err := os.Rename(source+".tmp", source); err != nil {
+			t.Fatalf("renaming patterns: %v", err)
+		}
+	}
+	write("*.example.com")
+
+	cfg := patternxl.DefaultConfig()
+	cfg.Autoreload = true
+	cfg.ReloadTime = 50 * time.Millisecond
+	list := patternxl.New("test1", source, nil, []xlist.Resource{xlist.Domain}, cfg, yalogi.LogNull)
+	if err := list.Open(); err != nil {
+		t.Fatalf("patternxl.Open(): err=%v", err)
+	}
+	defer list.Close()
+	check := func(name string, want bool) {
+		resp, err := list.Check(context.Background(), name, xlist.Domain)
+		if err != nil || resp.Result != want {
+			t.Errorf("patternxl.Check(%s): want=%v got=%v err=%v", name, want, resp.Result, err)
+		}
+	}
+	check("www.example.com", true)
+	check("www.example.org", false)
+
+	write("*.example.com", "*.example.org")
+	time.Sleep(200 * time.Millisecond)
+	check("www.example.org", true)
+	if err := list.Ping(); err != nil {
+		t.Errorf("patternxl.Ping(): err=%v", err)
+	}
+
+	// invalid patterns keeps the previous ones
+	write("*.example.com", "*")
+	time.Sleep(200 * time.Millisecond)
+	check("www.example.org", true)
+	if err := list.Ping(); err == nil {
+		t.Error("patternxl.Ping(): expected error")
+	}
+}
+
+func TestReadPatterns(t *testing.T) {
+	var tests = []struct {
+		line    string
+		want    patternxl.Pattern
+		wantErr string
+	}{
+		{`domain,glob,*.example.com`,
+			patternxl.Pattern{Resource: xlist.Domain, Type: patternxl.Glob, Value: "*.example.com"}, ""},
+		{`domain,glob,ads,*.example.com`,
+			patternxl.Pattern{Resource: xlist.Domain, Type: patternxl.Glob, Reason: "ads", Value: "*.example.com"}, ""},
+		{`domain,regex,,x{1,3}\.example\.com`,
+			patternxl.Pattern{Resource: xlist.Domain, Type: patternxl.Regex, Value: `x{1,3}\.example\.com`}, ""},
+		{`domain,regex,"x{1,3}\.example\.com"`,
+			patternxl.Pattern{Resource: xlist.Domain, Type: patternxl.Regex, Value: `x{1,3}\.example\.com`}, ""},
+		{`domain,regex,dga (x),"[a-z]{12,16}\.top"`,
+			patternxl.Pattern{Resource: xlist.Domain, Type: patternxl.Regex, Reason: "dga (x)", Value: `[a-z]{12,16}\.top`}, ""},
+		{`domain,regex,x{1,3}\.example\.com`, patternxl.Pattern{}, "ambiguous pattern"},
+		{`domain,regex,[a-z]{1,3}\.(com,net)`, patternxl.Pattern{}, "ambiguous pattern"},
+		{`domain,regex`, patternxl.Pattern{}, "invalid line"},
+		{`domain,bogus,x`, patternxl.Pattern{}, "invalid pattern type"},
+	}
+	for idx, test := range tests {
+		got, err := patternxl.ReadPatterns(strings.NewReader(test.line))
+		switch {
+		case test.wantErr == "" && err != nil:
+			t.Errorf("idx[%v] patternxl.ReadPatterns(): err=%v", idx, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("idx[%v] patternxl.ReadPatterns(): expected error", idx)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("idx[%v] patternxl.ReadPatterns(): unexpected err=%v", idx, err)
+		case test.wantErr == "" && (len(got) != 1 || got[0] != test.want):
+			t.Errorf("idx[%v] patternxl.ReadPatterns(): want=%v got=%v", idx, test.want, got)
+		}
+	}
+}
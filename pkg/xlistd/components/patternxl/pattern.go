@@ -0,0 +1,308 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package patternxl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/luids-io/api/xlist"
+)
+
+// Type of pattern.
+type Type int
+
+// List of types.
+const (
+	Glob Type = iota
+	Regex
+)
+
+// String implements stringer interface.
+func (t Type) String() string {
+	switch t {
+	case Glob:
+		return "glob"
+	case Regex:
+		return "regex"
+	default:
+		return fmt.Sprintf("unknown(%d)", t)
+	}
+}
+
+// ToType returns the type from its string representation.
+func ToType(s string) (Type, error) {
+	switch strings.ToLower(s) {
+	case "glob":
+		return Glob, nil
+	case "regex", "regexp":
+		return Regex, nil
+	default:
+		return Type(-1), fmt.Errorf("invalid type %s", s)
+	}
+}
+
+// Pattern stores a pattern definition.
+type Pattern struct {
+	Resource xlist.Resource
+	Type     Type
+	Value    string
+	// Reason returned when the pattern matches, optional
+	Reason string
+}
+
+func (p Pattern) String() string {
+	return fmt.Sprintf("%v,%v,%s", p.Resource, p.Type, p.Value)
+}
+
+// Limits used as guards against expensive patterns.
+type Limits struct {
+	// MaxLength is the max length of a pattern
+	MaxLength int
+	// MaxProgram is the max number of instructions of a compiled pattern
+	MaxProgram int
+}
+
+// Errors returned by compile.
+var (
+	ErrTooLong    = errors.New("pattern too long")
+	ErrTooComplex = errors.New("pattern too complex")
+	ErrTooBroad   = errors.New("pattern matches the empty string")
+)
+
+// GlobToRegex translates a glob pattern to a regular expression. Wildcard
+// '*' matches any sequence of characters (dots included), '?' matches a
+// single character and '[...]' matches a class of characters.
+func GlobToRegex(glob string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return "", errors.New("unclosed '['")
+			}
+			class := glob[i+1 : i+1+end]
+			if class == "" {
+				return "", errors.New("empty class")
+			}
+			if class[0] == '!' {
+				class = "^" + class[1:]
+			}
+			sb.WriteByte('[')
+			sb.WriteString(strings.Replace(class, `\`, `\\`, -1))
+			sb.WriteByte(']')
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String(), nil
+}
+
+// compile returns the anchored regular expression of the pattern checking
+// the limits passed.
+func compile(p Pattern, limits Limits) (string, *regexp.Regexp, error) {
+	if p.Value == "" {
+		return "", nil, errors.New("empty pattern")
+	}
+	if limits.MaxLength > 0 && len(p.Value) > limits.MaxLength {
+		return "", nil, ErrTooLong
+	}
+	expr := p.Value
+	if p.Type == Glob {
+		var err error
+		expr, err = GlobToRegex(p.Value)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	expr = "(?i:" + expr + ")"
+	// parse and compile to syntax program to check its size before
+	// compiling, repetitions like (a{100}){100} make huge programs
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", nil, err
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return "", nil, err
+	}
+	if limits.MaxProgram > 0 && len(prog.Inst) > limits.MaxProgram {
+		return "", nil, ErrTooComplex
+	}
+	compiled, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return "", nil, err
+	}
+	if compiled.MatchString("") {
+		return "", nil, ErrTooBroad
+	}
+	return expr, compiled, nil
+}
+
+// chunkSize is the number of patterns joined in each alternation.
+const chunkSize = 64
+
+type compiled struct {
+	pattern Pattern
+	re      *regexp.Regexp
+}
+
+type chunk struct {
+	re       *regexp.Regexp
+	patterns []compiled
+}
+
+// matcher checks names against a set of patterns. Patterns are joined in
+// alternations, so a negative check only runs a few regular expressions.
+// Individual patterns are only used to get the reason of a positive.
+type matcher struct {
+	chunks map[xlist.Resource][]chunk
+	count  map[xlist.Resource]int
+}
+
+func newMatcher(patterns []Pattern, limits Limits) (*matcher, error) {
+	m := &matcher{
+		chunks: make(map[xlist.Resource][]chunk),
+		count:  make(map[xlist.Resource]int),
+	}
+	pending := make(map[xlist.Resource][]compiled)
+	exprs := make(map[xlist.Resource][]string)
+	for idx, p := range patterns {
+		expr, re, err := compile(p, limits)
+		if err != nil {
+			return nil, fmt.Errorf("idx %v: invalid '%v': %v", idx, p, err)
+		}
+		pending[p.Resource] = append(pending[p.Resource], compiled{pattern: p, re: re})
+		exprs[p.Resource] = append(exprs[p.Resource], expr)
+		m.count[p.Resource]++
+	}
+	for r, list := range pending {
+		for i := 0; i < len(list); i += chunkSize {
+			end := i + chunkSize
+			if end > len(list) {
+				end = len(list)
+			}
+			re, err := regexp.Compile("^(?:" + strings.Join(exprs[r][i:end], "|") + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("compiling patterns: %v", err)
+			}
+			m.chunks[r] = append(m.chunks[r], chunk{re: re, patterns: list[i:end]})
+		}
+	}
+	return m, nil
+}
+
+// match returns the pattern that matches the name.
+func (m *matcher) match(ctx context.Context, name string, resource xlist.Resource) (Pattern, bool, error) {
+	for _, c := range m.chunks[resource] {
+		select {
+		case <-ctx.Done():
+			return Pattern{}, false, ctx.Err()
+		default:
+		}
+		if !c.re.MatchString(name) {
+			continue
+		}
+		for _, p := range c.patterns {
+			if p.re.MatchString(name) {
+				return p.pattern, true, nil
+			}
+		}
+	}
+	return Pattern{}, false, nil
+}
+
+// ReadPatterns reads patterns from an io.Reader. Each line has the format
+// "resource,type,pattern" or "resource,type,reason,pattern", reason may be
+// empty. Patterns that contain commas must be in the second form or be
+// double-quoted: `domain,regex,"x{1,3}\.com"`. Lines whose reason contains
+// unbalanced brackets are ambiguous and rejected. Lines starting with '#' are
+// comments.
+func ReadPatterns(in io.Reader) ([]Pattern, error) {
+	patterns := make([]Pattern, 0)
+	nline := 0
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		nline++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, ",", 3)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %v: invalid line", nline)
+		}
+		resource, err := xlist.ToResource(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %v: invalid resource type '%s'", nline, fields[0])
+		}
+		ptype, err := ToType(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %v: invalid pattern type '%s'", nline, fields[1])
+		}
+		p := Pattern{Resource: resource, Type: ptype}
+		p.Reason, p.Value, err = splitPattern(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %v", nline, err)
+		}
+		patterns = append(patterns, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanning input: %v", err)
+	}
+	return patterns, nil
+}
+
+// splitPattern returns the reason and the pattern of the last fields of a line
+func splitPattern(s string) (string, string, error) {
+	if quoted(s) {
+		return "", s[1 : len(s)-1], nil
+	}
+	idx := strings.IndexByte(s, ',')
+	if idx < 0 {
+		return "", s, nil
+	}
+	reason, value := s[:idx], s[idx+1:]
+	if quoted(value) {
+		return reason, value[1 : len(value)-1], nil
+	}
+	if !balanced(reason) {
+		return "", "", fmt.Errorf("ambiguous pattern '%s', patterns with commas must be quoted", s)
+	}
+	return reason, value, nil
+}
+
+func quoted(s string) bool {
+	return len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"'
+}
+
+// balanced returns true if brackets, braces and parentheses are balanced
+func balanced(s string) bool {
+	stack := make([]rune, 0)
+	pairs := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	for _, c := range s {
+		switch c {
+		case '(', '[', '{':
+			stack = append(stack, c)
+		case ')', ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1] != pairs[c] {
+				return false
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return len(stack) == 0
+}
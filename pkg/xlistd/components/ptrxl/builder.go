@@ -0,0 +1,159 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package ptrxl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/dnsxl"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildListFn {
+	return func(b *xlistd.Builder, parents []string, def xlistd.ListDef) (xlistd.List, error) {
+		cfg := defaultCfg
+		if len(def.Contains) > 1 {
+			return nil, errors.New("number of childs must be 0 or 1")
+		}
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		var child xlistd.List
+		if len(def.Contains) == 1 {
+			var err error
+			child, err = b.BuildChild(append(parents, def.ID), def.Contains[0])
+			if err != nil {
+				return nil, fmt.Errorf("constructing child '%s': %v", def.Contains[0].ID, err)
+			}
+		}
+		return New(def.ID, child, def.Resources, cfg, b.Logger())
+	}
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	reason, ok, err := option.String(opts, "reason")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Reason = reason
+	}
+	pingdns, ok, err := option.String(opts, "pingdns")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.PingDNS = pingdns
+	}
+	resolvers, ok, err := option.SliceString(opts, "resolvers")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		pool, err := dnsxl.NewResolverRRPool(resolvers)
+		if err != nil {
+			return dst, fmt.Errorf("invalid 'resolvers': %v", err)
+		}
+		dst.Resolver = pool
+	}
+	timeout, ok, err := option.Int(opts, "timeout")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if timeout <= 0 {
+			return dst, errors.New("invalid 'timeout'")
+		}
+		dst.Timeout = time.Duration(timeout) * time.Millisecond
+	}
+	retries, ok, err := option.Int(opts, "retries")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if retries <= 0 {
+			return dst, errors.New("invalid 'retries'")
+		}
+		dst.Retries = retries
+	}
+	forward, ok, err := option.Bool(opts, "forwardconfirm")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.ForwardConfirm = forward
+	}
+	maxNames, ok, err := option.Int(opts, "maxnames")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if maxNames < 0 {
+			return dst, errors.New("invalid 'maxnames'")
+		}
+		dst.MaxNames = maxNames
+	}
+	generic, ok, err := option.Bool(opts, "generic")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Generic = generic
+	}
+	patterns, ok, err := option.SliceString(opts, "patterns")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Patterns = make([]*regexp.Regexp, 0, len(patterns))
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return dst, fmt.Errorf("invalid 'patterns': %v", err)
+			}
+			dst.Patterns = append(dst.Patterns, re)
+		}
+	}
+	cache, ok, err := option.Bool(opts, "cache")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Cache = cache
+	}
+	maxTTL, ok, err := option.Int(opts, "maxttl")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if maxTTL < 0 {
+			return dst, errors.New("invalid 'maxttl'")
+		}
+		dst.MaxTTL = time.Duration(maxTTL) * time.Second
+	}
+	negTTL, ok, err := option.Int(opts, "negativettl")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if negTTL < 0 {
+			return dst, errors.New("invalid 'negativettl'")
+		}
+		dst.NegativeTTL = time.Duration(negTTL) * time.Second
+	}
+	return dst, nil
+}
+
+func init() {
+	xlistd.RegisterListBuilder(ComponentClass, Builder(DefaultConfig()))
+}
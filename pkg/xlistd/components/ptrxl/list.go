@@ -0,0 +1,320 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package ptrxl provides a composite xlistd.List implementation that
+// checks the hostnames obtained from the reverse resolution of ips against a
+// domain list and hostname patterns.
+//
+// This package is a work in progress and makes no API stability promises.
+package ptrxl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/dnscache"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/dnsxl"
+)
+
+// ComponentClass registered.
+const ComponentClass = "ptr"
+
+// DefaultConfig returns default configuration.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:     1 * time.Second,
+		Retries:     1,
+		MaxNames:    8,
+		Cache:       true,
+		MaxTTL:      1 * time.Hour,
+		NegativeTTL: 1 * time.Minute,
+	}
+}
+
+// Config options.
+type Config struct {
+	Timeout  time.Duration
+	Retries  int
+	Resolver dnsxl.Resolver
+	// ForwardConfirm only uses hostnames that resolve to the ip checked.
+	ForwardConfirm bool
+	// MaxNames is the maximum number of hostnames checked.
+	MaxNames int
+	// Generic flags hostnames that contains the ipv4 address checked,
+	// usually used in dynamic pools.
+	Generic bool
+	// Patterns flag hostnames matching them.
+	Patterns []*regexp.Regexp
+	// Cache enables the cache of resolutions, ttls of the records are
+	// used with MaxTTL as limit. NegativeTTL is used with empty answers.
+	Cache           bool
+	MaxTTL          time.Duration
+	NegativeTTL     time.Duration
+	PingDNS         string
+	ForceValidation bool
+	Reason          string
+}
+
+// List is a composite list that checks the hostnames of ips.
+type List struct {
+	id        string
+	logger    yalogi.Logger
+	cfg       Config
+	child     xlistd.List
+	resolver  *dnscache.Resolver
+	provides  []bool
+	resources []xlist.Resource
+}
+
+// New returns a new ptr component with the resources passed. Child list is
+// optional and must check domains.
+func New(id string, child xlistd.List, resources []xlist.Resource, cfg Config, logger yalogi.Logger) (*List, error) {
+	l := &List{
+		id:        id,
+		logger:    logger,
+		cfg:       cfg,
+		child:     child,
+		resources: xlist.ClearResourceDups(resources, true),
+		provides:  make([]bool, len(xlist.Resources), len(xlist.Resources)),
+	}
+	//set resource types that provides
+	for _, r := range l.resources {
+		if r != xlist.IPv4 && r != xlist.IPv6 {
+			return nil, fmt.Errorf("resource '%v' not supported", r)
+		}
+		l.provides[int(r)] = true
+	}
+	if child != nil {
+		childres, err := child.Resources(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("getting resources from '%s': %v", child.ID(), err)
+		}
+		if !xlist.Domain.InArray(childres) {
+			return nil, fmt.Errorf("child '%s' doesn't checks domains", child.ID())
+		}
+	} else if !cfg.Generic && len(cfg.Patterns) == 0 {
+		return nil, errors.New("child or patterns are required")
+	}
+	if l.cfg.Resolver == nil {
+		l.cfg.Resolver = dnsxl.GetDefaultResolver()
+	}
+	l.resolver = dnscache.New(l.cfg.Resolver, dnscache.Config{
+		Timeout:     cfg.Timeout,
+		Retries:     cfg.Retries,
+		Cache:       cfg.Cache,
+		MaxTTL:      cfg.MaxTTL,
+		NegativeTTL: cfg.NegativeTTL,
+	})
+	return l, nil
+}
+
+// ID implements xlistd.List interface.
+func (l *List) ID() string {
+	return l.id
+}
+
+// Class implements xlistd.List interface.
+func (l *List) Class() string {
+	return ComponentClass
+}
+
+// Check implements xlist.Checker interface.
+func (l *List) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	if !l.checks(resource) {
+		return xlist.Response{}, xlist.ErrNotSupported
+	}
+	name, ctx, err := xlist.DoValidation(ctx, name, resource, l.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	hosts, ttl, err := l.hostnames(ctx, name, resource)
+	if err != nil {
+		if err == dnscache.ErrCanceled {
+			return xlist.Response{}, xlist.ErrCanceledRequest
+		}
+		l.logger.Warnf("%s: resolving '%s': %v", l.id, name, err)
+		return xlist.Response{}, xlist.ErrInternal
+	}
+	for _, host := range hosts {
+		reason, ok := l.matchPatterns(host, name, resource)
+		if ok {
+			return l.positive(xlist.Response{Result: true, Reason: reason}, ttl), nil
+		}
+		if l.child == nil {
+			continue
+		}
+		resp, err := l.child.Check(ctx, host, xlist.Domain)
+		if err != nil {
+			return xlist.Response{}, err
+		}
+		if resp.Result {
+			resp.Reason = fmt.Sprintf("ptr %s: %s", host, resp.Reason)
+			return l.positive(resp, ttl), nil
+		}
+	}
+	return xlist.Response{TTL: ttl}, nil
+}
+
+func (l *List) positive(resp xlist.Response, ttl int) xlist.Response {
+	resp.TTL = xlistd.MinTTL(resp.TTL, ttl)
+	if l.cfg.Reason != "" {
+		resp.Reason = l.cfg.Reason
+	}
+	return resp
+}
+
+// hostnames returns the valid hostnames of the ip in canonical form and the
+// min ttl of the records used.
+func (l *List) hostnames(ctx context.Context, ip string, resource xlist.Resource) ([]string, int, error) {
+	a, err := l.resolver.LookupAddr(ctx, ip)
+	if err != nil {
+		return nil, 0, err
+	}
+	ttl := a.TTL
+	hosts := make([]string, 0, len(a.Values))
+	qtype := dns.TypeA
+	if resource == xlist.IPv6 {
+		qtype = dns.TypeAAAA
+	}
+	for _, host := range a.Values {
+		if l.cfg.MaxNames > 0 && len(hosts) >= l.cfg.MaxNames {
+			break
+		}
+		// hostnames come from dns, so they are always validated
+		host, _, err = xlist.DoValidation(ctx, host, xlist.Domain, true)
+		if err != nil {
+			l.logger.Debugf("%s: ignoring ptr '%s' of '%s': invalid domain", l.id, host, ip)
+			continue
+		}
+		if l.cfg.ForwardConfirm {
+			fwd, err := l.resolver.Lookup(ctx, host, qtype)
+			if err != nil {
+				return nil, 0, err
+			}
+			ttl = xlistd.MinTTL(ttl, fwd.TTL)
+			if !containsIP(fwd.Values, ip) {
+				continue
+			}
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, ttl, nil
+}
+
+func (l *List) matchPatterns(host, ip string, resource xlist.Resource) (string, bool) {
+	if l.cfg.Generic && resource == xlist.IPv4 && isGeneric(host, ip) {
+		return fmt.Sprintf("ptr %s is generic", host), true
+	}
+	for _, p := range l.cfg.Patterns {
+		if p.MatchString(host) {
+			return fmt.Sprintf("ptr %s matches '%s'", host, p.String()), true
+		}
+	}
+	return "", false
+}
+
+// isGeneric returns true if hostname contains the ip in some of the usual
+// formats of dynamic pools: 10-1-2-3, 10.1.2.3, 3-2-1-10, 010001002003...
+// The ip must not be part of a longer number, so other ips don't match.
+func isGeneric(host, ip string) bool {
+	parsed := net.ParseIP(ip).To4()
+	if parsed == nil {
+		return false
+	}
+	a, b, c, d := parsed[0], parsed[1], parsed[2], parsed[3]
+	for _, s := range []string{
+		fmt.Sprintf("%d-%d-%d-%d", a, b, c, d),
+		fmt.Sprintf("%d-%d-%d-%d", d, c, b, a),
+		fmt.Sprintf("%d.%d.%d.%d", a, b, c, d),
+		fmt.Sprintf("%d.%d.%d.%d", d, c, b, a),
+		fmt.Sprintf("%d_%d_%d_%d", a, b, c, d),
+		fmt.Sprintf("%03d%03d%03d%03d", a, b, c, d),
+	} {
+		if containsBounded(host, s, isDigit) {
+			return true
+		}
+	}
+	return containsBounded(host, fmt.Sprintf("%02x%02x%02x%02x", a, b, c, d), isHexDigit)
+}
+
+// containsBounded returns true if s is in host and it's not preceded or
+// followed by a character of the class passed.
+func containsBounded(host, s string, class func(byte) bool) bool {
+	for start := 0; start < len(host); {
+		idx := strings.Index(host[start:], s)
+		if idx < 0 {
+			return false
+		}
+		begin, end := start+idx, start+idx+len(s)
+		if (begin == 0 || !class(host[begin-1])) && (end == len(host) || !class(host[end])) {
+			return true
+		}
+		start = begin + 1
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f')
+}
+
+func containsIP(ips []string, ip string) bool {
+	parsed := net.ParseIP(ip)
+	for _, s := range ips {
+		if parsed.Equal(net.ParseIP(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resources implements xlist.Checker interface.
+func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	resources := make([]xlist.Resource, len(l.resources), len(l.resources))
+	copy(resources, l.resources)
+	return resources, nil
+}
+
+// Ping implements xlistd.List interface.
+func (l *List) Ping() error {
+	if l.cfg.PingDNS != "" {
+		err := l.cfg.Resolver.Ping(l.cfg.PingDNS)
+		if err != nil {
+			return err
+		}
+	}
+	if l.child != nil {
+		return l.child.Ping()
+	}
+	return nil
+}
+
+// Flush implements xlistd.Flusher interface.
+func (l *List) Flush() {
+	l.resolver.Flush()
+}
+
+// Inspect implements xlistd.Inspector interface.
+func (l *List) Inspect() map[string]interface{} {
+	return map[string]interface{}{"cached": l.resolver.Len()}
+}
+
+func (l *List) checks(r xlist.Resource) bool {
+	if r.IsValid() {
+		return l.provides[int(r)]
+	}
+	return false
+}
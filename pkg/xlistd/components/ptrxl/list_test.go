@@ -0,0 +1,186 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package ptrxl_test
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/dnscache/dnstest"
+	"github.com/luids-io/xlist/pkg/xlistd/components/dnsxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/memxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/ptrxl"
+)
+
+var testzone = []string{
+	"1.1.168.192.in-addr.arpa. 300 IN PTR www.badhoster.example.",
+	"2.1.168.192.in-addr.arpa. 300 IN PTR spoofed.badhoster.example.",
+	"3.1.168.192.in-addr.arpa. 300 IN PTR host-192-168-1-3.pool.isp.example.",
+	"4.1.168.192.in-addr.arpa. 120 IN PTR mail.good.example.",
+	"4.1.168.192.in-addr.arpa. 120 IN PTR www.badhoster.example.",
+	"5.1.168.192.in-addr.arpa. 300 IN PTR dsl5.dynamic.isp.example.",
+	"6.1.168.192.in-addr.arpa. 300 IN PTR host.bad_tld.",
+	"8.1.168.192.in-addr.arpa. 300 IN PTR host-192-168-1-80.pool.isp.example.",
+	"9.1.168.192.in-addr.arpa. 300 IN PTR host-2192-168-1-9.pool.isp.example.",
+	"10.1.168.192.in-addr.arpa. 300 IN PTR c0a8010a.pool.isp.example.",
+	"11.1.168.192.in-addr.arpa. 300 IN PTR dc0a8010b.pool.isp.example.",
+	"d.a.b.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa. 300 IN PTR v6.badhoster.example.",
+	"www.badhoster.example. 60 IN A 192.168.1.1",
+	"www.badhoster.example. 60 IN A 192.168.1.4",
+	"spoofed.badhoster.example. 300 IN A 10.0.0.1",
+	"v6.badhoster.example. 300 IN AAAA 2001:db8::bad",
+}
+
+func testBadDomains() *memxl.List {
+	l := memxl.New("baddomains", []xlist.Resource{xlist.Domain}, memxl.Config{Reason: "bad hoster"})
+	l.AddSubdomain("badhoster.example")
+	return l
+}
+
+func TestList_Check(t *testing.T) {
+	s := dnstest.NewServer(t, testzone)
+	defer s.Close()
+	resolver, err := dnsxl.NewResolverRRPool([]string{s.Addr})
+	if err != nil {
+		t.Fatalf("creating resolver: %v", err)
+	}
+	dynamic := regexp.MustCompile(`\.dynamic\.`)
+
+	var tests = []struct {
+		name     string
+		resource xlist.Resource
+		forward  bool
+		generic  bool
+
+		want       bool
+		wantReason string
+		wantTTL    int
+		wantErr    error
+	}{
+		{"192.168.1.1", xlist.IPv4, false, false, true, "ptr www.badhoster.example: bad hoster", 300, nil},
+		{"192.168.1.1", xlist.IPv4, true, false, true, "ptr www.badhoster.example: bad hoster", 60, nil},
+		{"192.168.1.2", xlist.IPv4, false, false, true, "ptr spoofed.badhoster.example: bad hoster", 300, nil},
+		{"192.168.1.2", xlist.IPv4, true, false, false, "", 300, nil},
+		{"192.168.1.3", xlist.IPv4, false, false, false, "", 300, nil},
+		{"192.168.1.3", xlist.IPv4, false, true, true, "ptr host-192-168-1-3.pool.isp.example is generic", 300, nil},
+		{"192.168.1.4", xlist.IPv4, true, false, true, "ptr www.badhoster.example: bad hoster", 60, nil},
+		{"192.168.1.5", xlist.IPv4, false, false, true, `ptr dsl5.dynamic.isp.example matches '\.dynamic\.'`, 300, nil},
+		{"192.168.1.6", xlist.IPv4, false, false, false, "", 300, nil},
+		{"192.168.1.7", xlist.IPv4, false, false, false, "", 60, nil},
+		{"192.168.1.8", xlist.IPv4, false, true, false, "", 300, nil},
+		{"192.168.1.9", xlist.IPv4, false, true, false, "", 300, nil},
+		{"192.168.1.10", xlist.IPv4, false, true, true, "ptr c0a8010a.pool.isp.example is generic", 300, nil},
+		{"192.168.1.11", xlist.IPv4, false, true, false, "", 300, nil},
+		{"2001:db8::bad", xlist.IPv6, true, false, true, "ptr v6.badhoster.example: bad hoster", 300, nil},
+	}
+	for idx, test := range tests {
+		cfg := ptrxl.DefaultConfig()
+		cfg.Resolver = resolver
+		cfg.ForwardConfirm, cfg.Generic = test.forward, test.generic
+		cfg.Patterns = []*regexp.Regexp{dynamic}
+		l, err := ptrxl.New("test", testBadDomains(), []xlist.Resource{xlist.IPv4, xlist.IPv6}, cfg, yalogi.LogNull)
+		if err != nil {
+			t.Fatalf("ptr.New idx[%v] unexpected error: %v", idx, err)
+		}
+		resp, err := l.Check(context.Background(), test.name, test.resource)
+		if err != test.wantErr {
+			t.Errorf("ptr.Check idx[%v] err want=%v got=%v", idx, test.wantErr, err)
+		}
+		if resp.Result != test.want {
+			t.Errorf("ptr.Check idx[%v] want=%v got=%v", idx, test.want, resp.Result)
+		}
+		if resp.Reason != test.wantReason {
+			t.Errorf("ptr.Check idx[%v] reason want=%v got=%v", idx, test.wantReason, resp.Reason)
+		}
+		if resp.TTL != test.wantTTL {
+			t.Errorf("ptr.Check idx[%v] ttl want=%v got=%v", idx, test.wantTTL, resp.TTL)
+		}
+	}
+}
+
+func TestList_CheckInvalid(t *testing.T) {
+	s := dnstest.NewServer(t, testzone)
+	defer s.Close()
+	resolver, _ := dnsxl.NewResolverRRPool([]string{s.Addr})
+
+	// child doesn't validate, so invalid hostnames must be discarded
+	child := &mockxl.List{ResourceList: []xlist.Resource{xlist.Domain}, Results: []bool{true}}
+	cfg := ptrxl.DefaultConfig()
+	cfg.Resolver = resolver
+	l, err := ptrxl.New("test", child, []xlist.Resource{xlist.IPv4}, cfg, yalogi.LogNull)
+	if err != nil {
+		t.Fatalf("ptr.New unexpected error: %v", err)
+	}
+	resp, err := l.Check(context.Background(), "192.168.1.6", xlist.IPv4)
+	if err != nil || resp.Result {
+		t.Errorf("ptr.Check unexpected response: %v %v", resp, err)
+	}
+	resp, err = l.Check(context.Background(), "192.168.1.4", xlist.IPv4)
+	if err != nil || !resp.Result || !strings.HasPrefix(resp.Reason, "ptr mail.good.example: ") {
+		t.Errorf("ptr.Check unexpected response: %v %v", resp, err)
+	}
+}
+
+func TestList_Cache(t *testing.T) {
+	s := dnstest.NewServer(t, testzone)
+	defer s.Close()
+	resolver, _ := dnsxl.NewResolverRRPool([]string{s.Addr})
+
+	cfg := ptrxl.DefaultConfig()
+	cfg.Resolver = resolver
+	cfg.ForwardConfirm = true
+	l, err := ptrxl.New("test", testBadDomains(), []xlist.Resource{xlist.IPv4}, cfg, yalogi.LogNull)
+	if err != nil {
+		t.Fatalf("ptr.New unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		resp, err := l.Check(context.Background(), "192.168.1.1", xlist.IPv4)
+		if err != nil || !resp.Result {
+			t.Fatalf("ptr.Check unexpected response: %v %v", resp, err)
+		}
+	}
+	// PTR and A queries
+	if s.Count() != 2 {
+		t.Errorf("ptr.Check queries want=2 got=%v", s.Count())
+	}
+	l.Flush()
+	if got := l.Inspect()["cached"]; got != 0 {
+		t.Errorf("ptr.Inspect cached want=0 got=%v", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	ip4 := []xlist.Resource{xlist.IPv4}
+	var tests = []struct {
+		child     *mockxl.List
+		resources []xlist.Resource
+		generic   bool
+		wantErr   bool
+	}{
+		{&mockxl.List{ResourceList: []xlist.Resource{xlist.Domain}}, ip4, false, false},
+		{&mockxl.List{ResourceList: ip4}, ip4, false, true},
+		{&mockxl.List{ResourceList: []xlist.Resource{xlist.Domain}}, []xlist.Resource{xlist.Domain}, false, true},
+		{nil, ip4, true, false},
+		{nil, ip4, false, true},
+	}
+	for idx, test := range tests {
+		cfg := ptrxl.DefaultConfig()
+		cfg.Generic = test.generic
+		var err error
+		if test.child != nil {
+			_, err = ptrxl.New("test", test.child, test.resources, cfg, yalogi.LogNull)
+		} else {
+			_, err = ptrxl.New("test", nil, test.resources, cfg, yalogi.LogNull)
+		}
+		if test.wantErr && err == nil {
+			t.Errorf("ptr.New idx[%v] expected error", idx)
+		} else if !test.wantErr && err != nil {
+			t.Errorf("ptr.New idx[%v] unexpected error: %v", idx, err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package quorumxl provides a composite xlistd.List implementation that
+// checks in parallel on the child components and returns positive only
+// when a quorum of them agree.
+//
+// This package is a work in progress and makes no API stability promises.
+package quorumxl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// ComponentClass registered.
+const ComponentClass = "quorum"
+
+// Config options.
+type Config struct {
+	// Quorum is the sum of weights of positive childs required to return
+	// a positive. If zero, Percent is used.
+	Quorum int
+	// Percent is the percentage of the total weight required. If Quorum
+	// and Percent are zero, a simple majority is required.
+	Percent int
+	// Weights of the childs by identifier, default weight is 1.
+	Weights         map[string]int
+	SkipErrors      bool
+	ForceValidation bool
+	Reason          string
+}
+
+// List is a composite list that returns positive when a quorum of its
+// childs agree.
+type List struct {
+	id        string
+	cfg       Config
+	childs    []xlistd.List
+	weights   []int
+	total     int
+	required  int
+	provides  []bool
+	resources []xlist.Resource
+}
+
+// New returns a new quorum component with the resources passed.
+func New(id string, childs []xlistd.List, resources []xlist.Resource, cfg Config) (*List, error) {
+	l := &List{
+		id:        id,
+		cfg:       cfg,
+		resources: xlist.ClearResourceDups(resources, true),
+		provides:  make([]bool, len(xlist.Resources), len(xlist.Resources)),
+	}
+	//set resource types that provides
+	for _, r := range l.resources {
+		l.provides[int(r)] = true
+	}
+	//set childs and weights
+	ids := make(map[string]bool, len(childs))
+	l.childs = make([]xlistd.List, 0, len(childs))
+	l.weights = make([]int, 0, len(childs))
+	for _, child := range childs {
+		weight := 1
+		if w, ok := cfg.Weights[child.ID()]; ok {
+			weight = w
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("invalid weight for child '%s'", child.ID())
+		}
+		ids[child.ID()] = true
+		l.childs = append(l.childs, child)
+		l.weights = append(l.weights, weight)
+		l.total += weight
+	}
+	for cid := range cfg.Weights {
+		if !ids[cid] {
+			return nil, fmt.Errorf("weight for unknown child '%s'", cid)
+		}
+	}
+	//compute required weight
+	switch {
+	case cfg.Quorum < 0:
+		return nil, errors.New("invalid quorum")
+	case cfg.Percent < 0 || cfg.Percent > 100:
+		return nil, errors.New("invalid percent")
+	case cfg.Quorum > 0:
+		l.required = cfg.Quorum
+	case cfg.Percent > 0:
+		l.required = (l.total*cfg.Percent + 99) / 100
+	default:
+		l.required = l.total/2 + 1
+	}
+	if l.required > l.total && len(l.childs) > 0 {
+		return nil, fmt.Errorf("quorum %v can't be reached with total weight %v", l.required, l.total)
+	}
+	return l, nil
+}
+
+// ID implements xlistd.List interface.
+func (l *List) ID() string {
+	return l.id
+}
+
+// Class implements xlistd.List interface.
+func (l *List) Class() string {
+	return ComponentClass
+}
+
+// Required returns the sum of weights required for a positive.
+func (l *List) Required() int {
+	return l.required
+}
+
+// checkResult is used for store parallel checks
+type checkResult struct {
+	listIdx  int
+	response xlist.Response
+	err      error
+}
+
+// Check implements xlist.Checker interface.
+func (l *List) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	if !l.checks(resource) {
+		return xlist.Response{}, xlist.ErrNotSupported
+	}
+	name, ctx, err := xlist.DoValidation(ctx, name, resource, l.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	if len(l.childs) == 0 {
+		return xlist.Response{}, nil
+	}
+	//create context for childs
+	childCtx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	results := make(chan *checkResult, len(l.childs))
+	for idx, child := range l.childs {
+		wg.Add(1)
+		go workerCheck(childCtx, &wg, child, idx, name, resource, results)
+	}
+
+	ttl := 0
+	votes := 0
+	pending := l.total
+	reasons := make([]string, 0, len(l.childs))
+	finished := 0
+RESULTLOOP:
+	for finished < len(l.childs) {
+		select {
+		case r := <-results:
+			finished++
+			pending -= l.weights[r.listIdx]
+			if r.err != nil {
+				if !l.cfg.SkipErrors {
+					err = r.err
+					break RESULTLOOP
+				}
+			} else if r.response.Result && l.weights[r.listIdx] > 0 {
+				if votes == 0 || ttl > r.response.TTL {
+					ttl = r.response.TTL
+				}
+				votes += l.weights[r.listIdx]
+				reasons = append(reasons, r.response.Reason)
+			}
+			// outcome decided
+			if votes >= l.required || votes+pending < l.required {
+				break RESULTLOOP
+			}
+		case <-ctx.Done():
+			err = xlist.ErrCanceledRequest
+			break RESULTLOOP
+		}
+	}
+	cancel()
+	wg.Wait()
+	close(results)
+
+	var resp xlist.Response
+	if err == nil && votes >= l.required {
+		resp.Result = true
+		if ttl > 0 {
+			resp.TTL = ttl
+		}
+		if l.cfg.Reason == "" {
+			resp.Reason = strings.Join(reasons, ";")
+		} else {
+			resp.Reason = l.cfg.Reason
+		}
+	}
+	return resp, err
+}
+
+// Resources implements xlist.Checker interface.
+func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	resources := make([]xlist.Resource, len(l.resources), len(l.resources))
+	copy(resources, l.resources)
+	return resources, nil
+}
+
+// Ping implements xlist.Checker interface.
+func (l *List) Ping() error {
+	msgErr := make([]string, 0)
+	for _, child := range l.childs {
+		if err := child.Ping(); err != nil {
+			msgErr = append(msgErr, fmt.Sprintf("%s: %v", child.ID(), err))
+		}
+	}
+	if len(msgErr) > 0 {
+		return errors.New(strings.Join(msgErr, ";"))
+	}
+	return nil
+}
+
+func (l *List) checks(r xlist.Resource) bool {
+	if r.IsValid() {
+		return l.provides[int(r)]
+	}
+	return false
+}
+
+func workerCheck(ctx context.Context, wg *sync.WaitGroup, list xlist.Checker, listIdx int,
+	name string, resource xlist.Resource, results chan<- *checkResult) {
+	defer wg.Done()
+	response, err := list.Check(ctx, name, resource)
+	results <- &checkResult{
+		listIdx:  listIdx,
+		response: response,
+		err:      err,
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package quorumxl_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/quorumxl"
+)
+
+func TestList_Check(t *testing.T) {
+	ip4 := []xlist.Resource{xlist.IPv4}
+
+	t5ms := 5 * time.Millisecond
+	t50ms := 50 * time.Millisecond
+
+	rblFalse := &mockxl.List{Identifier: "false", ResourceList: ip4}
+	rblTrue := &mockxl.List{Identifier: "true", ResourceList: ip4, Results: []bool{true}}
+	rblFail := &mockxl.List{Identifier: "fail", ResourceList: ip4, Fail: true}
+	rblLazyF := &mockxl.List{Identifier: "lazyf", ResourceList: ip4, Lazy: t50ms}
+	rblLazyT := &mockxl.List{Identifier: "lazyt", ResourceList: ip4, Lazy: t50ms, Results: []bool{true}}
+
+	var tests = []struct {
+		childs  []xlistd.List
+		cfg     quorumxl.Config
+		timeout time.Duration
+
+		want    bool
+		wantErr bool
+	}{
+		{[]xlistd.List{}, quorumxl.Config{}, 0, false, false},                                       //0
+		{[]xlistd.List{rblTrue}, quorumxl.Config{}, 0, true, false},                                 //1
+		{[]xlistd.List{rblTrue, rblFalse}, quorumxl.Config{}, 0, false, false},                      //2
+		{[]xlistd.List{rblTrue, rblFalse, rblTrue}, quorumxl.Config{}, 0, true, false},              //3
+		{[]xlistd.List{rblTrue, rblFalse, rblTrue}, quorumxl.Config{Quorum: 3}, 0, false, false},    //4
+		{[]xlistd.List{rblTrue, rblFalse}, quorumxl.Config{Quorum: 1}, 0, true, false},              //5
+		{[]xlistd.List{rblTrue, rblFalse, rblFalse}, quorumxl.Config{Percent: 30}, 0, true, false},  //6
+		{[]xlistd.List{rblTrue, rblFalse, rblFalse}, quorumxl.Config{Percent: 50}, 0, false, false}, //7
+		{[]xlistd.List{rblTrue, rblFalse, rblFalse},
+			quorumxl.Config{Weights: map[string]int{"true": 3}}, 0, true, false}, //8
+		{[]xlistd.List{rblTrue, rblFalse, rblFalse},
+			quorumxl.Config{Weights: map[string]int{"true": 0}, Quorum: 1}, 0, false, false}, //9
+		// short-circuit: decided before lazy childs finish
+		{[]xlistd.List{rblTrue, rblTrue, rblLazyF}, quorumxl.Config{}, t5ms * 4, true, false},    //10
+		{[]xlistd.List{rblFalse, rblFalse, rblLazyT}, quorumxl.Config{}, t5ms * 4, false, false}, //11
+		// errors
+		{[]xlistd.List{rblLazyT, rblFail, rblLazyT}, quorumxl.Config{}, 0, false, true},                 //12
+		{[]xlistd.List{rblTrue, rblFail, rblTrue}, quorumxl.Config{SkipErrors: true}, 0, true, false},   //13
+		{[]xlistd.List{rblTrue, rblFail, rblFalse}, quorumxl.Config{SkipErrors: true}, 0, false, false}, //14
+		{[]xlistd.List{rblLazyT, rblLazyT, rblLazyT}, quorumxl.Config{}, t5ms, false, true},             //15
+	}
+	for idx, test := range tests {
+		l, err := quorumxl.New("test", test.childs, ip4, test.cfg)
+		if err != nil {
+			t.Fatalf("quorum.New idx[%v] unexpected error: %v", idx, err)
+		}
+		//create context with timeout
+		ctx := context.Background()
+		if test.timeout > 0 {
+			var cancelctx context.CancelFunc
+			ctx, cancelctx = context.WithTimeout(ctx, test.timeout)
+			defer cancelctx()
+		}
+		//do the check
+		resp, err := l.Check(ctx, "10.10.10.10", xlist.IPv4)
+		if test.wantErr && err == nil {
+			t.Errorf("quorum.Check idx[%v] expected error", idx)
+		} else if !test.wantErr && err != nil {
+			t.Errorf("quorum.Check idx[%v] unexpected error: %v", idx, err)
+		}
+		if test.want != resp.Result {
+			t.Errorf("quorum.Check idx[%v] want=%v got=%v", idx, test.want, resp.Result)
+		}
+	}
+	//resource not supported
+	l, _ := quorumxl.New("test", []xlistd.List{rblTrue}, ip4, quorumxl.Config{})
+	_, err := l.Check(context.Background(), "www.google.com", xlist.Domain)
+	if err != xlist.ErrNotSupported {
+		t.Errorf("quorum.Check unexpected error: %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	ip4 := []xlist.Resource{xlist.IPv4}
+	childs := []xlistd.List{
+		&mockxl.List{Identifier: "rbl1", ResourceList: ip4},
+		&mockxl.List{Identifier: "rbl2", ResourceList: ip4},
+		&mockxl.List{Identifier: "rbl3", ResourceList: ip4},
+		&mockxl.List{Identifier: "rbl4", ResourceList: ip4},
+	}
+	var tests = []struct {
+		cfg      quorumxl.Config
+		required int
+		wantErr  bool
+	}{
+		{quorumxl.Config{}, 3, false},
+		{quorumxl.Config{Quorum: 4}, 4, false},
+		{quorumxl.Config{Quorum: 5}, 0, true},
+		{quorumxl.Config{Percent: 50}, 2, false},
+		{quorumxl.Config{Percent: 60}, 3, false},
+		{quorumxl.Config{Percent: 100}, 4, false},
+		{quorumxl.Config{Percent: 101}, 0, true},
+		{quorumxl.Config{Weights: map[string]int{"rbl1": 4}}, 4, false},
+		{quorumxl.Config{Weights: map[string]int{"rbl1": 4}, Quorum: 7}, 7, false},
+		{quorumxl.Config{Weights: map[string]int{"rbl1": -1}}, 0, true},
+		{quorumxl.Config{Weights: map[string]int{"rbl5": 1}}, 0, true},
+	}
+	for idx, test := range tests {
+		l, err := quorumxl.New("test", childs, ip4, test.cfg)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("quorum.New idx[%v] expected error", idx)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("quorum.New idx[%v] unexpected error: %v", idx, err)
+			continue
+		}
+		if l.Required() != test.required {
+			t.Errorf("quorum.New idx[%v] required want=%v got=%v", idx, test.required, l.Required())
+		}
+	}
+}
+
+func ExampleList() {
+	ip4 := []xlist.Resource{xlist.IPv4}
+
+	childs := []xlistd.List{
+		&mockxl.List{Identifier: "rbl1", Results: []bool{true}, ResourceList: ip4, Reason: "rbl1"},
+		&mockxl.List{Identifier: "rbl2", Results: []bool{false, true}, ResourceList: ip4, Reason: "rbl2"},
+		&mockxl.List{Identifier: "rbl3", Results: []bool{false}, ResourceList: ip4, Reason: "rbl3"},
+	}
+	//constructs a quorum list that requires rbl1 and one more
+	rbl, err := quorumxl.New("test", childs, ip4, quorumxl.Config{
+		Quorum:  3,
+		Weights: map[string]int{"rbl1": 2},
+	})
+	if err != nil {
+		log.Fatalln("this should not happen")
+	}
+	for i := 0; i < 4; i++ {
+		resp, err := rbl.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+		if err != nil {
+			log.Fatalln("this should not happen")
+		}
+		fmt.Println(resp.Result)
+	}
+
+	// Output:
+	// false
+	// true
+	// false
+	// true
+}
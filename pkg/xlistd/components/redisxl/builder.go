@@ -0,0 +1,138 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package redisxl
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildListFn {
+	return func(b *xlistd.Builder, parents []string, def xlistd.ListDef) (xlistd.List, error) {
+		if def.Source == "" {
+			return nil, errors.New("source is empty")
+		}
+		if _, _, err := net.SplitHostPort(def.Source); err != nil {
+			return nil, errors.New("invalid source: address must be host:port")
+		}
+		cfg := defaultCfg
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		bl := New(def.ID, def.Source, def.Resources, cfg)
+		//register shutdown
+		b.OnShutdown(func() error {
+			bl.Close()
+			return nil
+		})
+		return bl, nil
+	}
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	prefix, ok, err := option.String(opts, "prefix")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Prefix = prefix
+	}
+
+	keys, ok, err := option.HashString(opts, "keys")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Keys = make(map[string]string, len(keys))
+		for k, v := range keys {
+			dst.Keys[k] = v
+		}
+	}
+
+	ktype, ok, err := option.String(opts, "type")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if ktype != Set && ktype != Hash {
+			return dst, errors.New("invalid 'type'")
+		}
+		dst.Type = ktype
+	}
+
+	password, ok, err := option.String(opts, "password")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Password = password
+	}
+
+	db, ok, err := option.Int(opts, "db")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if db < 0 {
+			return dst, errors.New("invalid 'db'")
+		}
+		dst.DB = db
+	}
+
+	timeout, ok, err := option.Int(opts, "timeout")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if timeout <= 0 {
+			return dst, errors.New("invalid 'timeout'")
+		}
+		dst.Timeout = time.Duration(timeout) * time.Second
+	}
+
+	maxIdle, ok, err := option.Int(opts, "maxidle")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if maxIdle < 0 {
+			return dst, errors.New("invalid 'maxidle'")
+		}
+		dst.MaxIdle = maxIdle
+	}
+
+	maxActive, ok, err := option.Int(opts, "maxactive")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if maxActive <= 0 {
+			return dst, errors.New("invalid 'maxactive'")
+		}
+		dst.MaxActive = maxActive
+	}
+
+	reason, ok, err := option.String(opts, "reason")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Reason = reason
+	}
+
+	return dst, nil
+}
+
+func init() {
+	xlistd.RegisterListBuilder(ComponentClass, Builder(DefaultConfig()))
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package redisxl_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/redisxl"
+)
+
+func TestBuild(t *testing.T) {
+	srv := newServer(t, "")
+	defer srv.Close()
+
+	var testdatabase1 = []xlistd.ListDef{
+		{ID: "list1",
+			Class:     redisxl.ComponentClass,
+			Resources: []xlist.Resource{xlist.Domain}},
+		{ID: "list2",
+			Class:     redisxl.ComponentClass,
+			Source:    "localhost",
+			Resources: []xlist.Resource{xlist.Domain}},
+		{ID: "list3",
+			Class:     redisxl.ComponentClass,
+			Source:    srv.Addr(),
+			Resources: []xlist.Resource{xlist.IPv4, xlist.Domain}},
+		{ID: "list4",
+			Class:     redisxl.ComponentClass,
+			Source:    srv.Addr(),
+			Resources: []xlist.Resource{xlist.Domain},
+			Opts: map[string]interface{}{
+				"prefix": "bl:", "type": "hash", "db": 1, "timeout": 1, "maxidle": 2, "maxactive": 10,
+				"reason": "redis", "keys": map[string]interface{}{"domain:sub": "zones"}}},
+		{ID: "list5",
+			Class:     redisxl.ComponentClass,
+			Source:    srv.Addr(),
+			Resources: []xlist.Resource{xlist.Domain},
+			Opts:      map[string]interface{}{"type": "list"}},
+		{ID: "list6",
+			Class:     redisxl.ComponentClass,
+			Source:    srv.Addr(),
+			Resources: []xlist.Resource{xlist.Domain},
+			Opts:      map[string]interface{}{"maxactive": 0}},
+		{ID: "list7",
+			Class:     redisxl.ComponentClass,
+			Source:    srv.Addr(),
+			Resources: []xlist.Resource{xlist.Domain},
+			Opts:      map[string]interface{}{"keys": []string{"zones"}}},
+	}
+
+	b := xlistd.NewBuilder(apiservice.NewRegistry())
+	defer b.Shutdown()
+
+	//define and do tests
+	var tests = []struct {
+		listid  string
+		wantErr string
+	}{
+		{"list1", "source is empty"},
+		{"list2", "invalid source"},
+		{"list3", ""},
+		{"list4", ""},
+		{"list5", "invalid 'type'"},
+		{"list6", "invalid 'maxactive'"},
+		{"list7", "invalid 'keys'"},
+	}
+	for _, test := range tests {
+		def, ok := xlistd.FilterID(test.listid, testdatabase1)
+		if !ok {
+			t.Errorf("can't find id %s in database tests", test.listid)
+			continue
+		}
+		list, err := b.Build(def)
+		switch {
+		case test.wantErr == "" && err == nil:
+			if err := list.Ping(); err != nil {
+				t.Errorf("unexpected ping error for %s: %v", test.listid, err)
+			}
+		case test.wantErr == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("expected error for %s", test.listid)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package redisxl provides a xlistd.List implementation that checks names
+// against sets or hashes stored in a redis server, so lists can be shared
+// by many instances and updated in real time.
+//
+// This package is a work in progress and makes no API stability promises.
+package redisxl
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+)
+
+// ComponentClass defines default class for component builder
+const ComponentClass = "redis"
+
+// Types of keys.
+const (
+	Set  = "set"
+	Hash = "hash"
+)
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		Prefix:    "xlist:",
+		Type:      Set,
+		Timeout:   2 * time.Second,
+		MaxIdle:   8,
+		MaxActive: 64,
+	}
+}
+
+// Config options. Keys are named with the prefix and the resource (ex:
+// xlist:ip4, xlist:domain), subdomains use the suffix ":sub" and ip ranges
+// the suffix ":ranges" (ex: xlist:domain:sub, xlist:ip4:ranges). Names in
+// Keys map replaces the default names (ex: {"domain:sub": "zones"}).
+//
+// With Type Set the items are members of sets. With Type Hash the items are
+// fields of hashes and their values the reasons.
+type Config struct {
+	Prefix          string
+	Keys            map[string]string
+	Type            string
+	Password        string
+	DB              int
+	Timeout         time.Duration
+	MaxIdle         int
+	MaxActive       int
+	Reason          string
+	ForceValidation bool
+}
+
+// List is a list that checks names against a redis server.
+//
+// Ip ranges are stored in a sorted set with the same score, with members
+// encoded by EncodeRange, so they are sorted by the end of the range and
+// one ZRANGEBYLEX finds the candidate range. Ranges stored mustn't overlap.
+type List struct {
+	id        string
+	cfg       Config
+	resources []xlist.Resource
+	pool      *pool
+}
+
+// New creates a new List for the redis server at address addr (host:port).
+func New(id, addr string, resources []xlist.Resource, cfg Config) *List {
+	return &List{
+		id:        id,
+		cfg:       cfg,
+		resources: xlist.ClearResourceDups(resources, true),
+		pool:      newPool(addr, cfg.Password, cfg.DB, cfg.Timeout, cfg.MaxIdle, cfg.MaxActive),
+	}
+}
+
+// ID implements xlistd.List interface
+func (l *List) ID() string {
+	return l.id
+}
+
+// Class implements xlistd.List interface
+func (l *List) Class() string {
+	return ComponentClass
+}
+
+// Check implements xlist.Checker interface
+func (l *List) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	if !resource.InArray(l.resources) {
+		return xlist.Response{}, xlist.ErrNotSupported
+	}
+	name, ctx, err := xlist.DoValidation(ctx, name, resource, l.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	var cmds [][]string
+	var ip string
+	switch resource {
+	case xlist.IPv4, xlist.IPv6:
+		ip = encodeIP(net.ParseIP(name), resource)
+		if ip == "" {
+			return xlist.Response{}, xlist.ErrBadRequest
+		}
+		cmds = append(cmds, l.member(l.key(resource, ""), name),
+			[]string{"ZRANGEBYLEX", l.key(resource, ":ranges"), "[" + ip, "+", "LIMIT", "0", "1"})
+	case xlist.Domain:
+		cmds = append(cmds, l.member(l.key(resource, ""), name))
+		// name and all its parents
+		subkey := l.key(resource, ":sub")
+		for s := name; ; {
+			cmds = append(cmds, l.member(subkey, s))
+			dot := strings.IndexByte(s, '.')
+			if dot < 0 {
+				break
+			}
+			s = s[dot+1:]
+		}
+	default:
+		cmds = append(cmds, l.member(l.key(resource, ""), strings.ToLower(name)))
+	}
+	replies, err := l.pool.do(ctx, cmds)
+	if err != nil {
+		if ctx.Err() != nil {
+			return xlist.Response{}, xlist.ErrCanceledRequest
+		}
+		return xlist.Response{}, fmt.Errorf("redis: %v", err)
+	}
+	for i, reply := range replies {
+		if e, ok := reply.(Error); ok {
+			return xlist.Response{}, e
+		}
+		var found bool
+		var reason string
+		if ip != "" && i == 1 {
+			found, reason = inRange(reply, ip)
+		} else {
+			found, reason = l.isMember(reply)
+		}
+		if found {
+			if reason == "" {
+				reason = l.cfg.Reason
+			}
+			return xlist.Response{Result: true, Reason: reason}, nil
+		}
+	}
+	return xlist.Response{}, nil
+}
+
+// Resources implements xlist.Checker interface
+func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	resources := make([]xlist.Resource, len(l.resources), len(l.resources))
+	copy(resources, l.resources)
+	return resources, nil
+}
+
+// Ping implements xlistd.List interface.
+func (l *List) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.cfg.Timeout)
+	defer cancel()
+	replies, err := l.pool.do(ctx, [][]string{{"PING"}})
+	if err != nil {
+		return err
+	}
+	if replies[0] != "PONG" {
+		return fmt.Errorf("redis: unexpected ping reply '%v'", replies[0])
+	}
+	return nil
+}
+
+// Close closes the connections to the server.
+func (l *List) Close() {
+	l.pool.close()
+}
+
+func (l *List) key(resource xlist.Resource, suffix string) string {
+	name := resource.String() + suffix
+	if k, ok := l.cfg.Keys[name]; ok {
+		return k
+	}
+	return l.cfg.Prefix + name
+}
+
+func (l *List) member(key, name string) []string {
+	if l.cfg.Type == Hash {
+		return []string{"HGET", key, name}
+	}
+	return []string{"SISMEMBER", key, name}
+}
+
+func (l *List) isMember(reply interface{}) (bool, string) {
+	switch v := reply.(type) {
+	case int64:
+		return v == 1, ""
+	case string:
+		return true, v
+	}
+	return false, ""
+}
+
+// EncodeRange returns the member of the sorted set for the ip range, reason
+// is optional.
+func EncodeRange(start, end net.IP, reason string) (string, error) {
+	resource := xlist.IPv6
+	if start.To4() != nil {
+		resource = xlist.IPv4
+	}
+	s, e := encodeIP(start, resource), encodeIP(end, resource)
+	if s == "" || e == "" || s > e {
+		return "", errors.New("invalid range")
+	}
+	member := e + "-" + s
+	if reason != "" {
+		member = member + "|" + reason
+	}
+	return member, nil
+}
+
+// EncodeCIDR returns the member of the sorted set for the network, reason
+// is optional.
+func EncodeCIDR(cidr, reason string) (string, error) {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	end := make(net.IP, len(ipnet.IP))
+	for i := range ipnet.IP {
+		end[i] = ipnet.IP[i] | ^ipnet.Mask[i]
+	}
+	return EncodeRange(ipnet.IP, end, reason)
+}
+
+func encodeIP(ip net.IP, resource xlist.Resource) string {
+	if resource == xlist.IPv4 {
+		ip = ip.To4()
+	} else {
+		ip = ip.To16()
+	}
+	if ip == nil {
+		return ""
+	}
+	return hex.EncodeToString(ip)
+}
+
+// inRange checks the reply of the ZRANGEBYLEX with the first range whose end
+// is greater or equal than ip.
+func inRange(reply interface{}, ip string) (bool, string) {
+	items, ok := reply.([]interface{})
+	if !ok || len(items) == 0 {
+		return false, ""
+	}
+	member, ok := items[0].(string)
+	if !ok {
+		return false, ""
+	}
+	var reason string
+	if idx := strings.IndexByte(member, '|'); idx >= 0 {
+		member, reason = member[:idx], member[idx+1:]
+	}
+	fields := strings.Split(member, "-")
+	if len(fields) != 2 || len(fields[1]) != len(ip) {
+		return false, ""
+	}
+	return fields[1] <= ip, reason
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package redisxl_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd/components/redisxl"
+)
+
+func TestList_Check(t *testing.T) {
+	srv := newServer(t, "secret")
+	defer srv.Close()
+	srv.SAdd("xlist:ip4", "10.0.0.1")
+	srv.SAdd("xlist:ip6", "2001:db8::1")
+	srv.SAdd("xlist:domain", "www.example.com")
+	srv.SAdd("zones", "evil.org")
+	srv.SAdd("xlist:md5", "d41d8cd98f00b204e9800998ecf8427e")
+	for _, cidr := range []string{"192.168.0.0/24", "172.16.0.0/12"} {
+		m, err := redisxl.EncodeCIDR(cidr, "")
+		if err != nil {
+			t.Fatalf("redisxl.EncodeCIDR(): err=%v", err)
+		}
+		srv.ZAdd("xlist:ip4:ranges", m)
+	}
+	m, _ := redisxl.EncodeRange(net.ParseIP("10.1.0.10"), net.ParseIP("10.1.0.20"), "range")
+	srv.ZAdd("xlist:ip4:ranges", m)
+	m, _ = redisxl.EncodeCIDR("fe80::/16", "")
+	srv.ZAdd("xlist:ip6:ranges", m)
+
+	cfg := redisxl.DefaultConfig()
+	cfg.Password = "secret"
+	cfg.Keys = map[string]string{"domain:sub": "zones"}
+	cfg.Reason = "redis"
+	list := redisxl.New("test1", srv.Addr(), []xlist.Resource{xlist.IPv4, xlist.IPv6, xlist.Domain, xlist.MD5}, cfg)
+	defer list.Close()
+	if err := list.Ping(); err != nil {
+		t.Fatalf("redisxl.Ping(): err=%v", err)
+	}
+
+	var tests = []struct {
+		name     string
+		resource xlist.Resource
+		want     bool
+		reason   string
+	}{
+		{"10.0.0.1", xlist.IPv4, true, "redis"},
+		{"10.0.0.2", xlist.IPv4, false, ""},
+		{"192.168.0.0", xlist.IPv4, true, "redis"},
+		{"192.168.0.255", xlist.IPv4, true, "redis"},
+		{"192.168.1.0", xlist.IPv4, false, ""},
+		{"172.31.255.255", xlist.IPv4, true, "redis"},
+		{"172.32.0.0", xlist.IPv4, false, ""},
+		{"10.1.0.15", xlist.IPv4, true, "range"},
+		{"10.1.0.9", xlist.IPv4, false, ""},
+		{"10.1.0.21", xlist.IPv4, false, ""},
+		{"2001:db8::1", xlist.IPv6, true, "redis"},
+		{"fe80::1", xlist.IPv6, true, "redis"},
+		{"fe81::1", xlist.IPv6, false, ""},
+		{"www.example.com", xlist.Domain, true, "redis"},
+		{"example.com", xlist.Domain, false, ""},
+		{"evil.org", xlist.Domain, true, "redis"},
+		{"a.b.evil.org", xlist.Domain, true, "redis"},
+		{"D41D8CD98F00B204E9800998ECF8427E", xlist.MD5, true, "redis"},
+	}
+	for idx, test := range tests {
+		got, err := list.Check(context.Background(), test.name, test.resource)
+		if err != nil {
+			t.Errorf("idx[%v] redisxl.Check(): err=%v", idx, err)
+		}
+		if got.Result != test.want || got.Reason != test.reason {
+			t.Errorf("idx[%v] redisxl.Check(%s): want=%v got=%v", idx, test.name, test.want, got)
+		}
+	}
+	_, err := list.Check(context.Background(), "da39a3ee5e6b4b0d3255bfef95601890afd80709", xlist.SHA1)
+	if err != xlist.ErrNotSupported {
+		t.Errorf("redisxl.Check(): unexpected err=%v", err)
+	}
+}
+
+func TestList_Hash(t *testing.T) {
+	srv := newServer(t, "")
+	defer srv.Close()
+	srv.HSet("feed:domain", "www.example.com", "phishing")
+	srv.HSet("feed:domain:sub", "evil.org", "")
+
+	cfg := redisxl.DefaultConfig()
+	cfg.Prefix = "feed:"
+	cfg.Type = redisxl.Hash
+	cfg.Reason = "feed"
+	list := redisxl.New("test1", srv.Addr(), []xlist.Resource{xlist.Domain}, cfg)
+	defer list.Close()
+
+	var tests = []struct {
+		name   string
+		want   bool
+		reason string
+	}{
+		{"www.example.com", true, "phishing"},
+		{"www.evil.org", true, "feed"},
+		{"www.example.org", false, ""},
+	}
+	for idx, test := range tests {
+		got, err := list.Check(context.Background(), test.name, xlist.Domain)
+		if err != nil || got.Result != test.want || got.Reason != test.reason {
+			t.Errorf("idx[%v] redisxl.Check(%s): got=%v err=%v", idx, test.name, got, err)
+		}
+	}
+}
+
+func TestList_Pool(t *testing.T) {
+	srv := newServer(t, "")
+	defer srv.Close()
+	srv.SAdd("xlist:domain:sub", "example.com")
+
+	cfg := redisxl.DefaultConfig()
+	cfg.MaxIdle = 4
+	cfg.MaxActive = 4
+	list := redisxl.New("test1", srv.Addr(), []xlist.Resource{xlist.Domain}, cfg)
+	defer list.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				resp, err := list.Check(context.Background(), "a.b.www.example.com", xlist.Domain)
+				if err != nil || !resp.Result {
+					t.Errorf("redisxl.Check(): got=%v err=%v", resp, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	conns, cmds := srv.Stats()
+	if conns > 4 {
+		t.Errorf("unexpected connections %v", conns)
+	}
+	// one member check and five subdomain checks pipelined per check
+	if cmds != 8*50*6 {
+		t.Errorf("unexpected commands %v", cmds)
+	}
+}
+
+func TestList_Errors(t *testing.T) {
+	srv := newServer(t, "secret")
+	cfg := redisxl.DefaultConfig()
+	list := redisxl.New("test1", srv.Addr(), []xlist.Resource{xlist.Domain}, cfg)
+	defer list.Close()
+	if err := list.Ping(); err == nil {
+		t.Error("redisxl.Ping(): expected auth error")
+	}
+	if _, err := list.Check(context.Background(), "www.example.com", xlist.Domain); err == nil {
+		t.Error("redisxl.Check(): expected error")
+	}
+	srv.Close()
+	cfg.Password = "secret"
+	list2 := redisxl.New("test2", srv.Addr(), []xlist.Resource{xlist.Domain}, cfg)
+	defer list2.Close()
+	if err := list2.Ping(); err == nil {
+		t.Error("redisxl.Ping(): expected connection error")
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package redisxl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Error is an error reply from the server.
+type Error string
+
+// Error implements error interface.
+func (e Error) Error() string {
+	return string(e)
+}
+
+// ErrPoolClosed is returned when the pool is closed.
+var ErrPoolClosed = errors.New("redis: pool closed")
+
+// conn is a connection that speaks the redis protocol (RESP).
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func (c *conn) writeCommand(args []string) {
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+}
+
+// readReply returns string, int64, nil, []interface{} or Error values.
+func (c *conn) readReply() (interface{}, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return Error(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid reply '%s'", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid reply '%s'", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i], err = c.readReply()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: invalid reply '%s'", line)
+}
+
+func (c *conn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errors.New("redis: invalid line terminator")
+	}
+	return line[:len(line)-2], nil
+}
+
+// pool is a pool of connections to a redis server.
+type pool struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+	idle     chan *conn
+	active   chan struct{}
+	closed   chan struct{}
+}
+
+func newPool(addr, password string, db int, timeout time.Duration, maxIdle, maxActive int) *pool {
+	return &pool{
+		addr:     addr,
+		password: password,
+		db:       db,
+		timeout:  timeout,
+		idle:     make(chan *conn, maxIdle),
+		active:   make(chan struct{}, maxActive),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (p *pool) get(ctx context.Context) (*conn, error) {
+	select {
+	case <-p.closed:
+		return nil, ErrPoolClosed
+	case p.active <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case c := <-p.idle:
+		return c, nil
+	default:
+	}
+	c, err := p.dial(ctx)
+	if err != nil {
+		<-p.active
+		return nil, err
+	}
+	return c, nil
+}
+
+func (p *pool) put(c *conn, broken bool) {
+	defer func() { <-p.active }()
+	if broken {
+		c.Close()
+		return
+	}
+	select {
+	case <-p.closed:
+		c.Close()
+	case p.idle <- c:
+	default:
+		c.Close()
+	}
+}
+
+func (p *pool) dial(ctx context.Context) (*conn, error) {
+	d := net.Dialer{Timeout: p.timeout}
+	nc, err := d.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+	var setup [][]string
+	if p.password != "" {
+		setup = append(setup, []string{"AUTH", p.password})
+	}
+	if p.db > 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(p.db)})
+	}
+	if len(setup) > 0 {
+		replies, err := p.exec(ctx, c, setup)
+		if err == nil {
+			for _, r := range replies {
+				if e, ok := r.(Error); ok {
+					err = e
+					break
+				}
+			}
+		}
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// do sends the commands in a pipeline and returns their replies.
+func (p *pool) do(ctx context.Context, cmds [][]string) ([]interface{}, error) {
+	c, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := p.exec(ctx, c, cmds)
+	p.put(c, err != nil)
+	return replies, err
+}
+
+func (p *pool) exec(ctx context.Context, c *conn, cmds [][]string) ([]interface{}, error) {
+	deadline := time.Now().Add(p.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.SetDeadline(deadline)
+	for _, cmd := range cmds {
+		c.writeCommand(cmd)
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+	replies := make([]interface{}, len(cmds))
+	for i := range replies {
+		var err error
+		replies[i], err = c.readReply()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return replies, nil
+}
+
+func (p *pool) close() {
+	select {
+	case <-p.closed:
+		return
+	default:
+	}
+	close(p.closed)
+	for {
+		select {
+		case c := <-p.idle:
+			c.Close()
+		default:
+			return
+		}
+	}
+}
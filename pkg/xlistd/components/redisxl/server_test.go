@@ -0,0 +1,195 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package redisxl_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// server is a redis stand-in that implements the commands used by the list.
+type server struct {
+	ln       net.Listener
+	password string
+
+	mu     sync.Mutex
+	sets   map[string]map[string]bool
+	hashes map[string]map[string]string
+	zsets  map[string][]string
+	conns  int
+	cmds   int
+}
+
+func newServer(t *testing.T, password string) *server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	s := &server{
+		ln:       ln,
+		password: password,
+		sets:     make(map[string]map[string]bool),
+		hashes:   make(map[string]map[string]string),
+		zsets:    make(map[string][]string),
+	}
+	go s.serve()
+	return s
+}
+
+func (s *server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *server) Close() {
+	s.ln.Close()
+}
+
+func (s *server) SAdd(key string, members ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sets[key] == nil {
+		s.sets[key] = make(map[string]bool)
+	}
+	for _, m := range members {
+		s.sets[key][m] = true
+	}
+}
+
+func (s *server) HSet(key, field, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hashes[key] == nil {
+		s.hashes[key] = make(map[string]string)
+	}
+	s.hashes[key][field] = value
+}
+
+func (s *server) ZAdd(key string, members ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.zsets[key] = append(s.zsets[key], members...)
+	sort.Strings(s.zsets[key])
+}
+
+func (s *server) Stats() (conns, cmds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns, s.cmds
+}
+
+func (s *server) serve() {
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		s.mu.Unlock()
+		go s.handle(c)
+	}
+}
+
+func (s *server) handle(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+	authed := s.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.cmds++
+		cmd := strings.ToUpper(args[0])
+		switch {
+		case cmd == "AUTH":
+			authed = len(args) == 2 && args[1] == s.password
+			if authed {
+				w.WriteString("+OK\r\n")
+			} else {
+				w.WriteString("-WRONGPASS invalid password\r\n")
+			}
+		case !authed:
+			w.WriteString("-NOAUTH Authentication required.\r\n")
+		case cmd == "PING":
+			w.WriteString("+PONG\r\n")
+		case cmd == "SELECT":
+			w.WriteString("+OK\r\n")
+		case cmd == "SISMEMBER" && len(args) == 3:
+			if s.sets[args[1]][args[2]] {
+				w.WriteString(":1\r\n")
+			} else {
+				w.WriteString(":0\r\n")
+			}
+		case cmd == "HGET" && len(args) == 3:
+			v, ok := s.hashes[args[1]][args[2]]
+			if ok {
+				fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+			} else {
+				w.WriteString("$-1\r\n")
+			}
+		case cmd == "ZRANGEBYLEX" && len(args) == 7:
+			// only supports: key [min + LIMIT 0 count
+			count, _ := strconv.Atoi(args[6])
+			var found []string
+			for _, m := range s.zsets[args[1]] {
+				if len(found) < count && m >= args[2][1:] {
+					found = append(found, m)
+				}
+			}
+			fmt.Fprintf(w, "*%d\r\n", len(found))
+			for _, m := range found {
+				fmt.Fprintf(w, "$%d\r\n%s\r\n", len(m), m)
+			}
+		default:
+			fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", args[0])
+		}
+		s.mu.Unlock()
+		// pipelined commands are replied together
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("invalid command")
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid command")
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
@@ -0,0 +1,235 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package resolvexl provides a composite xlistd.List implementation that
+// resolves domains and checks the addresses obtained against an ip list.
+//
+// This package is a work in progress and makes no API stability promises.
+package resolvexl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/dnscache"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/dnsxl"
+)
+
+// ComponentClass registered.
+const ComponentClass = "resolve"
+
+// DefaultConfig returns default configuration.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:     1 * time.Second,
+		Retries:     1,
+		MaxRecords:  16,
+		Cache:       true,
+		MaxTTL:      1 * time.Hour,
+		NegativeTTL: 1 * time.Minute,
+	}
+}
+
+// Config options.
+type Config struct {
+	Timeout  time.Duration
+	Retries  int
+	Resolver dnsxl.Resolver
+	// MX and NS resolve the hosts of the records and checks their ips.
+	MX, NS bool
+	// MaxRecords is the maximum number of ips checked.
+	MaxRecords int
+	// Cache enables the cache of resolutions, ttls of the records are
+	// used with MaxTTL as limit. NegativeTTL is used with empty answers.
+	Cache           bool
+	MaxTTL          time.Duration
+	NegativeTTL     time.Duration
+	PingDNS         string
+	ForceValidation bool
+	Reason          string
+}
+
+// List is a composite list that checks the ips resolved from a domain.
+type List struct {
+	id       string
+	logger   yalogi.Logger
+	cfg      Config
+	child    xlistd.List
+	resolver *dnscache.Resolver
+	ip4, ip6 bool
+}
+
+// New returns a new resolve component that checks domains against the ip
+// list passed.
+func New(id string, child xlistd.List, cfg Config, logger yalogi.Logger) (*List, error) {
+	if child == nil {
+		return nil, errors.New("child is required")
+	}
+	l := &List{
+		id:     id,
+		logger: logger,
+		cfg:    cfg,
+		child:  child,
+	}
+	if l.cfg.Resolver == nil {
+		l.cfg.Resolver = dnsxl.GetDefaultResolver()
+	}
+	l.resolver = dnscache.New(l.cfg.Resolver, dnscache.Config{
+		Timeout:     cfg.Timeout,
+		Retries:     cfg.Retries,
+		Cache:       cfg.Cache,
+		MaxTTL:      cfg.MaxTTL,
+		NegativeTTL: cfg.NegativeTTL,
+	})
+	resources, err := child.Resources(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("getting resources from '%s': %v", child.ID(), err)
+	}
+	l.ip4 = xlist.IPv4.InArray(resources)
+	l.ip6 = xlist.IPv6.InArray(resources)
+	if !l.ip4 && !l.ip6 {
+		return nil, fmt.Errorf("child '%s' doesn't checks ips", child.ID())
+	}
+	return l, nil
+}
+
+// ID implements xlistd.List interface.
+func (l *List) ID() string {
+	return l.id
+}
+
+// Class implements xlistd.List interface.
+func (l *List) Class() string {
+	return ComponentClass
+}
+
+// address stores an ip to check and how it was obtained
+type address struct {
+	ip       string
+	resource xlist.Resource
+	via      string
+	host     string
+}
+
+// Check implements xlist.Checker interface.
+func (l *List) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	if resource != xlist.Domain {
+		return xlist.Response{}, xlist.ErrNotSupported
+	}
+	name, ctx, err := xlist.DoValidation(ctx, name, resource, l.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	addrs, ttl, err := l.resolve(ctx, name)
+	if err != nil {
+		if err == dnscache.ErrCanceled {
+			return xlist.Response{}, xlist.ErrCanceledRequest
+		}
+		l.logger.Warnf("%s: resolving '%s': %v", l.id, name, err)
+		return xlist.Response{}, xlist.ErrInternal
+	}
+	for _, addr := range addrs {
+		resp, err := l.child.Check(ctx, addr.ip, addr.resource)
+		if err != nil {
+			return xlist.Response{}, err
+		}
+		if resp.Result {
+			resp.TTL = xlistd.MinTTL(resp.TTL, ttl)
+			if l.cfg.Reason != "" {
+				resp.Reason = l.cfg.Reason
+			} else if addr.via != "" {
+				resp.Reason = fmt.Sprintf("%s %s resolves to %s: %s", addr.via, addr.host, addr.ip, resp.Reason)
+			} else {
+				resp.Reason = fmt.Sprintf("resolves to %s: %s", addr.ip, resp.Reason)
+			}
+			return resp, nil
+		}
+	}
+	return xlist.Response{TTL: ttl}, nil
+}
+
+// resolve returns the addresses of the domain and the min ttl of the
+// records used.
+func (l *List) resolve(ctx context.Context, name string) ([]address, int, error) {
+	type target struct{ via, host string }
+	targets := []target{{host: name}}
+	ttl := 0
+	for _, q := range []struct {
+		enabled bool
+		qtype   uint16
+		via     string
+	}{{l.cfg.MX, dns.TypeMX, "mx"}, {l.cfg.NS, dns.TypeNS, "ns"}} {
+		if !q.enabled {
+			continue
+		}
+		a, err := l.resolver.Lookup(ctx, name, q.qtype)
+		if err != nil {
+			return nil, 0, err
+		}
+		ttl = xlistd.MinTTL(ttl, a.TTL)
+		for _, host := range a.Values {
+			targets = append(targets, target{via: q.via, host: host})
+		}
+	}
+	addrs := make([]address, 0)
+	seen := make(map[string]bool)
+	for _, t := range targets {
+		for _, q := range []struct {
+			enabled  bool
+			qtype    uint16
+			resource xlist.Resource
+		}{{l.ip4, dns.TypeA, xlist.IPv4}, {l.ip6, dns.TypeAAAA, xlist.IPv6}} {
+			if !q.enabled {
+				continue
+			}
+			a, err := l.resolver.Lookup(ctx, t.host, q.qtype)
+			if err != nil {
+				return nil, 0, err
+			}
+			ttl = xlistd.MinTTL(ttl, a.TTL)
+			for _, ip := range a.Values {
+				if seen[ip] {
+					continue
+				}
+				if l.cfg.MaxRecords > 0 && len(addrs) >= l.cfg.MaxRecords {
+					return addrs, ttl, nil
+				}
+				seen[ip] = true
+				addrs = append(addrs, address{ip: ip, resource: q.resource, via: t.via, host: t.host})
+			}
+		}
+	}
+	return addrs, ttl, nil
+}
+
+// Resources implements xlist.Checker interface.
+func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	return []xlist.Resource{xlist.Domain}, nil
+}
+
+// Ping implements xlistd.List interface.
+func (l *List) Ping() error {
+	if l.cfg.PingDNS != "" {
+		err := l.cfg.Resolver.Ping(l.cfg.PingDNS)
+		if err != nil {
+			return err
+		}
+	}
+	return l.child.Ping()
+}
+
+// Flush implements xlistd.Flusher interface.
+func (l *List) Flush() {
+	l.resolver.Flush()
+}
+
+// Inspect implements xlistd.Inspector interface.
+func (l *List) Inspect() map[string]interface{} {
+	return map[string]interface{}{"cached": l.resolver.Len()}
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package resolvexl_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/dnscache/dnstest"
+	"github.com/luids-io/xlist/pkg/xlistd/components/dnsxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/memxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/resolvexl"
+)
+
+var testzone = []string{
+	"www.example.com. 300 IN A 10.0.0.1",
+	"www.example.com. 300 IN A 10.0.0.2",
+	"www.example.com. 300 IN AAAA 2001:db8::1",
+	"bad.example.com. 60 IN A 192.168.1.1",
+	"bad6.example.com. 300 IN AAAA 2001:db8::bad",
+	"example.com. 300 IN MX 10 mail.example.com.",
+	"example.com. 300 IN NS ns1.example.com.",
+	"mail.example.com. 300 IN A 10.0.0.3",
+	"ns1.example.com. 120 IN A 192.168.1.1",
+	"many.example.com. 300 IN A 10.0.1.1",
+	"many.example.com. 300 IN A 10.0.1.2",
+	"many.example.com. 300 IN A 10.0.1.3",
+	"many.example.com. 300 IN A 192.168.1.1",
+}
+
+func testBadIPs() *memxl.List {
+	l := memxl.New("badips", []xlist.Resource{xlist.IPv4, xlist.IPv6}, memxl.Config{Reason: "bad ip"})
+	l.AddIP4("192.168.1.1")
+	l.AddIP6("2001:db8::bad")
+	return l
+}
+
+func TestList_Check(t *testing.T) {
+	s := dnstest.NewServer(t, testzone)
+	defer s.Close()
+	resolver, err := dnsxl.NewResolverRRPool([]string{s.Addr})
+	if err != nil {
+		t.Fatalf("creating resolver: %v", err)
+	}
+
+	var tests = []struct {
+		name   string
+		mx, ns bool
+		max    int
+
+		want       bool
+		wantReason string
+		wantTTL    int
+		wantErr    error
+	}{
+		{"www.example.com", false, false, 0, false, "", 300, nil},
+		{"bad.example.com", false, false, 0, true, "resolves to 192.168.1.1: bad ip", 60, nil},
+		{"bad6.example.com", false, false, 0, true, "resolves to 2001:db8::bad: bad ip", 60, nil},
+		{"nxdomain.example.com", false, false, 0, false, "", 60, nil},
+		{"example.com", false, false, 0, false, "", 60, nil},
+		{"example.com", true, false, 0, false, "", 60, nil},
+		{"example.com", true, true, 0, true, "ns ns1.example.com resolves to 192.168.1.1: bad ip", 60, nil},
+		{"many.example.com", false, false, 0, true, "resolves to 192.168.1.1: bad ip", 60, nil},
+		{"many.example.com", false, false, 3, false, "", 300, nil},
+		{"servfail.example.com", false, false, 0, false, "", 0, xlist.ErrInternal},
+	}
+	for idx, test := range tests {
+		cfg := resolvexl.DefaultConfig()
+		cfg.Resolver = resolver
+		cfg.MX, cfg.NS, cfg.MaxRecords = test.mx, test.ns, test.max
+		l, err := resolvexl.New("test", testBadIPs(), cfg, yalogi.LogNull)
+		if err != nil {
+			t.Fatalf("resolve.New idx[%v] unexpected error: %v", idx, err)
+		}
+		resp, err := l.Check(context.Background(), test.name, xlist.Domain)
+		if err != test.wantErr {
+			t.Errorf("resolve.Check idx[%v] err want=%v got=%v", idx, test.wantErr, err)
+		}
+		if resp.Result != test.want {
+			t.Errorf("resolve.Check idx[%v] want=%v got=%v", idx, test.want, resp.Result)
+		}
+		if resp.Reason != test.wantReason {
+			t.Errorf("resolve.Check idx[%v] reason want=%v got=%v", idx, test.wantReason, resp.Reason)
+		}
+		if resp.TTL != test.wantTTL && err == nil {
+			t.Errorf("resolve.Check idx[%v] ttl want=%v got=%v", idx, test.wantTTL, resp.TTL)
+		}
+	}
+	// resource not supported
+	l, _ := resolvexl.New("test", testBadIPs(), resolvexl.Config{Resolver: resolver}, yalogi.LogNull)
+	_, err = l.Check(context.Background(), "10.0.0.1", xlist.IPv4)
+	if err != xlist.ErrNotSupported {
+		t.Errorf("resolve.Check unexpected error: %v", err)
+	}
+}
+
+func TestList_Cache(t *testing.T) {
+	s := dnstest.NewServer(t, testzone)
+	defer s.Close()
+	resolver, _ := dnsxl.NewResolverRRPool([]string{s.Addr})
+
+	cfg := resolvexl.DefaultConfig()
+	cfg.Resolver = resolver
+	l, err := resolvexl.New("test", testBadIPs(), cfg, yalogi.LogNull)
+	if err != nil {
+		t.Fatalf("resolve.New unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		resp, err := l.Check(context.Background(), "bad.example.com", xlist.Domain)
+		if err != nil || !resp.Result {
+			t.Fatalf("resolve.Check unexpected response: %v %v", resp, err)
+		}
+	}
+	// A and AAAA queries
+	if s.Count() != 2 {
+		t.Errorf("resolve.Check queries want=2 got=%v", s.Count())
+	}
+	if got := l.Inspect()["cached"]; got != 2 {
+		t.Errorf("resolve.Inspect cached want=2 got=%v", got)
+	}
+	l.Flush()
+	l.Check(context.Background(), "bad.example.com", xlist.Domain)
+	if s.Count() != 4 {
+		t.Errorf("resolve.Check queries want=4 got=%v", s.Count())
+	}
+	// without cache
+	cfg.Cache = false
+	l, _ = resolvexl.New("test", testBadIPs(), cfg, yalogi.LogNull)
+	l.Check(context.Background(), "bad.example.com", xlist.Domain)
+	l.Check(context.Background(), "bad.example.com", xlist.Domain)
+	if s.Count() != 8 {
+		t.Errorf("resolve.Check queries want=8 got=%v", s.Count())
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := resolvexl.New("test", &mockxl.List{ResourceList: []xlist.Resource{xlist.Domain}}, resolvexl.DefaultConfig(), yalogi.LogNull)
+	if err == nil {
+		t.Error("resolve.New expected error")
+	}
+	_, err = resolvexl.New("test", nil, resolvexl.DefaultConfig(), yalogi.LogNull)
+	if err == nil {
+		t.Error("resolve.New expected error")
+	}
+}
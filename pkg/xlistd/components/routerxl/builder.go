@@ -0,0 +1,100 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package routerxl
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildListFn {
+	return func(b *xlistd.Builder, parents []string, def xlistd.ListDef) (xlistd.List, error) {
+		cfg := defaultCfg
+		if def.Opts == nil {
+			return nil, errors.New("'routes' is required")
+		}
+		cfg, err := parseOptions(cfg, def.Opts)
+		if err != nil {
+			return nil, err
+		}
+		rules, err := parseRoutes(def.Opts)
+		if err != nil {
+			return nil, err
+		}
+		defID, _, err := option.String(def.Opts, "default")
+		if err != nil {
+			return nil, err
+		}
+		routes := make([]Route, 0, len(def.Contains))
+		var defList xlistd.List
+		for _, sublist := range def.Contains {
+			if sublist.Disabled {
+				continue
+			}
+			childRules, routed := rules[sublist.ID]
+			if !routed && sublist.ID != defID {
+				return nil, fmt.Errorf("child '%s' without routes", sublist.ID)
+			}
+			child, err := b.BuildChild(append(parents, def.ID), sublist)
+			if err != nil {
+				return nil, fmt.Errorf("constructing child '%s': %v", sublist.ID, err)
+			}
+			if sublist.ID == defID {
+				defList = child
+			}
+			if routed {
+				routes = append(routes, Route{List: child, Rules: childRules})
+				delete(rules, sublist.ID)
+			}
+		}
+		if len(rules) > 0 {
+			for cid := range rules {
+				err = fmt.Errorf("routes for unknown child '%s'", cid)
+			}
+			return nil, err
+		}
+		if defID != "" && defList == nil {
+			return nil, fmt.Errorf("default child '%s' not found", defID)
+		}
+		return New(def.ID, routes, defList, def.Resources, cfg)
+	}
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	reason, ok, err := option.String(opts, "reason")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Reason = reason
+	}
+	return dst, nil
+}
+
+func parseRoutes(opts map[string]interface{}) (map[string][]string, error) {
+	routes, ok, err := option.Hash(opts, "routes")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("'routes' is required")
+	}
+	rules := make(map[string][]string, len(routes))
+	for cid := range routes {
+		childRules, _, err := option.SliceString(routes, cid)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'routes': invalid '%s'", cid)
+		}
+		rules[cid] = childRules
+	}
+	return rules, nil
+}
+
+func init() {
+	xlistd.RegisterListBuilder(ComponentClass, Builder(Config{}))
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package routerxl_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/routerxl"
+)
+
+var (
+	onlyIP     = []xlist.Resource{xlist.IPv4, xlist.IPv6}
+	onlyIPv4   = []xlist.Resource{xlist.IPv4}
+	onlyIPv6   = []xlist.Resource{xlist.IPv6}
+	onlyDomain = []xlist.Resource{xlist.Domain}
+)
+
+var testmocks = []xlistd.ListDef{
+	{ID: "mock1",
+		Class:     mockxl.ComponentClass,
+		Resources: onlyIPv4},
+	{ID: "mock2",
+		Class:     mockxl.ComponentClass,
+		Resources: onlyIPv4,
+		Source:    "true",
+		Opts:      map[string]interface{}{"reason": "mock2"}},
+	{ID: "mock3",
+		Class:     mockxl.ComponentClass,
+		Resources: onlyIPv4,
+		Opts:      map[string]interface{}{"lazy": 10}},
+	{ID: "mock4",
+		Class:     mockxl.ComponentClass,
+		Resources: onlyIPv4,
+		Source:    "true",
+		Opts:      map[string]interface{}{"lazy": 10, "reason": "mock4"}},
+	{ID: "mock5",
+		Class:     mockxl.ComponentClass,
+		Resources: onlyIP},
+	{ID: "mock6",
+		Class:     mockxl.ComponentClass,
+		Resources: onlyDomain},
+}
+
+var testrouter1 = []xlistd.ListDef{
+	{ID: "list1",
+		Class:     routerxl.ComponentClass,
+		Resources: onlyIP,
+		Opts: map[string]interface{}{
+			"routes":  map[string]interface{}{"mock1": []interface{}{"10.0.0.0/8"}},
+			"default": "mock5"},
+		Contains: []xlistd.ListDef{{ID: "mock1"}, {ID: "mock5"}}},
+	{ID: "list2",
+		Class:     routerxl.ComponentClass,
+		Resources: onlyIPv4,
+		Contains:  []xlistd.ListDef{{ID: "mock1"}}},
+	{ID: "list3",
+		Class:     routerxl.ComponentClass,
+		Resources: onlyIPv4,
+		Opts:      map[string]interface{}{"routes": map[string]interface{}{"mock1": []interface{}{"10.0.0.0/8"}}},
+		Contains:  []xlistd.ListDef{{ID: "mock1"}, {ID: "mock2"}}},
+	{ID: "list4",
+		Class:     routerxl.ComponentClass,
+		Resources: onlyIPv4,
+		Opts:      map[string]interface{}{"routes": map[string]interface{}{"mock1": []interface{}{"10.0.0.0/8"}, "mock2": []interface{}{"11.0.0.0/8"}}},
+		Contains:  []xlistd.ListDef{{ID: "mock1"}}},
+	{ID: "list5",
+		Class:     routerxl.ComponentClass,
+		Resources: onlyIPv4,
+		Opts:      map[string]interface{}{"routes": map[string]interface{}{"mock1": "10.0.0.0/8"}},
+		Contains:  []xlistd.ListDef{{ID: "mock1"}}},
+	{ID: "list6",
+		Class:     routerxl.ComponentClass,
+		Resources: onlyIPv4,
+		Opts:      map[string]interface{}{"routes": map[string]interface{}{"mock1": []interface{}{"10.0.0.0/88"}}},
+		Contains:  []xlistd.ListDef{{ID: "mock1"}}},
+	{ID: "list7",
+		Class:     routerxl.ComponentClass,
+		Resources: onlyIPv4,
+		Opts: map[string]interface{}{
+			"routes":  map[string]interface{}{"mock1": []interface{}{"10.0.0.0/8"}},
+			"default": "mock2"},
+		Contains: []xlistd.ListDef{{ID: "mock1"}}},
+}
+
+func TestBuild(t *testing.T) {
+	b := xlistd.NewBuilder(apiservice.NewRegistry())
+
+	//create mocks
+	for _, defmock := range testmocks {
+		_, err := b.Build(defmock)
+		if err != nil {
+			t.Fatalf("building mock %s: %v", defmock.ID, err)
+		}
+	}
+	//define and do tests
+	var tests = []struct {
+		listid  string
+		wantErr string
+	}{
+		{"list1", ""},
+		{"list2", "'routes' is required"},
+		{"list3", "child 'mock2' without routes"},
+		{"list4", "unknown child 'mock2'"},
+		{"list5", "invalid 'routes'"},
+		{"list6", "invalid rule"},
+		{"list7", "default child 'mock2' not found"},
+	}
+	for _, test := range tests {
+		def, _ := xlistd.FilterID(test.listid, testrouter1)
+		_, err := b.Build(def)
+		switch {
+		case test.wantErr == "" && err == nil:
+			//
+		case test.wantErr == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("expected error for %s", test.listid)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package routerxl provides a composite xlistd.List implementation that
+// dispatches the checks to its child components using rules based on the
+// value of the resource: domain suffixes and ip prefixes. The rules of each
+// child are stored in a memxl list and the most specific matching rule
+// selects the child.
+//
+// This package is a work in progress and makes no API stability promises.
+package routerxl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/memxl"
+)
+
+// ComponentClass registered.
+const ComponentClass = "router"
+
+// Config options.
+type Config struct {
+	ForceValidation bool
+	Reason          string
+}
+
+// Route defines the rules that dispatch checks to a list. Rules can be:
+// a domain ("example.com"), a domain and its subdomains ("*.example.com"
+// or ".example.com"), an ip ("10.1.1.1") or a cidr ("10.0.0.0/8").
+type Route struct {
+	List  xlistd.List
+	Rules []string
+}
+
+// List is a composite list that dispatches checks by the value checked.
+type List struct {
+	id        string
+	cfg       Config
+	provides  []bool
+	resources []xlist.Resource
+
+	childs   []xlistd.List
+	childRes [][]bool
+	rules    []*memxl.List
+	defIdx   int
+	inserted map[string]bool
+}
+
+// New returns a new router component with the resources passed. Default
+// list is optional and is used when no rule matches.
+func New(id string, routes []Route, defList xlistd.List, resources []xlist.Resource, cfg Config) (*List, error) {
+	l := &List{
+		id:        id,
+		cfg:       cfg,
+		resources: xlist.ClearResourceDups(resources, true),
+		provides:  make([]bool, len(xlist.Resources), len(xlist.Resources)),
+		defIdx:    -1,
+		inserted:  make(map[string]bool),
+	}
+	//set resource types that provides
+	for _, r := range l.resources {
+		l.provides[int(r)] = true
+	}
+	//set childs and rules
+	for _, route := range routes {
+		rules := memxl.New(route.List.ID(), []xlist.Resource{xlist.IPv4, xlist.IPv6, xlist.Domain}, memxl.Config{})
+		_, err := l.addChild(route.List, rules)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range route.Rules {
+			err := l.addRule(rules, rule)
+			if err != nil {
+				return nil, fmt.Errorf("route '%s': %v", route.List.ID(), err)
+			}
+		}
+	}
+	if defList != nil {
+		idx, err := l.addChild(defList, nil)
+		if err != nil {
+			return nil, err
+		}
+		l.defIdx = idx
+	}
+	return l, nil
+}
+
+func (l *List) addChild(child xlistd.List, rules *memxl.List) (int, error) {
+	res, err := child.Resources(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("getting resources from '%s': %v", child.ID(), err)
+	}
+	checks := make([]bool, len(xlist.Resources), len(xlist.Resources))
+	for _, r := range res {
+		if r.IsValid() {
+			checks[int(r)] = true
+		}
+	}
+	l.childs = append(l.childs, child)
+	l.childRes = append(l.childRes, checks)
+	l.rules = append(l.rules, rules)
+	return len(l.childs) - 1, nil
+}
+
+func (l *List) addRule(rules *memxl.List, rule string) error {
+	rule = strings.TrimSpace(rule)
+	item := memxl.Data{Resource: xlist.Domain, Format: xlistd.Plain, Value: rule}
+	switch {
+	case strings.Contains(rule, "/"):
+		ip, ipnet, err := net.ParseCIDR(rule)
+		if err != nil {
+			return fmt.Errorf("invalid rule '%s'", rule)
+		}
+		item = memxl.Data{Resource: ipResource(ip), Format: xlistd.CIDR, Value: ipnet.String()}
+	case net.ParseIP(rule) != nil:
+		// ips are stored as cidrs to detect duplicates
+		ip, size := net.ParseIP(rule), 8*net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, size = ip4, 8*net.IPv4len
+		}
+		ipnet := net.IPNet{IP: ip, Mask: net.CIDRMask(size, size)}
+		item = memxl.Data{Resource: ipResource(ip), Format: xlistd.CIDR, Value: ipnet.String()}
+	case strings.HasPrefix(rule, "*.") || strings.HasPrefix(rule, "."):
+		item.Format = xlistd.Sub
+		item.Value = strings.TrimPrefix(strings.TrimPrefix(rule, "*"), ".")
+	}
+	if item.Resource == xlist.Domain {
+		k, ok := xlist.Canonicalize(item.Value, xlist.Domain)
+		if !ok {
+			return fmt.Errorf("invalid rule '%s'", rule)
+		}
+		item.Value = k
+	}
+	if l.inserted[item.String()] {
+		return fmt.Errorf("duplicated rule '%s'", rule)
+	}
+	err := rules.Append(context.Background(), item.Value, item.Resource, item.Format)
+	if err != nil {
+		return fmt.Errorf("invalid rule '%s'", rule)
+	}
+	l.inserted[item.String()] = true
+	return nil
+}
+
+func ipResource(ip net.IP) xlist.Resource {
+	if ip.To4() != nil {
+		return xlist.IPv4
+	}
+	return xlist.IPv6
+}
+
+// ID implements xlistd.List interface.
+func (l *List) ID() string {
+	return l.id
+}
+
+// Class implements xlistd.List interface.
+func (l *List) Class() string {
+	return ComponentClass
+}
+
+// Check implements xlist.Checker interface.
+func (l *List) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	if !l.checks(resource) {
+		return xlist.Response{}, xlist.ErrNotSupported
+	}
+	name, ctx, err := xlist.DoValidation(ctx, name, resource, l.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	// if the child selected doesn't check the resource, default is used
+	idx := l.route(ctx, name, resource)
+	if idx < 0 || !l.childRes[idx][int(resource)] {
+		idx = l.defIdx
+	}
+	if idx < 0 || !l.childRes[idx][int(resource)] {
+		return xlist.Response{}, nil
+	}
+	resp, err := l.childs[idx].Check(ctx, name, resource)
+	if err == nil && resp.Result && l.cfg.Reason != "" {
+		resp.Reason = l.cfg.Reason
+	}
+	return resp, err
+}
+
+// route returns the index of the child selected by the most specific
+// matching rule or -1 if no rule matches.
+func (l *List) route(ctx context.Context, name string, resource xlist.Resource) int {
+	if resource != xlist.IPv4 && resource != xlist.IPv6 && resource != xlist.Domain {
+		return -1
+	}
+	best, bestSpec := -1, -1
+	for idx, rules := range l.rules {
+		if rules == nil {
+			continue
+		}
+		_, spec, ok, err := rules.Match(ctx, name, resource)
+		if err == nil && ok && spec > bestSpec {
+			best, bestSpec = idx, spec
+		}
+	}
+	return best
+}
+
+// Resources implements xlist.Checker interface.
+func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	resources := make([]xlist.Resource, len(l.resources), len(l.resources))
+	copy(resources, l.resources)
+	return resources, nil
+}
+
+// Ping implements xlistd.List interface.
+func (l *List) Ping() error {
+	msgErr := make([]string, 0)
+	for _, child := range l.childs {
+		if err := child.Ping(); err != nil {
+			msgErr = append(msgErr, fmt.Sprintf("%s: %v", child.ID(), err))
+		}
+	}
+	if len(msgErr) > 0 {
+		return errors.New(strings.Join(msgErr, ";"))
+	}
+	return nil
+}
+
+func (l *List) checks(r xlist.Resource) bool {
+	if r.IsValid() {
+		return l.provides[int(r)]
+	}
+	return false
+}
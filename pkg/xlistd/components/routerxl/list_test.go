@@ -0,0 +1,168 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package routerxl_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/routerxl"
+)
+
+func TestList_Check(t *testing.T) {
+	ips := []xlist.Resource{xlist.IPv4, xlist.IPv6}
+	domains := []xlist.Resource{xlist.Domain}
+	all := []xlist.Resource{xlist.IPv4, xlist.IPv6, xlist.Domain, xlist.MD5}
+
+	mock := func(id string, resources []xlist.Resource) *mockxl.List {
+		return &mockxl.List{Identifier: id, ResourceList: resources, Results: []bool{true}, Reason: id}
+	}
+	routes := []routerxl.Route{
+		{List: mock("internal", ips), Rules: []string{"10.0.0.0/8", "192.168.0.0/16", "fd00::/8"}},
+		{List: mock("lab", ips), Rules: []string{"10.1.0.0/16", "192.168.1.1"}},
+		{List: mock("ru", domains), Rules: []string{".ru", "*.onion"}},
+		{List: mock("corp", domains), Rules: []string{"*.corp.example", "www.example.ru"}},
+		{List: mock("corpdev", domains), Rules: []string{".dev.corp.example"}},
+	}
+	l, err := routerxl.New("test", routes, mock("default", all), all, routerxl.Config{})
+	if err != nil {
+		t.Fatalf("router.New unexpected error: %v", err)
+	}
+
+	var tests = []struct {
+		name     string
+		resource xlist.Resource
+		want     string
+	}{
+		{"10.10.10.10", xlist.IPv4, "internal"},
+		{"10.1.10.10", xlist.IPv4, "lab"},
+		{"192.168.1.1", xlist.IPv4, "lab"},
+		{"192.168.1.2", xlist.IPv4, "internal"},
+		{"8.8.8.8", xlist.IPv4, "default"},
+		{"fd00::1", xlist.IPv6, "internal"},
+		{"2001:db8::1", xlist.IPv6, "default"},
+		{"ru", xlist.Domain, "ru"},
+		{"mail.yandex.ru", xlist.Domain, "ru"},
+		{"www.example.ru", xlist.Domain, "corp"},
+		{"www2.example.ru", xlist.Domain, "ru"},
+		{"hidden.onion", xlist.Domain, "ru"},
+		{"corp.example", xlist.Domain, "corp"},
+		{"www.corp.example", xlist.Domain, "corp"},
+		{"www.dev.corp.example", xlist.Domain, "corpdev"},
+		{"dev.corp.example", xlist.Domain, "corpdev"},
+		{"www.google.com", xlist.Domain, "default"},
+		{"d41d8cd98f00b204e9800998ecf8427e", xlist.MD5, "default"},
+	}
+	for _, test := range tests {
+		resp, err := l.Check(context.Background(), test.name, test.resource)
+		if err != nil {
+			t.Errorf("router.Check(%s) unexpected error: %v", test.name, err)
+			continue
+		}
+		if resp.Reason != test.want {
+			t.Errorf("router.Check(%s) want=%v got=%v", test.name, test.want, resp.Reason)
+		}
+	}
+	// without default returns negative
+	l, _ = routerxl.New("test", routes, nil, all, routerxl.Config{})
+	resp, err := l.Check(context.Background(), "www.google.com", xlist.Domain)
+	if err != nil || resp.Result {
+		t.Errorf("router.Check unexpected response: %v %v", resp, err)
+	}
+	_, err = l.Check(context.Background(), "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", xlist.SHA256)
+	if err != xlist.ErrNotSupported {
+		t.Errorf("router.Check unexpected error: %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	all := []xlist.Resource{xlist.IPv4, xlist.IPv6, xlist.Domain}
+	var tests = []struct {
+		rules   []string
+		wantErr bool
+	}{
+		{[]string{"10.0.0.0/8", "10.0.0.0/16", ".com", "www.example.com"}, false},
+		{[]string{"10.0.0.0/33"}, true},
+		{[]string{"10.0.0.0/8", "10.1.2.3/8"}, true},
+		{[]string{"10.1.1.1", "10.1.1.1/32"}, true},
+		{[]string{".com", "*.com"}, true},
+		{[]string{"-bad-.com"}, true},
+		{[]string{"*."}, true},
+	}
+	for idx, test := range tests {
+		routes := []routerxl.Route{{List: &mockxl.List{Identifier: "mock", ResourceList: all}, Rules: test.rules}}
+		_, err := routerxl.New("test", routes, nil, all, routerxl.Config{})
+		if test.wantErr && err == nil {
+			t.Errorf("router.New idx[%v] expected error", idx)
+		} else if !test.wantErr && err != nil {
+			t.Errorf("router.New idx[%v] unexpected error: %v", idx, err)
+		}
+	}
+}
+
+func TestNew_Duplicated(t *testing.T) {
+	all := []xlist.Resource{xlist.IPv4, xlist.IPv6, xlist.Domain}
+	var tests = []struct {
+		first  []string
+		second []string
+		dup    bool
+	}{
+		{[]string{"10.0.0.0/8"}, []string{"10.0.0.0/8"}, true},
+		{[]string{"10.0.0.0/8"}, []string{"10.255.0.0/8"}, true},
+		{[]string{"10.0.0.0/8"}, []string{"10.0.0.0/9"}, false},
+		{[]string{"10.1.0.0/16"}, []string{"10.0.0.0/8"}, false},
+		{[]string{"10.1.0.0/16", "10.0.0.0/8"}, []string{"10.1.2.0/16"}, true},
+		{[]string{"192.168.1.1"}, []string{"192.168.1.1/32"}, true},
+		{[]string{"192.168.1.1"}, []string{"192.168.1.0/31"}, false},
+		{[]string{"2001:db8::/32"}, []string{"2001:db8:0:1::/32"}, true},
+		{[]string{"fd00::1"}, []string{"fd00::1/128"}, true},
+		{[]string{"fd00::/8"}, []string{"10.0.0.0/8"}, false},
+	}
+	for idx, test := range tests {
+		routes := []routerxl.Route{
+			{List: &mockxl.List{Identifier: "first", ResourceList: all}, Rules: test.first},
+			{List: &mockxl.List{Identifier: "second", ResourceList: all}, Rules: test.second},
+		}
+		_, err := routerxl.New("test", routes, nil, all, routerxl.Config{})
+		switch {
+		case test.dup && err == nil:
+			t.Errorf("router.New idx[%v] expected error", idx)
+		case test.dup && !strings.Contains(err.Error(), "route 'second': duplicated rule"):
+			t.Errorf("router.New idx[%v] unexpected error: %v", idx, err)
+		case !test.dup && err != nil:
+			t.Errorf("router.New idx[%v] unexpected error: %v", idx, err)
+		}
+	}
+}
+
+func ExampleList() {
+	all := []xlist.Resource{xlist.IPv4, xlist.Domain}
+
+	routes := []routerxl.Route{
+		{
+			List:  &mockxl.List{Identifier: "internal", ResourceList: all, Results: []bool{true}, Reason: "internal"},
+			Rules: []string{"10.0.0.0/8", "*.corp.example"},
+		},
+	}
+	defList := &mockxl.List{Identifier: "public", ResourceList: all, Results: []bool{true}, Reason: "public"}
+	rbl, err := routerxl.New("test", routes, defList, all, routerxl.Config{})
+	if err != nil {
+		log.Fatalln("this should not happen")
+	}
+	resp, _ := rbl.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+	fmt.Println(resp.Reason)
+	resp, _ = rbl.Check(context.Background(), "www.corp.example", xlist.Domain)
+	fmt.Println(resp.Reason)
+	resp, _ = rbl.Check(context.Background(), "www.google.com", xlist.Domain)
+	fmt.Println(resp.Reason)
+
+	// Output:
+	// internal
+	// internal
+	// public
+}
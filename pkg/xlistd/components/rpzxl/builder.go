@@ -0,0 +1,106 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package rpzxl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildListFn {
+	return func(b *xlistd.Builder, parents []string, def xlistd.ListDef) (xlistd.List, error) {
+		cfg := defaultCfg
+		if def.Source == "" {
+			def.Source = fmt.Sprintf("%s.rpz", def.ID)
+		}
+		source := b.DataPath(def.Source)
+		if !fileExists(source) {
+			return nil, fmt.Errorf("file '%s' doesn't exists", source)
+		}
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		bl := New(def.ID, source, def.Resources, cfg, b.Logger())
+		//register startup
+		b.OnStartup(func() error {
+			return bl.Open()
+		})
+		//register shutdown
+		b.OnShutdown(func() error {
+			bl.Close()
+			return nil
+		})
+
+		return bl, nil
+	}
+}
+
+func fileExists(filename string) bool {
+	info, err := os.Stat(filename)
+	if err != nil || os.IsNotExist(err) {
+		return false
+	}
+	return !info.IsDir()
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	origin, ok, err := option.String(opts, "origin")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Origin = origin
+	}
+
+	reasons, ok, err := option.HashString(opts, "reasons")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Reasons = make(map[Action]string, len(reasons))
+		for k, v := range reasons {
+			action, err := ToAction(k)
+			if err != nil {
+				return dst, fmt.Errorf("invalid 'reasons': %v", err)
+			}
+			dst.Reasons[action] = v
+		}
+	}
+
+	autoreload, ok, err := option.Bool(opts, "autoreload")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Autoreload = autoreload
+	}
+
+	reloadSecs, ok, err := option.Int(opts, "reloadseconds")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if reloadSecs <= 0 {
+			return dst, errors.New("invalid 'reloadseconds'")
+		}
+		dst.ReloadTime = time.Duration(reloadSecs) * time.Second
+	}
+
+	return dst, nil
+}
+
+func init() {
+	xlistd.RegisterListBuilder(ComponentClass, Builder(DefaultConfig()))
+}
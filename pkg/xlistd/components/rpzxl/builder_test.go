@@ -0,0 +1,78 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package rpzxl_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/rpzxl"
+)
+
+var testdatabase1 = []xlistd.ListDef{
+	{ID: "list1",
+		Class:     rpzxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.Domain}},
+	{ID: "list2",
+		Class:     rpzxl.ComponentClass,
+		Source:    "testfile1.rpz",
+		Resources: []xlist.Resource{xlist.IPv4, xlist.IPv6, xlist.Domain}},
+	{ID: "list3",
+		Class:     rpzxl.ComponentClass,
+		Source:    "testfile1.rpz",
+		Resources: []xlist.Resource{xlist.Domain},
+		Opts: map[string]interface{}{
+			"origin": "rpz.local", "autoreload": true, "reloadseconds": 10,
+			"reasons": map[string]interface{}{"nxdomain": "blocked", "local-data": "redirected"}}},
+	{ID: "list4",
+		Class:     rpzxl.ComponentClass,
+		Source:    "testfile1.rpz",
+		Resources: []xlist.Resource{xlist.Domain},
+		Opts:      map[string]interface{}{"reasons": map[string]interface{}{"redirect": "redirected"}}},
+	{ID: "list5",
+		Class:     rpzxl.ComponentClass,
+		Source:    "testfile1.rpz",
+		Resources: []xlist.Resource{xlist.Domain},
+		Opts:      map[string]interface{}{"reloadseconds": 0}},
+}
+
+func TestBuild(t *testing.T) {
+	b := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.DataDir(testdir))
+	defer b.Shutdown()
+
+	//define and do tests
+	var tests = []struct {
+		listid  string
+		wantErr string
+	}{
+		{"list1", "doesn't exists"},
+		{"list2", ""},
+		{"list3", ""},
+		{"list4", "invalid 'reasons'"},
+		{"list5", "invalid 'reloadseconds'"},
+	}
+	for _, test := range tests {
+		def, ok := xlistd.FilterID(test.listid, testdatabase1)
+		if !ok {
+			t.Errorf("can't find id %s in database tests", test.listid)
+			continue
+		}
+		_, err := b.Build(def)
+		switch {
+		case test.wantErr == "" && err == nil:
+			//
+		case test.wantErr == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("expected error for %s", test.listid)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		}
+	}
+	if err := b.Start(); err != nil {
+		t.Errorf("starting lists: %v", err)
+	}
+}
@@ -0,0 +1,360 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package rpzxl provides a xlistd.List implementation that uses a DNS
+// Response Policy Zone (RPZ) file as source for its checks.
+//
+// This package is a work in progress and makes no API stability promises.
+package rpzxl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/memxl"
+)
+
+// ComponentClass defines default class for component builder
+const ComponentClass = "rpz"
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		ReloadTime: 30 * time.Second,
+	}
+}
+
+// Config options. Reasons maps the actions of the rules to the reasons of
+// the responses, by default "rpz <action>".
+type Config struct {
+	ForceValidation bool
+	Origin          string
+	Reasons         map[Action]string
+	Autoreload      bool
+	ReloadTime      time.Duration
+}
+
+// List loads the rules of a RPZ file into memxl lists. As in RPZ, the most
+// specific trigger that matches is used: exact names prevail over wildcards,
+// longer wildcards over shorter ones and longer prefixes over shorter ones.
+// Order of precedence of actions is only used between equally specific
+// triggers, and names matching a passthru rule are negatives. Rpz-nsdname
+// and rpz-nsip triggers are skipped, they match the name servers of the
+// answers and not the names checked.
+type List struct {
+	id        string
+	cfg       Config
+	logger    yalogi.Logger
+	filename  string
+	resources []xlist.Resource
+
+	mu      sync.RWMutex
+	zone    *zone
+	mtime   time.Time
+	size    int64
+	err     error
+	close   chan bool
+	started bool
+}
+
+// zone stores the triggers of each action in order of precedence
+type zone struct {
+	policies []policy
+	triggers []*memxl.List
+	wild     []*memxl.List
+	count    map[string]int
+}
+
+type policy struct {
+	action Action
+	reason string
+}
+
+// New creates a new List with the filename passed.
+func New(id, filename string, resources []xlist.Resource, cfg Config, logger yalogi.Logger) *List {
+	l := &List{
+		id:        id,
+		filename:  filename,
+		cfg:       cfg,
+		logger:    logger,
+		resources: xlist.ClearResourceDups(resources, true),
+		close:     make(chan bool),
+	}
+	if l.logger == nil {
+		l.logger = yalogi.LogNull
+	}
+	return l
+}
+
+// ID implements xlistd.List interface
+func (l *List) ID() string {
+	return l.id
+}
+
+// Class implements xlistd.List interface
+func (l *List) Class() string {
+	return ComponentClass
+}
+
+// Check implements xlist.Checker interface
+func (l *List) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	if !resource.InArray(l.resources) {
+		return xlist.Response{}, xlist.ErrNotSupported
+	}
+	name, _, err := xlist.DoValidation(ctx, name, resource, l.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	l.mu.RLock()
+	z := l.zone
+	l.mu.RUnlock()
+	if z == nil {
+		return xlist.Response{}, xlist.ErrUnavailable
+	}
+	idx, err := z.match(ctx, name, resource)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	if idx < 0 || z.policies[idx].action == Passthru {
+		return xlist.Response{}, nil
+	}
+	return xlist.Response{Result: true, Reason: z.policies[idx].reason}, nil
+}
+
+// match returns the index of the policy of the most specific trigger
+// matching the name or -1 if no trigger matches
+func (z *zone) match(ctx context.Context, name string, resource xlist.Resource) (int, error) {
+	idx, err := bestMatch(ctx, z.triggers, name, resource)
+	if err != nil || idx >= 0 || resource != xlist.Domain {
+		return idx, err
+	}
+	// wildcards match the subdomains, not the domain
+	dot := strings.IndexByte(name, '.')
+	if dot < 0 {
+		return -1, nil
+	}
+	return bestMatch(ctx, z.wild, name[dot+1:], resource)
+}
+
+// bestMatch returns the index of the list with the most specific item, the
+// first one if they are equally specific
+func bestMatch(ctx context.Context, lists []*memxl.List, name string, resource xlist.Resource) (int, error) {
+	best, bestSpec := -1, -1
+	for idx, list := range lists {
+		_, spec, ok, err := list.Match(ctx, name, resource)
+		if err != nil {
+			return -1, err
+		}
+		if ok && spec > bestSpec {
+			best, bestSpec = idx, spec
+		}
+	}
+	return best, nil
+}
+
+// Resources implements xlist.Checker interface
+func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	resources := make([]xlist.Resource, len(l.resources), len(l.resources))
+	copy(resources, l.resources)
+	return resources, nil
+}
+
+// Ping implements xlistd.Ping interface
+func (l *List) Ping() error {
+	if !l.started {
+		return errors.New("list is closed")
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.err
+}
+
+// Inspect implements xlistd.Inspector interface.
+func (l *List) Inspect() map[string]interface{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	rules := make(map[string]int)
+	if l.zone != nil {
+		for k, v := range l.zone.count {
+			rules[k] = v
+		}
+	}
+	return map[string]interface{}{"rules": rules}
+}
+
+// Open loads the zone file.
+func (l *List) Open() error {
+	l.logger.Debugf("%s: opening source '%s'", l.id, l.filename)
+	if err := l.load(); err != nil {
+		return err
+	}
+	if l.cfg.Autoreload {
+		go l.doReload()
+	}
+	l.started = true
+	return nil
+}
+
+// Close releases the rules.
+func (l *List) Close() {
+	l.logger.Debugf("%s: closing source '%s'", l.id, l.filename)
+	if l.started {
+		if l.cfg.Autoreload {
+			l.close <- true
+		}
+		l.started = false
+		l.mu.Lock()
+		l.zone = nil
+		l.mu.Unlock()
+	}
+}
+
+// Reload implements xlistd.Reloader interface, it reloads the zone file.
+// Current rules are kept if there are errors.
+func (l *List) Reload() error {
+	l.logger.Debugf("reloading source '%s'", l.filename)
+	return l.load()
+}
+
+func (l *List) load() error {
+	file, err := os.Open(l.filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	rules, err := ReadRules(file, l.cfg.Origin, l.filename)
+	if err != nil {
+		return err
+	}
+	z := l.newZone()
+	skipped, nsSkipped := 0, 0
+	for _, rule := range rules {
+		if rule.Trigger == NSDName || rule.Trigger == NSIP {
+			nsSkipped++
+			continue
+		}
+		if !ruleResource(rule).InArray(l.resources) {
+			continue
+		}
+		if err := z.add(rule); err != nil {
+			skipped++
+			continue
+		}
+		z.count[rule.Action.String()]++
+	}
+	if nsSkipped > 0 {
+		l.logger.Warnf("%s: %v rpz-nsdname and rpz-nsip rules skipped in '%s'", l.id, nsSkipped, l.filename)
+	}
+	if skipped > 0 {
+		l.logger.Warnf("%s: %v rules skipped in '%s'", l.id, skipped, l.filename)
+	}
+	l.mu.Lock()
+	l.zone, l.mtime, l.size = z, stat.ModTime(), stat.Size()
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *List) newZone() *zone {
+	z := &zone{
+		policies: make([]policy, 0, len(Actions)),
+		triggers: make([]*memxl.List, 0, len(Actions)),
+		wild:     make([]*memxl.List, 0, len(Actions)),
+		count:    make(map[string]int),
+	}
+	for _, action := range Actions {
+		reason, ok := l.cfg.Reasons[action]
+		if !ok {
+			reason = fmt.Sprintf("rpz %s", action)
+		}
+		z.policies = append(z.policies, policy{action: action, reason: reason})
+		z.triggers = append(z.triggers, memxl.New(l.id, l.resources, memxl.Config{}))
+		z.wild = append(z.wild, memxl.New(l.id, l.resources, memxl.Config{}))
+	}
+	return z
+}
+
+func (z *zone) add(rule Rule) error {
+	idx := -1
+	for i, p := range z.policies {
+		if p.action == rule.Action {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return xlist.ErrBadRequest
+	}
+	ctx := context.Background()
+	switch {
+	case rule.Trigger == QName && rule.Wildcard:
+		return z.wild[idx].Append(ctx, rule.Value, xlist.Domain, xlistd.Sub)
+	case rule.Trigger == QName:
+		return z.triggers[idx].Append(ctx, rule.Value, xlist.Domain, xlistd.Plain)
+	case rule.Trigger == IP:
+		return z.triggers[idx].Append(ctx, rule.Value, ruleResource(rule), xlistd.CIDR)
+	}
+	return xlist.ErrBadRequest
+}
+
+func ruleResource(rule Rule) xlist.Resource {
+	switch rule.Trigger {
+	case IP, NSIP:
+		if strings.Contains(rule.Value, ":") {
+			return xlist.IPv6
+		}
+		return xlist.IPv4
+	}
+	return xlist.Domain
+}
+
+func (l *List) doReload() {
+	ticker := time.NewTicker(l.cfg.ReloadTime)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.close:
+			return
+		case <-ticker.C:
+			l.logger.Debugf("checking source '%s'", l.filename)
+			changed, err := l.changed()
+			if err == nil && changed {
+				l.logger.Infof("source '%s' has changed", l.filename)
+				err = l.Reload()
+				l.setErr(err)
+			}
+		}
+	}
+}
+
+func (l *List) changed() (bool, error) {
+	stat, err := os.Stat(l.filename)
+	if err != nil {
+		return false, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.mtime.Equal(stat.ModTime()) && l.size == stat.Size() {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (l *List) setErr(err error) {
+	l.mu.Lock()
+	l.err = err
+	l.mu.Unlock()
+	if err != nil {
+		l.logger.Warnf("%s: %v", l.id, err)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package rpzxl_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/xlistd/components/rpzxl"
+)
+
+var testdir = "../../../../test/testdata"
+var testfile1 = "../../../../test/testdata/testfile1.rpz"
+
+func TestList_Check(t *testing.T) {
+	cfg := rpzxl.DefaultConfig()
+	cfg.Origin = "rpz.local"
+	cfg.Reasons = map[rpzxl.Action]string{rpzxl.LocalData: "walled garden"}
+	list := rpzxl.New("test1", testfile1, []xlist.Resource{xlist.IPv4, xlist.IPv6, xlist.Domain}, cfg, yalogi.LogNull)
+	if err := list.Open(); err != nil {
+		t.Fatalf("rpzxl.Open(): err=%v", err)
+	}
+	defer list.Close()
+
+	var tests = []struct {
+		name     string
+		resource xlist.Resource
+		want     bool
+		reason   string
+	}{
+		{"malware.example.com", xlist.Domain, true, "rpz nxdomain"},
+		{"www.malware.example.com", xlist.Domain, true, "rpz nxdomain"},
+		{"ok.malware.example.com", xlist.Domain, false, ""},
+		{"tracking.example.net", xlist.Domain, false, ""},
+		{"a.b.tracking.example.net", xlist.Domain, true, "rpz nodata"},
+		{"slow.example.org", xlist.Domain, true, "rpz drop"},
+		{"www.slow.example.org", xlist.Domain, false, ""},
+		{"big.example.org", xlist.Domain, true, "rpz tcp-only"},
+		{"walled.example.org", xlist.Domain, true, "walled garden"},
+		{"local.example.org", xlist.Domain, true, "walled garden"},
+		// name server triggers are skipped
+		{"ns1.evil-dns.com", xlist.Domain, false, ""},
+		{"example.com", xlist.Domain, false, ""},
+		{"192.0.2.10", xlist.IPv4, true, "rpz nxdomain"},
+		{"198.0.2.10", xlist.IPv4, true, "rpz drop"},
+		{"198.0.2.11", xlist.IPv4, false, ""},
+		{"203.0.113.2", xlist.IPv4, false, ""},
+		{"10.0.2.1", xlist.IPv4, false, ""},
+		{"2001:db8::1", xlist.IPv6, true, "rpz nxdomain"},
+		{"2001:db9::1", xlist.IPv6, false, ""},
+	}
+	for idx, test := range tests {
+		got, err := list.Check(context.Background(), test.name, test.resource)
+		if err != nil {
+			t.Errorf("idx[%v] rpzxl.Check(): err=%v", idx, err)
+		}
+		if got.Result != test.want || got.Reason != test.reason {
+			t.Errorf("idx[%v] rpzxl.Check(%s): want=%v got=%v", idx, test.name, test.want, got)
+		}
+	}
+	rules := list.Inspect()["rules"].(map[string]int)
+	if rules["nxdomain"] != 4 || rules["local-data"] != 2 {
+		t.Errorf("rpzxl.Inspect(): unexpected %v", rules)
+	}
+}
+
+func TestReadRules(t *testing.T) {
+	var tests = []struct {
+		zone    string
+		origin  string
+		want    []rpzxl.Rule
+		wantErr string
+	}{
+		{"$ORIGIN rpz.\n@ SOA localhost. admin.localhost. 1 3600 600 86400 300\nevil.com CNAME .\n", "",
+			[]rpzxl.Rule{{Trigger: rpzxl.QName, Value: "evil.com", Action: rpzxl.NXDomain}}, ""},
+		{"*.Evil.COM CNAME *.\n", "rpz.local",
+			[]rpzxl.Rule{{Trigger: rpzxl.QName, Value: "evil.com", Wildcard: true, Action: rpzxl.NoData}}, ""},
+		{"128.1.zz.db8.2001.rpz-ip CNAME rpz-drop.\n", "rpz.local",
+			[]rpzxl.Rule{{Trigger: rpzxl.IP, Value: "2001:db8::1/128", Action: rpzxl.Drop}}, ""},
+		{"64.zz.db8.2001.rpz-nsip CNAME .\n", "rpz.local",
+			[]rpzxl.Rule{{Trigger: rpzxl.NSIP, Value: "2001:db8::/64", Action: rpzxl.NXDomain}}, ""},
+		{"evil.com. CNAME .\n", "", nil, "without origin"},
+		{"evil.com. CNAME .\n", "rpz.local", nil, "out of zone"},
+		{"24.1.2.0.192.rpz-ip CNAME .\n", "rpz.local", nil, "host bits"},
+		{"33.1.2.0.192.rpz-ip CNAME .\n", "rpz.local", nil, "invalid ip"},
+		{"a.*.evil.com CNAME .\n", "rpz.local", nil, "invalid name"},
+		{"evil.com BOGUS .\n", "rpz.local", nil, "dns:"},
+	}
+	for idx, test := range tests {
+		got, err := rpzxl.ReadRules(strings.NewReader(test.zone), test.origin, "test")
+		switch {
+		case test.wantErr == "" && err != nil:
+			t.Errorf("idx[%v] rpzxl.ReadRules(): err=%v", idx, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("idx[%v] rpzxl.ReadRules(): expected error", idx)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("idx[%v] rpzxl.ReadRules(): unexpected err=%v", idx, err)
+		case test.wantErr == "" && (len(got) != len(test.want) || got[0] != test.want[0]):
+			t.Errorf("idx[%v] rpzxl.ReadRules(): want=%v got=%v", idx, test.want, got)
+		}
+	}
+}
+
+func TestList_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpzxl")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "test.rpz")
+	write := func(content string) {
+		content = "$ORIGIN rpz.local.\n@ SOA localhost. admin.localhost. 1 3600 600 86400 300\n" + content
+		if err := ioutil.WriteFile(source+".tmp", []byte(content), 0644); err != nil {
+			t.Fatalf("writing zone: %v", err)
+		}
+		if err := os.Rename(source+".tmp", source); err != nil {
+			t.Fatalf("renaming zone: %v", err)
+		}
+	}
+	write("www.example.com CNAME .\n")
+
+	cfg := rpzxl.DefaultConfig()
+	cfg.Autoreload = true
+	cfg.ReloadTime = 50 * time.Millisecond
+	list := rpzxl.New("test1", source, []xlist.Resource{xlist.Domain}, cfg, yalogi.LogNull)
+	if err := list.Open(); err != nil {
+		t.Fatalf("rpzxl.Open(): err=%v", err)
+	}
+	defer list.Close()
+	check := func(name string, want bool) {
+		resp, err := list.Check(context.Background(), name, xlist.Domain)
+		if err != nil || resp.Result != want {
+			t.Errorf("rpzxl.Check(%s): want=%v got=%v err=%v", name, want, resp.Result, err)
+		}
+	}
+	check("www.example.com", true)
+	check("www.example.org", false)
+
+	write("www.example.com CNAME .\nwww.example.org CNAME .\n")
+	time.Sleep(200 * time.Millisecond)
+	check("www.example.org", true)
+
+	// invalid zone keeps the previous rules
+	write("www.example.net BOGUS .\n")
+	time.Sleep(200 * time.Millisecond)
+	check("www.example.org", true)
+	if err := list.Ping(); err == nil {
+		t.Error("rpzxl.Ping(): expected error")
+	}
+}
+
+func TestList_Specificity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpzxl")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "test.rpz")
+	content := `$ORIGIN rpz.local.
+@ SOA localhost. admin.localhost. 1 3600 600 86400 300
+*.cdn.example.com          CNAME rpz-passthru.
+bad.cdn.example.com        CNAME .
+*.evil.cdn.example.com     CNAME rpz-drop.
+evil.example.com           CNAME rpz-tcp-only.
+evil.example.com           CNAME rpz-drop.
+16.0.0.51.198.rpz-ip       CNAME rpz-passthru.
+32.7.100.51.198.rpz-ip     CNAME .
+24.0.100.51.198.rpz-ip     CNAME rpz-tcp-only.
+24.0.100.51.198.rpz-ip     CNAME rpz-drop.
+`
+	if err := ioutil.WriteFile(source, []byte(content), 0644); err != nil {
+		t.Fatalf("writing zone: %v", err)
+	}
+	list := rpzxl.New("test1", source, []xlist.Resource{xlist.IPv4, xlist.Domain}, rpzxl.DefaultConfig(), yalogi.LogNull)
+	if err := list.Open(); err != nil {
+		t.Fatalf("rpzxl.Open(): err=%v", err)
+	}
+	defer list.Close()
+
+	var tests = []struct {
+		name     string
+		resource xlist.Resource
+		want     bool
+		reason   string
+	}{
+		// exact name prevails over wildcard
+		{"bad.cdn.example.com", xlist.Domain, true, "rpz nxdomain"},
+		{"good.cdn.example.com", xlist.Domain, false, ""},
+		// longer wildcard prevails over shorter
+		{"www.evil.cdn.example.com", xlist.Domain, true, "rpz drop"},
+		{"evil.cdn.example.com", xlist.Domain, false, ""},
+		// equally specific triggers use precedence of actions
+		{"evil.example.com", xlist.Domain, true, "rpz drop"},
+		// longer prefix prevails over shorter
+		{"198.51.100.7", xlist.IPv4, true, "rpz nxdomain"},
+		{"198.51.100.8", xlist.IPv4, true, "rpz drop"},
+		{"198.51.101.8", xlist.IPv4, false, ""},
+	}
+	for idx, test := range tests {
+		got, err := list.Check(context.Background(), test.name, test.resource)
+		if err != nil {
+			t.Errorf("idx[%v] rpzxl.Check(): err=%v", idx, err)
+		}
+		if got.Result != test.want || got.Reason != test.reason {
+			t.Errorf("idx[%v] rpzxl.Check(%s): want=%v got=%v", idx, test.name, test.want, got)
+		}
+	}
+}
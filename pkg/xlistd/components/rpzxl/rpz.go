@@ -0,0 +1,234 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package rpzxl
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// Trigger of a rule.
+type Trigger int
+
+// List of triggers.
+const (
+	QName Trigger = iota
+	IP
+	NSDName
+	NSIP
+)
+
+// String implements stringer interface.
+func (t Trigger) String() string {
+	switch t {
+	case QName:
+		return "qname"
+	case IP:
+		return "rpz-ip"
+	case NSDName:
+		return "rpz-nsdname"
+	case NSIP:
+		return "rpz-nsip"
+	}
+	return fmt.Sprintf("unknown(%d)", t)
+}
+
+// Action of a rule.
+type Action string
+
+// List of actions.
+const (
+	NXDomain  Action = "nxdomain"
+	NoData    Action = "nodata"
+	Passthru  Action = "passthru"
+	Drop      Action = "drop"
+	TCPOnly   Action = "tcp-only"
+	LocalData Action = "local-data"
+)
+
+// String implements stringer interface.
+func (a Action) String() string {
+	return string(a)
+}
+
+// ToAction returns the action from its string representation.
+func ToAction(s string) (Action, error) {
+	for _, a := range Actions {
+		if strings.EqualFold(s, string(a)) {
+			return a, nil
+		}
+	}
+	return Action(""), fmt.Errorf("invalid action '%s'", s)
+}
+
+// Actions is the list of actions in order of precedence.
+var Actions = []Action{Passthru, Drop, NXDomain, NoData, TCPOnly, LocalData}
+
+// Rule is a rule of a response policy zone. Value is a domain for QName and
+// NSDName triggers, and a network in CIDR notation for IP and NSIP triggers.
+type Rule struct {
+	Trigger  Trigger
+	Value    string
+	Wildcard bool
+	Action   Action
+}
+
+// ReadRules parses a zone file and returns its rules. Origin is the name of
+// the zone, if it's empty the owner of the SOA record is used. Triggers that
+// depend on the client (rpz-client-ip) are ignored.
+func ReadRules(in io.Reader, origin, filename string) ([]Rule, error) {
+	origin = dns.Fqdn(strings.ToLower(origin))
+	if origin == "." {
+		origin = ""
+	}
+	zp := dns.NewZoneParser(in, origin, filename)
+	// ttls are meaningless for checks
+	zp.SetDefaultTTL(300)
+	rules := make([]Rule, 0)
+	// local data of a trigger may have many records
+	seen := make(map[string]bool)
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		owner := strings.ToLower(rr.Header().Name)
+		if rr.Header().Rrtype == dns.TypeSOA {
+			if origin == "" {
+				origin = owner
+			}
+			continue
+		}
+		if rr.Header().Rrtype == dns.TypeNS {
+			continue
+		}
+		if origin == "" {
+			return nil, fmt.Errorf("%s: record before SOA without origin", owner)
+		}
+		if !dns.IsSubDomain(origin, owner) || owner == origin {
+			return nil, fmt.Errorf("%s: out of zone '%s'", owner, origin)
+		}
+		rule, ok, err := parseTrigger(strings.TrimSuffix(owner, "."+origin))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", owner, err)
+		}
+		if !ok {
+			continue
+		}
+		rule.Action = LocalData
+		if cname, ok := rr.(*dns.CNAME); ok {
+			rule.Action = cnameAction(strings.ToLower(cname.Target))
+		}
+		key := fmt.Sprintf("%v|%s|%v|%s", rule.Trigger, rule.Value, rule.Wildcard, rule.Action)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		rules = append(rules, rule)
+	}
+	if err := zp.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func cnameAction(target string) Action {
+	switch target {
+	case ".":
+		return NXDomain
+	case "*.":
+		return NoData
+	case "rpz-passthru.":
+		return Passthru
+	case "rpz-drop.":
+		return Drop
+	case "rpz-tcp-only.":
+		return TCPOnly
+	}
+	return LocalData
+}
+
+func parseTrigger(name string) (Rule, bool, error) {
+	var rule Rule
+	switch {
+	case strings.HasSuffix(name, ".rpz-ip"):
+		rule.Trigger = IP
+		cidr, err := parseIP(strings.TrimSuffix(name, ".rpz-ip"))
+		if err != nil {
+			return rule, false, err
+		}
+		rule.Value = cidr
+	case strings.HasSuffix(name, ".rpz-nsip"):
+		rule.Trigger = NSIP
+		cidr, err := parseIP(strings.TrimSuffix(name, ".rpz-nsip"))
+		if err != nil {
+			return rule, false, err
+		}
+		rule.Value = cidr
+	case strings.HasSuffix(name, ".rpz-client-ip"):
+		return rule, false, nil
+	case strings.HasSuffix(name, ".rpz-nsdname"):
+		rule.Trigger = NSDName
+		rule.Value = strings.TrimSuffix(name, ".rpz-nsdname")
+	default:
+		rule.Trigger = QName
+		rule.Value = name
+	}
+	if rule.Trigger == QName || rule.Trigger == NSDName {
+		if strings.HasPrefix(rule.Value, "*.") {
+			rule.Wildcard = true
+			rule.Value = rule.Value[2:]
+		}
+		if rule.Value == "" || strings.Contains(rule.Value, "*") {
+			return rule, false, fmt.Errorf("invalid name")
+		}
+	}
+	return rule, true, nil
+}
+
+// parseIP returns the network of a rpz-ip trigger, encoded as the prefix
+// length followed by the labels of the address in reverse order, ex:
+// 24.0.2.0.192 or 48.zz.db8.2001.
+func parseIP(s string) (string, error) {
+	labels := strings.Split(s, ".")
+	if len(labels) < 2 {
+		return "", fmt.Errorf("invalid ip trigger")
+	}
+	prefix, err := strconv.Atoi(labels[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid prefix length")
+	}
+	labels = labels[1:]
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	var ip net.IP
+	bits := 128
+	if len(labels) == 4 && prefix <= 32 {
+		ip = net.ParseIP(strings.Join(labels, ".")).To4()
+		bits = 32
+	} else {
+		addr := strings.Join(labels, ":")
+		switch {
+		case strings.Contains(addr, ":zz:"):
+			addr = strings.Replace(addr, ":zz:", "::", 1)
+		case strings.HasPrefix(addr, "zz:"):
+			addr = "::" + addr[3:]
+		case strings.HasSuffix(addr, ":zz"):
+			addr = addr[:len(addr)-3] + "::"
+		}
+		ip = net.ParseIP(addr)
+		if ip != nil && ip.To4() != nil {
+			ip = nil
+		}
+	}
+	if ip == nil || prefix < 0 || prefix > bits {
+		return "", fmt.Errorf("invalid ip trigger")
+	}
+	network := ip.Mask(net.CIDRMask(prefix, bits))
+	if !network.Equal(ip) {
+		return "", fmt.Errorf("invalid ip trigger: host bits set")
+	}
+	return fmt.Sprintf("%s/%d", network, prefix), nil
+}
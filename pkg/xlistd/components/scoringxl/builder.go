@@ -0,0 +1,121 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package scoringxl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildListFn {
+	return func(b *xlistd.Builder, parents []string, def xlistd.ListDef) (xlistd.List, error) {
+		cfg := defaultCfg
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if cfg.Threshold <= 0 {
+			return nil, errors.New("'threshold' is required")
+		}
+		childs := make([]xlistd.List, 0, len(def.Contains))
+		for _, sublist := range def.Contains {
+			if sublist.Disabled {
+				continue
+			}
+			child, err := b.BuildChild(append(parents, def.ID), sublist)
+			if err != nil {
+				return nil, fmt.Errorf("constructing child '%s': %v", sublist.ID, err)
+			}
+			childres, err := child.Resources(context.Background())
+			if err != nil {
+				return nil, fmt.Errorf("constructing child '%s': %v", sublist.ID, err)
+			}
+			for _, r := range def.Resources {
+				if !r.InArray(childres) {
+					return nil, fmt.Errorf("child '%s' doesn't checks resource '%s'", sublist.ID, r)
+				}
+			}
+			childs = append(childs, child)
+		}
+		return New(def.ID, childs, def.Resources, cfg)
+	}
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	reason, ok, err := option.String(opts, "reason")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Reason = reason
+	}
+	skipErrors, ok, err := option.Bool(opts, "skiperrors")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.SkipErrors = skipErrors
+	}
+	earlyStop, ok, err := option.Bool(opts, "earlystop")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.EarlyStop = earlyStop
+	}
+	mode, ok, err := option.String(opts, "mode")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Mode, err = ToMode(mode)
+		if err != nil {
+			return dst, fmt.Errorf("invalid 'mode': %v", err)
+		}
+	}
+	threshold, ok, err := option.Int(opts, "threshold")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if threshold <= 0 {
+			return dst, fmt.Errorf("invalid 'threshold'")
+		}
+		dst.Threshold = threshold
+	}
+	defScore, ok, err := option.Int(opts, "defaultscore")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.DefaultScore = defScore
+	}
+	weights, ok, err := option.Hash(opts, "weights")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Weights = make(map[string]int, len(weights))
+		for cid := range weights {
+			weight, _, err := option.Int(weights, cid)
+			if err != nil {
+				return dst, fmt.Errorf("invalid 'weights': invalid '%s'", cid)
+			}
+			dst.Weights[cid] = weight
+		}
+	}
+	return dst, nil
+}
+
+func init() {
+	xlistd.RegisterListBuilder(ComponentClass, Builder(Config{EarlyStop: true}))
+}
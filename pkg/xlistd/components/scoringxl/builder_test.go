@@ -0,0 +1,139 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. View LICENSE.
+
+package scoringxl_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/scoringxl"
+)
+
+var (
+	onlyIP     = []xlist.Resource{xlist.IPv4, xlist.IPv6}
+	onlyIPv4   = []xlist.Resource{xlist.IPv4}
+	onlyIPv6   = []xlist.Resource{xlist.IPv6}
+	onlyDomain = []xlist.Resource{xlist.Domain}
+)
+
+var testmocks = []xlistd.ListDef{
+	{ID: "mock1",
+		Class:     mockxl.ComponentClass,
+		Resources: onlyIPv4},
+	{ID: "mock2",
+		Class:     mockxl.ComponentClass,
+		Resources: onlyIPv4,
+		Source:    "true",
+		Opts:      map[string]interface{}{"reason": "mock2"}},
+	{ID: "mock3",
+		Class:     mockxl.ComponentClass,
+		Resources: onlyIPv4,
+		Opts:      map[string]interface{}{"lazy": 10}},
+	{ID: "mock4",
+		Class:     mockxl.ComponentClass,
+		Resources: onlyIPv4,
+		Source:    "true",
+		Opts:      map[string]interface{}{"lazy": 10, "reason": "mock4"}},
+	{ID: "mock5",
+		Class:     mockxl.ComponentClass,
+		Resources: onlyIP},
+	{ID: "mock6",
+		Class:     mockxl.ComponentClass,
+		Resources: onlyDomain},
+}
+
+var testscoring1 = []xlistd.ListDef{
+	{ID: "list1",
+		Class:     scoringxl.ComponentClass,
+		Resources: onlyIPv4,
+		Opts:      map[string]interface{}{"threshold": 10},
+		Contains:  []xlistd.ListDef{{ID: "mock1"}}},
+	{ID: "list2",
+		Class:     scoringxl.ComponentClass,
+		Resources: onlyIPv4,
+		Contains:  []xlistd.ListDef{{ID: "mock1"}, {ID: "mock2"}}},
+	{ID: "list3",
+		Class: scoringxl.ComponentClass,
+		Opts:  map[string]interface{}{"threshold": 10}},
+	{ID: "list4",
+		Class:     scoringxl.ComponentClass,
+		Resources: onlyIP,
+		Opts:      map[string]interface{}{"threshold": 10},
+		Contains:  []xlistd.ListDef{{ID: "mock1"}, {ID: "mock2"}}},
+	{ID: "list5",
+		Class:     scoringxl.ComponentClass,
+		Resources: onlyIPv4,
+		Opts:      map[string]interface{}{"threshold": 10, "mode": "median"},
+		Contains:  []xlistd.ListDef{{ID: "mock1"}, {ID: "mock2"}}},
+	{ID: "list6",
+		Class:     scoringxl.ComponentClass,
+		Resources: onlyIPv4,
+		Opts:      map[string]interface{}{"threshold": -1},
+		Contains:  []xlistd.ListDef{{ID: "mock1"}}},
+	{ID: "list7",
+		Class:     scoringxl.ComponentClass,
+		Resources: onlyIPv4,
+		Opts: map[string]interface{}{
+			"threshold":    10,
+			"mode":         "avg",
+			"defaultscore": 2,
+			"earlystop":    false,
+			"skiperrors":   true,
+			"weights":      map[string]interface{}{"mock2": 3, "mock4": -1.0}},
+		Contains: []xlistd.ListDef{{ID: "mock1"}, {ID: "mock2"}, {ID: "mock3"}, {ID: "mock4"}}},
+	{ID: "list8",
+		Class:     scoringxl.ComponentClass,
+		Resources: onlyIPv4,
+		Opts:      map[string]interface{}{"threshold": 10, "weights": map[string]interface{}{"mock2": "a"}},
+		Contains:  []xlistd.ListDef{{ID: "mock1"}, {ID: "mock2"}}},
+	{ID: "list9",
+		Class:     scoringxl.ComponentClass,
+		Resources: onlyIPv4,
+		Opts:      map[string]interface{}{"threshold": 10, "weights": map[string]interface{}{"mock5": 1}},
+		Contains:  []xlistd.ListDef{{ID: "mock1"}, {ID: "mock2"}}},
+}
+
+func TestBuild(t *testing.T) {
+	b := xlistd.NewBuilder(apiservice.NewRegistry())
+
+	//create mocks
+	for _, defmock := range testmocks {
+		_, err := b.Build(defmock)
+		if err != nil {
+			t.Fatalf("building mock %s: %v", defmock.ID, err)
+		}
+	}
+	//define and do tests
+	var tests = []struct {
+		listid  string
+		wantErr string
+	}{
+		{"list1", ""},
+		{"list2", "'threshold' is required"},
+		{"list3", ""},
+		{"list4", "doesn't checks resource"},
+		{"list5", "invalid 'mode'"},
+		{"list6", "invalid 'threshold'"},
+		{"list7", ""},
+		{"list8", "invalid 'weights'"},
+		{"list9", "unknown child 'mock5'"},
+	}
+	for _, test := range tests {
+		def, _ := xlistd.FilterID(test.listid, testscoring1)
+		_, err := b.Build(def)
+		switch {
+		case test.wantErr == "" && err == nil:
+			//
+		case test.wantErr == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("expected error for %s", test.listid)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		}
+	}
+}
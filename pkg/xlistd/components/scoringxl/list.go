@@ -0,0 +1,310 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package scoringxl provides a composite xlistd.List implementation that
+// checks in parallel on the child components, aggregates the scores of
+// their responses and returns positive when a threshold is reached.
+//
+// This package is a work in progress and makes no API stability promises.
+package scoringxl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/reason"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// ComponentClass registered.
+const ComponentClass = "scoring"
+
+// Mode defines how scores are aggregated.
+type Mode int
+
+// Mode values.
+const (
+	Sum Mode = iota
+	Max
+	Avg
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Sum:
+		return "sum"
+	case Max:
+		return "max"
+	case Avg:
+		return "avg"
+	}
+	return fmt.Sprintf("unknown(%d)", m)
+}
+
+// ToMode returns the mode from its string representation.
+func ToMode(s string) (Mode, error) {
+	switch strings.ToLower(s) {
+	case "sum":
+		return Sum, nil
+	case "max":
+		return Max, nil
+	case "avg":
+		return Avg, nil
+	}
+	return Sum, fmt.Errorf("invalid mode '%s'", s)
+}
+
+// Config options.
+type Config struct {
+	// Mode of aggregation.
+	Mode Mode
+	// Threshold is the score required to return a positive.
+	Threshold int
+	// Weights of the childs by identifier, scores are multiplied by them.
+	// Default weight is 1.
+	Weights map[string]int
+	// DefaultScore is used for positive responses without score.
+	DefaultScore int
+	// EarlyStop returns as soon as threshold is reached in sum and max
+	// modes. Negative scores of the pending childs are not considered.
+	EarlyStop       bool
+	SkipErrors      bool
+	ForceValidation bool
+	Reason          string
+}
+
+// List is a composite list that aggregates the scores of its childs.
+type List struct {
+	id        string
+	cfg       Config
+	childs    []xlistd.List
+	weights   []int
+	provides  []bool
+	resources []xlist.Resource
+}
+
+// New returns a new scoring component with the resources passed.
+func New(id string, childs []xlistd.List, resources []xlist.Resource, cfg Config) (*List, error) {
+	if cfg.Threshold <= 0 {
+		return nil, errors.New("invalid threshold")
+	}
+	if cfg.Mode != Sum && cfg.Mode != Max && cfg.Mode != Avg {
+		return nil, errors.New("invalid mode")
+	}
+	l := &List{
+		id:        id,
+		cfg:       cfg,
+		resources: xlist.ClearResourceDups(resources, true),
+		provides:  make([]bool, len(xlist.Resources), len(xlist.Resources)),
+	}
+	//set resource types that provides
+	for _, r := range l.resources {
+		l.provides[int(r)] = true
+	}
+	//set childs and weights
+	ids := make(map[string]bool, len(childs))
+	l.childs = make([]xlistd.List, 0, len(childs))
+	l.weights = make([]int, 0, len(childs))
+	for _, child := range childs {
+		weight := 1
+		if w, ok := cfg.Weights[child.ID()]; ok {
+			weight = w
+		}
+		ids[child.ID()] = true
+		l.childs = append(l.childs, child)
+		l.weights = append(l.weights, weight)
+	}
+	for cid := range cfg.Weights {
+		if !ids[cid] {
+			return nil, fmt.Errorf("weight for unknown child '%s'", cid)
+		}
+	}
+	return l, nil
+}
+
+// ID implements xlistd.List interface.
+func (l *List) ID() string {
+	return l.id
+}
+
+// Class implements xlistd.List interface.
+func (l *List) Class() string {
+	return ComponentClass
+}
+
+// checkResult is used for store parallel checks
+type checkResult struct {
+	listIdx  int
+	response xlist.Response
+	err      error
+}
+
+// contribution stores the score of a positive child
+type contribution struct {
+	listIdx int
+	score   int
+}
+
+// Check implements xlist.Checker interface.
+func (l *List) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	if !l.checks(resource) {
+		return xlist.Response{}, xlist.ErrNotSupported
+	}
+	name, ctx, err := xlist.DoValidation(ctx, name, resource, l.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	if len(l.childs) == 0 {
+		return xlist.Response{}, nil
+	}
+	//create context for childs
+	childCtx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	results := make(chan *checkResult, len(l.childs))
+	for idx, child := range l.childs {
+		wg.Add(1)
+		go workerCheck(childCtx, &wg, child, idx, name, resource, results)
+	}
+
+	ttl := 0
+	total := 0
+	weights := 0
+	contribs := make([]contribution, 0, len(l.childs))
+	finished := 0
+RESULTLOOP:
+	for finished < len(l.childs) {
+		select {
+		case r := <-results:
+			finished++
+			if r.err == nil && r.response.Result {
+				score, rest, serr := reason.ExtractScore(r.response.Reason)
+				if serr != nil {
+					r.err = fmt.Errorf("%s: %v", l.childs[r.listIdx].ID(), serr)
+				} else {
+					// reason without score tags, a score of zero is kept
+					if rest == r.response.Reason {
+						score = l.cfg.DefaultScore
+					}
+					score = score * l.weights[r.listIdx]
+					if len(contribs) == 0 || ttl > r.response.TTL {
+						ttl = r.response.TTL
+					}
+					contribs = append(contribs, contribution{listIdx: r.listIdx, score: score})
+				}
+			}
+			if r.err != nil {
+				if !l.cfg.SkipErrors {
+					err = r.err
+					break RESULTLOOP
+				}
+				continue
+			}
+			weights += l.weights[r.listIdx]
+			total = l.aggregate(contribs, weights)
+			if l.cfg.EarlyStop && l.cfg.Mode != Avg && total >= l.cfg.Threshold {
+				break RESULTLOOP
+			}
+		case <-ctx.Done():
+			err = xlist.ErrCanceledRequest
+			break RESULTLOOP
+		}
+	}
+	cancel()
+	wg.Wait()
+	close(results)
+
+	var resp xlist.Response
+	if err == nil && len(contribs) > 0 && total >= l.cfg.Threshold {
+		resp.Result = true
+		if ttl > 0 {
+			resp.TTL = ttl
+		}
+		if l.cfg.Reason == "" {
+			sort.Slice(contribs, func(i, j int) bool { return contribs[i].listIdx < contribs[j].listIdx })
+			items := make([]string, 0, len(contribs))
+			for _, c := range contribs {
+				items = append(items, fmt.Sprintf("%s=%v", l.childs[c.listIdx].ID(), c.score))
+			}
+			resp.Reason = reason.WithScore(total,
+				fmt.Sprintf("score %v: %s", total, strings.Join(items, ",")))
+		} else {
+			resp.Reason = reason.WithScore(total, l.cfg.Reason)
+		}
+	}
+	return resp, err
+}
+
+func (l *List) aggregate(contribs []contribution, weights int) int {
+	if len(contribs) == 0 {
+		return 0
+	}
+	switch l.cfg.Mode {
+	case Max:
+		max := contribs[0].score
+		for _, c := range contribs[1:] {
+			if c.score > max {
+				max = c.score
+			}
+		}
+		return max
+	case Avg:
+		if weights <= 0 {
+			return 0
+		}
+		sum := 0
+		for _, c := range contribs {
+			sum += c.score
+		}
+		return sum / weights
+	default:
+		sum := 0
+		for _, c := range contribs {
+			sum += c.score
+		}
+		return sum
+	}
+}
+
+// Resources implements xlist.Checker interface.
+func (l *List) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	resources := make([]xlist.Resource, len(l.resources), len(l.resources))
+	copy(resources, l.resources)
+	return resources, nil
+}
+
+// Ping implements xlist.Checker interface.
+func (l *List) Ping() error {
+	msgErr := make([]string, 0)
+	for _, child := range l.childs {
+		if err := child.Ping(); err != nil {
+			msgErr = append(msgErr, fmt.Sprintf("%s: %v", child.ID(), err))
+		}
+	}
+	if len(msgErr) > 0 {
+		return errors.New(strings.Join(msgErr, ";"))
+	}
+	return nil
+}
+
+func (l *List) checks(r xlist.Resource) bool {
+	if r.IsValid() {
+		return l.provides[int(r)]
+	}
+	return false
+}
+
+func workerCheck(ctx context.Context, wg *sync.WaitGroup, list xlist.Checker, listIdx int,
+	name string, resource xlist.Resource, results chan<- *checkResult) {
+	defer wg.Done()
+	response, err := list.Check(ctx, name, resource)
+	results <- &checkResult{
+		listIdx:  listIdx,
+		response: response,
+		err:      err,
+	}
+}
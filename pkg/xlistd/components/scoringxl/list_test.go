@@ -0,0 +1,139 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package scoringxl_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/scoringxl"
+)
+
+func TestList_Check(t *testing.T) {
+	ip4 := []xlist.Resource{xlist.IPv4}
+	t50ms := 50 * time.Millisecond
+
+	rblFalse := &mockxl.List{Identifier: "false", ResourceList: ip4}
+	rbl5 := &mockxl.List{Identifier: "rbl5", ResourceList: ip4, Results: []bool{true}, Reason: "[score]5[/score]five"}
+	rbl10 := &mockxl.List{Identifier: "rbl10", ResourceList: ip4, Results: []bool{true}, Reason: "[score]10[/score]ten"}
+	rblNeg := &mockxl.List{Identifier: "neg", ResourceList: ip4, Results: []bool{true}, Reason: "[score]-10[/score]white"}
+	rblNoScore := &mockxl.List{Identifier: "noscore", ResourceList: ip4, Results: []bool{true}}
+	rblZero := &mockxl.List{Identifier: "zero", ResourceList: ip4, Results: []bool{true}, Reason: "[score]0[/score]zero"}
+	rblBad := &mockxl.List{Identifier: "bad", ResourceList: ip4, Results: []bool{true}, Reason: "[score]x[/score]bad"}
+	rblFail := &mockxl.List{Identifier: "fail", ResourceList: ip4, Fail: true}
+	rblLazyNeg := &mockxl.List{Identifier: "lazyneg", ResourceList: ip4, Lazy: t50ms, Results: []bool{true}, Reason: "[score]-10[/score]white"}
+
+	var tests = []struct {
+		childs []xlistd.List
+		cfg    scoringxl.Config
+
+		want       bool
+		wantReason string
+		wantErr    bool
+	}{
+		{[]xlistd.List{}, scoringxl.Config{Threshold: 1}, false, "", false},         //0
+		{[]xlistd.List{rblFalse}, scoringxl.Config{Threshold: 1}, false, "", false}, //1
+		{[]xlistd.List{rbl5}, scoringxl.Config{Threshold: 5}, true, "[score]5[/score]score 5: rbl5=5", false},
+		{[]xlistd.List{rbl5}, scoringxl.Config{Threshold: 6}, false, "", false}, //3
+		{[]xlistd.List{rbl5, rblFalse, rbl10}, scoringxl.Config{Threshold: 15}, true,
+			"[score]15[/score]score 15: rbl5=5,rbl10=10", false}, //4
+		{[]xlistd.List{rbl5, rbl10, rblNeg}, scoringxl.Config{Threshold: 10}, false, "", false}, //5
+		{[]xlistd.List{rbl5, rbl10}, scoringxl.Config{Threshold: 15, Mode: scoringxl.Max}, false, "", false},
+		{[]xlistd.List{rbl5, rbl10}, scoringxl.Config{Threshold: 10, Mode: scoringxl.Max}, true,
+			"[score]10[/score]score 10: rbl5=5,rbl10=10", false}, //7
+		{[]xlistd.List{rbl5, rbl10, rblFalse}, scoringxl.Config{Threshold: 5, Mode: scoringxl.Avg}, true,
+			"[score]5[/score]score 5: rbl5=5,rbl10=10", false}, //8
+		{[]xlistd.List{rbl5, rblFalse, rblFalse}, scoringxl.Config{Threshold: 2, Mode: scoringxl.Avg}, false, "", false},
+		{[]xlistd.List{rbl5, rbl10}, scoringxl.Config{Threshold: 20, Weights: map[string]int{"rbl5": 2}}, true,
+			"[score]20[/score]score 20: rbl5=10,rbl10=10", false}, //10
+		{[]xlistd.List{rblNoScore}, scoringxl.Config{Threshold: 1}, false, "", false}, //11
+		{[]xlistd.List{rblNoScore}, scoringxl.Config{Threshold: 1, DefaultScore: 1}, true,
+			"[score]1[/score]score 1: noscore=1", false}, //12
+		{[]xlistd.List{rbl10}, scoringxl.Config{Threshold: 1, Reason: "hey"}, true, "[score]10[/score]hey", false},
+		// explicit zeros aren't replaced by defaults
+		{[]xlistd.List{rblZero}, scoringxl.Config{Threshold: 1, DefaultScore: 1}, false, "", false}, //14
+		{[]xlistd.List{rbl5, rbl10}, scoringxl.Config{Threshold: 10, Weights: map[string]int{"rbl10": 0}}, false, "", false},
+		{[]xlistd.List{rbl5, rbl10}, scoringxl.Config{Threshold: 5, Weights: map[string]int{"rbl10": 0}}, true,
+			"[score]5[/score]score 5: rbl5=5,rbl10=0", false}, //16
+		// early stop ignores pending negative scores
+		{[]xlistd.List{rbl10, rblLazyNeg}, scoringxl.Config{Threshold: 10, EarlyStop: true}, true,
+			"[score]10[/score]score 10: rbl10=10", false}, //17
+		{[]xlistd.List{rbl10, rblLazyNeg}, scoringxl.Config{Threshold: 10}, false, "", false}, //18
+		// errors
+		{[]xlistd.List{rbl10, rblFail}, scoringxl.Config{Threshold: 10}, false, "", true}, //19
+		{[]xlistd.List{rbl10, rblFail}, scoringxl.Config{Threshold: 10, SkipErrors: true}, true,
+			"[score]10[/score]score 10: rbl10=10", false}, //20
+		{[]xlistd.List{rbl10, rblBad}, scoringxl.Config{Threshold: 10}, false, "", true}, //21
+	}
+	for idx, test := range tests {
+		l, err := scoringxl.New("test", test.childs, ip4, test.cfg)
+		if err != nil {
+			t.Fatalf("scoring.New idx[%v] unexpected error: %v", idx, err)
+		}
+		resp, err := l.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+		if test.wantErr && err == nil {
+			t.Errorf("scoring.Check idx[%v] expected error", idx)
+		} else if !test.wantErr && err != nil {
+			t.Errorf("scoring.Check idx[%v] unexpected error: %v", idx, err)
+		}
+		if test.want != resp.Result {
+			t.Errorf("scoring.Check idx[%v] want=%v got=%v", idx, test.want, resp.Result)
+		}
+		if test.wantReason != resp.Reason {
+			t.Errorf("scoring.Check idx[%v] reason want=%v got=%v", idx, test.wantReason, resp.Reason)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	ip4 := []xlist.Resource{xlist.IPv4}
+	childs := []xlistd.List{&mockxl.List{Identifier: "rbl1", ResourceList: ip4}}
+
+	_, err := scoringxl.New("test", childs, ip4, scoringxl.Config{})
+	if err == nil {
+		t.Error("scoring.New expected error for threshold")
+	}
+	_, err = scoringxl.New("test", childs, ip4, scoringxl.Config{Threshold: 1, Mode: scoringxl.Mode(10)})
+	if err == nil {
+		t.Error("scoring.New expected error for mode")
+	}
+	_, err = scoringxl.New("test", childs, ip4, scoringxl.Config{Threshold: 1, Weights: map[string]int{"rbl2": 1}})
+	if err == nil {
+		t.Error("scoring.New expected error for weights")
+	}
+}
+
+func ExampleList() {
+	ip4 := []xlist.Resource{xlist.IPv4}
+
+	childs := []xlistd.List{
+		&mockxl.List{Identifier: "rbl1", Results: []bool{true}, ResourceList: ip4, Reason: "[score]5[/score]spam"},
+		&mockxl.List{Identifier: "rbl2", Results: []bool{false, true}, ResourceList: ip4, Reason: "[score]3[/score]scan"},
+		&mockxl.List{Identifier: "rbl3", Results: []bool{true, false}, ResourceList: ip4, Reason: "malware"},
+	}
+	rbl, err := scoringxl.New("test", childs, ip4, scoringxl.Config{
+		Threshold:    8,
+		DefaultScore: 1,
+		Weights:      map[string]int{"rbl3": 3},
+	})
+	if err != nil {
+		log.Fatalln("this should not happen")
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := rbl.Check(context.Background(), "10.10.10.10", xlist.IPv4)
+		if err != nil {
+			log.Fatalln("this should not happen")
+		}
+		fmt.Println(resp.Result, resp.Reason)
+	}
+
+	// Output:
+	// true [score]8[/score]score 8: rbl1=5,rbl3=3
+	// true [score]8[/score]score 8: rbl1=5,rbl2=3
+}
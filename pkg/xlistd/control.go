@@ -0,0 +1,144 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package xlistd
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+)
+
+// Optional interfaces that lists and wrappers may implement for being
+// controlled at runtime.
+
+// Reloader is implemented by lists that can reload their data source.
+type Reloader interface {
+	Reload() error
+}
+
+// Flusher is implemented by lists or wrappers that store cached data.
+type Flusher interface {
+	Flush()
+}
+
+// Inspector is implemented by lists or wrappers that can report runtime
+// information about themselves.
+type Inspector interface {
+	Inspect() map[string]interface{}
+}
+
+// Item stores an item of a list that can be edited at runtime.
+type Item struct {
+	Resource  xlist.Resource `json:"resource"`
+	Format    Format         `json:"format"`
+	Value     string         `json:"value"`
+	Author    string         `json:"author,omitempty"`
+	Comment   string         `json:"comment,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// ErrItemNotFound is returned by editors when removing an item that doesn't
+// exist in the list.
+var ErrItemNotFound = errors.New("item not found")
+
+// ErrReadOnly is returned by editors that can't be modified at runtime, for
+// example, because they are replicas of other lists.
+var ErrReadOnly = errors.New("list is read only")
+
+// Editor is implemented by lists that can be modified at runtime. Editors
+// must return xlist.ErrNotSupported if resource of the item is not provided
+// by the list and xlist.ErrBadRequest if the item is not valid.
+type Editor interface {
+	Add(ctx context.Context, item Item) error
+	Remove(ctx context.Context, item Item) error
+	Items(ctx context.Context) ([]Item, error)
+}
+
+// Unwrapper is implemented by wrappers and returns the wrapped list.
+type Unwrapper interface {
+	Unwrap() List
+}
+
+// Chain returns the list passed followed by all the lists wrapped by it,
+// from the outermost to the innermost.
+func Chain(l List) []List {
+	chain := []List{l}
+	for {
+		w, ok := l.(Unwrapper)
+		if !ok {
+			return chain
+		}
+		l = w.Unwrap()
+		if l == nil {
+			return chain
+		}
+		chain = append(chain, l)
+	}
+}
+
+// managedList is used by builder to register lists when runtime control is
+// enabled and allows enabling or disabling them. A disabled list returns
+// negative responses.
+type managedList struct {
+	list     List
+	disabled int32
+}
+
+// ID implements xlistd.List interface.
+func (m *managedList) ID() string {
+	return m.list.ID()
+}
+
+// Class implements xlistd.List interface.
+func (m *managedList) Class() string {
+	return m.list.Class()
+}
+
+// Check implements xlist.Checker interface. Disabled lists return negatives
+// for the resources they provide.
+func (m *managedList) Check(ctx context.Context, name string, res xlist.Resource) (xlist.Response, error) {
+	if atomic.LoadInt32(&m.disabled) == 1 {
+		resources, err := m.list.Resources(ctx)
+		if err != nil {
+			return xlist.Response{}, err
+		}
+		if !res.InArray(resources) {
+			return xlist.Response{}, xlist.ErrNotSupported
+		}
+		return xlist.Response{}, nil
+	}
+	return m.list.Check(ctx, name, res)
+}
+
+// Resources implements xlist.Checker interface.
+func (m *managedList) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	return m.list.Resources(ctx)
+}
+
+// Ping implements xlistd.List interface.
+func (m *managedList) Ping() error {
+	if atomic.LoadInt32(&m.disabled) == 1 {
+		return nil
+	}
+	return m.list.Ping()
+}
+
+// Unwrap implements xlistd.Unwrapper interface.
+func (m *managedList) Unwrap() List {
+	return m.list
+}
+
+func (m *managedList) enabled() bool {
+	return atomic.LoadInt32(&m.disabled) == 0
+}
+
+func (m *managedList) setEnabled(b bool) {
+	if b {
+		atomic.StoreInt32(&m.disabled, 0)
+		return
+	}
+	atomic.StoreInt32(&m.disabled, 1)
+}
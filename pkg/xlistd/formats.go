@@ -3,6 +3,7 @@
 package xlistd
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -52,3 +53,26 @@ func ToFormat(s string) (Format, error) {
 		return Format(-1), fmt.Errorf("invalid format %s", s)
 	}
 }
+
+// MarshalJSON implements interface for struct marshalling.
+func (f Format) MarshalJSON() ([]byte, error) {
+	s := f.string()
+	if s == "" {
+		return nil, fmt.Errorf("invalid value %v for format", f)
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON implements interface for struct unmarshalling.
+func (f *Format) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := ToFormat(s)
+	if err != nil {
+		return err
+	}
+	*f = v
+	return nil
+}
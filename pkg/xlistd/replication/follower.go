@@ -0,0 +1,222 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	cliprom "github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+
+	"github.com/luids-io/core/yalogi"
+)
+
+// DefaultRetry is the default time between reconnections.
+const DefaultRetry = 5 * time.Second
+
+// FollowerOption is used for follower configuration.
+type FollowerOption func(*followerOpts)
+
+type followerOpts struct {
+	logger   yalogi.Logger
+	retry    time.Duration
+	onChange func()
+}
+
+var defaultFollowerOpts = followerOpts{
+	logger: yalogi.LogNull,
+	retry:  DefaultRetry,
+}
+
+// SetFollowerLogger option sets a logger for the follower.
+func SetFollowerLogger(l yalogi.Logger) FollowerOption {
+	return func(o *followerOpts) {
+		if l != nil {
+			o.logger = l
+		}
+	}
+}
+
+// Retry option sets the time between reconnections to the leader.
+func Retry(d time.Duration) FollowerOption {
+	return func(o *followerOpts) {
+		if d > 0 {
+			o.retry = d
+		}
+	}
+}
+
+// OnChange option sets a function that is called after the sink is
+// modified, for example, to flush caches.
+func OnChange(fn func()) FollowerOption {
+	return func(o *followerOpts) {
+		o.onChange = fn
+	}
+}
+
+// stats is a global structure.
+var stats struct {
+	offset    *cliprom.GaugeVec
+	snapshots *cliprom.CounterVec
+	entries   *cliprom.CounterVec
+}
+
+// Follower replicates a list from a leader.
+type Follower struct {
+	opts   followerOpts
+	logger yalogi.Logger
+	listID string
+	remote string
+	conn   *grpc.ClientConn
+	sink   Sink
+
+	mu        sync.Mutex
+	started   bool
+	connected bool
+	lastErr   error
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewFollower returns a follower that replicates the remote list of the
+// leader in the sink. ListID is used in logs and metrics.
+func NewFollower(listID string, conn *grpc.ClientConn, remote string, sink Sink, opt ...FollowerOption) *Follower {
+	opts := defaultFollowerOpts
+	for _, o := range opt {
+		o(&opts)
+	}
+	return &Follower{
+		opts:   opts,
+		logger: opts.logger,
+		listID: listID,
+		remote: remote,
+		conn:   conn,
+		sink:   sink,
+	}
+}
+
+// Start starts replication in background.
+func (f *Follower) Start() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.started {
+		return errors.New("replication: follower already started")
+	}
+	var ctx context.Context
+	ctx, f.cancel = context.WithCancel(context.Background())
+	f.started = true
+	f.wg.Add(1)
+	go f.run(ctx)
+	return nil
+}
+
+// Close stops replication.
+func (f *Follower) Close() error {
+	f.mu.Lock()
+	if !f.started {
+		f.mu.Unlock()
+		return nil
+	}
+	f.started = false
+	f.cancel()
+	f.mu.Unlock()
+	f.wg.Wait()
+	return nil
+}
+
+// Status returns if follower is connected to the leader and the last error.
+func (f *Follower) Status() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connected, f.lastErr
+}
+
+func (f *Follower) run(ctx context.Context) {
+	defer f.wg.Done()
+	for {
+		err := f.follow(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		f.setStatus(false, err)
+		f.logger.Warnf("replication: %s: following '%s': %v", f.listID, f.remote, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(f.opts.retry):
+		}
+	}
+}
+
+func (f *Follower) follow(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := f.conn.NewStream(ctx, &serviceDesc.Streams[0], followMethod,
+		grpc.CallContentSubtype(codecName))
+	if err != nil {
+		return err
+	}
+	offset := f.sink.Offset()
+	if err := stream.SendMsg(&request{List: f.remote, Offset: offset}); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	f.logger.Infof("replication: %s: following '%s' from offset %v", f.listID, f.remote, offset)
+	for {
+		var msg message
+		if err := stream.RecvMsg(&msg); err != nil {
+			return err
+		}
+		f.setStatus(true, nil)
+		if msg.Snapshot {
+			f.logger.Infof("replication: %s: restoring snapshot at offset %v", f.listID, msg.Offset)
+			if err := f.sink.Restore(msg.Entries, msg.Offset); err != nil {
+				return err
+			}
+			stats.snapshots.WithLabelValues(f.listID).Inc()
+		} else if len(msg.Entries) > 0 {
+			if err := f.sink.Replicate(msg.Entries); err != nil {
+				return err
+			}
+			stats.entries.WithLabelValues(f.listID).Add(float64(len(msg.Entries)))
+		}
+		if f.opts.onChange != nil && (msg.Snapshot || len(msg.Entries) > 0) {
+			f.opts.onChange()
+		}
+		stats.offset.WithLabelValues(f.listID).Set(float64(f.sink.Offset()))
+	}
+}
+
+func (f *Follower) setStatus(connected bool, err error) {
+	f.mu.Lock()
+	f.connected = connected
+	f.lastErr = err
+	f.mu.Unlock()
+}
+
+func init() {
+	stats.offset = cliprom.NewGaugeVec(
+		cliprom.GaugeOpts{
+			Name: "xlist_replication_offset",
+			Help: "Offset of the log replicated by followers.",
+		},
+		[]string{"list"})
+	stats.snapshots = cliprom.NewCounterVec(
+		cliprom.CounterOpts{
+			Name: "xlist_replication_snapshots_total",
+			Help: "Snapshots restored by followers.",
+		},
+		[]string{"list"})
+	stats.entries = cliprom.NewCounterVec(
+		cliprom.CounterOpts{
+			Name: "xlist_replication_entries_total",
+			Help: "Log entries replicated by followers.",
+		},
+		[]string{"list"})
+	cliprom.MustRegister(stats.offset, stats.snapshots, stats.entries)
+}
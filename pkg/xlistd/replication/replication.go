@@ -0,0 +1,89 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package replication implements the replication of lists modified at
+// runtime between xlistd instances. A leader streams its mutation log over
+// grpc and followers catch up from a snapshot and the offset of the log.
+//
+// Conflicting mutations over the same item are resolved with a last writer
+// wins policy, so replicas converge regardless of the order in which
+// mutations are applied.
+//
+// This package is a work in progress and makes no API stability promises.
+package replication
+
+import (
+	"errors"
+
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Operations of the mutations.
+const (
+	OpAdd    = "add"
+	OpRemove = "remove"
+)
+
+// ServiceName is the name of the grpc service.
+const ServiceName = "luids.xlist.v1.Replication"
+
+// Entry is a mutation of the log. Seq is the offset assigned by the leader.
+type Entry struct {
+	Seq uint64 `json:"seq,omitempty"`
+	Op  string `json:"op"`
+	xlistd.Item
+}
+
+// ErrListNotFound is returned if the list doesn't exist or it can't be
+// replicated.
+var ErrListNotFound = errors.New("replication: list not found")
+
+// Source is implemented by lists that can be replicated.
+type Source interface {
+	// Snapshot returns the state of the list and the offset of the log.
+	Snapshot() ([]Entry, uint64)
+	// Since returns the entries after the offset. It returns false if the
+	// entries are not available and a snapshot is required.
+	Since(offset uint64) ([]Entry, bool)
+	// Changes returns a channel that is closed when the log changes.
+	Changes() <-chan struct{}
+}
+
+// Sink is implemented by lists that can follow a leader.
+type Sink interface {
+	// Offset returns the offset of the last entry applied.
+	Offset() uint64
+	// Restore replaces the state of the list with the snapshot.
+	Restore(entries []Entry, offset uint64) error
+	// Replicate applies the entries of the log.
+	Replicate(entries []Entry) error
+}
+
+// Newer returns true if mutation a must prevail over b. Mutations are
+// ordered by timestamp, removes prevail over adds and then author and
+// comment are compared, so the result is deterministic.
+func Newer(a, b Entry) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.After(b.Timestamp)
+	}
+	if a.Op != b.Op {
+		return a.Op == OpRemove
+	}
+	if a.Author != b.Author {
+		return a.Author > b.Author
+	}
+	return a.Comment > b.Comment
+}
+
+// request is sent by followers.
+type request struct {
+	List   string `json:"list"`
+	Offset uint64 `json:"offset"`
+}
+
+// message is sent by leader. If Snapshot is true, entries are the full state
+// of the list.
+type message struct {
+	Snapshot bool    `json:"snapshot,omitempty"`
+	Offset   uint64  `json:"offset"`
+	Entries  []Entry `json:"entries,omitempty"`
+}
@@ -0,0 +1,209 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package replication_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/dynxl"
+	"github.com/luids-io/xlist/pkg/xlistd/replication"
+)
+
+type finder map[string]xlistd.List
+
+func (f finder) List(id string) (xlistd.List, bool) {
+	l, ok := f[id]
+	return l, ok
+}
+
+// instance is an in-process xlistd with a dynamic list
+type instance struct {
+	list     *dynxl.List
+	follower *replication.Follower
+	conn     *grpc.ClientConn
+}
+
+func newLeader(t *testing.T, dir string, cfg dynxl.Config) (*dynxl.List, *grpc.Server, *bufconn.Listener) {
+	list := dynxl.New("list1", filepath.Join(dir, "leader.journal"),
+		[]xlist.Resource{xlist.IPv4, xlist.Domain}, cfg, yalogi.LogNull)
+	if err := list.Open(); err != nil {
+		t.Fatalf("opening leader: %v", err)
+	}
+	lis := bufconn.Listen(1024 * 1024)
+	gsrv := grpc.NewServer()
+	replication.RegisterServer(gsrv, replication.NewServer(finder{"list1": list}, replication.BatchSize(2)))
+	go gsrv.Serve(lis)
+	return list, gsrv, lis
+}
+
+func newFollower(t *testing.T, name, dir string, lis *bufconn.Listener) *instance {
+	cfg := dynxl.DefaultConfig()
+	cfg.ReadOnly = true
+	list := dynxl.New(name, filepath.Join(dir, name+".journal"),
+		[]xlist.Resource{xlist.IPv4, xlist.Domain}, cfg, yalogi.LogNull)
+	if err := list.Open(); err != nil {
+		t.Fatalf("opening follower: %v", err)
+	}
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }))
+	if err != nil {
+		t.Fatalf("dialing leader: %v", err)
+	}
+	f := replication.NewFollower(name, conn, "list1", list, replication.Retry(50*time.Millisecond))
+	if err := f.Start(); err != nil {
+		t.Fatalf("starting follower: %v", err)
+	}
+	return &instance{list: list, follower: f, conn: conn}
+}
+
+func (i *instance) close() {
+	i.follower.Close()
+	i.conn.Close()
+	i.list.Close()
+}
+
+func item(value string) xlistd.Item {
+	return xlistd.Item{Resource: xlist.IPv4, Format: xlistd.Plain, Value: value, Author: "test"}
+}
+
+func values(list *dynxl.List) string {
+	items, _ := list.Items(context.Background())
+	s := ""
+	for _, i := range items {
+		s = s + fmt.Sprintf("%s,%s,%v;", i.Value, i.Author, i.Timestamp.UnixNano())
+	}
+	return s
+}
+
+// waitSync waits until follower has the same items than leader
+func waitSync(t *testing.T, leader *dynxl.List, f *instance) {
+	want := values(leader)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if f.list.Offset() == leader.Offset() && values(f.list) == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	got := values(f.list)
+	t.Fatalf("follower not synced: offset=%v want=%v items=%v want=%v",
+		f.list.Offset(), leader.Offset(), got, want)
+}
+
+func TestReplication(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replication")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	leader, gsrv, lis := newLeader(t, dir, dynxl.DefaultConfig())
+	defer leader.Close()
+	defer gsrv.Stop()
+	for i := 1; i <= 5; i++ {
+		if err := leader.Add(ctx, item(fmt.Sprintf("10.0.0.%v", i))); err != nil {
+			t.Fatalf("leader.Add(): %v", err)
+		}
+	}
+	// followers catch up from offset zero
+	f1 := newFollower(t, "f1", dir, lis)
+	f2 := newFollower(t, "f2", dir, lis)
+	defer f2.close()
+	waitSync(t, leader, f1)
+	waitSync(t, leader, f2)
+
+	// live mutations are streamed
+	leader.Remove(ctx, item("10.0.0.1"))
+	leader.Add(ctx, item("10.0.0.6"))
+	waitSync(t, leader, f1)
+	waitSync(t, leader, f2)
+	resp, _ := f1.list.Check(ctx, "10.0.0.6", xlist.IPv4)
+	if !resp.Result {
+		t.Errorf("follower.Check(): replicated item not found")
+	}
+	// followers are read only
+	if err := f1.list.Add(ctx, item("10.0.0.100")); err != xlistd.ErrReadOnly {
+		t.Errorf("follower.Add(): unexpected err=%v", err)
+	}
+
+	// restarted follower catches up from its offset
+	f1.close()
+	leader.Add(ctx, item("10.0.0.7"))
+	leader.Remove(ctx, item("10.0.0.2"))
+	f1 = newFollower(t, "f1", dir, lis)
+	defer f1.close()
+	waitSync(t, leader, f1)
+	waitSync(t, leader, f2)
+}
+
+func TestReplication_Snapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replication")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	cfg := dynxl.DefaultConfig()
+	cfg.LogSize = 2
+	leader, gsrv, lis := newLeader(t, dir, cfg)
+	defer leader.Close()
+	defer gsrv.Stop()
+	f1 := newFollower(t, "f1", dir, lis)
+	leader.Add(ctx, item("10.0.0.1"))
+	waitSync(t, leader, f1)
+	f1.close()
+
+	// log is truncated while follower is stopped, so a snapshot is required
+	for i := 2; i <= 6; i++ {
+		leader.Add(ctx, item(fmt.Sprintf("10.0.0.%v", i)))
+	}
+	leader.Remove(ctx, item("10.0.0.1"))
+	if _, ok := leader.Since(1); ok {
+		t.Fatalf("leader.Since(): expected truncated log")
+	}
+	f1 = newFollower(t, "f1", dir, lis)
+	defer f1.close()
+	waitSync(t, leader, f1)
+	resp, _ := f1.list.Check(ctx, "10.0.0.1", xlist.IPv4)
+	if resp.Result {
+		t.Errorf("follower.Check(): removed item found after snapshot")
+	}
+}
+
+func TestNewer(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	add := replication.Entry{Op: replication.OpAdd, Item: xlistd.Item{Timestamp: t0, Author: "a"}}
+	var tests = []struct {
+		a, b replication.Entry
+		want bool
+	}{
+		{add, add, false},
+		{replication.Entry{Op: replication.OpAdd, Item: xlistd.Item{Timestamp: t0.Add(time.Second)}}, add, true},
+		{replication.Entry{Op: replication.OpRemove, Item: xlistd.Item{Timestamp: t0}}, add, true},
+		{replication.Entry{Op: replication.OpAdd, Item: xlistd.Item{Timestamp: t0, Author: "b"}}, add, true},
+		{replication.Entry{Op: replication.OpRemove, Item: xlistd.Item{Timestamp: t0.Add(-time.Second)}}, add, false},
+	}
+	for idx, test := range tests {
+		if got := replication.Newer(test.a, test.b); got != test.want {
+			t.Errorf("idx[%v] Newer(): want=%v got=%v", idx, test.want, got)
+		}
+		if test.want && replication.Newer(test.b, test.a) {
+			t.Errorf("idx[%v] Newer(): not antisymmetric", idx)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package replication
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/authz"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// DefaultBatchSize is the max number of entries sent in a message.
+const DefaultBatchSize = 1000
+
+// Finder is used by the server for getting lists.
+type Finder interface {
+	List(id string) (xlistd.List, bool)
+}
+
+// ServerOption is used for server configuration.
+type ServerOption func(*serverOpts)
+
+type serverOpts struct {
+	logger     yalogi.Logger
+	authorizer *authz.Authorizer
+	batchSize  int
+}
+
+var defaultServerOpts = serverOpts{
+	logger:    yalogi.LogNull,
+	batchSize: DefaultBatchSize,
+}
+
+// SetLogger option sets a logger for the component.
+func SetLogger(l yalogi.Logger) ServerOption {
+	return func(o *serverOpts) {
+		if l != nil {
+			o.logger = l
+		}
+	}
+}
+
+// SetAuthorizer option sets an authorizer that checks if the identity of
+// the follower can access the list. It requires the authz interceptors in
+// the grpc server.
+func SetAuthorizer(a *authz.Authorizer) ServerOption {
+	return func(o *serverOpts) {
+		o.authorizer = a
+	}
+}
+
+// BatchSize option sets the max number of entries sent in a message.
+func BatchSize(n int) ServerOption {
+	return func(o *serverOpts) {
+		if n > 0 {
+			o.batchSize = n
+		}
+	}
+}
+
+// Server streams the mutation log of the lists to the followers.
+type Server struct {
+	opts   serverOpts
+	logger yalogi.Logger
+	finder Finder
+}
+
+// NewServer returns a new server.
+func NewServer(finder Finder, opt ...ServerOption) *Server {
+	opts := defaultServerOpts
+	for _, o := range opt {
+		o(&opts)
+	}
+	return &Server{opts: opts, logger: opts.logger, finder: finder}
+}
+
+// RegisterServer registers the replication service in the grpc server.
+func RegisterServer(gsrv *grpc.Server, s *Server) {
+	gsrv.RegisterService(&serviceDesc, s)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Follow",
+			Handler:       followHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+const followMethod = "/" + ServiceName + "/Follow"
+
+func followHandler(srv interface{}, stream grpc.ServerStream) error {
+	var req request
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+	return srv.(*Server).follow(req, stream)
+}
+
+func (s *Server) follow(req request, stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	if s.opts.authorizer != nil {
+		identity, _ := authz.FromContext(ctx)
+		if !s.opts.authorizer.AllowList(identity, req.List) {
+			s.opts.authorizer.Denied(followMethod, identity, authz.Forbidden, authz.Request{})
+			return status.Error(codes.PermissionDenied, "permission denied")
+		}
+	}
+	src, ok := s.source(req.List)
+	if !ok {
+		return status.Error(codes.NotFound, ErrListNotFound.Error())
+	}
+	s.logger.Infof("replication: follower of '%s' connected at offset %v", req.List, req.Offset)
+	defer s.logger.Infof("replication: follower of '%s' disconnected", req.List)
+	offset := req.Offset
+	for {
+		changes := src.Changes()
+		entries, ok := src.Since(offset)
+		if !ok {
+			snapshot, seq := src.Snapshot()
+			s.logger.Debugf("replication: sending snapshot of '%s' at offset %v", req.List, seq)
+			if err := stream.SendMsg(&message{Snapshot: true, Offset: seq, Entries: snapshot}); err != nil {
+				return err
+			}
+			offset = seq
+			continue
+		}
+		if len(entries) > 0 {
+			more := len(entries) > s.opts.batchSize
+			if more {
+				entries = entries[:s.opts.batchSize]
+			}
+			offset = entries[len(entries)-1].Seq
+			if err := stream.SendMsg(&message{Offset: offset, Entries: entries}); err != nil {
+				return err
+			}
+			if more {
+				continue
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changes:
+		}
+	}
+}
+
+func (s *Server) source(id string) (Source, bool) {
+	list, ok := s.finder.List(id)
+	if !ok {
+		return nil, false
+	}
+	for _, l := range xlistd.Chain(list) {
+		if src, ok := l.(Source); ok {
+			return src, true
+		}
+	}
+	return nil, false
+}
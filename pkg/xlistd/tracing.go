@@ -0,0 +1,74 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package xlistd
+
+import (
+	"context"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/tracing"
+)
+
+// tracedList is used by builder to create a span on each check of lists and
+// wrappers when tracing is enabled. Spans are only created if the context
+// contains a span started by a tracer.
+type tracedList struct {
+	list    List
+	name    string
+	wrapper string
+}
+
+func traceList(l List) List {
+	return &tracedList{list: l, name: "list " + l.ID()}
+}
+
+func traceWrapper(class string, l List) List {
+	return &tracedList{list: l, name: "wrapper " + class + " " + l.ID(), wrapper: class}
+}
+
+// ID implements xlistd.List interface.
+func (t *tracedList) ID() string {
+	return t.list.ID()
+}
+
+// Class implements xlistd.List interface.
+func (t *tracedList) Class() string {
+	return t.list.Class()
+}
+
+// Check implements xlist.Checker interface.
+func (t *tracedList) Check(ctx context.Context, name string, res xlist.Resource) (xlist.Response, error) {
+	ctx, span := tracing.Start(ctx, t.name)
+	if span == nil {
+		return t.list.Check(ctx, name, res)
+	}
+	span.SetAttribute("xlist.id", t.list.ID())
+	span.SetAttribute("xlist.class", t.list.Class())
+	if t.wrapper != "" {
+		span.SetAttribute("xlist.wrapper", t.wrapper)
+	}
+	span.SetAttribute("xlist.resource", res.String())
+	resp, err := t.list.Check(ctx, name, res)
+	if err != nil {
+		span.SetError(err)
+	} else {
+		span.SetAttribute("xlist.result", resp.Result)
+	}
+	span.End()
+	return resp, err
+}
+
+// Resources implements xlist.Checker interface.
+func (t *tracedList) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	return t.list.Resources(ctx)
+}
+
+// Ping implements xlistd.List interface.
+func (t *tracedList) Ping() error {
+	return t.list.Ping()
+}
+
+// Unwrap implements xlistd.Unwrapper interface.
+func (t *tracedList) Unwrap() List {
+	return t.list
+}
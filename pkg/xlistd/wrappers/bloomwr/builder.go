@@ -0,0 +1,100 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package bloomwr
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildWrapperFn {
+	return func(b *xlistd.Builder, def xlistd.WrapperDef, list xlistd.List) (xlistd.List, error) {
+		cfg := defaultCfg
+		source := fmt.Sprintf("%s.xlist", list.ID())
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+			s, ok, err := option.String(def.Opts, "source")
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				source = s
+			}
+		}
+		w := New(list, b.DataPath(source), cfg, b.Logger())
+		//register startup
+		b.OnStartup(func() error {
+			return w.Open()
+		})
+		//register shutdown
+		b.OnShutdown(func() error {
+			w.Close()
+			return nil
+		})
+		return w, nil
+	}
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	fprate, ok, err := floatOption(opts, "fprate")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if fprate <= 0 || fprate >= 1 {
+			return dst, errors.New("invalid 'fprate'")
+		}
+		dst.FPRate = fprate
+	}
+
+	autoreload, ok, err := option.Bool(opts, "autoreload")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Autoreload = autoreload
+	}
+
+	reloadSecs, ok, err := option.Int(opts, "reloadseconds")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if reloadSecs <= 0 {
+			return dst, errors.New("invalid 'reloadseconds'")
+		}
+		dst.ReloadTime = time.Duration(reloadSecs) * time.Second
+	}
+
+	return dst, nil
+}
+
+func floatOption(opts map[string]interface{}, field string) (float64, bool, error) {
+	v, ok := opts[field]
+	if !ok {
+		return 0, false, nil
+	}
+	switch f := v.(type) {
+	case float64:
+		return f, true, nil
+	case float32:
+		return float64(f), true, nil
+	case int:
+		return float64(f), true, nil
+	}
+	return 0, false, fmt.Errorf("invalid '%s'", field)
+}
+
+func init() {
+	xlistd.RegisterWrapperBuilder(WrapperClass, Builder(DefaultConfig()))
+}
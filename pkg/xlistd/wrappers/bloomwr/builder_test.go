@@ -0,0 +1,87 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package bloomwr_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/filexl"
+	"github.com/luids-io/xlist/pkg/xlistd/wrappers/bloomwr"
+)
+
+var testdatabase1 = []xlistd.ListDef{
+	{ID: "testfile1",
+		Class:     filexl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4, xlist.Domain},
+		Wrappers:  []xlistd.WrapperDef{{Class: bloomwr.WrapperClass}}},
+	{ID: "list2",
+		Class:     filexl.ComponentClass,
+		Source:    "testfile1.xlist",
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: bloomwr.WrapperClass,
+				Opts: map[string]interface{}{"source": "testfile1.xlist", "fprate": 0.001, "autoreload": true, "reloadseconds": 60}}}},
+	{ID: "list3",
+		Class:     filexl.ComponentClass,
+		Source:    "testfile1.xlist",
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: bloomwr.WrapperClass,
+				Opts: map[string]interface{}{"source": "testfile1.xlist", "fprate": 1.5}}}},
+	{ID: "list4",
+		Class:     filexl.ComponentClass,
+		Source:    "testfile1.xlist",
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: bloomwr.WrapperClass,
+				Opts: map[string]interface{}{"source": "testfile1.xlist", "fprate": "low"}}}},
+	{ID: "list5",
+		Class:     filexl.ComponentClass,
+		Source:    "testfile1.xlist",
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: bloomwr.WrapperClass,
+				Opts: map[string]interface{}{"source": "testfile1.xlist", "reloadseconds": 0}}}},
+}
+
+func TestBuild(t *testing.T) {
+	b := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.DataDir(testdir))
+	defer b.Shutdown()
+
+	//define and do tests
+	var tests = []struct {
+		listid  string
+		wantErr string
+	}{
+		{"testfile1", ""},
+		{"list2", ""},
+		{"list3", "invalid 'fprate'"},
+		{"list4", "invalid 'fprate'"},
+		{"list5", "invalid 'reloadseconds'"},
+	}
+	for _, test := range tests {
+		def, ok := xlistd.FilterID(test.listid, testdatabase1)
+		if !ok {
+			t.Errorf("can't find id %s in database tests", test.listid)
+			continue
+		}
+		_, err := b.Build(def)
+		switch {
+		case test.wantErr == "" && err == nil:
+			//
+		case test.wantErr == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("expected error for %s", test.listid)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		}
+	}
+	if err := b.Start(); err != nil {
+		t.Errorf("starting lists: %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package bloomwr
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net"
+	"strings"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Filter is a Bloom filter of the items of a list. Ip networks are stored
+// by prefix length and subdomains by name, so checks test the masked
+// address for each prefix length and the name with all its parents.
+type Filter struct {
+	bits   []uint64
+	m      uint64
+	k      uint64
+	count  int
+	masks  map[xlist.Resource][]int
+	hasSub bool
+}
+
+// item kinds used in keys
+const (
+	keyIP byte = iota
+	keyDomain
+	keySub
+	keyHash
+)
+
+// NewFilter returns an empty filter sized for n items with the false
+// positive rate passed.
+func NewFilter(n int, fprate float64) *Filter {
+	if n < 1 {
+		n = 1
+	}
+	if fprate <= 0 || fprate >= 1 {
+		fprate = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fprate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &Filter{
+		bits:  make([]uint64, (m+63)/64),
+		m:     m,
+		k:     k,
+		masks: make(map[xlist.Resource][]int),
+	}
+}
+
+// Count returns the number of items added.
+func (f *Filter) Count() int {
+	return f.count
+}
+
+// Size returns the size in bits of the filter.
+func (f *Filter) Size() uint64 {
+	return f.m
+}
+
+// Add adds an item to the filter.
+func (f *Filter) Add(resource xlist.Resource, format xlistd.Format, value string) error {
+	switch resource {
+	case xlist.IPv4, xlist.IPv6:
+		var ip net.IP
+		var ones int
+		switch format {
+		case xlistd.Plain:
+			ip = net.ParseIP(value)
+			if ip == nil {
+				return xlist.ErrBadRequest
+			}
+			ones = 128
+			if resource == xlist.IPv4 {
+				ones = 32
+			}
+		case xlistd.CIDR:
+			_, ipnet, err := net.ParseCIDR(value)
+			if err != nil {
+				return xlist.ErrBadRequest
+			}
+			ip = ipnet.IP
+			ones, _ = ipnet.Mask.Size()
+		default:
+			return xlist.ErrNotSupported
+		}
+		ip, ok := maskIP(ip, resource, ones)
+		if !ok {
+			return xlist.ErrBadRequest
+		}
+		f.addMask(resource, ones)
+		f.insert(ipKey(resource, ones, ip))
+	case xlist.Domain:
+		name := strings.ToLower(strings.TrimSuffix(value, "."))
+		switch format {
+		case xlistd.Plain:
+			f.insert(key(keyDomain, resource, name))
+		case xlistd.Sub:
+			f.hasSub = true
+			f.insert(key(keySub, resource, name))
+		default:
+			return xlist.ErrNotSupported
+		}
+	case xlist.MD5, xlist.SHA1, xlist.SHA256:
+		if format != xlistd.Plain {
+			return xlist.ErrNotSupported
+		}
+		f.insert(key(keyHash, resource, strings.ToLower(value)))
+	default:
+		return xlist.ErrNotSupported
+	}
+	f.count++
+	return nil
+}
+
+// MayContain returns false if the name is not in the list. Name must be in
+// canonical form.
+func (f *Filter) MayContain(name string, resource xlist.Resource) bool {
+	switch resource {
+	case xlist.IPv4, xlist.IPv6:
+		ip := net.ParseIP(name)
+		if ip == nil {
+			return true
+		}
+		for _, ones := range f.masks[resource] {
+			masked, ok := maskIP(ip, resource, ones)
+			if ok && f.test(ipKey(resource, ones, masked)) {
+				return true
+			}
+		}
+		return false
+	case xlist.Domain:
+		if f.test(key(keyDomain, resource, name)) {
+			return true
+		}
+		if !f.hasSub {
+			return false
+		}
+		for s := name; ; {
+			if f.test(key(keySub, resource, s)) {
+				return true
+			}
+			dot := strings.IndexByte(s, '.')
+			if dot < 0 {
+				return false
+			}
+			s = s[dot+1:]
+		}
+	case xlist.MD5, xlist.SHA1, xlist.SHA256:
+		return f.test(key(keyHash, resource, name))
+	}
+	return true
+}
+
+// Estimate returns the estimated false positive rate with the items added.
+func (f *Filter) Estimate() float64 {
+	return math.Pow(1-math.Exp(-float64(f.k)*float64(f.count)/float64(f.m)), float64(f.k))
+}
+
+func (f *Filter) addMask(resource xlist.Resource, ones int) {
+	for _, v := range f.masks[resource] {
+		if v == ones {
+			return
+		}
+	}
+	f.masks[resource] = append(f.masks[resource], ones)
+}
+
+func (f *Filter) insert(data []byte) {
+	h1, h2 := hashes(data)
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		f.bits[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+func (f *Filter) test(data []byte) bool {
+	h1, h2 := hashes(data)
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		if f.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hashes returns two hashes for double hashing.
+func hashes(data []byte) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write(data)
+	h1 := h.Sum64()
+	// splitmix64 finalizer
+	h2 := h1 + 0x9e3779b97f4a7c15
+	h2 = (h2 ^ (h2 >> 30)) * 0xbf58476d1ce4e5b9
+	h2 = (h2 ^ (h2 >> 27)) * 0x94d049bb133111eb
+	h2 = h2 ^ (h2 >> 31)
+	return h1, h2 | 1
+}
+
+func key(kind byte, resource xlist.Resource, value string) []byte {
+	b := make([]byte, 0, len(value)+2)
+	b = append(b, kind, byte(resource))
+	return append(b, value...)
+}
+
+func ipKey(resource xlist.Resource, ones int, ip net.IP) []byte {
+	b := make([]byte, 0, len(ip)+3)
+	b = append(b, keyIP, byte(resource), byte(ones))
+	return append(b, ip...)
+}
+
+func maskIP(ip net.IP, resource xlist.Resource, ones int) (net.IP, bool) {
+	if resource == xlist.IPv4 {
+		ip = ip.To4()
+		if ip == nil {
+			return nil, false
+		}
+		return ip.Mask(net.CIDRMask(ones, 32)), true
+	}
+	ip = ip.To16()
+	if ip == nil {
+		return nil, false
+	}
+	return ip.Mask(net.CIDRMask(ones, 128)), true
+}
+
+// ReadFilter creates a filter from a reader in the xlist file format
+// (resource,format,value). It reads the source twice, first for sizing the
+// filter, so the reader must be seekable.
+func ReadFilter(ctx context.Context, in io.ReadSeeker, resources []xlist.Resource, fprate float64) (*Filter, error) {
+	n := 0
+	err := scanItems(ctx, in, resources, func(xlist.Resource, xlistd.Format, string) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	f := NewFilter(n, fprate)
+	err = scanItems(ctx, in, resources, f.Add)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func scanItems(ctx context.Context, in io.Reader, resources []xlist.Resource, fn func(xlist.Resource, xlistd.Format, string) error) error {
+	nline := 0
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		nline++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) < 3 {
+			return fmt.Errorf("line %v: invalid line", nline)
+		}
+		resource, err := xlist.ToResource(fields[0])
+		if err != nil {
+			return fmt.Errorf("line %v: invalid resource type '%s'", nline, fields[0])
+		}
+		format, err := xlistd.ToFormat(fields[1])
+		if err != nil {
+			return fmt.Errorf("line %v: invalid format type '%s'", nline, fields[1])
+		}
+		if !resource.InArray(resources) {
+			continue
+		}
+		if err := fn(resource, format, fields[2]); err != nil {
+			return fmt.Errorf("line %v: invalid '%v,%v,%s'", nline, resource, format, fields[2])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scanning input: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package bloomwr provides a wrapper that prefilters checks with a Bloom
+// filter created from a local snapshot of the list, so definite negatives
+// don't reach expensive lists (remote or disk backed).
+//
+// This package is a work in progress and makes no API stability promises.
+package bloomwr
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	cliprom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// WrapperClass registered.
+const WrapperClass = "bloom"
+
+// DefaultConfig returns default configuration.
+func DefaultConfig() Config {
+	return Config{
+		FPRate:     0.01,
+		ReloadTime: 30 * time.Second,
+	}
+}
+
+// Config options.
+type Config struct {
+	FPRate          float64
+	Autoreload      bool
+	ReloadTime      time.Duration
+	ForceValidation bool
+}
+
+// Wrapper implements a Bloom filter prefilter for lists. The snapshot must
+// contain all the items of the wrapped list, or positives will be lost. With
+// autoreload, the snapshot should be replaced atomically (ex: rename).
+type Wrapper struct {
+	cfg       Config
+	list      xlistd.List
+	logger    yalogi.Logger
+	filename  string
+	resources []xlist.Resource
+
+	mu     sync.RWMutex
+	filter *Filter
+	mtime  time.Time
+	size   int64
+	err    error
+	close  chan bool
+	opened bool
+}
+
+// New returns a new wrapper that uses the snapshot in filename.
+func New(list xlistd.List, filename string, cfg Config, logger yalogi.Logger) *Wrapper {
+	w := &Wrapper{
+		cfg:      cfg,
+		list:     list,
+		logger:   logger,
+		filename: filename,
+		close:    make(chan bool),
+	}
+	if w.logger == nil {
+		w.logger = yalogi.LogNull
+	}
+	w.resources, _ = list.Resources(context.Background())
+	return w
+}
+
+// ID implements xlistd.List interface.
+func (w *Wrapper) ID() string {
+	return w.list.ID()
+}
+
+// Class implements xlistd.List interface.
+func (w *Wrapper) Class() string {
+	return w.list.Class()
+}
+
+// Check implements xlist.Checker interface.
+func (w *Wrapper) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	w.mu.RLock()
+	f := w.filter
+	w.mu.RUnlock()
+	if f == nil || !resource.InArray(w.resources) {
+		return w.list.Check(ctx, name, resource)
+	}
+	name, ctx, err := xlist.DoValidation(ctx, name, resource, w.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	if !f.MayContain(name, resource) {
+		stats.checks.WithLabelValues(w.list.ID(), "filtered").Inc()
+		return xlist.Response{}, nil
+	}
+	resp, err := w.list.Check(ctx, name, resource)
+	if err == nil {
+		if resp.Result {
+			stats.checks.WithLabelValues(w.list.ID(), "positive").Inc()
+		} else {
+			stats.checks.WithLabelValues(w.list.ID(), "falsepositive").Inc()
+		}
+	}
+	return resp, err
+}
+
+// Resources implements xlist.Checker interface.
+func (w *Wrapper) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	return w.list.Resources(ctx)
+}
+
+// Ping implements xlistd.List interface.
+func (w *Wrapper) Ping() error {
+	w.mu.RLock()
+	err := w.err
+	w.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return w.list.Ping()
+}
+
+// Unwrap implements xlistd.Unwrapper interface.
+func (w *Wrapper) Unwrap() xlistd.List {
+	return w.list
+}
+
+// Inspect implements xlistd.Inspector interface.
+func (w *Wrapper) Inspect() map[string]interface{} {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.filter == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"bloom": map[string]interface{}{
+			"items":    w.filter.Count(),
+			"bits":     w.filter.Size(),
+			"estimate": w.filter.Estimate(),
+		},
+	}
+}
+
+// Reload implements xlistd.Reloader interface. It reloads the snapshot and
+// then the data source of the wrapped list, if it can be reloaded.
+func (w *Wrapper) Reload() error {
+	if err := w.load(); err != nil {
+		return err
+	}
+	for _, l := range xlistd.Chain(w.list) {
+		if rl, ok := l.(xlistd.Reloader); ok {
+			return rl.Reload()
+		}
+	}
+	return nil
+}
+
+// Open loads the snapshot.
+func (w *Wrapper) Open() error {
+	if err := w.load(); err != nil {
+		return err
+	}
+	if w.cfg.Autoreload {
+		go w.doReload()
+	}
+	w.opened = true
+	return nil
+}
+
+// Close stops the autoreload and releases the filter. Checks are forwarded
+// to the wrapped list.
+func (w *Wrapper) Close() {
+	if w.opened {
+		if w.cfg.Autoreload {
+			w.close <- true
+		}
+		w.opened = false
+		w.mu.Lock()
+		w.filter = nil
+		w.mu.Unlock()
+	}
+}
+
+func (w *Wrapper) load() error {
+	w.logger.Debugf("%s: loading snapshot '%s'", w.list.ID(), w.filename)
+	file, err := os.Open(w.filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	f, err := ReadFilter(context.Background(), file, w.resources, w.cfg.FPRate)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.filter, w.mtime, w.size = f, stat.ModTime(), stat.Size()
+	w.mu.Unlock()
+	stats.items.WithLabelValues(w.list.ID()).Set(float64(f.Count()))
+	return nil
+}
+
+func (w *Wrapper) doReload() {
+	ticker := time.NewTicker(w.cfg.ReloadTime)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.close:
+			return
+		case <-ticker.C:
+			changed, err := w.changed()
+			if err == nil && changed {
+				w.logger.Infof("%s: snapshot '%s' has changed", w.list.ID(), w.filename)
+				err = w.load()
+			}
+			w.setErr(err)
+		}
+	}
+}
+
+func (w *Wrapper) changed() (bool, error) {
+	stat, err := os.Stat(w.filename)
+	if err != nil {
+		return false, err
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.mtime.Equal(stat.ModTime()) && w.size == stat.Size() {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (w *Wrapper) setErr(err error) {
+	w.mu.Lock()
+	w.err = err
+	w.mu.Unlock()
+	if err != nil {
+		w.logger.Warnf("%s: %v", w.list.ID(), err)
+	}
+}
+
+// stats is a global structure.
+var stats struct {
+	checks *cliprom.CounterVec
+	items  *cliprom.GaugeVec
+}
+
+func init() {
+	stats.checks = cliprom.NewCounterVec(
+		cliprom.CounterOpts{
+			Name: "xlist_bloom_checks_total",
+			Help: "Checks processed by the bloom filter, partitioned by result (filtered, positive or falsepositive)",
+		},
+		[]string{"list", "result"},
+	)
+	stats.items = cliprom.NewGaugeVec(
+		cliprom.GaugeOpts{
+			Name: "xlist_bloom_items",
+			Help: "Items loaded in the bloom filter",
+		},
+		[]string{"list"},
+	)
+	cliprom.MustRegister(stats.checks, stats.items)
+}
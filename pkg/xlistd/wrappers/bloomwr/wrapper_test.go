@@ -0,0 +1,177 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package bloomwr_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/wrappers/bloomwr"
+)
+
+var testdir = "../../../../test/testdata"
+var testfile1 = "../../../../test/testdata/testfile1.xlist"
+
+func TestWrapper_Check(t *testing.T) {
+	// mockup returns true for all checks forwarded
+	mockup := &mockxl.List{
+		Identifier:   "test1",
+		ResourceList: []xlist.Resource{xlist.IPv4, xlist.IPv6, xlist.Domain},
+		Results:      []bool{true},
+	}
+	w := bloomwr.New(mockup, testfile1, bloomwr.DefaultConfig(), yalogi.LogNull)
+	if err := w.Open(); err != nil {
+		t.Fatalf("bloomwr.Open(): err=%v", err)
+	}
+	defer w.Close()
+
+	var tests = []struct {
+		name     string
+		resource xlist.Resource
+		want     bool
+	}{
+		{"11.22.33.44", xlist.IPv4, true},
+		{"10.5.0.1", xlist.IPv4, true},
+		{"10.5.255.255", xlist.IPv4, true},
+		{"10.6.0.1", xlist.IPv4, false},
+		{"1.2.3.4", xlist.IPv4, true},
+		{"1.2.3.5", xlist.IPv4, false},
+		{"2001:d00::1", xlist.IPv6, true},
+		{"2001:e00::1", xlist.IPv6, false},
+		{"www.micasa.com", xlist.Domain, true},
+		{"micasa.com", xlist.Domain, false},
+		{"sucasa.com", xlist.Domain, true},
+		{"www.sucasa.com", xlist.Domain, true},
+		{"WWW.SUCASA.COM", xlist.Domain, true},
+		{"www.tucasa.com", xlist.Domain, false},
+	}
+	for idx, test := range tests {
+		got, err := w.Check(context.Background(), test.name, test.resource)
+		if err != nil {
+			t.Errorf("idx[%v] bloomwr.Check(): err=%v", idx, err)
+		}
+		if got.Result != test.want {
+			t.Errorf("idx[%v] bloomwr.Check(%s): want=%v got=%v", idx, test.name, test.want, got.Result)
+		}
+	}
+	// resources not checked by the list are forwarded
+	_, err := w.Check(context.Background(), "d41d8cd98f00b204e9800998ecf8427e", xlist.MD5)
+	if err != xlist.ErrNotSupported {
+		t.Errorf("bloomwr.Check(): unexpected err=%v", err)
+	}
+	info := w.Inspect()["bloom"].(map[string]interface{})
+	if info["items"].(int) != 9 {
+		t.Errorf("bloomwr.Inspect(): unexpected %v", info)
+	}
+}
+
+func TestWrapper_FPRate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bloomwr")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	var sb strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&sb, "domain,plain,www%d.example.com\n", i)
+	}
+	source := filepath.Join(dir, "test.xlist")
+	if err := ioutil.WriteFile(source, []byte(sb.String()), 0644); err != nil {
+		t.Fatalf("writing snapshot: %v", err)
+	}
+	mockup := &mockxl.List{
+		Identifier:   "test1",
+		ResourceList: []xlist.Resource{xlist.Domain},
+		Results:      []bool{true},
+	}
+	cfg := bloomwr.DefaultConfig()
+	cfg.FPRate = 0.01
+	w := bloomwr.New(mockup, source, cfg, yalogi.LogNull)
+	if err := w.Open(); err != nil {
+		t.Fatalf("bloomwr.Open(): err=%v", err)
+	}
+	defer w.Close()
+	// no false negatives
+	for i := 0; i < 10000; i++ {
+		resp, _ := w.Check(context.Background(), fmt.Sprintf("www%d.example.com", i), xlist.Domain)
+		if !resp.Result {
+			t.Fatalf("bloomwr.Check(): false negative www%d.example.com", i)
+		}
+	}
+	// false positives near the rate configured
+	positives := 0
+	for i := 0; i < 10000; i++ {
+		resp, _ := w.Check(context.Background(), fmt.Sprintf("www%d.example.org", i), xlist.Domain)
+		if resp.Result {
+			positives++
+		}
+	}
+	if positives > 300 {
+		t.Errorf("bloomwr.Check(): too many false positives %v", positives)
+	}
+}
+
+func TestWrapper_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bloomwr")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "test.xlist")
+	write := func(content string) {
+		if err := ioutil.WriteFile(source+".tmp", []byte(content), 0644); err != nil {
+			t.Fatalf("writing snapshot: %v", err)
+		}
+		if err := os.Rename(source+".tmp", source); err != nil {
+			t.Fatalf("renaming snapshot: %v", err)
+		}
+	}
+	write("domain,plain,www.example.com\n")
+
+	mockup := &mockxl.List{
+		Identifier:   "test1",
+		ResourceList: []xlist.Resource{xlist.Domain},
+		Results:      []bool{true},
+	}
+	cfg := bloomwr.DefaultConfig()
+	cfg.Autoreload = true
+	cfg.ReloadTime = 50 * time.Millisecond
+	w := bloomwr.New(mockup, source, cfg, yalogi.LogNull)
+	if err := w.Open(); err != nil {
+		t.Fatalf("bloomwr.Open(): err=%v", err)
+	}
+	defer w.Close()
+	check := func(name string, want bool) {
+		resp, err := w.Check(context.Background(), name, xlist.Domain)
+		if err != nil || resp.Result != want {
+			t.Errorf("bloomwr.Check(%s): want=%v got=%v err=%v", name, want, resp.Result, err)
+		}
+	}
+	check("www.example.org", false)
+
+	write("domain,plain,www.example.com\ndomain,sub,example.org\n")
+	time.Sleep(200 * time.Millisecond)
+	check("www.example.org", true)
+
+	// invalid snapshot keeps the previous filter
+	write("domain,invalid,www.example.net\n")
+	time.Sleep(200 * time.Millisecond)
+	check("www.example.org", true)
+	if err := w.Ping(); err == nil {
+		t.Error("bloomwr.Ping(): expected error")
+	}
+	// wrapped list is found in the chain
+	if len(xlistd.Chain(w)) != 2 {
+		t.Errorf("xlistd.Chain(): unexpected %v", xlistd.Chain(w))
+	}
+}
@@ -103,6 +103,11 @@ func (c *Wrapper) Ping() error {
 	return c.list.Ping()
 }
 
+// Unwrap implements xlistd.Unwrapper interface.
+func (c *Wrapper) Unwrap() xlistd.List {
+	return c.list
+}
+
 // Flush deletes all items from cache.
 func (c *Wrapper) Flush() {
 	c.cache.Flush()
@@ -0,0 +1,105 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package cnamewr
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/dnsxl"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildWrapperFn {
+	return func(b *xlistd.Builder, def xlistd.WrapperDef, list xlistd.List) (xlistd.List, error) {
+		cfg := defaultCfg
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return New(list, cfg, b.Logger()), nil
+	}
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	resolvers, ok, err := option.SliceString(opts, "resolvers")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		pool, err := dnsxl.NewResolverRRPool(resolvers)
+		if err != nil {
+			return dst, fmt.Errorf("invalid 'resolvers': %v", err)
+		}
+		dst.Resolver = pool
+	}
+	timeout, ok, err := option.Int(opts, "timeout")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if timeout <= 0 {
+			return dst, errors.New("invalid 'timeout'")
+		}
+		dst.Timeout = time.Duration(timeout) * time.Millisecond
+	}
+	retries, ok, err := option.Int(opts, "retries")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if retries <= 0 {
+			return dst, errors.New("invalid 'retries'")
+		}
+		dst.Retries = retries
+	}
+	maxDepth, ok, err := option.Int(opts, "maxdepth")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if maxDepth <= 0 {
+			return dst, errors.New("invalid 'maxdepth'")
+		}
+		dst.MaxDepth = maxDepth
+	}
+	cache, ok, err := option.Bool(opts, "cache")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Cache = cache
+	}
+	maxTTL, ok, err := option.Int(opts, "maxttl")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if maxTTL < 0 {
+			return dst, errors.New("invalid 'maxttl'")
+		}
+		dst.MaxTTL = time.Duration(maxTTL) * time.Second
+	}
+	negTTL, ok, err := option.Int(opts, "negativettl")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if negTTL < 0 {
+			return dst, errors.New("invalid 'negativettl'")
+		}
+		dst.NegativeTTL = time.Duration(negTTL) * time.Second
+	}
+	return dst, nil
+}
+
+func init() {
+	xlistd.RegisterWrapperBuilder(WrapperClass, Builder(DefaultConfig()))
+}
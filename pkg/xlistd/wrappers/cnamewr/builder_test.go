@@ -0,0 +1,79 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package cnamewr_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/wrappers/cnamewr"
+)
+
+var testdatabase1 = []xlistd.ListDef{
+	{ID: "list1",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.Domain},
+		Wrappers:  []xlistd.WrapperDef{{Class: cnamewr.WrapperClass}}},
+	{ID: "list2",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.Domain},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: cnamewr.WrapperClass,
+				Opts: map[string]interface{}{
+					"resolvers":   []interface{}{"127.0.0.1:5353"},
+					"timeout":     500,
+					"retries":     2,
+					"maxdepth":    4,
+					"cache":       true,
+					"maxttl":      600,
+					"negativettl": 30}}}},
+	{ID: "list3",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.Domain},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: cnamewr.WrapperClass,
+				Opts: map[string]interface{}{"maxdepth": 0}}}},
+	{ID: "list4",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.Domain},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: cnamewr.WrapperClass,
+				Opts: map[string]interface{}{"resolvers": []interface{}{"notanip"}}}}},
+}
+
+func TestBuild(t *testing.T) {
+	b := xlistd.NewBuilder(apiservice.NewRegistry())
+
+	//define and do tests
+	var tests = []struct {
+		listid  string
+		wantErr string
+	}{
+		{"list1", ""},
+		{"list2", ""},
+		{"list3", "invalid 'maxdepth'"},
+		{"list4", "invalid 'resolvers'"},
+	}
+	for _, test := range tests {
+		def, ok := xlistd.FilterID(test.listid, testdatabase1)
+		if !ok {
+			t.Errorf("can't find id %s in database tests", test.listid)
+			continue
+		}
+		_, err := b.Build(def)
+		switch {
+		case test.wantErr == "" && err == nil:
+			//
+		case test.wantErr == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("expected error for %s", test.listid)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package cnamewr provides a wrapper that uncloaks domains hidden behind
+// CNAME records, checking every target of the chain against the wrapped
+// list.
+//
+// This package is a work in progress and makes no API stability promises.
+package cnamewr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/dnscache"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/dnsxl"
+)
+
+// WrapperClass registered.
+const WrapperClass = "cname"
+
+// DefaultConfig returns default configuration.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:     1 * time.Second,
+		Retries:     1,
+		MaxDepth:    8,
+		Cache:       true,
+		MaxTTL:      1 * time.Hour,
+		NegativeTTL: 1 * time.Minute,
+	}
+}
+
+// Config options.
+type Config struct {
+	Timeout  time.Duration
+	Retries  int
+	Resolver dnsxl.Resolver
+	// MaxDepth is the maximum number of CNAME records followed.
+	MaxDepth int
+	// Cache enables the cache of resolutions, ttls of the records are
+	// used with MaxTTL as limit. NegativeTTL is used with empty answers.
+	Cache           bool
+	MaxTTL          time.Duration
+	NegativeTTL     time.Duration
+	ForceValidation bool
+}
+
+// Wrapper implements CNAME uncloaking for lists that check domains. Other
+// resources are passed to the wrapped list.
+type Wrapper struct {
+	cfg      Config
+	list     xlistd.List
+	logger   yalogi.Logger
+	resolver *dnscache.Resolver
+	domains  bool
+}
+
+// New returns a new wrapper.
+func New(list xlistd.List, cfg Config, logger yalogi.Logger) *Wrapper {
+	w := &Wrapper{
+		cfg:    cfg,
+		list:   list,
+		logger: logger,
+	}
+	if w.logger == nil {
+		w.logger = yalogi.LogNull
+	}
+	if w.cfg.Resolver == nil {
+		w.cfg.Resolver = dnsxl.GetDefaultResolver()
+	}
+	w.resolver = dnscache.New(w.cfg.Resolver, dnscache.Config{
+		Timeout:     cfg.Timeout,
+		Retries:     cfg.Retries,
+		Cache:       cfg.Cache,
+		MaxTTL:      cfg.MaxTTL,
+		NegativeTTL: cfg.NegativeTTL,
+	})
+	resources, _ := list.Resources(context.Background())
+	w.domains = xlist.Domain.InArray(resources)
+	return w
+}
+
+// ID implements xlistd.List interface.
+func (w *Wrapper) ID() string {
+	return w.list.ID()
+}
+
+// Class implements xlistd.List interface.
+func (w *Wrapper) Class() string {
+	return w.list.Class()
+}
+
+// Check implements xlist.Checker interface.
+func (w *Wrapper) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	if resource != xlist.Domain || !w.domains {
+		return w.list.Check(ctx, name, resource)
+	}
+	name, ctx, err := xlist.DoValidation(ctx, name, resource, w.cfg.ForceValidation)
+	if err != nil {
+		return xlist.Response{}, err
+	}
+	resp, err := w.list.Check(ctx, name, resource)
+	if err != nil || resp.Result {
+		return resp, err
+	}
+	ttl := resp.TTL
+	chain := []string{strings.ToLower(name)}
+	seen := map[string]bool{chain[0]: true}
+	for depth := 0; depth < w.cfg.MaxDepth; depth++ {
+		a, err := w.resolver.Lookup(ctx, chain[len(chain)-1], dns.TypeCNAME)
+		if err != nil {
+			if err == dnscache.ErrCanceled {
+				return xlist.Response{}, xlist.ErrCanceledRequest
+			}
+			w.logger.Warnf("%s: resolving '%s': %v", w.list.ID(), chain[len(chain)-1], err)
+			return xlist.Response{}, xlist.ErrInternal
+		}
+		ttl = xlistd.MinTTL(ttl, a.TTL)
+		if len(a.Values) == 0 {
+			break
+		}
+		target := a.Values[0]
+		if seen[target] {
+			w.logger.Debugf("%s: cname loop in '%s' -> '%s'", w.list.ID(), strings.Join(chain, " -> "), target)
+			break
+		}
+		seen[target] = true
+		chain = append(chain, target)
+		// targets come from dns, so they are always validated, invalid
+		// targets are not checked but the chain is followed
+		target, _, err = xlist.DoValidation(ctx, target, xlist.Domain, true)
+		if err != nil {
+			w.logger.Debugf("%s: not checking cname '%s': invalid domain", w.list.ID(), target)
+			continue
+		}
+		tresp, err := w.list.Check(ctx, target, xlist.Domain)
+		if err != nil {
+			return xlist.Response{}, err
+		}
+		ttl = xlistd.MinTTL(ttl, tresp.TTL)
+		if tresp.Result {
+			tresp.TTL = ttl
+			tresp.Reason = fmt.Sprintf("cname %s: %s", strings.Join(chain, " -> "), tresp.Reason)
+			return tresp, nil
+		}
+	}
+	resp.TTL = ttl
+	return resp, nil
+}
+
+// Resources implements xlist.Checker interface.
+func (w *Wrapper) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	return w.list.Resources(ctx)
+}
+
+// Ping implements xlistd.List interface.
+func (w *Wrapper) Ping() error {
+	return w.list.Ping()
+}
+
+// Unwrap implements xlistd.Unwrapper interface.
+func (w *Wrapper) Unwrap() xlistd.List {
+	return w.list
+}
+
+// Flush implements xlistd.Flusher interface.
+func (w *Wrapper) Flush() {
+	w.resolver.Flush()
+}
+
+// Inspect implements xlistd.Inspector interface.
+func (w *Wrapper) Inspect() map[string]interface{} {
+	return map[string]interface{}{
+		"cname": map[string]interface{}{"cached": w.resolver.Len()},
+	}
+}
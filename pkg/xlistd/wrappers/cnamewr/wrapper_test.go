@@ -0,0 +1,146 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package cnamewr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/yalogi"
+	"github.com/luids-io/xlist/pkg/dnscache/dnstest"
+	"github.com/luids-io/xlist/pkg/xlistd/components/dnsxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/memxl"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/wrappers/cnamewr"
+)
+
+var testzone = []string{
+	"tracker.customer.example. 300 IN CNAME customer.tracker.example.",
+	"cdn.customer.example. 300 IN CNAME edge.cdn.example.",
+	"edge.cdn.example. 120 IN CNAME node.tracker.example.",
+	"deep.customer.example. 300 IN CNAME deep1.customer.example.",
+	"deep1.customer.example. 300 IN CNAME deep2.customer.example.",
+	"deep2.customer.example. 300 IN CNAME deep.tracker.example.",
+	"loop1.customer.example. 300 IN CNAME loop2.customer.example.",
+	"loop2.customer.example. 300 IN CNAME loop1.customer.example.",
+	"www.customer.example. 60 IN CNAME web.hosting.example.",
+	"cloak.customer.example. 300 IN CNAME cloak.bad_tld.",
+	"cloak.bad_tld. 300 IN CNAME cloak.tracker.example.",
+	"web.hosting.example. 300 IN A 10.0.0.1",
+}
+
+func testTrackers() *memxl.List {
+	l := memxl.New("trackers", []xlist.Resource{xlist.Domain}, memxl.Config{Reason: "tracker"})
+	l.AddSubdomain("tracker.example")
+	return l
+}
+
+func TestWrapper_Check(t *testing.T) {
+	s := dnstest.NewServer(t, testzone)
+	defer s.Close()
+	resolver, err := dnsxl.NewResolverRRPool([]string{s.Addr})
+	if err != nil {
+		t.Fatalf("creating resolver: %v", err)
+	}
+
+	var tests = []struct {
+		name     string
+		maxDepth int
+
+		want       bool
+		wantReason string
+		wantTTL    int
+	}{
+		{"www.tracker.example", 8, true, "tracker", 0},
+		{"tracker.customer.example", 8, true, "cname tracker.customer.example -> customer.tracker.example: tracker", 300},
+		{"cdn.customer.example", 8, true, "cname cdn.customer.example -> edge.cdn.example -> node.tracker.example: tracker", 120},
+		{"cdn.customer.example", 1, false, "", 300},
+		{"deep.customer.example", 3, true, "cname deep.customer.example -> deep1.customer.example -> deep2.customer.example -> deep.tracker.example: tracker", 300},
+		{"deep.customer.example", 2, false, "", 300},
+		{"loop1.customer.example", 8, false, "", 300},
+		{"www.customer.example", 8, false, "", 60},
+		{"nocname.customer.example", 8, false, "", 60},
+		{"cloak.customer.example", 8, true, "cname cloak.customer.example -> cloak.bad_tld -> cloak.tracker.example: tracker", 300},
+	}
+	for idx, test := range tests {
+		cfg := cnamewr.DefaultConfig()
+		cfg.Resolver = resolver
+		cfg.MaxDepth = test.maxDepth
+		w := cnamewr.New(testTrackers(), cfg, yalogi.LogNull)
+		resp, err := w.Check(context.Background(), test.name, xlist.Domain)
+		if err != nil {
+			t.Errorf("cname.Check idx[%v] unexpected error: %v", idx, err)
+		}
+		if resp.Result != test.want {
+			t.Errorf("cname.Check idx[%v] want=%v got=%v", idx, test.want, resp.Result)
+		}
+		if resp.Reason != test.wantReason {
+			t.Errorf("cname.Check idx[%v] reason want=%v got=%v", idx, test.wantReason, resp.Reason)
+		}
+		if resp.TTL != test.wantTTL {
+			t.Errorf("cname.Check idx[%v] ttl want=%v got=%v", idx, test.wantTTL, resp.TTL)
+		}
+	}
+}
+
+func TestWrapper_Errors(t *testing.T) {
+	s := dnstest.NewServer(t, testzone)
+	defer s.Close()
+	resolver, _ := dnsxl.NewResolverRRPool([]string{s.Addr})
+
+	cfg := cnamewr.DefaultConfig()
+	cfg.Resolver = resolver
+	w := cnamewr.New(testTrackers(), cfg, yalogi.LogNull)
+	_, err := w.Check(context.Background(), "servfail.customer.example", xlist.Domain)
+	if err != xlist.ErrInternal {
+		t.Errorf("cname.Check err want=%v got=%v", xlist.ErrInternal, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = w.Check(ctx, "tracker.customer.example", xlist.Domain)
+	if err != xlist.ErrCanceledRequest {
+		t.Errorf("cname.Check err want=%v got=%v", xlist.ErrCanceledRequest, err)
+	}
+	// resources that are not domains are passed to the list
+	mockup := &mockxl.List{ResourceList: []xlist.Resource{xlist.IPv4, xlist.Domain}, Results: []bool{true}}
+	w = cnamewr.New(mockup, cfg, yalogi.LogNull)
+	resp, err := w.Check(context.Background(), "10.0.0.1", xlist.IPv4)
+	if err != nil || !resp.Result {
+		t.Errorf("cname.Check unexpected response: %v %v", resp, err)
+	}
+	if s.Count() != 1 {
+		t.Errorf("cname.Check queries want=1 got=%v", s.Count())
+	}
+	// invalid targets never reach the list
+	mockup = &mockxl.List{ResourceList: []xlist.Resource{xlist.Domain}, Results: []bool{false, false, true}}
+	w = cnamewr.New(mockup, cfg, yalogi.LogNull)
+	resp, err = w.Check(context.Background(), "cloak.customer.example", xlist.Domain)
+	if err != nil || resp.Result {
+		t.Errorf("cname.Check unexpected response: %v %v", resp, err)
+	}
+}
+
+func TestWrapper_Cache(t *testing.T) {
+	s := dnstest.NewServer(t, testzone)
+	defer s.Close()
+	resolver, _ := dnsxl.NewResolverRRPool([]string{s.Addr})
+
+	cfg := cnamewr.DefaultConfig()
+	cfg.Resolver = resolver
+	w := cnamewr.New(testTrackers(), cfg, yalogi.LogNull)
+	for i := 0; i < 3; i++ {
+		resp, err := w.Check(context.Background(), "cdn.customer.example", xlist.Domain)
+		if err != nil || !resp.Result {
+			t.Fatalf("cname.Check unexpected response: %v %v", resp, err)
+		}
+	}
+	if s.Count() != 2 {
+		t.Errorf("cname.Check queries want=2 got=%v", s.Count())
+	}
+	w.Flush()
+	got := w.Inspect()["cname"].(map[string]interface{})["cached"]
+	if got != 0 {
+		t.Errorf("cname.Inspect cached want=0 got=%v", got)
+	}
+}
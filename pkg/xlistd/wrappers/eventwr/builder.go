@@ -0,0 +1,256 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package eventwr
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildWrapperFn {
+	return func(b *xlistd.Builder, def xlistd.WrapperDef, list xlistd.List) (xlistd.List, error) {
+		cfg := defaultCfg
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(cfg.Sinks) == 0 {
+			return nil, errors.New("'sinks' is required")
+		}
+		sinks := make([]*Dispatcher, 0, len(cfg.Sinks))
+		for _, sc := range cfg.Sinks {
+			d, err := newDispatcher(b, sc)
+			if err != nil {
+				for _, prev := range sinks {
+					prev.Close()
+				}
+				return nil, fmt.Errorf("sink '%s': %v", sc.Name, err)
+			}
+			sinks = append(sinks, d)
+		}
+		w := New(list, sinks, cfg)
+		b.OnShutdown(func() error {
+			return w.Close()
+		})
+		return w, nil
+	}
+}
+
+func newDispatcher(b *xlistd.Builder, cfg SinkConfig) (*Dispatcher, error) {
+	var sink Sink
+	var err error
+	switch cfg.Type {
+	case FileType:
+		sink, err = NewFileSink(b.DataPath(cfg.Path))
+	case SyslogType:
+		sink, err = NewSyslogSink(cfg.Network, cfg.Address, cfg.Tag, cfg.Format)
+	case WebhookType:
+		sink = NewWebhookSink(cfg.URL, cfg.Headers, cfg.Timeout)
+	default:
+		err = fmt.Errorf("invalid type '%s'", cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewDispatcher(cfg.Name, sink,
+		SetLogger(b.Logger()),
+		QueueSize(cfg.Queue),
+		Batch(cfg.Batch, cfg.Interval),
+		Retries(cfg.Retries, cfg.Backoff)), nil
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	errs, ok, err := option.Bool(opts, "errors")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Errors = errs
+	}
+
+	dedup, ok, err := option.Int(opts, "dedup")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if dedup < 0 {
+			return dst, errors.New("invalid 'dedup'")
+		}
+		dst.Dedup = time.Duration(dedup) * time.Second
+	}
+
+	maxdedup, ok, err := option.Int(opts, "maxdedup")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if maxdedup <= 0 {
+			return dst, errors.New("invalid 'maxdedup'")
+		}
+		dst.MaxDedup = maxdedup
+	}
+
+	sinks, ok, err := option.SliceHash(opts, "sinks")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Sinks = make([]SinkConfig, 0, len(sinks))
+		for idx, s := range sinks {
+			sc, err := parseSink(s)
+			if err != nil {
+				return dst, fmt.Errorf("invalid 'sinks[%v]': %v", idx, err)
+			}
+			if sc.Name == "" {
+				sc.Name = fmt.Sprintf("%s%v", sc.Type, idx)
+			}
+			dst.Sinks = append(dst.Sinks, sc)
+		}
+	}
+	return dst, nil
+}
+
+func parseSink(opts map[string]interface{}) (SinkConfig, error) {
+	dst := SinkConfig{
+		Tag:      "xlistd",
+		Format:   FormatCEF,
+		Timeout:  10 * time.Second,
+		Batch:    1,
+		Interval: time.Second,
+		Backoff:  time.Second,
+	}
+	var ok bool
+	var err error
+	dst.Type, ok, err = option.String(opts, "type")
+	if err != nil {
+		return dst, err
+	}
+	if !ok {
+		return dst, errors.New("'type' is required")
+	}
+	dst.Name, _, err = option.String(opts, "name")
+	if err != nil {
+		return dst, err
+	}
+
+	switch dst.Type {
+	case FileType:
+		dst.Path, ok, err = option.String(opts, "path")
+		if err != nil {
+			return dst, err
+		}
+		if !ok || dst.Path == "" {
+			return dst, errors.New("'path' is required")
+		}
+	case SyslogType:
+		for _, field := range []struct {
+			name string
+			dst  *string
+		}{{"network", &dst.Network}, {"address", &dst.Address}, {"tag", &dst.Tag}, {"format", &dst.Format}} {
+			value, ok, err := option.String(opts, field.name)
+			if err != nil {
+				return dst, err
+			}
+			if ok {
+				*field.dst = value
+			}
+		}
+		switch dst.Format {
+		case FormatCEF, FormatLEEF, FormatJSON:
+		default:
+			return dst, errors.New("invalid 'format'")
+		}
+		if dst.Network != "" && dst.Address == "" {
+			return dst, errors.New("'address' is required")
+		}
+	case WebhookType:
+		dst.URL, ok, err = option.String(opts, "url")
+		if err != nil {
+			return dst, err
+		}
+		if !ok || dst.URL == "" {
+			return dst, errors.New("'url' is required")
+		}
+		dst.Headers, _, err = option.HashString(opts, "headers")
+		if err != nil {
+			return dst, err
+		}
+		timeout, ok, err := option.Int(opts, "timeout")
+		if err != nil {
+			return dst, err
+		}
+		if ok {
+			if timeout <= 0 {
+				return dst, errors.New("invalid 'timeout'")
+			}
+			dst.Timeout = time.Duration(timeout) * time.Second
+		}
+	default:
+		return dst, errors.New("invalid 'type'")
+	}
+
+	queue, ok, err := option.Int(opts, "queue")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if queue <= 0 {
+			return dst, errors.New("invalid 'queue'")
+		}
+		dst.Queue = queue
+	}
+	batch, ok, err := option.Int(opts, "batch")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if batch <= 0 {
+			return dst, errors.New("invalid 'batch'")
+		}
+		dst.Batch = batch
+	}
+	interval, ok, err := option.Int(opts, "interval")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if interval <= 0 {
+			return dst, errors.New("invalid 'interval'")
+		}
+		dst.Interval = time.Duration(interval) * time.Millisecond
+	}
+	retries, ok, err := option.Int(opts, "retries")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if retries < 0 {
+			return dst, errors.New("invalid 'retries'")
+		}
+		dst.Retries = retries
+	}
+	backoff, ok, err := option.Int(opts, "backoff")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if backoff <= 0 {
+			return dst, errors.New("invalid 'backoff'")
+		}
+		dst.Backoff = time.Duration(backoff) * time.Millisecond
+	}
+	return dst, nil
+}
+
+func init() {
+	xlistd.RegisterWrapperBuilder(WrapperClass, Builder(DefaultConfig()))
+}
@@ -0,0 +1,92 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package eventwr_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/wrappers/eventwr"
+)
+
+func eventDef(id string, opts map[string]interface{}) xlistd.ListDef {
+	return xlistd.ListDef{ID: id,
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers:  []xlistd.WrapperDef{{Class: eventwr.WrapperClass, Opts: opts}}}
+}
+
+var testdatabase1 = []xlistd.ListDef{
+	eventDef("list1", map[string]interface{}{
+		"sinks": []interface{}{map[string]interface{}{"type": "file", "path": "events.json"}}}),
+	eventDef("list2", map[string]interface{}{
+		"errors": true, "dedup": 0,
+		"sinks": []interface{}{
+			map[string]interface{}{"type": "file", "name": "local", "path": "events2.json"},
+			map[string]interface{}{"type": "webhook", "url": "http://127.0.0.1:1/events",
+				"batch": 10, "interval": 500, "retries": 3, "backoff": 100, "timeout": 5,
+				"headers": map[string]interface{}{"Authorization": "Bearer x"}}}}),
+	eventDef("list3", nil),
+	eventDef("list4", map[string]interface{}{
+		"sinks": []interface{}{map[string]interface{}{"type": "kafka"}}}),
+	eventDef("list5", map[string]interface{}{
+		"sinks": []interface{}{map[string]interface{}{"type": "webhook"}}}),
+	eventDef("list6", map[string]interface{}{
+		"sinks": []interface{}{map[string]interface{}{"type": "syslog", "format": "xml"}}}),
+	eventDef("list7", map[string]interface{}{"dedup": -1,
+		"sinks": []interface{}{map[string]interface{}{"type": "file", "path": "events.json"}}}),
+	eventDef("list8", map[string]interface{}{
+		"sinks": []interface{}{map[string]interface{}{"type": "file", "path": "notexists/events.json"}}}),
+	eventDef("list9", map[string]interface{}{
+		"sinks": []interface{}{map[string]interface{}{"type": "webhook", "url": "http://localhost", "batch": 0}}}),
+}
+
+func TestBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eventwr")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	b := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.DataDir(dir))
+	defer b.Shutdown()
+
+	//define and do tests
+	var tests = []struct {
+		listid  string
+		wantErr string
+	}{
+		{"list1", ""},
+		{"list2", ""},
+		{"list3", "'sinks' is required"},
+		{"list4", "invalid 'type'"},
+		{"list5", "'url' is required"},
+		{"list6", "invalid 'format'"},
+		{"list7", "invalid 'dedup'"},
+		{"list8", "no such file"},
+		{"list9", "invalid 'batch'"},
+	}
+	for _, test := range tests {
+		def, ok := xlistd.FilterID(test.listid, testdatabase1)
+		if !ok {
+			t.Errorf("can't find id %s in database tests", test.listid)
+			continue
+		}
+		_, err := b.Build(def)
+		switch {
+		case test.wantErr == "" && err == nil:
+			//
+		case test.wantErr == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("expected error for %s", test.listid)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		}
+	}
+}
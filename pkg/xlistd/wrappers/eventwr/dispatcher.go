@@ -0,0 +1,208 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package eventwr
+
+import (
+	"sync"
+	"time"
+
+	"github.com/luids-io/core/yalogi"
+)
+
+// DispatcherOption is used for dispatcher configuration.
+type DispatcherOption func(*dispatcherOpts)
+
+type dispatcherOpts struct {
+	logger    yalogi.Logger
+	queueSize int
+	batch     int
+	interval  time.Duration
+	retries   int
+	backoff   time.Duration
+}
+
+var defaultDispatcherOpts = dispatcherOpts{
+	logger:    yalogi.LogNull,
+	queueSize: 1024,
+	batch:     1,
+	interval:  time.Second,
+	backoff:   time.Second,
+}
+
+// SetLogger option sets a logger for the dispatcher.
+func SetLogger(l yalogi.Logger) DispatcherOption {
+	return func(o *dispatcherOpts) {
+		if l != nil {
+			o.logger = l
+		}
+	}
+}
+
+// QueueSize option sets the max events queued, new events are dropped when
+// the queue is full.
+func QueueSize(n int) DispatcherOption {
+	return func(o *dispatcherOpts) {
+		if n > 0 {
+			o.queueSize = n
+		}
+	}
+}
+
+// Batch option sets the max number of events sent in each call to the sink
+// and the max time that an event waits in the batch.
+func Batch(n int, interval time.Duration) DispatcherOption {
+	return func(o *dispatcherOpts) {
+		if n > 0 {
+			o.batch = n
+		}
+		if interval > 0 {
+			o.interval = interval
+		}
+	}
+}
+
+// Retries option sets the number of retries when a sink fails. The time
+// between retries is doubled in each attempt starting at backoff.
+func Retries(n int, backoff time.Duration) DispatcherOption {
+	return func(o *dispatcherOpts) {
+		if n >= 0 {
+			o.retries = n
+		}
+		if backoff > 0 {
+			o.backoff = backoff
+		}
+	}
+}
+
+// Dispatcher sends events to a sink asynchronously.
+type Dispatcher struct {
+	name   string
+	opts   dispatcherOpts
+	logger yalogi.Logger
+	sink   Sink
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan Event
+	done   chan struct{}
+	close  chan struct{}
+}
+
+// NewDispatcher creates a dispatcher for the sink and starts it.
+func NewDispatcher(name string, sink Sink, opt ...DispatcherOption) *Dispatcher {
+	opts := defaultDispatcherOpts
+	for _, o := range opt {
+		o(&opts)
+	}
+	d := &Dispatcher{
+		name:   name,
+		opts:   opts,
+		logger: opts.logger,
+		sink:   sink,
+		queue:  make(chan Event, opts.queueSize),
+		done:   make(chan struct{}),
+		close:  make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// Name returns the name of the dispatcher.
+func (d *Dispatcher) Name() string {
+	return d.name
+}
+
+// Dispatch queues an event, returns false if the event was dropped.
+func (d *Dispatcher) Dispatch(e Event) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return false
+	}
+	select {
+	case d.queue <- e:
+		return true
+	default:
+		stats.events.WithLabelValues(e.List, d.name, "dropped").Inc()
+		return false
+	}
+}
+
+// Close sends pending events and closes the sink.
+func (d *Dispatcher) Close() error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.close)
+	d.mu.Unlock()
+	<-d.done
+	return d.sink.Close()
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	batch := make([]Event, 0, d.opts.batch)
+	ticker := time.NewTicker(d.opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case e := <-d.queue:
+			batch = append(batch, e)
+			if len(batch) >= d.opts.batch {
+				d.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				d.send(batch)
+				batch = batch[:0]
+			}
+		case <-d.close:
+			for {
+				select {
+				case e := <-d.queue:
+					batch = append(batch, e)
+					if len(batch) >= d.opts.batch {
+						d.send(batch)
+						batch = batch[:0]
+					}
+				default:
+					if len(batch) > 0 {
+						d.send(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) send(batch []Event) {
+	backoff := d.opts.backoff
+	var err error
+	for attempt := 0; attempt <= d.opts.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-d.close:
+				// on close retries don't wait
+			}
+			backoff = backoff * 2
+		}
+		err = d.sink.Send(batch)
+		if err == nil {
+			for _, e := range batch {
+				stats.events.WithLabelValues(e.List, d.name, "sent").Inc()
+			}
+			return
+		}
+		d.logger.Debugf("event: sink '%s' attempt %v failed: %v", d.name, attempt+1, err)
+	}
+	d.logger.Warnf("event: sink '%s' discarding %v events: %v", d.name, len(batch), err)
+	for _, e := range batch {
+		stats.events.WithLabelValues(e.List, d.name, "failed").Inc()
+	}
+}
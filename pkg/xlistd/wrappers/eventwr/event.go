@@ -0,0 +1,131 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package eventwr
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+)
+
+// Types of events.
+const (
+	Positive = "positive"
+	Failure  = "error"
+)
+
+// Event is emitted when a list returns a positive or an error.
+type Event struct {
+	Time     time.Time      `json:"ts"`
+	Type     string         `json:"type"`
+	List     string         `json:"list"`
+	Resource xlist.Resource `json:"resource"`
+	Name     string         `json:"name"`
+	Reason   string         `json:"reason,omitempty"`
+	TTL      int            `json:"ttl,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Peer     string         `json:"peer,omitempty"`
+	Identity string         `json:"identity,omitempty"`
+	// Count is the number of hits since the last event, it's greater than
+	// one if duplicated events were suppressed
+	Count int `json:"count"`
+}
+
+// Formats available for text sinks.
+const (
+	FormatJSON = "json"
+	FormatCEF  = "cef"
+	FormatLEEF = "leef"
+)
+
+// Vendor and product used in CEF and LEEF formats.
+var (
+	Vendor  = "luids"
+	Product = "xlist"
+	Version = "1.0"
+)
+
+func (e Event) peerIP() string {
+	if host, _, err := net.SplitHostPort(e.Peer); err == nil {
+		return host
+	}
+	return e.Peer
+}
+
+func (e Event) severity() int {
+	if e.Type == Failure {
+		return 3
+	}
+	return 7
+}
+
+// CEF returns the event in ArcSight Common Event Format.
+func (e Event) CEF() string {
+	name := "List positive"
+	if e.Type == Failure {
+		name = "List error"
+	}
+	ext := []string{
+		"rt=" + fmt.Sprint(e.Time.UnixNano()/int64(time.Millisecond)),
+		"cs1Label=list", "cs1=" + cefValue(e.List),
+		"cs2Label=resource", "cs2=" + e.Resource.String(),
+		"cs3Label=name", "cs3=" + cefValue(e.Name),
+		"cnt=" + fmt.Sprint(e.Count),
+	}
+	if e.Reason != "" {
+		ext = append(ext, "reason="+cefValue(e.Reason))
+	}
+	if e.Error != "" {
+		ext = append(ext, "msg="+cefValue(e.Error))
+	}
+	if e.Peer != "" {
+		ext = append(ext, "src="+cefValue(e.peerIP()))
+	}
+	if e.Identity != "" {
+		ext = append(ext, "suser="+cefValue(e.Identity))
+	}
+	return fmt.Sprintf("CEF:0|%s|%s|%s|xlist-%s|%s|%d|%s", cefHeader(Vendor), cefHeader(Product),
+		cefHeader(Version), e.Type, name, e.severity(), strings.Join(ext, " "))
+}
+
+// LEEF returns the event in IBM QRadar Log Event Extended Format 1.0.
+func (e Event) LEEF() string {
+	attrs := []string{
+		"devTime=" + e.Time.Format("Jan 02 2006 15:04:05.000 MST"),
+		"devTimeFormat=MMM dd yyyy HH:mm:ss.SSS z",
+		"sev=" + fmt.Sprint(e.severity()),
+		"list=" + leefValue(e.List),
+		"resource=" + e.Resource.String(),
+		"name=" + leefValue(e.Name),
+		"count=" + fmt.Sprint(e.Count),
+	}
+	if e.Reason != "" {
+		attrs = append(attrs, "reason="+leefValue(e.Reason))
+	}
+	if e.Error != "" {
+		attrs = append(attrs, "error="+leefValue(e.Error))
+	}
+	if e.Peer != "" {
+		attrs = append(attrs, "src="+leefValue(e.peerIP()))
+	}
+	if e.Identity != "" {
+		attrs = append(attrs, "usrName="+leefValue(e.Identity))
+	}
+	return fmt.Sprintf("LEEF:1.0|%s|%s|%s|%s|%s", leefHeader(Vendor), leefHeader(Product),
+		leefHeader(Version), e.Type, strings.Join(attrs, "\t"))
+}
+
+var (
+	cefHeaderReplacer  = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefValueReplacer   = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+	leefHeaderReplacer = strings.NewReplacer(`|`, ` `, "\n", " ", "\r", " ", "\t", " ")
+	leefValueReplacer  = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+)
+
+func cefHeader(s string) string  { return cefHeaderReplacer.Replace(s) }
+func cefValue(s string) string   { return cefValueReplacer.Replace(s) }
+func leefHeader(s string) string { return leefHeaderReplacer.Replace(s) }
+func leefValue(s string) string  { return leefValueReplacer.Replace(s) }
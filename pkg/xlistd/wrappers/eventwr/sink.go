@@ -0,0 +1,153 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package eventwr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sink is the interface for the destinations of the events.
+type Sink interface {
+	Send(events []Event) error
+	Close() error
+}
+
+// FileSink appends events to a file in json lines format.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink returns a new file sink.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: f}, nil
+}
+
+// Send implements Sink interface.
+func (s *FileSink) Send(events []Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.file.Write(buf.Bytes())
+	return err
+}
+
+// Close implements Sink interface.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// SyslogSink sends events to a syslog server in CEF, LEEF or json formats.
+type SyslogSink struct {
+	format string
+	writer *syslog.Writer
+}
+
+// NewSyslogSink returns a new syslog sink. If network is empty, it connects
+// to the local syslog server.
+func NewSyslogSink(network, address, tag, format string) (*SyslogSink, error) {
+	switch format {
+	case FormatCEF, FormatLEEF, FormatJSON:
+	default:
+		return nil, fmt.Errorf("invalid format '%s'", format)
+	}
+	w, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSink{format: format, writer: w}, nil
+}
+
+// Send implements Sink interface.
+func (s *SyslogSink) Send(events []Event) error {
+	for _, e := range events {
+		var msg string
+		switch s.format {
+		case FormatCEF:
+			msg = e.CEF()
+		case FormatLEEF:
+			msg = e.LEEF()
+		default:
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			msg = string(data)
+		}
+		if err := s.writer.Info(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements Sink interface.
+func (s *SyslogSink) Close() error {
+	return s.writer.Close()
+}
+
+// WebhookSink posts events to an http endpoint as a json array.
+type WebhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhookSink returns a new webhook sink.
+func NewWebhookSink(url string, headers map[string]string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Send implements Sink interface.
+func (s *WebhookSink) Send(events []Event) error {
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// Close implements Sink interface.
+func (s *WebhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,308 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package eventwr provides a wrapper for RBLs that emits structured events
+// for positive results to pluggable sinks.
+//
+// This package is a work in progress and makes no API stability promises.
+package eventwr
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cliprom "github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/peer"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/authz"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// WrapperClass registered.
+const WrapperClass = "event"
+
+// DefaultConfig returns default configuration.
+func DefaultConfig() Config {
+	return Config{
+		Dedup:    time.Minute,
+		MaxDedup: 10000,
+	}
+}
+
+// Config options.
+type Config struct {
+	// Errors enables events for errors
+	Errors bool
+	// Dedup is the window in which repeated events are suppressed, the
+	// count of suppressed events is emitted when the window expires
+	Dedup time.Duration
+	// MaxDedup is the max number of keys tracked for deduplication
+	MaxDedup int
+	Sinks    []SinkConfig
+}
+
+// Types of sinks.
+const (
+	FileType    = "file"
+	SyslogType  = "syslog"
+	WebhookType = "webhook"
+)
+
+// SinkConfig options of a sink.
+type SinkConfig struct {
+	Type string
+	// Name used in logs and metrics, type is used if empty
+	Name string
+	// file options
+	Path string
+	// syslog options
+	Network string
+	Address string
+	Tag     string
+	Format  string
+	// webhook options
+	URL     string
+	Headers map[string]string
+	Timeout time.Duration
+	// dispatch options
+	Queue    int
+	Batch    int
+	Interval time.Duration
+	Retries  int
+	Backoff  time.Duration
+}
+
+// Wrapper implements an event emitter for list checkers.
+type Wrapper struct {
+	cfg   Config
+	list  xlistd.List
+	sinks []*Dispatcher
+
+	mu     sync.Mutex
+	seen   map[dedupKey]*dedupState
+	now    func() time.Time
+	closed bool
+	close  chan struct{}
+	wg     sync.WaitGroup
+}
+
+type dedupKey struct {
+	kind     string
+	resource xlist.Resource
+	name     string
+}
+
+type dedupState struct {
+	last       time.Time
+	suppressed int
+	// event is the last suppressed event
+	event Event
+}
+
+// New returns a new wrapper that dispatches events to the sinks.
+func New(list xlistd.List, sinks []*Dispatcher, cfg Config) *Wrapper {
+	w := &Wrapper{
+		cfg:   cfg,
+		list:  list,
+		sinks: sinks,
+		seen:  make(map[dedupKey]*dedupState),
+		now:   time.Now,
+		close: make(chan struct{}),
+	}
+	if cfg.Dedup > 0 {
+		w.wg.Add(1)
+		go w.doExpire()
+	}
+	return w
+}
+
+// ID implements xlistd.List interface.
+func (w *Wrapper) ID() string {
+	return w.list.ID()
+}
+
+// Class implements xlistd.List interface.
+func (w *Wrapper) Class() string {
+	return w.list.Class()
+}
+
+// Check implements xlist.Checker interface.
+func (w *Wrapper) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	resp, err := w.list.Check(ctx, name, resource)
+	switch {
+	case err != nil && w.cfg.Errors:
+		w.emit(ctx, Event{Type: Failure, Resource: resource, Name: name, Error: err.Error()})
+	case err == nil && resp.Result:
+		w.emit(ctx, Event{Type: Positive, Resource: resource, Name: name, Reason: resp.Reason, TTL: resp.TTL})
+	}
+	return resp, err
+}
+
+func (w *Wrapper) emit(ctx context.Context, e Event) {
+	e.Time = w.now()
+	e.List = w.list.ID()
+	if p, ok := peer.FromContext(ctx); ok {
+		e.Peer = p.Addr.String()
+	}
+	if identity, ok := authz.FromContext(ctx); ok {
+		e.Identity = identity
+	}
+	count, ok := w.dedup(e)
+	if !ok {
+		stats.events.WithLabelValues(w.list.ID(), "", "suppressed").Inc()
+		return
+	}
+	e.Count = count
+	w.dispatch(e)
+}
+
+func (w *Wrapper) dispatch(e Event) {
+	for _, d := range w.sinks {
+		d.Dispatch(e)
+	}
+}
+
+// dedup returns the number of hits represented by the event and if it must
+// be emitted.
+func (w *Wrapper) dedup(e Event) (int, bool) {
+	if w.cfg.Dedup <= 0 {
+		return 1, true
+	}
+	key := dedupKey{kind: e.Type, resource: e.Resource, name: e.Name}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	st, ok := w.seen[key]
+	if ok && e.Time.Sub(st.last) < w.cfg.Dedup {
+		st.suppressed++
+		st.event = e
+		return 0, false
+	}
+	count := 1
+	if ok {
+		count += st.suppressed
+		st.last, st.suppressed, st.event = e.Time, 0, Event{}
+		return count, true
+	}
+	if w.cfg.MaxDedup > 0 && len(w.seen) >= w.cfg.MaxDedup {
+		for _, pe := range w.purge(e.Time) {
+			w.dispatch(pe)
+		}
+	}
+	w.seen[key] = &dedupState{last: e.Time}
+	return count, true
+}
+
+// purge removes expired keys, if there is no room it resets the map.
+// Pending counts of the removed keys are returned, warning! no lock
+func (w *Wrapper) purge(now time.Time) []Event {
+	pending := w.expire(now, false)
+	if len(w.seen) >= w.cfg.MaxDedup {
+		pending = append(pending, w.expire(now, true)...)
+	}
+	return pending
+}
+
+// expire removes the keys whose window expired, or all if force is true,
+// and returns events with the count of the suppressed ones, warning! no lock
+func (w *Wrapper) expire(now time.Time, force bool) []Event {
+	var pending []Event
+	for k, st := range w.seen {
+		if force || now.Sub(st.last) >= w.cfg.Dedup {
+			if st.suppressed > 0 {
+				e := st.event
+				e.Time, e.Count = now, st.suppressed
+				pending = append(pending, e)
+			}
+			delete(w.seen, k)
+		}
+	}
+	return pending
+}
+
+// doExpire emits pending counts when windows expire.
+func (w *Wrapper) doExpire() {
+	defer w.wg.Done()
+	interval := w.cfg.Dedup / 2
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.close:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			pending := w.expire(w.now(), false)
+			w.mu.Unlock()
+			for _, e := range pending {
+				w.dispatch(e)
+			}
+		}
+	}
+}
+
+// Resources implements xlist.Checker interface.
+func (w *Wrapper) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	return w.list.Resources(ctx)
+}
+
+// Ping implements xlistd.List interface.
+func (w *Wrapper) Ping() error {
+	return w.list.Ping()
+}
+
+// Flush implements xlistd.Flusher interface, it emits the pending counts
+// and resets the deduplication state.
+func (w *Wrapper) Flush() {
+	w.mu.Lock()
+	pending := w.expire(w.now(), true)
+	w.mu.Unlock()
+	for _, e := range pending {
+		w.dispatch(e)
+	}
+}
+
+// Unwrap implements xlistd.Unwrapper interface.
+func (w *Wrapper) Unwrap() xlistd.List {
+	return w.list
+}
+
+// Close closes the dispatchers, sending pending events and counts.
+func (w *Wrapper) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.close)
+	w.mu.Unlock()
+	w.wg.Wait()
+	w.Flush()
+	var first error
+	for _, d := range w.sinks {
+		if err := d.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// stats is a global structure.
+var stats struct {
+	events *cliprom.CounterVec
+}
+
+func init() {
+	stats.events = cliprom.NewCounterVec(
+		cliprom.CounterOpts{
+			Name: "xlist_events_total",
+			Help: "How many events emitted, partitioned by list, sink and status",
+		},
+		[]string{"list", "sink", "status"})
+
+	cliprom.MustRegister(stats.events)
+}
@@ -0,0 +1,201 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package eventwr_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/wrappers/eventwr"
+)
+
+func TestWrapper_File(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eventwr")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.json")
+	sink, err := eventwr.NewFileSink(path)
+	if err != nil {
+		t.Fatalf("eventwr.NewFileSink(): err=%v", err)
+	}
+
+	ip4 := []xlist.Resource{xlist.IPv4}
+	mockup := &mockxl.List{Identifier: "mockup", ResourceList: ip4, Results: []bool{true, false}, TTL: 60}
+	cfg := eventwr.DefaultConfig()
+	cfg.Dedup = 200 * time.Millisecond
+	wrapped := eventwr.New(mockup, []*eventwr.Dispatcher{eventwr.NewDispatcher("file", sink)}, cfg)
+
+	ctx := context.Background()
+	// 5 positives and 5 negatives, positives are deduplicated
+	for i := 0; i < 10; i++ {
+		wrapped.Check(ctx, "10.0.0.1", xlist.IPv4)
+	}
+	// count of suppressed events is emitted when the window expires
+	time.Sleep(500 * time.Millisecond)
+	for i := 0; i < 4; i++ {
+		wrapped.Check(ctx, "10.0.0.1", xlist.IPv4)
+	}
+	// errors are not emitted by default
+	wrapped.Check(ctx, "10.0.0.1", xlist.IPv6)
+	// pending count is emitted on close
+	if err := wrapped.Close(); err != nil {
+		t.Fatalf("eventwr.Close(): err=%v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening events: %v", err)
+	}
+	defer f.Close()
+	var events []eventwr.Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e eventwr.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("decoding event: %v", err)
+		}
+		events = append(events, e)
+	}
+	if len(events) != 4 {
+		t.Fatalf("unexpected events: %v", events)
+	}
+	for idx, want := range []int{1, 4, 1, 1} {
+		if events[idx].Count != want || events[idx].Name != "10.0.0.1" || events[idx].Type != eventwr.Positive {
+			t.Errorf("idx[%v] unexpected event: %v", idx, events[idx])
+		}
+	}
+	e := events[0]
+	if e.Type != eventwr.Positive || e.List != "mockup" || e.Name != "10.0.0.1" || e.Resource != xlist.IPv4 ||
+		e.TTL != 60 || e.Reason == "" {
+		t.Errorf("unexpected event: %v", e)
+	}
+}
+
+func TestWrapper_Webhook(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	var got []eventwr.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var batch []eventwr.Event
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		got = append(got, batch...)
+	}))
+	defer server.Close()
+
+	sink := eventwr.NewWebhookSink(server.URL, map[string]string{"Authorization": "Bearer secret"}, time.Second)
+	dispatcher := eventwr.NewDispatcher("webhook", sink,
+		eventwr.Batch(3, 50*time.Millisecond), eventwr.Retries(2, 10*time.Millisecond))
+	mockup := &mockxl.List{Identifier: "mockup", ResourceList: []xlist.Resource{xlist.Domain}, Results: []bool{true}}
+	cfg := eventwr.DefaultConfig()
+	cfg.Errors = true
+	wrapped := eventwr.New(mockup, []*eventwr.Dispatcher{dispatcher}, cfg)
+
+	ctx := context.Background()
+	for _, name := range []string{"a.com", "b.com", "c.com", "d.com", "a.com"} {
+		wrapped.Check(ctx, name, xlist.Domain)
+	}
+	wrapped.Check(ctx, "10.0.0.1", xlist.IPv4)
+	time.Sleep(200 * time.Millisecond)
+
+	mu.Lock()
+	if calls != 3 || len(got) != 5 {
+		t.Errorf("unexpected calls=%v events=%v", calls, got)
+	}
+	mu.Unlock()
+	wrapped.Close()
+	var errs int
+	for _, e := range got {
+		if e.Type == eventwr.Failure {
+			errs++
+			if e.Error == "" || e.Name != "10.0.0.1" {
+				t.Errorf("unexpected error event: %v", e)
+			}
+		}
+	}
+	if errs != 1 {
+		t.Errorf("unexpected events: %v", got)
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	defer conn.Close()
+	sink, err := eventwr.NewSyslogSink("udp", conn.LocalAddr().String(), "xlistd", eventwr.FormatLEEF)
+	if err != nil {
+		t.Fatalf("eventwr.NewSyslogSink(): err=%v", err)
+	}
+	defer sink.Close()
+	err = sink.Send([]eventwr.Event{{Time: time.Now(), Type: eventwr.Positive, List: "list1",
+		Resource: xlist.IPv4, Name: "10.0.0.1", Count: 1}})
+	if err != nil {
+		t.Fatalf("eventwr.Send(): err=%v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 2048)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("reading: %v", err)
+	}
+	msg := string(buf[:n])
+	if !strings.Contains(msg, "xlistd") || !strings.Contains(msg, "LEEF:1.0|luids|xlist|1.0|positive|") ||
+		!strings.Contains(msg, "\tname=10.0.0.1") {
+		t.Errorf("unexpected message: %q", msg)
+	}
+}
+
+func TestEvent_Format(t *testing.T) {
+	e := eventwr.Event{
+		Time:     time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Type:     eventwr.Positive,
+		List:     "list|1",
+		Resource: xlist.Domain,
+		Name:     "www.example.com",
+		Reason:   "score=10 a\\b\nc",
+		Peer:     "10.0.0.5:4444",
+		Count:    3,
+	}
+	cef := e.CEF()
+	want := `CEF:0|luids|xlist|1.0|xlist-positive|List positive|7|rt=1577836800000 cs1Label=list cs1=list|1 ` +
+		`cs2Label=resource cs2=domain cs3Label=name cs3=www.example.com cnt=3 reason=score\=10 a\\b\nc src=10.0.0.5`
+	if cef != want {
+		t.Errorf("unexpected cef:\n got=%s\nwant=%s", cef, want)
+	}
+	leef := e.LEEF()
+	if !strings.HasPrefix(leef, "LEEF:1.0|luids|xlist|1.0|positive|devTime=Jan 01 2020 00:00:00.000 UTC\t") ||
+		!strings.Contains(leef, "\tlist=list|1\t") || !strings.Contains(leef, "\treason=score=10 a\\b c\t") ||
+		strings.Contains(leef, "\n") {
+		t.Errorf("unexpected leef: %q", leef)
+	}
+}
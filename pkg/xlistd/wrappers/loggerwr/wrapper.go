@@ -132,6 +132,11 @@ func (w *Wrapper) Ping() error {
 	return nil
 }
 
+// Unwrap implements xlistd.Unwrapper interface.
+func (w *Wrapper) Unwrap() xlistd.List {
+	return w.list
+}
+
 func (w *Wrapper) getPeerInfo(ctx context.Context) string {
 	//get peer info
 	peerInfo := ""
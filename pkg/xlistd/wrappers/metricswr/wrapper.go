@@ -81,6 +81,11 @@ func (w *Wrapper) Ping() error {
 	return err
 }
 
+// Unwrap implements xlistd.Unwrapper interface.
+func (w *Wrapper) Unwrap() xlistd.List {
+	return w.list
+}
+
 // Resources implements xlist.Checker interface.
 func (w *Wrapper) Resources(ctx context.Context) ([]xlist.Resource, error) {
 	return w.list.Resources(ctx)
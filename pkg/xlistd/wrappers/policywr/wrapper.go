@@ -88,3 +88,8 @@ func (w *Wrapper) Resources(ctx context.Context) ([]xlist.Resource, error) {
 func (w *Wrapper) Ping() error {
 	return w.list.Ping()
 }
+
+// Unwrap implements xlistd.Unwrapper interface.
+func (w *Wrapper) Unwrap() xlistd.List {
+	return w.list
+}
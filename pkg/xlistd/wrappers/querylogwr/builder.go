@@ -0,0 +1,124 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package querylogwr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/querylog"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildWrapperFn {
+	return func(b *xlistd.Builder, def xlistd.WrapperDef, list xlistd.List) (xlistd.List, error) {
+		cfg := defaultCfg
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		dir := b.DataPath(cfg.Dir)
+		if !dirExists(dir) {
+			return nil, fmt.Errorf("dir '%s' doesn't exists", dir)
+		}
+		name := cfg.Name
+		if name == "" {
+			name = list.ID()
+		}
+		log, err := querylog.NewWriter(dir, name,
+			querylog.MaxSize(cfg.MaxSize),
+			querylog.MaxAge(cfg.MaxAge),
+			querylog.MaxFiles(cfg.MaxFiles))
+		if err != nil {
+			return nil, err
+		}
+		b.OnShutdown(func() error {
+			return log.Close()
+		})
+		return New(list, log, cfg), nil
+	}
+}
+
+func dirExists(dirname string) bool {
+	info, err := os.Stat(dirname)
+	if err != nil {
+		return false
+	}
+	return info.IsDir()
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	dir, ok, err := option.String(opts, "dir")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if dir == "" {
+			return dst, errors.New("invalid 'dir'")
+		}
+		dst.Dir = dir
+	}
+
+	name, ok, err := option.String(opts, "name")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Name = name
+	}
+
+	maxsize, ok, err := option.Int(opts, "maxsize")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if maxsize <= 0 {
+			return dst, errors.New("invalid 'maxsize'")
+		}
+		dst.MaxSize = int64(maxsize) * 1024 * 1024
+	}
+
+	maxage, ok, err := option.Int(opts, "maxage")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if maxage < 0 {
+			return dst, errors.New("invalid 'maxage'")
+		}
+		dst.MaxAge = time.Duration(maxage) * time.Hour
+	}
+
+	maxfiles, ok, err := option.Int(opts, "maxfiles")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if maxfiles < 0 {
+			return dst, errors.New("invalid 'maxfiles'")
+		}
+		dst.MaxFiles = maxfiles
+	}
+
+	positives, ok, err := option.Bool(opts, "onlypositives")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.OnlyPositives = positives
+	}
+
+	return dst, nil
+}
+
+func init() {
+	xlistd.RegisterWrapperBuilder(WrapperClass, Builder(DefaultConfig()))
+}
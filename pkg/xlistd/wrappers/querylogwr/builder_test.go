@@ -0,0 +1,91 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package querylogwr_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/wrappers/querylogwr"
+)
+
+var testdatabase1 = []xlistd.ListDef{
+	{ID: "list1",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: querylogwr.WrapperClass,
+				Opts: map[string]interface{}{"name": "list1"}}}},
+	{ID: "list2",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: querylogwr.WrapperClass,
+				Opts: map[string]interface{}{"name": "list2", "maxsize": 10, "maxage": 24, "maxfiles": 5, "onlypositives": true}}}},
+	{ID: "list3",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: querylogwr.WrapperClass,
+				Opts: map[string]interface{}{"dir": "nonexistent"}}}},
+	{ID: "list4",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: querylogwr.WrapperClass,
+				Opts: map[string]interface{}{"maxsize": 0}}}},
+	{ID: "list5",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: querylogwr.WrapperClass,
+				Opts: map[string]interface{}{"onlypositives": "yes"}}}},
+}
+
+func TestBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "querylogwr")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "querylog"), 0755)
+	b := xlistd.NewBuilder(apiservice.NewRegistry(), xlistd.DataDir(dir))
+	defer b.Shutdown()
+
+	//define and do tests
+	var tests = []struct {
+		listid  string
+		wantErr string
+	}{
+		{"list1", ""},
+		{"list2", ""},
+		{"list3", "doesn't exists"},
+		{"list4", "invalid 'maxsize'"},
+		{"list5", "invalid 'onlypositives'"},
+	}
+	for _, test := range tests {
+		def, ok := xlistd.FilterID(test.listid, testdatabase1)
+		if !ok {
+			t.Errorf("can't find id %s in database tests", test.listid)
+			continue
+		}
+		_, err := b.Build(def)
+		switch {
+		case test.wantErr == "" && err == nil:
+			//
+		case test.wantErr == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("expected error for %s", test.listid)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package querylogwr provides a wrapper for RBLs that records the queries in
+// a structured log that can be used later for reporting.
+//
+// This package is a work in progress and makes no API stability promises.
+package querylogwr
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/peer"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/authz"
+	"github.com/luids-io/xlist/pkg/querylog"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// WrapperClass registered.
+const WrapperClass = "querylog"
+
+// DefaultConfig returns default configuration.
+func DefaultConfig() Config {
+	return Config{
+		Dir:      "querylog",
+		MaxSize:  querylog.DefaultMaxSize,
+		MaxAge:   querylog.DefaultMaxAge,
+		MaxFiles: querylog.DefaultMaxFiles,
+	}
+}
+
+// Config options.
+type Config struct {
+	Dir string
+	// Name of the log file, list id is used if empty
+	Name          string
+	MaxSize       int64
+	MaxAge        time.Duration
+	MaxFiles      int
+	OnlyPositives bool
+}
+
+// Wrapper implements a query log for list checkers.
+type Wrapper struct {
+	list          xlistd.List
+	log           *querylog.Writer
+	onlyPositives bool
+}
+
+// New returns a new wrapper that writes to the log passed.
+func New(list xlistd.List, log *querylog.Writer, cfg Config) *Wrapper {
+	return &Wrapper{
+		list:          list,
+		log:           log,
+		onlyPositives: cfg.OnlyPositives,
+	}
+}
+
+// ID implements xlistd.List interface.
+func (w *Wrapper) ID() string {
+	return w.list.ID()
+}
+
+// Class implements xlistd.List interface.
+func (w *Wrapper) Class() string {
+	return w.list.Class()
+}
+
+// Check implements xlist.Checker interface.
+func (w *Wrapper) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	start := time.Now()
+	resp, err := w.list.Check(ctx, name, resource)
+	if w.onlyPositives && (err != nil || !resp.Result) {
+		return resp, err
+	}
+	r := querylog.Record{
+		Time:     start,
+		List:     w.list.ID(),
+		Resource: resource,
+		Name:     name,
+		Result:   resp.Result,
+		Reason:   resp.Reason,
+		TTL:      resp.TTL,
+		Latency:  time.Since(start).Microseconds(),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		r.Peer = p.Addr.String()
+	}
+	if identity, ok := authz.FromContext(ctx); ok {
+		r.Identity = identity
+	}
+	w.log.Write(r)
+	return resp, err
+}
+
+// Resources implements xlist.Checker interface.
+func (w *Wrapper) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	return w.list.Resources(ctx)
+}
+
+// Ping implements xlistd.List interface.
+func (w *Wrapper) Ping() error {
+	if err := w.list.Ping(); err != nil {
+		return err
+	}
+	return w.log.Ping()
+}
+
+// Unwrap implements xlistd.Unwrapper interface.
+func (w *Wrapper) Unwrap() xlistd.List {
+	return w.list
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package querylogwr_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+
+	"google.golang.org/grpc/peer"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/authz"
+	"github.com/luids-io/xlist/pkg/querylog"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/wrappers/querylogwr"
+)
+
+func TestWrapper_Check(t *testing.T) {
+	dir, err := ioutil.TempDir("", "querylogwr")
+	if err != nil {
+		t.Fatalf("creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	log, err := querylog.NewWriter(dir, "mockup")
+	if err != nil {
+		t.Fatalf("querylog.NewWriter(): err=%v", err)
+	}
+
+	ip4 := []xlist.Resource{xlist.IPv4}
+	mockup := &mockxl.List{Identifier: "mockup", ResourceList: ip4, Results: []bool{true, false}, Reason: "test"}
+	wrapped := querylogwr.New(mockup, log, querylogwr.DefaultConfig())
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.1.1.1"), Port: 1000}})
+	ctx = authz.NewContext(ctx, "client1")
+	for _, name := range []string{"10.10.10.1", "10.10.10.2", "10.10.10.3"} {
+		wrapped.Check(ctx, name, xlist.IPv4)
+	}
+	wrapped.Check(context.Background(), "10.10.10.1", xlist.IPv4)
+	log.Close()
+
+	records, err := querylog.History(dir, querylog.Filter{})
+	if err != nil {
+		t.Fatalf("querylog.History(): err=%v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("querylog.History(): unexpected records %v", records)
+	}
+	r := records[0]
+	if r.List != "mockup" || r.Name != "10.10.10.1" || !r.Result || r.Reason != "test" ||
+		r.Identity != "client1" || r.Peer != "10.1.1.1:1000" || r.Time.IsZero() {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if records[1].Result || records[3].Client() != "" {
+		t.Errorf("unexpected records %+v", records)
+	}
+}
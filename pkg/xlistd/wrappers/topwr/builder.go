@@ -0,0 +1,98 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package topwr
+
+import (
+	"errors"
+	"time"
+
+	"github.com/luids-io/core/option"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// Builder returns a builder function.
+func Builder(defaultCfg Config) xlistd.BuildWrapperFn {
+	return func(b *xlistd.Builder, def xlistd.WrapperDef, list xlistd.List) (xlistd.List, error) {
+		cfg := defaultCfg
+		if def.Opts != nil {
+			var err error
+			cfg, err = parseOptions(cfg, def.Opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		w := New(list, cfg)
+		if cfg.Metrics {
+			Register(w)
+			b.OnShutdown(func() error {
+				Unregister(w)
+				return nil
+			})
+		}
+		return w, nil
+	}
+}
+
+func parseOptions(src Config, opts map[string]interface{}) (Config, error) {
+	dst := src
+	capacity, ok, err := option.Int(opts, "capacity")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if capacity <= 0 {
+			return dst, errors.New("invalid 'capacity'")
+		}
+		dst.Capacity = capacity
+	}
+
+	top, ok, err := option.Int(opts, "top")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if top <= 0 {
+			return dst, errors.New("invalid 'top'")
+		}
+		dst.Top = top
+	}
+
+	window, ok, err := option.Int(opts, "window")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if window <= 0 {
+			return dst, errors.New("invalid 'window'")
+		}
+		dst.Window = time.Duration(window) * time.Second
+	}
+
+	buckets, ok, err := option.Int(opts, "buckets")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		if buckets <= 0 {
+			return dst, errors.New("invalid 'buckets'")
+		}
+		dst.Buckets = buckets
+	}
+
+	metrics, ok, err := option.Bool(opts, "metrics")
+	if err != nil {
+		return dst, err
+	}
+	if ok {
+		dst.Metrics = metrics
+	}
+
+	if dst.Top > dst.Capacity {
+		return dst, errors.New("invalid 'top': greater than capacity")
+	}
+	return dst, nil
+}
+
+func init() {
+	xlistd.RegisterWrapperBuilder(WrapperClass, Builder(DefaultConfig()))
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package topwr_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/core/apiservice"
+	"github.com/luids-io/xlist/pkg/xlistd"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/wrappers/topwr"
+)
+
+var testdatabase1 = []xlistd.ListDef{
+	{ID: "list1",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers:  []xlistd.WrapperDef{{Class: topwr.WrapperClass}}},
+	{ID: "list2",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: topwr.WrapperClass,
+				Opts: map[string]interface{}{"capacity": 50, "top": 5, "window": 600, "buckets": 10, "metrics": false}}}},
+	{ID: "list3",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: topwr.WrapperClass,
+				Opts: map[string]interface{}{"capacity": 0}}}},
+	{ID: "list4",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: topwr.WrapperClass,
+				Opts: map[string]interface{}{"capacity": 5, "top": 10}}}},
+	{ID: "list5",
+		Class:     mockxl.ComponentClass,
+		Resources: []xlist.Resource{xlist.IPv4},
+		Wrappers: []xlistd.WrapperDef{
+			{Class: topwr.WrapperClass,
+				Opts: map[string]interface{}{"window": "1h"}}}},
+}
+
+func TestBuild(t *testing.T) {
+	b := xlistd.NewBuilder(apiservice.NewRegistry())
+	defer b.Shutdown()
+
+	//define and do tests
+	var tests = []struct {
+		listid  string
+		wantErr string
+	}{
+		{"list1", ""},
+		{"list2", ""},
+		{"list3", "invalid 'capacity'"},
+		{"list4", "invalid 'top'"},
+		{"list5", "invalid 'window'"},
+	}
+	for _, test := range tests {
+		def, ok := xlistd.FilterID(test.listid, testdatabase1)
+		if !ok {
+			t.Errorf("can't find id %s in database tests", test.listid)
+			continue
+		}
+		_, err := b.Build(def)
+		switch {
+		case test.wantErr == "" && err == nil:
+			//
+		case test.wantErr == "" && err != nil:
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		case test.wantErr != "" && err == nil:
+			t.Errorf("expected error for %s", test.listid)
+		case test.wantErr != "" && !strings.Contains(err.Error(), test.wantErr):
+			t.Errorf("unexpected error for %s: %v", test.listid, err)
+		}
+	}
+}
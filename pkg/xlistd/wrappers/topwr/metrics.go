@@ -0,0 +1,72 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package topwr
+
+import (
+	"strconv"
+	"sync"
+
+	cliprom "github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports the counts of the top of the registered wrappers when
+// metrics are scraped. Checked names are not used as label values because
+// they are chosen by the clients, the series are labeled by the rank in the
+// top and the names are available with Inspect in the admin api.
+type collector struct {
+	desc     *cliprom.Desc
+	mu       sync.Mutex
+	wrappers map[*Wrapper]struct{}
+}
+
+// stats is a global structure.
+var stats = &collector{
+	desc: cliprom.NewDesc("xlist_top_hits",
+		"Estimated checks of the most frequent indicators in the window, partitioned by result and rank",
+		[]string{"list", "result", "rank"}, nil),
+	wrappers: make(map[*Wrapper]struct{}),
+}
+
+// Register wrapper for exporting metrics.
+func Register(w *Wrapper) {
+	stats.mu.Lock()
+	stats.wrappers[w] = struct{}{}
+	stats.mu.Unlock()
+}
+
+// Unregister wrapper from metrics.
+func Unregister(w *Wrapper) {
+	stats.mu.Lock()
+	delete(stats.wrappers, w)
+	stats.mu.Unlock()
+}
+
+// Describe implements prometheus.Collector interface.
+func (c *collector) Describe(ch chan<- *cliprom.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector interface.
+func (c *collector) Collect(ch chan<- cliprom.Metric) {
+	c.mu.Lock()
+	wrappers := make([]*Wrapper, 0, len(c.wrappers))
+	for w := range c.wrappers {
+		wrappers = append(wrappers, w)
+	}
+	c.mu.Unlock()
+	for _, w := range wrappers {
+		positives, negatives := w.Top(w.cfg.Top)
+		for i, h := range positives {
+			ch <- cliprom.MustNewConstMetric(c.desc, cliprom.GaugeValue, float64(h.Count),
+				w.listID, "hit", strconv.Itoa(i+1))
+		}
+		for i, h := range negatives {
+			ch <- cliprom.MustNewConstMetric(c.desc, cliprom.GaugeValue, float64(h.Count),
+				w.listID, "miss", strconv.Itoa(i+1))
+		}
+	}
+}
+
+func init() {
+	cliprom.MustRegister(stats)
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package topwr
+
+import "container/heap"
+
+// counter of the space-saving algorithm, err is the max overestimation
+type counter struct {
+	key   string
+	count uint64
+	err   uint64
+	index int
+}
+
+// summary implements the space-saving algorithm with a fixed capacity. It
+// keeps the counters in a min-heap, so the less frequent item is replaced
+// when a new item arrives and summary is full.
+type summary struct {
+	capacity int
+	counters map[string]*counter
+	heap     counterHeap
+}
+
+func newSummary(capacity int) *summary {
+	return &summary{
+		capacity: capacity,
+		counters: make(map[string]*counter, capacity),
+		heap:     make(counterHeap, 0, capacity),
+	}
+}
+
+func (s *summary) offer(key string) {
+	if c, ok := s.counters[key]; ok {
+		c.count++
+		heap.Fix(&s.heap, c.index)
+		return
+	}
+	if len(s.heap) < s.capacity {
+		c := &counter{key: key, count: 1}
+		heap.Push(&s.heap, c)
+		s.counters[key] = c
+		return
+	}
+	// replace the min counter
+	c := s.heap[0]
+	delete(s.counters, c.key)
+	c.key, c.err = key, c.count
+	c.count++
+	s.counters[key] = c
+	heap.Fix(&s.heap, 0)
+}
+
+func (s *summary) reset() {
+	s.counters = make(map[string]*counter, s.capacity)
+	s.heap = s.heap[:0]
+}
+
+type counterHeap []*counter
+
+func (h counterHeap) Len() int           { return len(h) }
+func (h counterHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h counterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *counterHeap) Push(x interface{}) {
+	c := x.(*counter)
+	c.index = len(*h)
+	*h = append(*h, c)
+}
+
+func (h *counterHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	c := old[n-1]
+	*h = old[:n-1]
+	return c
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+// Package topwr provides a wrapper for RBLs that keeps statistics of the
+// most frequent indicators checked, using the space-saving algorithm over a
+// sliding window. Memory used by each list is bounded by the capacity.
+//
+// This package is a work in progress and makes no API stability promises.
+package topwr
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd"
+)
+
+// WrapperClass registered.
+const WrapperClass = "top"
+
+// DefaultConfig returns default configuration.
+func DefaultConfig() Config {
+	return Config{
+		Capacity: 100,
+		Top:      10,
+		Window:   time.Hour,
+		Buckets:  6,
+		Metrics:  true,
+	}
+}
+
+// Config options.
+type Config struct {
+	// Capacity is the number of counters of each bucket
+	Capacity int
+	// Top is the number of indicators exported
+	Top int
+	// Window is the time of the sliding window, divided in buckets
+	Window  time.Duration
+	Buckets int
+	// Metrics exports the counts of the top in prometheus metrics
+	Metrics bool
+}
+
+// Hit stores the estimated checks of an indicator. Real value is between
+// Count-Error and Count.
+type Hit struct {
+	Resource xlist.Resource `json:"resource"`
+	Name     string         `json:"name"`
+	Count    uint64         `json:"count"`
+	Error    uint64         `json:"error"`
+}
+
+// Wrapper implements an xlist.Checker wrapper that computes heavy hitters.
+type Wrapper struct {
+	cfg     Config
+	list    xlistd.List
+	listID  string
+	size    time.Duration
+	mu      sync.Mutex
+	current int64
+	// buckets for positive and negative responses
+	positives []*summary
+	negatives []*summary
+}
+
+// New returns a new wrapper.
+func New(list xlistd.List, cfg Config) *Wrapper {
+	if cfg.Buckets <= 0 {
+		cfg.Buckets = 1
+	}
+	w := &Wrapper{
+		cfg:       cfg,
+		list:      list,
+		listID:    list.ID(),
+		size:      cfg.Window / time.Duration(cfg.Buckets),
+		positives: make([]*summary, cfg.Buckets),
+		negatives: make([]*summary, cfg.Buckets),
+	}
+	if w.size <= 0 {
+		w.size = time.Second
+	}
+	for i := 0; i < cfg.Buckets; i++ {
+		w.positives[i] = newSummary(cfg.Capacity)
+		w.negatives[i] = newSummary(cfg.Capacity)
+	}
+	w.current = w.slot(time.Now())
+	return w
+}
+
+// ID implements xlistd.List interface.
+func (w *Wrapper) ID() string {
+	return w.listID
+}
+
+// Class implements xlistd.List interface.
+func (w *Wrapper) Class() string {
+	return w.list.Class()
+}
+
+// Check implements xlist.Checker interface.
+func (w *Wrapper) Check(ctx context.Context, name string, resource xlist.Resource) (xlist.Response, error) {
+	resp, err := w.list.Check(ctx, name, resource)
+	if err != nil {
+		return resp, err
+	}
+	key := fmt.Sprintf("%v,%s", resource, name)
+	w.mu.Lock()
+	idx := w.advance(time.Now())
+	if resp.Result {
+		w.positives[idx].offer(key)
+	} else {
+		w.negatives[idx].offer(key)
+	}
+	w.mu.Unlock()
+	return resp, err
+}
+
+// Resources implements xlist.Checker interface.
+func (w *Wrapper) Resources(ctx context.Context) ([]xlist.Resource, error) {
+	return w.list.Resources(ctx)
+}
+
+// Ping implements xlistd.List interface.
+func (w *Wrapper) Ping() error {
+	return w.list.Ping()
+}
+
+// Unwrap implements xlistd.Unwrapper interface.
+func (w *Wrapper) Unwrap() xlistd.List {
+	return w.list
+}
+
+// Top returns the top indicators in the window for positive and negative
+// responses.
+func (w *Wrapper) Top(n int) (positives []Hit, negatives []Hit) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.advance(time.Now())
+	return merge(w.positives, n), merge(w.negatives, n)
+}
+
+// Flush implements xlistd.Flusher interface.
+func (w *Wrapper) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range w.positives {
+		w.positives[i].reset()
+		w.negatives[i].reset()
+	}
+}
+
+// Inspect implements xlistd.Inspector interface.
+func (w *Wrapper) Inspect() map[string]interface{} {
+	positives, negatives := w.Top(w.cfg.Top)
+	return map[string]interface{}{
+		"window":        w.cfg.Window.String(),
+		"top_positives": positives,
+		"top_negatives": negatives,
+	}
+}
+
+func (w *Wrapper) slot(t time.Time) int64 {
+	return t.UnixNano() / int64(w.size)
+}
+
+// advance resets expired buckets and returns the current, warning! no lock
+func (w *Wrapper) advance(now time.Time) int {
+	slot := w.slot(now)
+	if slot > w.current {
+		expired := slot - w.current
+		if expired > int64(w.cfg.Buckets) {
+			expired = int64(w.cfg.Buckets)
+		}
+		for i := int64(1); i <= expired; i++ {
+			idx := int((w.current + i) % int64(w.cfg.Buckets))
+			w.positives[idx].reset()
+			w.negatives[idx].reset()
+		}
+		w.current = slot
+	}
+	return int(w.current % int64(w.cfg.Buckets))
+}
+
+// merge the counters of the buckets
+func merge(buckets []*summary, n int) []Hit {
+	counts := make(map[string]*counter)
+	for _, b := range buckets {
+		for _, c := range b.heap {
+			m, ok := counts[c.key]
+			if !ok {
+				m = &counter{key: c.key}
+				counts[c.key] = m
+			}
+			m.count += c.count
+			m.err += c.err
+		}
+	}
+	merged := make([]*counter, 0, len(counts))
+	for _, c := range counts {
+		merged = append(merged, c)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].count == merged[j].count {
+			return merged[i].key < merged[j].key
+		}
+		return merged[i].count > merged[j].count
+	})
+	if n > 0 && len(merged) > n {
+		merged = merged[:n]
+	}
+	hits := make([]Hit, 0, len(merged))
+	for _, c := range merged {
+		idx := strings.Index(c.key, ",")
+		resource, _ := xlist.ToResource(c.key[:idx])
+		hits = append(hits, Hit{Resource: resource, Name: c.key[idx+1:], Count: c.count, Error: c.err})
+	}
+	return hits
+}
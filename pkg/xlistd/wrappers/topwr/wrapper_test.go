@@ -0,0 +1,128 @@
+// Copyright 2020 Luis Guillén Civera <luisguillenc@gmail.com>. See LICENSE.
+
+package topwr_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	cliprom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/luids-io/api/xlist"
+	"github.com/luids-io/xlist/pkg/xlistd/components/mockxl"
+	"github.com/luids-io/xlist/pkg/xlistd/wrappers/topwr"
+)
+
+func TestWrapper_Top(t *testing.T) {
+	ip4 := []xlist.Resource{xlist.IPv4}
+	mockup := &mockxl.List{Identifier: "mockup", ResourceList: ip4, Results: []bool{true}}
+	cfg := topwr.DefaultConfig()
+	cfg.Capacity = 10
+	cfg.Top = 3
+	wrapped := topwr.New(mockup, cfg)
+
+	ctx := context.Background()
+	// heavy hitters mixed with a lot of noise
+	for i := 0; i < 1000; i++ {
+		wrapped.Check(ctx, "10.0.0.1", xlist.IPv4)
+		if i%2 == 0 {
+			wrapped.Check(ctx, "10.0.0.2", xlist.IPv4)
+		}
+		if i%4 == 0 {
+			wrapped.Check(ctx, "10.0.0.3", xlist.IPv4)
+		}
+		wrapped.Check(ctx, fmt.Sprintf("10.1.%v.%v", i/250, i%250), xlist.IPv4)
+	}
+	positives, negatives := wrapped.Top(3)
+	if len(negatives) != 0 {
+		t.Errorf("unexpected negatives: %v", negatives)
+	}
+	if len(positives) != 3 {
+		t.Fatalf("unexpected positives: %v", positives)
+	}
+	for idx, want := range []struct {
+		name  string
+		count uint64
+	}{{"10.0.0.1", 1000}, {"10.0.0.2", 500}, {"10.0.0.3", 250}} {
+		got := positives[idx]
+		if got.Name != want.name || got.Resource != xlist.IPv4 {
+			t.Errorf("idx[%v] unexpected hit: %v", idx, got)
+		}
+		// space-saving guarantees count-err <= real <= count
+		if got.Count < want.count || got.Count-got.Error > want.count {
+			t.Errorf("idx[%v] unexpected count: %v", idx, got)
+		}
+	}
+	info := wrapped.Inspect()
+	if _, ok := info["top_positives"]; !ok {
+		t.Errorf("unexpected inspect: %v", info)
+	}
+	wrapped.Flush()
+	if positives, _ := wrapped.Top(3); len(positives) != 0 {
+		t.Errorf("unexpected positives after flush: %v", positives)
+	}
+}
+
+func TestWrapper_Window(t *testing.T) {
+	ip4 := []xlist.Resource{xlist.IPv4}
+	mockup := &mockxl.List{Identifier: "mockup", ResourceList: ip4, Results: []bool{false}}
+	cfg := topwr.DefaultConfig()
+	cfg.Window = 400 * time.Millisecond
+	cfg.Buckets = 4
+	wrapped := topwr.New(mockup, cfg)
+
+	ctx := context.Background()
+	wrapped.Check(ctx, "10.0.0.1", xlist.IPv4)
+	_, negatives := wrapped.Top(10)
+	if len(negatives) != 1 || negatives[0].Count != 1 {
+		t.Fatalf("unexpected negatives: %v", negatives)
+	}
+	time.Sleep(150 * time.Millisecond)
+	wrapped.Check(ctx, "10.0.0.2", xlist.IPv4)
+	time.Sleep(300 * time.Millisecond)
+	_, negatives = wrapped.Top(10)
+	if len(negatives) != 1 || negatives[0].Name != "10.0.0.2" {
+		t.Errorf("unexpected negatives after window: %v", negatives)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	ip4 := []xlist.Resource{xlist.IPv4}
+	mockup := &mockxl.List{Identifier: "metrics", ResourceList: ip4, Results: []bool{true}}
+	wrapped := topwr.New(mockup, topwr.DefaultConfig())
+	topwr.Register(wrapped)
+	defer topwr.Unregister(wrapped)
+	wrapped.Check(context.Background(), "10.0.0.1", xlist.IPv4)
+	wrapped.Check(context.Background(), "10.0.0.1", xlist.IPv4)
+	wrapped.Check(context.Background(), "10.0.0.2", xlist.IPv4)
+
+	families, err := cliprom.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gathering metrics: %v", err)
+	}
+	got := make(map[string]float64)
+	for _, f := range families {
+		if f.GetName() != "xlist_top_hits" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			// checked names must not be label values
+			if strings.Contains(m.String(), "10.0.0.") {
+				t.Errorf("name exported in metric: %v", m)
+			}
+			labels := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["list"] == "metrics" && labels["result"] == "hit" {
+				got[labels["rank"]] = m.GetGauge().GetValue()
+			}
+		}
+	}
+	if len(got) != 2 || got["1"] != 2 || got["2"] != 1 {
+		t.Errorf("unexpected metrics: %v", got)
+	}
+}
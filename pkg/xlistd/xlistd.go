@@ -16,3 +16,17 @@ type List interface {
 	Ping() error
 	xlist.Checker
 }
+
+// MinTTL returns the ttl for a response obtained from others with the ttls
+// passed. NeverCache prevails and zero is the absence of a ttl.
+func MinTTL(a, b int) int {
+	switch {
+	case a == xlist.NeverCache || b == xlist.NeverCache:
+		return xlist.NeverCache
+	case a == 0:
+		return b
+	case b == 0 || a < b:
+		return a
+	}
+	return b
+}